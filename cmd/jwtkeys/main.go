@@ -0,0 +1,70 @@
+package main
+
+import (
+	"backend/internal/config"
+	"backend/pkg/jwt"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+var (
+	algorithm string
+	keep      int
+)
+
+func init() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	slog.Info("Logger initialized")
+
+	config.Config.MustInitializeConfig()
+}
+
+// Rotating adds a new signing key and retires the oldest ones. Servers pick up
+// the new key on restart; retired keys should be older than the refresh token TTL,
+// otherwise the sessions they signed are lost.
+func main() {
+	flag.StringVar(&algorithm, "alg", jwt.AlgorithmRS256, "algorithm of the new key (RS256 or EdDSA)")
+	flag.IntVar(&keep, "keep", 3, "number of keys to keep for verification, including the new one")
+	flag.Parse()
+
+	if keep < 1 {
+		slog.Error("[JWT Keys] keep must be at least 1")
+		os.Exit(1)
+	}
+
+	if err := rotate(config.Config.JwtKeysDir); err != nil {
+		slog.Error("[JWT Keys] failed to rotate keys", "error", err)
+		os.Exit(1)
+	}
+}
+
+func rotate(dir string) error {
+	key, err := jwt.GenerateKey(algorithm)
+	if err != nil {
+		return err
+	}
+
+	if err := jwt.WriteKey(dir, key); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("[JWT Keys] created %s key %s", key.Algorithm, key.ID))
+
+	keyring, err := jwt.LoadKeyring(dir)
+	if err != nil {
+		return err
+	}
+
+	keys := keyring.Keys()
+	for i := 0; i < len(keys)-keep; i++ {
+		if err := jwt.RemoveKey(dir, keys[i].ID); err != nil {
+			return err
+		}
+		slog.Info(fmt.Sprintf("[JWT Keys] retired key %s", keys[i].ID))
+	}
+
+	return nil
+}
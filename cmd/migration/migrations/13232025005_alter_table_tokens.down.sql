@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_tokens_family_id;
+DROP INDEX IF EXISTS idx_tokens_token;
+
+ALTER TABLE IF EXISTS tokens
+    DROP COLUMN IF EXISTS rotated_at,
+    DROP COLUMN IF EXISTS family_id;
+
+-- The token column stays TEXT: tokens issued since may not fit the old
+-- VARCHAR(255).
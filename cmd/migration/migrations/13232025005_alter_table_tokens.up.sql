@@ -0,0 +1,9 @@
+ALTER TABLE IF EXISTS tokens
+    ALTER COLUMN token TYPE TEXT;
+
+ALTER TABLE IF EXISTS tokens
+    ADD COLUMN IF NOT EXISTS family_id VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMP DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tokens_token ON tokens(token);
+CREATE INDEX IF NOT EXISTS idx_tokens_family_id ON tokens(family_id);
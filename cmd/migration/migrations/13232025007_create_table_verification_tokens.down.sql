@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS verification_tokens CASCADE;
+
+ALTER TABLE IF EXISTS users
+    DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS totp_recovery_codes CASCADE;
+DROP TABLE IF EXISTS totp_credentials CASCADE;
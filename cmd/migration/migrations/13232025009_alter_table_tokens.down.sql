@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_tokens_user_id;
+
+ALTER TABLE IF EXISTS tokens
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS signed_in_at,
+    DROP COLUMN IF EXISTS ip_address,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS device_name;
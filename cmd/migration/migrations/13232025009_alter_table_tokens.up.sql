@@ -0,0 +1,20 @@
+ALTER TABLE IF EXISTS tokens
+    ADD COLUMN IF NOT EXISTS device_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS signed_in_at TIMESTAMP DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP DEFAULT NULL;
+
+-- Every session is identified by its token family, so legacy tokens get one.
+UPDATE tokens SET family_id = md5(random()::TEXT || id::TEXT) WHERE family_id = '';
+
+-- Only tokens from before these columns existed are left without them; the
+-- defaults are set afterwards so they are told apart on the first run.
+UPDATE tokens SET signed_in_at = COALESCE(created_at, CURRENT_TIMESTAMP) WHERE signed_in_at IS NULL;
+UPDATE tokens SET last_used_at = COALESCE(created_at, CURRENT_TIMESTAMP) WHERE last_used_at IS NULL;
+
+ALTER TABLE IF EXISTS tokens
+    ALTER COLUMN signed_in_at SET DEFAULT CURRENT_TIMESTAMP,
+    ALTER COLUMN last_used_at SET DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_tokens_user_id ON tokens(user_id);
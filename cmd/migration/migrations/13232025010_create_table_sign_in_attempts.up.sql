@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS sign_in_attempts (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    user_id INT DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    reason VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sign_in_attempts_email ON sign_in_attempts(email);
+CREATE INDEX IF NOT EXISTS idx_sign_in_attempts_ip_address ON sign_in_attempts(ip_address);
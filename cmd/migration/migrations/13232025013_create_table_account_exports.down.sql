@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS account_exports CASCADE;
+
+ALTER TABLE IF EXISTS users
+    DROP COLUMN IF EXISTS deletion_requested_at,
+    DROP COLUMN IF EXISTS deletion_scheduled_at;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workout_id INT DEFAULT NULL REFERENCES workouts(id) ON DELETE SET NULL,
+    gateway VARCHAR(32) NOT NULL,
+    intent_id VARCHAR(255) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    failure_reason TEXT NOT NULL DEFAULT '',
+    copied_workout_id INT DEFAULT NULL REFERENCES workouts(id) ON DELETE SET NULL,
+    fulfilled_at TIMESTAMP DEFAULT NULL,
+    refunded_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_user_id ON payments(user_id);
+
+-- A payment is saved before its intent is created at the gateway, so the
+-- intent is only unique once it is set.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_gateway_intent_id ON payments(gateway, intent_id) WHERE intent_id <> '';
+
+-- At most one open or succeeded payment per buyer and workout.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_user_workout_open ON payments(user_id, workout_id)
+    WHERE status IN ('pending', 'requires_action', 'succeeded') AND deleted_at IS NULL;
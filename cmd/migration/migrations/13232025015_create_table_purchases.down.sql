@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS payments
+    ADD COLUMN IF NOT EXISTS copied_workout_id INT DEFAULT NULL REFERENCES workouts(id) ON DELETE SET NULL;
+
+DROP TABLE IF EXISTS purchases CASCADE;
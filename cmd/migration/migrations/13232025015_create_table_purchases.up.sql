@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS purchases (
+    id SERIAL PRIMARY KEY,
+    buyer_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workout_id INT DEFAULT NULL REFERENCES workouts(id) ON DELETE SET NULL,
+    payment_id INT DEFAULT NULL REFERENCES payments(id) ON DELETE SET NULL,
+    price_paid DECIMAL(10, 2) NOT NULL CHECK (price_paid >= 0.00),
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_purchases_buyer_workout_active ON purchases(buyer_id, workout_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_purchases_workout_id ON purchases(workout_id);
+
+-- Payments settled so far were fulfilled with a copy of the workout; record
+-- them in the ledger so their buyers keep access to the original. This only
+-- runs while the ledger is empty: afterwards every fulfilled payment without
+-- a purchase is one that was refunded because the buyer already held the
+-- workout, and it must not get one.
+INSERT INTO purchases (buyer_id, workout_id, payment_id, price_paid, currency, status, created_at)
+SELECT user_id, workout_id, id, amount / 100.0, currency,
+       CASE WHEN status = 'refunded' THEN 'refunded' ELSE 'active' END, COALESCE(fulfilled_at, created_at)
+FROM payments
+WHERE status IN ('succeeded', 'refunded') AND fulfilled_at IS NOT NULL
+    AND NOT EXISTS (SELECT 1 FROM purchases)
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE IF EXISTS payments
+    DROP COLUMN IF EXISTS copied_workout_id;
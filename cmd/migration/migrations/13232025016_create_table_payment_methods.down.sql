@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS users
+    ALTER COLUMN card_pan SET DEFAULT ''::VARCHAR;
+
+DROP TABLE IF EXISTS payment_methods CASCADE;
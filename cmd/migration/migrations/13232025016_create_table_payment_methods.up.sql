@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS payment_methods (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    gateway VARCHAR(32) NOT NULL,
+    token VARCHAR(255) DEFAULT NULL,
+    brand VARCHAR(32) NOT NULL DEFAULT 'unknown',
+    last4 VARCHAR(4) NOT NULL,
+    exp_month INT DEFAULT NULL,
+    exp_year INT DEFAULT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_methods_user_id ON payment_methods(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_methods_gateway_token ON payment_methods(gateway, token) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_methods_default ON payment_methods(user_id) WHERE is_default AND deleted_at IS NULL;
+
+-- Card numbers stored so far cannot be tokenized from SQL. Keep only what is
+-- needed to show them to their owner as "legacy" cards to be added again,
+-- then drop the numbers.
+INSERT INTO payment_methods (user_id, gateway, brand, last4, is_default)
+SELECT id,
+       'legacy',
+       CASE
+           WHEN pan LIKE '4%' THEN 'visa'
+           WHEN pan ~ '^(5[1-5]|2[2-7])' THEN 'mastercard'
+           WHEN pan ~ '^3[47]' THEN 'amex'
+           WHEN pan ~ '^(6011|65)' THEN 'discover'
+           ELSE 'unknown'
+       END,
+       RIGHT(pan, 4),
+       TRUE
+FROM (SELECT id, REGEXP_REPLACE(card_pan, '[^0-9]', '', 'g') AS pan FROM users WHERE card_pan IS NOT NULL) AS cards
+WHERE LENGTH(pan) BETWEEN 12 AND 19;
+
+ALTER TABLE IF EXISTS users
+    ALTER COLUMN card_pan DROP DEFAULT;
+
+UPDATE users SET card_pan = NULL WHERE card_pan IS NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS workouts
+    DROP COLUMN IF EXISTS members_only;
+
+DROP TABLE IF EXISTS subscriptions CASCADE;
+DROP TABLE IF EXISTS subscription_plans CASCADE;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS subscription_plans (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price DECIMAL(10, 2) NOT NULL CHECK (price > 0.00),
+    currency VARCHAR(3) NOT NULL,
+    billing_interval VARCHAR(16) NOT NULL CHECK (billing_interval IN ('month', 'year')),
+    trial_days INT NOT NULL DEFAULT 0 CHECK (trial_days >= 0),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan_id INT NOT NULL REFERENCES subscription_plans(id),
+    payment_method_id INT DEFAULT NULL REFERENCES payment_methods(id) ON DELETE SET NULL,
+    status VARCHAR(16) NOT NULL,
+    trial_ends_at TIMESTAMP DEFAULT NULL,
+    current_period_start TIMESTAMP NOT NULL,
+    current_period_end TIMESTAMP NOT NULL,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    canceled_at TIMESTAMP DEFAULT NULL,
+    past_due_since TIMESTAMP DEFAULT NULL,
+    next_attempt_at TIMESTAMP DEFAULT NULL,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    last_error VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+-- A user has at most one subscription that is not canceled.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_user_live ON subscriptions(user_id) WHERE status <> 'canceled';
+CREATE INDEX IF NOT EXISTS idx_subscriptions_current_period_end ON subscriptions(current_period_end) WHERE status IN ('trial', 'active');
+CREATE INDEX IF NOT EXISTS idx_subscriptions_next_attempt_at ON subscriptions(next_attempt_at) WHERE status = 'past_due';
+
+ALTER TABLE IF EXISTS workouts
+    ADD COLUMN IF NOT EXISTS members_only BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS creator_earnings CASCADE;
+DROP TABLE IF EXISTS creator_payouts CASCADE;
@@ -0,0 +1,63 @@
+CREATE TABLE IF NOT EXISTS creator_payouts (
+    id SERIAL PRIMARY KEY,
+    creator_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    entries_count INT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    paid_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_creator_payouts_creator_id ON creator_payouts(creator_id);
+
+-- Amounts are in the currency's minor unit. Reversals carry negative amounts
+-- so that sums over the ledger are net of refunds.
+CREATE TABLE IF NOT EXISTS creator_earnings (
+    id SERIAL PRIMARY KEY,
+    creator_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workout_id INT DEFAULT NULL REFERENCES workouts(id) ON DELETE SET NULL,
+    workout_title VARCHAR(255) NOT NULL DEFAULT '',
+    payment_id INT DEFAULT NULL REFERENCES payments(id) ON DELETE SET NULL,
+    entry_type VARCHAR(16) NOT NULL,
+    gross BIGINT NOT NULL,
+    fee BIGINT NOT NULL,
+    net BIGINT NOT NULL,
+    fee_percent DECIMAL(5, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    payout_id INT DEFAULT NULL REFERENCES creator_payouts(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_creator_earnings_payment_entry ON creator_earnings(payment_id, entry_type);
+CREATE INDEX IF NOT EXISTS idx_creator_earnings_creator_id ON creator_earnings(creator_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_creator_earnings_unpaid ON creator_earnings(creator_id) WHERE payout_id IS NULL;
+
+-- Record the sales made so far, and reverse the refunded ones. This only
+-- runs while the ledger is empty: afterwards payments refunded because the
+-- buyer already held the workout were never credited and must not be. The
+-- fee of 20% must match the default platform_fee_percent in config.yml.
+WITH sales AS (
+    INSERT INTO creator_earnings (creator_id, workout_id, workout_title, payment_id, entry_type, gross, fee, net, fee_percent, currency, created_at)
+    SELECT workouts.owner_id, workouts.id, workouts.title, payments.id, 'sale',
+           payments.amount, ROUND(payments.amount * 0.20), payments.amount - ROUND(payments.amount * 0.20), 20.00,
+           payments.currency, COALESCE(payments.fulfilled_at, payments.created_at)
+    FROM payments
+    JOIN workouts ON workouts.id = payments.workout_id
+    WHERE payments.status IN ('succeeded', 'refunded') AND payments.fulfilled_at IS NOT NULL
+        AND NOT EXISTS (SELECT 1 FROM creator_earnings)
+    ON CONFLICT DO NOTHING
+    RETURNING *
+)
+INSERT INTO creator_earnings (creator_id, workout_id, workout_title, payment_id, entry_type, gross, fee, net, fee_percent, currency, created_at)
+SELECT sales.creator_id, sales.workout_id, sales.workout_title, sales.payment_id, 'reversal', -sales.gross, -sales.fee, -sales.net, sales.fee_percent, sales.currency,
+       COALESCE(payments.refunded_at, payments.updated_at, sales.created_at)
+FROM sales
+JOIN payments ON payments.id = sales.payment_id
+WHERE payments.status = 'refunded'
+ON CONFLICT DO NOTHING;
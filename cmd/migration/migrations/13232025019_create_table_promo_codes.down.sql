@@ -0,0 +1,13 @@
+ALTER TABLE IF EXISTS purchases
+    DROP COLUMN IF EXISTS original_price,
+    DROP COLUMN IF EXISTS discount,
+    DROP COLUMN IF EXISTS promo_code;
+
+ALTER TABLE IF EXISTS payments
+    DROP COLUMN IF EXISTS original_amount,
+    DROP COLUMN IF EXISTS discount,
+    DROP COLUMN IF EXISTS promo_code;
+
+DROP TABLE IF EXISTS promo_redemptions CASCADE;
+DROP TABLE IF EXISTS promo_code_workouts CASCADE;
+DROP TABLE IF EXISTS promo_codes CASCADE;
@@ -0,0 +1,61 @@
+-- Promo codes without an owner are platform codes created by admins; the
+-- others are created by a creator and only apply to that creator's workouts.
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(64) NOT NULL,
+    owner_id INT DEFAULT NULL REFERENCES users(id) ON DELETE CASCADE,
+    discount_type VARCHAR(16) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    discount_value DECIMAL(10, 2) NOT NULL CHECK (discount_value > 0.00),
+    currency VARCHAR(3) NOT NULL,
+    max_redemptions INT DEFAULT NULL CHECK (max_redemptions > 0),
+    per_user_limit INT NOT NULL DEFAULT 1 CHECK (per_user_limit > 0),
+    starts_at TIMESTAMP DEFAULT NULL,
+    expires_at TIMESTAMP DEFAULT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promo_codes_code ON promo_codes(code) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_promo_codes_owner_id ON promo_codes(owner_id);
+
+-- A promo code with no workouts applies to every workout it may be used for.
+CREATE TABLE IF NOT EXISTS promo_code_workouts (
+    promo_code_id INT NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+    workout_id INT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    PRIMARY KEY (promo_code_id, workout_id)
+);
+
+-- Payments made with a promo code. Redemptions of failed payments do not
+-- count towards the limits of the code.
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id SERIAL PRIMARY KEY,
+    promo_code_id INT NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payment_id INT NOT NULL UNIQUE REFERENCES payments(id) ON DELETE CASCADE,
+    discount BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_promo_code_user ON promo_redemptions(promo_code_id, user_id);
+
+ALTER TABLE IF EXISTS payments
+    ADD COLUMN IF NOT EXISTS original_amount BIGINT DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS discount BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS promo_code VARCHAR(64) NOT NULL DEFAULT '';
+
+UPDATE payments SET original_amount = amount WHERE original_amount IS NULL;
+ALTER TABLE IF EXISTS payments
+    ALTER COLUMN original_amount SET NOT NULL;
+
+ALTER TABLE IF EXISTS purchases
+    ADD COLUMN IF NOT EXISTS original_price DECIMAL(10, 2) DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS discount DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+    ADD COLUMN IF NOT EXISTS promo_code VARCHAR(64) NOT NULL DEFAULT '';
+
+UPDATE purchases SET original_price = price_paid WHERE original_price IS NULL;
+ALTER TABLE IF EXISTS purchases
+    ALTER COLUMN original_price SET NOT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE IF EXISTS workouts
+    DROP COLUMN IF EXISTS rating_average,
+    DROP COLUMN IF EXISTS rating_count;
+
+DROP TABLE IF EXISTS review_reports CASCADE;
+DROP TABLE IF EXISTS workout_reviews CASCADE;
+DROP TABLE IF EXISTS workout_copies CASCADE;
@@ -0,0 +1,45 @@
+-- Copies made through the copy endpoint. Copying a workout, like buying it,
+-- lets the user review the original.
+CREATE TABLE IF NOT EXISTS workout_copies (
+    id SERIAL PRIMARY KEY,
+    workout_id INT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    copy_id INT NOT NULL UNIQUE REFERENCES workouts(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_copies_workout_user ON workout_copies(workout_id, user_id);
+
+CREATE TABLE IF NOT EXISTS workout_reviews (
+    id SERIAL PRIMARY KEY,
+    workout_id INT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT NOT NULL DEFAULT '',
+    reports_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workout_reviews_workout_user ON workout_reviews(workout_id, user_id) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS review_reports (
+    id SERIAL PRIMARY KEY,
+    review_id INT NOT NULL REFERENCES workout_reviews(id) ON DELETE CASCADE,
+    reporter_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(32) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (review_id, reporter_id)
+);
+
+-- Kept in step with workout_reviews by the reviews repository so listings
+-- can sort by rating without aggregating.
+ALTER TABLE IF EXISTS workouts
+    ADD COLUMN IF NOT EXISTS rating_average DECIMAL(3, 2) NOT NULL DEFAULT 0.00,
+    ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0;
@@ -0,0 +1,20 @@
+-- Replies point at their parent comment. replies_count only counts replies
+-- that were not deleted, so deleted comments without replies can be left out
+-- of listings while deleted comments with replies keep their thread together.
+CREATE TABLE IF NOT EXISTS workout_comments (
+    id SERIAL PRIMARY KEY,
+    workout_id INT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id INT DEFAULT NULL REFERENCES workout_comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    is_pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    pinned_at TIMESTAMP DEFAULT NULL,
+    replies_count INT NOT NULL DEFAULT 0,
+    edited_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_comments_workout_created ON workout_comments(workout_id, created_at DESC, id DESC) WHERE parent_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_workout_comments_parent_created ON workout_comments(parent_id, created_at DESC, id DESC);
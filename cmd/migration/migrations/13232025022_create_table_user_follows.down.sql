@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_sessions_owner_end_time;
+DROP INDEX IF EXISTS idx_workout_likes_user_created;
+DROP INDEX IF EXISTS idx_workouts_owner_created;
+
+ALTER TABLE IF EXISTS users
+    DROP COLUMN IF EXISTS followers_count,
+    DROP COLUMN IF EXISTS following_count;
+
+DROP TABLE IF EXISTS user_follows CASCADE;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS user_follows (
+    id SERIAL PRIMARY KEY,
+    follower_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    following_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE CHECK (following_id <> follower_id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (follower_id, following_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_follows_following_id ON user_follows(following_id);
+
+-- Kept in step with user_follows by the follows repository.
+ALTER TABLE IF EXISTS users
+    ADD COLUMN IF NOT EXISTS followers_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS following_count INT NOT NULL DEFAULT 0;
+
+-- The feed reads the recent activity of a set of users.
+CREATE INDEX IF NOT EXISTS idx_workouts_owner_created ON workouts(owner_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_workout_likes_user_created ON workout_likes(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_owner_end_time ON sessions(owner_id, end_time);
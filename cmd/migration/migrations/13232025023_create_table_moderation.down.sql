@@ -0,0 +1,13 @@
+ALTER TABLE IF EXISTS users
+    DROP COLUMN IF EXISTS hidden_at,
+    DROP COLUMN IF EXISTS banned_at;
+
+ALTER TABLE IF EXISTS exercises
+    DROP COLUMN IF EXISTS hidden_at;
+
+ALTER TABLE IF EXISTS workouts
+    DROP COLUMN IF EXISTS hidden_at;
+
+DROP TABLE IF EXISTS content_reports CASCADE;
+DROP TABLE IF EXISTS moderation_actions CASCADE;
+DROP TABLE IF EXISTS user_blocks CASCADE;
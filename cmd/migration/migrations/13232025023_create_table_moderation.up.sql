@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS user_blocks (
+    id SERIAL PRIMARY KEY,
+    blocker_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE CHECK (blocked_id <> blocker_id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (blocker_id, blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked_id ON user_blocks(blocked_id);
+
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id SERIAL PRIMARY KEY,
+    moderator_id INT NOT NULL REFERENCES users(id),
+    content_type VARCHAR(20) NOT NULL CHECK (content_type IN ('workout', 'user', 'exercise')),
+    content_id INT NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('hide', 'restore', 'ban', 'dismiss')),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_content ON moderation_actions(content_type, content_id);
+
+CREATE TABLE IF NOT EXISTS content_reports (
+    id SERIAL PRIMARY KEY,
+    reporter_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content_type VARCHAR(20) NOT NULL CHECK (content_type IN ('workout', 'user', 'exercise')),
+    content_id INT NOT NULL,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('spam', 'abuse', 'inappropriate', 'impersonation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+    action_id INT DEFAULT NULL REFERENCES moderation_actions(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+-- A user can have one open report per piece of content.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_content_reports_open
+    ON content_reports(reporter_id, content_type, content_id)
+    WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_content_reports_status ON content_reports(status, created_at);
+
+ALTER TABLE IF EXISTS workouts
+    ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP DEFAULT NULL;
+
+ALTER TABLE IF EXISTS exercises
+    ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP DEFAULT NULL;
+
+ALTER TABLE IF EXISTS users
+    ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS banned_at TIMESTAMP DEFAULT NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS program_completions CASCADE;
+DROP TABLE IF EXISTS program_enrollments CASCADE;
+DROP TABLE IF EXISTS program_days CASCADE;
+DROP TABLE IF EXISTS program_weeks CASCADE;
+DROP TABLE IF EXISTS programs CASCADE;
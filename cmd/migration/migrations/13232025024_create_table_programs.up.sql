@@ -0,0 +1,72 @@
+CREATE TABLE IF NOT EXISTS programs (
+    id SERIAL PRIMARY KEY,
+    owner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    days_per_week SMALLINT NOT NULL CHECK (days_per_week BETWEEN 1 AND 7),
+    is_private BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_programs_owner_id ON programs(owner_id);
+
+CREATE TABLE IF NOT EXISTS program_weeks (
+    id SERIAL PRIMARY KEY,
+    program_id INT NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    week_number SMALLINT NOT NULL CHECK (week_number >= 1),
+    is_deload BOOLEAN NOT NULL DEFAULT FALSE,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (program_id, week_number)
+);
+
+CREATE TABLE IF NOT EXISTS program_days (
+    id SERIAL PRIMARY KEY,
+    program_id INT NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    week_number SMALLINT NOT NULL CHECK (week_number >= 1),
+    day_number SMALLINT NOT NULL CHECK (day_number BETWEEN 1 AND 7),
+    workout_id INT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (program_id, week_number, day_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_program_days_workout_id ON program_days(workout_id);
+
+CREATE TABLE IF NOT EXISTS program_enrollments (
+    id SERIAL PRIMARY KEY,
+    program_id INT NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    current_week SMALLINT NOT NULL DEFAULT 1,
+    current_day SMALLINT NOT NULL DEFAULT 1,
+    completed_days INT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+-- A user can be enrolled in a program once at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_program_enrollments_active
+    ON program_enrollments(program_id, user_id)
+    WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_program_enrollments_user_id ON program_enrollments(user_id);
+
+-- Completions are kept by week and day rather than by program_days row, so
+-- that they survive the schedule being edited.
+CREATE TABLE IF NOT EXISTS program_completions (
+    id SERIAL PRIMARY KEY,
+    enrollment_id INT NOT NULL REFERENCES program_enrollments(id) ON DELETE CASCADE,
+    week_number SMALLINT NOT NULL,
+    day_number SMALLINT NOT NULL,
+    workout_id INT DEFAULT NULL REFERENCES workouts(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (enrollment_id, week_number, day_number)
+);
@@ -0,0 +1,10 @@
+ALTER TABLE IF EXISTS workout_exercises
+    DROP CONSTRAINT IF EXISTS workout_exercises_target_reps_check,
+    DROP CONSTRAINT IF EXISTS workout_exercises_load_check,
+    DROP COLUMN IF EXISTS target_sets,
+    DROP COLUMN IF EXISTS target_reps_min,
+    DROP COLUMN IF EXISTS target_reps_max,
+    DROP COLUMN IF EXISTS load_type,
+    DROP COLUMN IF EXISTS load_value,
+    DROP COLUMN IF EXISTS tempo,
+    DROP COLUMN IF EXISTS rest_seconds;
@@ -0,0 +1,16 @@
+-- Structured targets for a workout exercise. Every column is optional so
+-- existing exercises keep relying on their notes.
+ALTER TABLE IF EXISTS workout_exercises
+    ADD COLUMN IF NOT EXISTS target_sets INT DEFAULT NULL CHECK (target_sets > 0),
+    ADD COLUMN IF NOT EXISTS target_reps_min INT DEFAULT NULL CHECK (target_reps_min > 0),
+    ADD COLUMN IF NOT EXISTS target_reps_max INT DEFAULT NULL CHECK (target_reps_max > 0),
+    ADD COLUMN IF NOT EXISTS load_type VARCHAR(16) DEFAULT NULL CHECK (load_type IN ('absolute', 'percent_1rm', 'rpe')),
+    ADD COLUMN IF NOT EXISTS load_value NUMERIC(6, 2) DEFAULT NULL CHECK (load_value > 0),
+    ADD COLUMN IF NOT EXISTS tempo VARCHAR(16) DEFAULT NULL,
+    ADD COLUMN IF NOT EXISTS rest_seconds INT DEFAULT NULL CHECK (rest_seconds >= 0);
+
+ALTER TABLE IF EXISTS workout_exercises
+    DROP CONSTRAINT IF EXISTS workout_exercises_target_reps_check,
+    ADD CONSTRAINT workout_exercises_target_reps_check CHECK (target_reps_min <= target_reps_max),
+    DROP CONSTRAINT IF EXISTS workout_exercises_load_check,
+    ADD CONSTRAINT workout_exercises_load_check CHECK ((load_type IS NULL) = (load_value IS NULL));
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_workout_exercises_position;
+
+ALTER TABLE IF EXISTS workout_exercises
+    DROP COLUMN IF EXISTS group_id,
+    DROP COLUMN IF EXISTS position;
+
+DROP TABLE IF EXISTS workout_exercise_groups CASCADE;
@@ -0,0 +1,37 @@
+-- Supersets, giant sets and circuits: exercises of a workout done back to
+-- back, for a number of rounds.
+CREATE TABLE IF NOT EXISTS workout_exercise_groups (
+    id SERIAL PRIMARY KEY,
+    workout_id INT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    group_type VARCHAR(16) NOT NULL CHECK (group_type IN ('superset', 'giant_set', 'circuit')),
+    rounds INT NOT NULL DEFAULT 1 CHECK (rounds > 0),
+    rest_seconds INT DEFAULT NULL CHECK (rest_seconds >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_exercise_groups_workout ON workout_exercise_groups(workout_id);
+
+ALTER TABLE IF EXISTS workout_exercises
+    ADD COLUMN IF NOT EXISTS position INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS group_id INT DEFAULT NULL REFERENCES workout_exercise_groups(id) ON DELETE SET NULL;
+
+-- Existing exercises keep the order they were added in. Only workouts whose
+-- exercises all still sit at position 0 are numbered, so a rerun leaves
+-- orders the users set since alone.
+UPDATE workout_exercises
+SET position = ordered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY workout_id ORDER BY id) - 1 AS position
+    FROM workout_exercises
+    WHERE workout_id IN (
+        SELECT workout_id
+        FROM workout_exercises
+        GROUP BY workout_id
+        HAVING COUNT(*) > 1 AND MAX(position) = 0 AND MIN(position) = 0
+    )
+) AS ordered
+WHERE workout_exercises.id = ordered.id;
+
+CREATE INDEX IF NOT EXISTS idx_workout_exercises_position ON workout_exercises(workout_id, position);
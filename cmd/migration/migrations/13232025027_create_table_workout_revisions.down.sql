@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workout_revision_exercises CASCADE;
+DROP TABLE IF EXISTS workout_revisions CASCADE;
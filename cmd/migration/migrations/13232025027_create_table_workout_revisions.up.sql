@@ -0,0 +1,72 @@
+-- Immutable snapshots of a workout, one per change, with the list of changes
+-- against the previous revision.
+CREATE TABLE IF NOT EXISTS workout_revisions (
+    id SERIAL PRIMARY KEY,
+    workout_id INT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    revision_number INT NOT NULL CHECK (revision_number > 0),
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price DECIMAL(10, 2) NOT NULL DEFAULT 0.00,
+    is_private BOOLEAN NOT NULL DEFAULT FALSE,
+    members_only BOOLEAN NOT NULL DEFAULT FALSE,
+    changelog TEXT[] NOT NULL DEFAULT '{}',
+    restored_from INT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL,
+
+    UNIQUE (workout_id, revision_number)
+);
+
+-- group_key tells which exercises of a revision were grouped together.
+CREATE TABLE IF NOT EXISTS workout_revision_exercises (
+    id SERIAL PRIMARY KEY,
+    revision_id INT NOT NULL REFERENCES workout_revisions(id) ON DELETE CASCADE,
+    exercise_id INT NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    exercise_name VARCHAR(255) NOT NULL DEFAULT '',
+    main_note TEXT NOT NULL DEFAULT '',
+    secondary_note TEXT NOT NULL DEFAULT '',
+    target_sets INT DEFAULT NULL,
+    target_reps_min INT DEFAULT NULL,
+    target_reps_max INT DEFAULT NULL,
+    load_type VARCHAR(16) DEFAULT NULL,
+    load_value NUMERIC(6, 2) DEFAULT NULL,
+    tempo VARCHAR(16) DEFAULT NULL,
+    rest_seconds INT DEFAULT NULL,
+    position INT NOT NULL DEFAULT 0,
+    group_key INT DEFAULT NULL,
+    group_type VARCHAR(16) DEFAULT NULL,
+    group_rounds INT DEFAULT NULL,
+    group_rest_seconds INT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_revision_exercises_revision ON workout_revision_exercises(revision_id);
+
+-- Existing workouts start their history from their current state. Workouts
+-- that already have a first revision are skipped, and only the revisions
+-- created here get their exercises, so a rerun changes nothing.
+WITH revisions AS (
+    INSERT INTO workout_revisions (workout_id, revision_number, title, description, price, is_private, members_only, changelog)
+    SELECT id, 1, title, COALESCE(description, ''), COALESCE(price, 0), COALESCE(is_private, FALSE), members_only, ARRAY['Initial revision']
+    FROM workouts
+    ON CONFLICT (workout_id, revision_number) DO NOTHING
+    RETURNING id, workout_id
+)
+INSERT INTO workout_revision_exercises (
+    revision_id, exercise_id, exercise_name, main_note, secondary_note,
+    target_sets, target_reps_min, target_reps_max, load_type, load_value, tempo, rest_seconds,
+    position, group_key, group_type, group_rounds, group_rest_seconds
+)
+SELECT
+    revisions.id, workout_exercises.exercise_id, exercises.name, workout_exercises.main_note, workout_exercises.secondary_note,
+    workout_exercises.target_sets, workout_exercises.target_reps_min, workout_exercises.target_reps_max,
+    workout_exercises.load_type, workout_exercises.load_value, workout_exercises.tempo, workout_exercises.rest_seconds,
+    workout_exercises.position, workout_exercises.group_id, workout_exercise_groups.group_type,
+    workout_exercise_groups.rounds, workout_exercise_groups.rest_seconds
+FROM workout_exercises
+JOIN revisions ON revisions.workout_id = workout_exercises.workout_id
+JOIN exercises ON exercises.id = workout_exercises.exercise_id
+LEFT JOIN workout_exercise_groups ON workout_exercise_groups.id = workout_exercises.group_id;
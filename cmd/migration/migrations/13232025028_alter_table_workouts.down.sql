@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_workouts_root;
+DROP INDEX IF EXISTS idx_workouts_forked_from;
+
+ALTER TABLE IF EXISTS workouts
+    DROP COLUMN IF EXISTS upstream_revision,
+    DROP COLUMN IF EXISTS root_workout_id,
+    DROP COLUMN IF EXISTS forked_from_workout_id;
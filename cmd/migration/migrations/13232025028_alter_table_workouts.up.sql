@@ -0,0 +1,45 @@
+-- Fork lineage of copied workouts: the workout it was copied from, the
+-- original at the top of the chain and the revision of the source the copy
+-- was last in step with.
+ALTER TABLE IF EXISTS workouts
+    ADD COLUMN IF NOT EXISTS forked_from_workout_id INT DEFAULT NULL REFERENCES workouts(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS root_workout_id INT DEFAULT NULL REFERENCES workouts(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS upstream_revision INT DEFAULT NULL;
+
+-- Existing copies take their lineage from workout_copies. Every backfill only
+-- fills columns that are still empty, so a rerun keeps the lineage and the
+-- upstream revisions recorded since.
+UPDATE workouts
+SET forked_from_workout_id = workout_copies.workout_id
+FROM workout_copies
+WHERE workouts.id = workout_copies.copy_id AND workouts.forked_from_workout_id IS NULL;
+
+WITH RECURSIVE lineage AS (
+    SELECT id, forked_from_workout_id AS ancestor_id, 1 AS depth
+    FROM workouts
+    WHERE forked_from_workout_id IS NOT NULL
+    UNION ALL
+    SELECT lineage.id, workouts.forked_from_workout_id, lineage.depth + 1
+    FROM lineage
+    JOIN workouts ON workouts.id = lineage.ancestor_id
+    WHERE workouts.forked_from_workout_id IS NOT NULL AND lineage.depth < 100
+)
+UPDATE workouts
+SET root_workout_id = roots.ancestor_id
+FROM (
+    SELECT DISTINCT ON (id) id, ancestor_id
+    FROM lineage
+    ORDER BY id, depth DESC
+) AS roots
+WHERE workouts.id = roots.id AND workouts.root_workout_id IS NULL;
+
+UPDATE workouts
+SET upstream_revision = (
+    SELECT MAX(revision_number)
+    FROM workout_revisions
+    WHERE workout_revisions.workout_id = workouts.forked_from_workout_id
+)
+WHERE forked_from_workout_id IS NOT NULL AND upstream_revision IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_workouts_forked_from ON workouts(forked_from_workout_id);
+CREATE INDEX IF NOT EXISTS idx_workouts_root ON workouts(root_workout_id);
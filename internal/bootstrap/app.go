@@ -5,17 +5,27 @@ import (
 	"backend/internal/container"
 	"backend/internal/datasources/drivers"
 	"backend/internal/helpers"
+	"backend/internal/http/middlewares"
 	"backend/internal/http/routes"
 	"backend/internal/utils"
+	"backend/pkg/attempts"
+	"backend/pkg/billing"
 	"backend/pkg/httpserver"
 	"backend/pkg/jwt"
 	"backend/pkg/logger"
+	"backend/pkg/mailer"
+	"backend/pkg/oidc"
+	"backend/pkg/payments"
 	"backend/third_party/io"
 	"backend/third_party/s3"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/redis/go-redis/v9"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -40,7 +50,33 @@ func MustRun() {
 
 	ionet := io.NewClient(config.Config.IOAPIKey)
 
+	mail, err := newMailer()
+	if err != nil {
+		logger.ZeroLogger.Fatal().Msgf("bootstrap - MustRun - newMailer: %v", err)
+	}
+
+	attemptsStore, err := newAttemptsStore()
+	if err != nil {
+		logger.ZeroLogger.Fatal().Msgf("bootstrap - MustRun - newAttemptsStore: %v", err)
+	}
+
+	paymentGateway, err := newPaymentGateway()
+	if err != nil {
+		logger.ZeroLogger.Fatal().Msgf("bootstrap - MustRun - newPaymentGateway: %v", err)
+	}
+
+	biller, err := newBiller(paymentGateway)
+	if err != nil {
+		logger.ZeroLogger.Fatal().Msgf("bootstrap - MustRun - newBiller: %v", err)
+	}
+
+	ipExtractor, err := newIPExtractor()
+	if err != nil {
+		logger.ZeroLogger.Fatal().Msgf("bootstrap - MustRun - newIPExtractor: %v", err)
+	}
+
 	e := echo.New()
+	e.IPExtractor = ipExtractor
 	e.Use(middleware.CORSWithConfig(middleware.DefaultCORSConfig))
 	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		LogMethod:  true,
@@ -58,12 +94,36 @@ func MustRun() {
 			return nil
 		},
 	}))
-	jwt.MustInitializeConfig(config.Config.JwtSecretKey, time.Minute*time.Duration(config.Config.JwtAccessTokenExpiresIn), time.Hour*time.Duration(config.Config.JwtRefreshTokenExpiresIn))
+	keyring, err := jwt.LoadKeyring(config.Config.JwtKeysDir)
+	if err != nil {
+		logger.ZeroLogger.Fatal().Msgf("bootstrap - MustRun - jwt.LoadKeyring: %v", err)
+	}
+	if keyring.SigningKey() == nil {
+		if config.Config.JwtSecretKey == "" {
+			logger.ZeroLogger.Fatal().Msg("bootstrap - MustRun - no JWT signing keys found and no jwt_secret_key set. Run cmd/jwtkeys to create one.")
+		}
+		logger.ZeroLogger.Warn().Msg("No JWT signing keys found, falling back to HS256 with the secret key. Run cmd/jwtkeys to create one.")
+	}
+	var legacyTokensUntil time.Time
+	if config.Config.JwtLegacyTokensUntil != "" {
+		legacyTokensUntil, err = time.Parse(time.RFC3339, config.Config.JwtLegacyTokensUntil)
+		if err != nil {
+			logger.ZeroLogger.Fatal().Msgf("bootstrap - MustRun - time.Parse jwt_legacy_tokens_until: %v", err)
+		}
+	}
+	jwt.MustInitializeConfig(config.Config.JwtSecretKey, keyring, legacyTokensUntil, time.Minute*time.Duration(config.Config.JwtAccessTokenExpiresIn), time.Hour*time.Duration(config.Config.JwtRefreshTokenExpiresIn))
 	e.Validator = helpers.NewValidator()
 
 	v1 := e.Group("/api/v1")
 
-	setupRoutes(v1, conn, s3Client, ionet)
+	cont := setupRoutes(v1, conn, s3Client, ionet, mail, attemptsStore, newOIDCProviders(), paymentGateway, biller)
+	routes.NewJWKSRoute(e.Group("/.well-known")).Register()
+
+	// background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go cont.AccountsService.Run(jobsCtx, time.Second*time.Duration(config.Config.AccountJobsInterval))
+	go cont.SubscriptionsService.Run(jobsCtx, time.Second*time.Duration(config.Config.SubscriptionJobsInterval))
 
 	// running server
 	logger.ZeroLogger.Info().Msg("Starting http server...")
@@ -88,8 +148,18 @@ func MustRun() {
 	}
 }
 
-func setupRoutes(e *echo.Group, conn *sqlx.DB, s3Client *s3.Client, ionet *io.Client) {
-	cont := container.NewContainer(conn, s3Client, ionet)
+func setupRoutes(e *echo.Group, conn *sqlx.DB, s3Client *s3.Client, ionet *io.Client, mail mailer.Mailer, attemptsStore attempts.Store, oidcProviders map[string]*oidc.Provider, paymentGateway payments.Gateway, biller billing.Biller) *container.Container {
+	cont := container.NewContainer(conn, s3Client, ionet, mail, attemptsStore, oidcProviders, paymentGateway, biller)
+	middlewares.SetSessionChecker(cont.AuthService)
+	middlewares.SetAPIKeyAuthenticator(cont.APIKeysService)
+	middlewares.SetMembershipChecker(cont.SubscriptionsService)
+	if fake, ok := paymentGateway.(*payments.FakeGateway); ok {
+		fake.OnEvent(func(event payments.Event) {
+			if _, err := cont.PaymentsService.HandleEvent(event); err != nil {
+				logger.ZeroLogger.Error().Msgf("bootstrap - fake payment gateway - HandleEvent: %v", err)
+			}
+		})
+	}
 
 	// Register routes
 	routes.NewUsersRoute(cont, e).Register()
@@ -104,6 +174,129 @@ func setupRoutes(e *echo.Group, conn *sqlx.DB, s3Client *s3.Client, ionet *io.Cl
 	routes.NewSessionDetailsRoute(cont, e).Register()
 	routes.NewAnalyticsRoute(cont, e).Register()
 	routes.NewNutritionsRoute(cont, e).Register()
+	routes.NewRolesRoute(cont, e).Register()
+	routes.NewTwoFactorRoute(cont, e).Register()
+	routes.NewOIDCRoute(cont, e).Register()
+	routes.NewAPIKeysRoute(cont, e).Register()
+	routes.NewAccountsRoute(cont, e).Register()
+	routes.NewPaymentsRoute(cont, e).Register()
+	routes.NewPaymentMethodsRoute(cont, e).Register()
+	routes.NewSubscriptionsRoute(cont, e).Register()
+	routes.NewEarningsRoute(cont, e).Register()
+	routes.NewPromoCodesRoute(cont, e).Register()
+	routes.NewWorkoutReviewsRoute(cont, e).Register()
+	routes.NewWorkoutCommentsRoute(cont, e).Register()
+	routes.NewFollowsRoute(cont, e).Register()
+	routes.NewModerationRoute(cont, e).Register()
+	routes.NewProgramsRoute(cont, e).Register()
+	routes.NewWorkoutRevisionsRoute(cont, e).Register()
 
 	routes.NewHealthCheckRoute(e).Register()
+
+	return cont
+}
+
+func newMailer() (mailer.Mailer, error) {
+	switch config.Config.MailerDriver {
+	case "smtp":
+		return mailer.NewSMTPMailer(
+			config.Config.SMTPHost,
+			config.Config.SMTPPort,
+			config.Config.SMTPUsername,
+			config.Config.SMTPPassword,
+			config.Config.MailerFrom,
+		), nil
+	case "memory":
+		return mailer.NewMemoryMailer(), nil
+	case "file", "":
+		return mailer.NewFileMailer(config.Config.MailerFileDir, config.Config.MailerFrom)
+	default:
+		return nil, fmt.Errorf("unknown mailer driver %q", config.Config.MailerDriver)
+	}
+}
+
+// newAttemptsStore keeps sign-in counters in Redis when several instances
+// share the load, and in memory otherwise.
+func newAttemptsStore() (attempts.Store, error) {
+	switch config.Config.SignInAttemptsStore {
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     net.JoinHostPort(config.Config.RedisHost, config.Config.RedisPort),
+			Password: config.Config.RedisPassword,
+		})
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			return nil, fmt.Errorf("redis ping: %w", err)
+		}
+		return attempts.NewRedisStore(client), nil
+	case "memory", "":
+		return attempts.NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown sign-in attempts store %q", config.Config.SignInAttemptsStore)
+	}
+}
+
+// newIPExtractor reads the client IP from X-Forwarded-For only when the
+// request comes through one of the trusted proxies. Otherwise the header could
+// be set by the client, for example to get around the sign-in lockout.
+func newIPExtractor() (echo.IPExtractor, error) {
+	if len(config.Config.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range config.Config.TrustedProxies {
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", proxy, err)
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+func newOIDCProviders() map[string]*oidc.Provider {
+	providers := make(map[string]*oidc.Provider, len(config.Config.OIDCProviders))
+	for _, provider := range config.Config.OIDCProviders {
+		providers[provider.Name] = oidc.NewProvider(oidc.Config{
+			Name:         provider.Name,
+			IssuerURL:    provider.IssuerURL,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		}, nil)
+	}
+
+	return providers
+}
+
+func newPaymentGateway() (payments.Gateway, error) {
+	switch config.Config.PaymentsGateway {
+	case "stripe":
+		gateway := payments.NewStripeGateway(config.Config.StripeSecretKey, config.Config.PaymentsWebhookSecret, nil)
+		if config.Config.StripeAPIURL != "" {
+			gateway.WithBaseURL(config.Config.StripeAPIURL)
+		}
+		return gateway, nil
+	case "fake":
+		return payments.NewFakeGateway(config.Config.PaymentsWebhookSecret, config.Config.AppURL+"/api/v1/payments/fake/authenticate/"), nil
+	case "":
+		return nil, errors.New("payments_gateway is not set, use stripe or fake")
+	default:
+		return nil, fmt.Errorf("unknown payments gateway %q", config.Config.PaymentsGateway)
+	}
+}
+
+func newBiller(gateway payments.Gateway) (billing.Biller, error) {
+	switch config.Config.BillingProvider {
+	case "gateway":
+		return billing.NewGatewayBiller(gateway), nil
+	case "fake":
+		return billing.NewFakeBiller(), nil
+	case "":
+		return nil, errors.New("billing_provider is not set, use gateway or fake")
+	default:
+		return nil, fmt.Errorf("unknown billing provider %q", config.Config.BillingProvider)
+	}
 }
@@ -27,6 +27,8 @@ type config struct {
 	RedisExpires  string `yaml:"redis_expires"`
 
 	JwtSecretKey             string `yaml:"jwt_secret_key"`
+	JwtKeysDir               string `yaml:"jwt_keys_dir"`
+	JwtLegacyTokensUntil     string `yaml:"jwt_legacy_tokens_until"`
 	JwtIssuer                string `yaml:"jwt_issuer"`
 	JwtAccessTokenExpiresIn  int    `yaml:"jwt_access_token_expires_in"`
 	JwtRefreshTokenExpiresIn int    `yaml:"jwt_refresh_token_expires_in"`
@@ -37,6 +39,65 @@ type config struct {
 	AWSBucketName      string `yaml:"aws_bucket_name"`
 
 	IOAPIKey string `yaml:"io_api_key"`
+
+	AppURL string `yaml:"app_url"`
+
+	MailerDriver  string `yaml:"mailer_driver"`
+	MailerFileDir string `yaml:"mailer_file_dir"`
+	MailerFrom    string `yaml:"mailer_from"`
+	SMTPHost      string `yaml:"smtp_host"`
+	SMTPPort      string `yaml:"smtp_port"`
+	SMTPUsername  string `yaml:"smtp_username"`
+	SMTPPassword  string `yaml:"smtp_password"`
+
+	PasswordResetExpiresIn     int `yaml:"password_reset_expires_in"`
+	EmailVerificationExpiresIn int `yaml:"email_verification_expires_in"`
+
+	TOTPIssuer                  string `yaml:"totp_issuer"`
+	TwoFactorChallengeExpiresIn int    `yaml:"two_factor_challenge_expires_in"`
+
+	SignInAttemptsStore         string `yaml:"sign_in_attempts_store"`
+	SignInAttemptsWindow        int    `yaml:"sign_in_attempts_window"`
+	SignInMaxAttemptsPerAccount int    `yaml:"sign_in_max_attempts_per_account"`
+	SignInMaxAttemptsPerIP      int    `yaml:"sign_in_max_attempts_per_ip"`
+	SignInLockoutBase           int    `yaml:"sign_in_lockout_base"`
+	SignInLockoutMax            int    `yaml:"sign_in_lockout_max"`
+
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	OIDCStateExpiresIn int                  `yaml:"oidc_state_expires_in"`
+	OIDCProviders      []OIDCProviderConfig `yaml:"oidc_providers"`
+
+	AccountExportsDir          string `yaml:"account_exports_dir"`
+	AccountExportExpiresIn     int    `yaml:"account_export_expires_in"`
+	AccountDeletionGracePeriod int    `yaml:"account_deletion_grace_period"`
+	AccountJobsInterval        int    `yaml:"account_jobs_interval"`
+
+	PaymentsGateway       string `yaml:"payments_gateway"`
+	PaymentsCurrency      string `yaml:"payments_currency"`
+	PaymentsReturnURL     string `yaml:"payments_return_url"`
+	PaymentsWebhookSecret string `yaml:"payments_webhook_secret"`
+	StripeSecretKey       string `yaml:"stripe_secret_key"`
+	StripeAPIURL          string `yaml:"stripe_api_url"`
+
+	BillingProvider           string `yaml:"billing_provider"`
+	SubscriptionJobsInterval  int    `yaml:"subscription_jobs_interval"`
+	SubscriptionRetryInterval int    `yaml:"subscription_retry_interval"`
+	SubscriptionGracePeriod   int    `yaml:"subscription_grace_period"`
+
+	PlatformFeePercent float64 `yaml:"platform_fee_percent"`
+	PayoutHoldDays     int     `yaml:"payout_hold_days"`
+
+	CommentEditWindow int `yaml:"comment_edit_window"`
+}
+
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"name"`
+	IssuerURL    string   `yaml:"issuer_url"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"`
 }
 
 var Config config
@@ -0,0 +1,27 @@
+package constants
+
+// API key scopes have the form "<resource>:<access>". Write access includes read access.
+var (
+	APIKeyResourceWorkouts   = "workouts"
+	APIKeyResourceExercises  = "exercises"
+	APIKeyResourceSessions   = "sessions"
+	APIKeyResourceNutritions = "nutritions"
+	APIKeyResourceAnalytics  = "analytics"
+
+	APIKeyResources = []string{
+		APIKeyResourceWorkouts,
+		APIKeyResourceExercises,
+		APIKeyResourceSessions,
+		APIKeyResourceNutritions,
+		APIKeyResourceAnalytics,
+	}
+
+	APIKeyAccessRead  = "read"
+	APIKeyAccessWrite = "write"
+
+	// APIKeyPrefix starts every API key so that they can be told apart from JWTs
+	// and found by secret scanners.
+	APIKeyPrefix = "fyp_"
+
+	CtxAPIKeyResourceKey = "CtxAPIKeyResourceKey"
+)
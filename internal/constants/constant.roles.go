@@ -0,0 +1,9 @@
+package constants
+
+var (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+
+	PermissionCatalogWrite = "catalog:write"
+	PermissionRolesManage  = "roles:manage"
+)
@@ -4,6 +4,11 @@ import (
 	"backend/internal/datasources/repositories/postgres"
 	"backend/internal/http/handlers"
 	"backend/internal/services"
+	"backend/pkg/attempts"
+	"backend/pkg/billing"
+	"backend/pkg/mailer"
+	"backend/pkg/oidc"
+	"backend/pkg/payments"
 	"backend/third_party/io"
 	"backend/third_party/s3"
 	"github.com/jmoiron/sqlx"
@@ -23,6 +28,24 @@ type Container struct {
 	SessionsRepository         services.SessionsRepository
 	SessionDetailsRepository   services.SessionDetailsRepository
 	NutritionsRepository       services.NutritionsRepository
+	RolesRepository            services.RolesRepository
+	TwoFactorRepository        services.TwoFactorRepository
+	SignInAttemptsRepository   services.SignInAttemptsRepository
+	OIDCRepository             services.OIDCRepository
+	APIKeysRepository          services.APIKeysRepository
+	AccountsRepository         services.AccountsRepository
+	PaymentsRepository         services.PaymentsRepository
+	PurchasesRepository        services.PurchasesRepository
+	PaymentMethodsRepository   services.PaymentMethodsRepository
+	SubscriptionsRepository    services.SubscriptionsRepository
+	EarningsRepository         services.EarningsRepository
+	PromoCodesRepository       services.PromoCodesRepository
+	WorkoutReviewsRepository   services.WorkoutReviewsRepository
+	WorkoutCommentsRepository  services.WorkoutCommentsRepository
+	FollowsRepository          services.FollowsRepository
+	ModerationRepository       services.ModerationRepository
+	ProgramsRepository         services.ProgramsRepository
+	WorkoutRevisionsRepository services.WorkoutRevisionsRepository
 
 	// Services
 	UsersService            *services.UsersService
@@ -37,6 +60,24 @@ type Container struct {
 	SessionDetailsService   *services.SessionDetailsService
 	AnalyticsService        *services.AnalyticsService
 	NutritionsService       *services.NutritionsService
+	RolesService            *services.RolesService
+	TwoFactorService        *services.TwoFactorService
+	SignInAttemptsService   *services.SignInAttemptsService
+	OIDCService             *services.OIDCService
+	APIKeysService          *services.APIKeysService
+	AccountsService         *services.AccountsService
+	PaymentsService         *services.PaymentsService
+	PurchasesService        *services.PurchasesService
+	PaymentMethodsService   *services.PaymentMethodsService
+	SubscriptionsService    *services.SubscriptionsService
+	EarningsService         *services.EarningsService
+	PromoCodesService       *services.PromoCodesService
+	WorkoutReviewsService   *services.WorkoutReviewsService
+	WorkoutCommentsService  *services.WorkoutCommentsService
+	FollowsService          *services.FollowsService
+	ModerationService       *services.ModerationService
+	ProgramsService         *services.ProgramsService
+	WorkoutRevisionsService *services.WorkoutRevisionsService
 
 	// Handlers
 	UsersHandler            *handlers.UsersHandler
@@ -51,9 +92,25 @@ type Container struct {
 	SessionDetailsHandler   *handlers.SessionDetailsHandler
 	AnalyticsHandler        *handlers.AnalyticsHandler
 	NutritionsHandler       *handlers.NutritionsHandler
+	RolesHandler            *handlers.RolesHandler
+	TwoFactorHandler        *handlers.TwoFactorHandler
+	OIDCHandler             *handlers.OIDCHandler
+	APIKeysHandler          *handlers.APIKeysHandler
+	AccountsHandler         *handlers.AccountsHandler
+	PaymentsHandler         *handlers.PaymentsHandler
+	PaymentMethodsHandler   *handlers.PaymentMethodsHandler
+	SubscriptionsHandler    *handlers.SubscriptionsHandler
+	EarningsHandler         *handlers.EarningsHandler
+	PromoCodesHandler       *handlers.PromoCodesHandler
+	WorkoutReviewsHandler   *handlers.WorkoutReviewsHandler
+	WorkoutCommentsHandler  *handlers.WorkoutCommentsHandler
+	FollowsHandler          *handlers.FollowsHandler
+	ModerationHandler       *handlers.ModerationHandler
+	ProgramsHandler         *handlers.ProgramsHandler
+	WorkoutRevisionsHandler *handlers.WorkoutRevisionsHandler
 }
 
-func NewContainer(db *sqlx.DB, s3Client *s3.Client, ionet *io.Client) *Container {
+func NewContainer(db *sqlx.DB, s3Client *s3.Client, ionet *io.Client, mail mailer.Mailer, attemptsStore attempts.Store, oidcProviders map[string]*oidc.Provider, paymentGateway payments.Gateway, biller billing.Biller) *Container {
 	// Initialize repositories
 	usersRepository := postgres.NewPostgresUsersRepository(db)
 	tokenRepository := postgres.NewPostgresTokensRepository(db)
@@ -66,24 +123,62 @@ func NewContainer(db *sqlx.DB, s3Client *s3.Client, ionet *io.Client) *Container
 	sessionsRepository := postgres.NewPostgresSessionsRepository(db)
 	sessionDetailsRepository := postgres.NewPostgresSessionDetailsRepository(db)
 	nutritionsRepository := postgres.NewPostgresNutritionsRepository(db)
+	rolesRepository := postgres.NewPostgresRolesRepository(db)
+	verificationTokensRepository := postgres.NewPostgresVerificationTokensRepository(db)
+	twoFactorRepository := postgres.NewPostgresTwoFactorRepository(db)
+	signInAttemptsRepository := postgres.NewPostgresSignInAttemptsRepository(db)
+	oidcRepository := postgres.NewPostgresOIDCRepository(db)
+	apiKeysRepository := postgres.NewPostgresAPIKeysRepository(db)
+	accountsRepository := postgres.NewPostgresAccountsRepository(db)
+	paymentsRepository := postgres.NewPostgresPaymentsRepository(db)
+	purchasesRepository := postgres.NewPostgresPurchasesRepository(db)
+	paymentMethodsRepository := postgres.NewPostgresPaymentMethodsRepository(db)
+	subscriptionsRepository := postgres.NewPostgresSubscriptionsRepository(db)
+	earningsRepository := postgres.NewPostgresEarningsRepository(db)
+	promoCodesRepository := postgres.NewPostgresPromoCodesRepository(db)
+	workoutReviewsRepository := postgres.NewPostgresWorkoutReviewsRepository(db)
+	workoutCommentsRepository := postgres.NewPostgresWorkoutCommentsRepository(db)
+	followsRepository := postgres.NewPostgresFollowsRepository(db)
+	moderationRepository := postgres.NewPostgresModerationRepository(db)
+	programsRepository := postgres.NewPostgresProgramsRepository(db)
+	workoutRevisionsRepository := postgres.NewPostgresWorkoutRevisionsRepository(db)
 
 	// Initialize services
 	usersService := services.NewUsersService(usersRepository)
 	tokenService := services.NewTokensService(tokenRepository)
-	authService := services.NewAuthService(usersService, tokenService)
+	rolesService := services.NewRolesService(rolesRepository)
+	verificationTokensService := services.NewVerificationTokensService(verificationTokensRepository)
+	twoFactorService := services.NewTwoFactorService(twoFactorRepository, usersService)
+	signInAttemptsService := services.NewSignInAttemptsService(signInAttemptsRepository, attemptsStore)
+	authService := services.NewAuthService(usersService, tokenService, rolesService, verificationTokensService, twoFactorService, signInAttemptsService, mail)
+	oidcService := services.NewOIDCService(oidcRepository, oidcProviders, usersService, tokenService, authService)
+	apiKeysService := services.NewAPIKeysService(apiKeysRepository, usersService)
+	accountsService := services.NewAccountsService(accountsRepository, usersRepository, authService, s3Client)
 	exercisesService := services.NewExercisesService(exercisesRepository)
-	workoutExercisesService := services.NewWorkoutExercisesService(workoutExercisesRepository)
-	workoutsService := services.NewWorkoutsService(workoutsRepository, workoutExercisesService, ionet, exercisesService)
-	exerciseSetsService := services.NewExerciseSetsService(exerciseSetsRepository)
+	workoutRevisionsService := services.NewWorkoutRevisionsService(workoutRevisionsRepository)
+	workoutExercisesService := services.NewWorkoutExercisesService(workoutExercisesRepository, workoutRevisionsService)
+	purchasesService := services.NewPurchasesService(purchasesRepository)
+	paymentMethodsService := services.NewPaymentMethodsService(paymentMethodsRepository, paymentGateway)
+	subscriptionsService := services.NewSubscriptionsService(subscriptionsRepository, paymentMethodsService, biller)
+	workoutsService := services.NewWorkoutsService(workoutsRepository, workoutExercisesService, ionet, exercisesService, purchasesService, subscriptionsService, workoutRevisionsService)
+	exerciseSetsService := services.NewExerciseSetsService(exerciseSetsRepository, workoutExercisesService)
 	activityGroupsService := services.NewActivityGroupsService(activityGroupsRepository)
 	activitiesService := services.NewActivitiesService(activitiesRepository)
 	sessionsService := services.NewSessionsService(sessionsRepository)
 	sessionDetailsService := services.NewSessionDetailsService(sessionDetailsRepository)
 	analyticsService := services.NewAnalyticsService(exerciseSetsRepository, sessionsRepository)
 	nutritionsService := services.NewNutritionsService(nutritionsRepository)
+	earningsService := services.NewEarningsService(earningsRepository)
+	promoCodesService := services.NewPromoCodesService(promoCodesRepository, workoutsService)
+	workoutReviewsService := services.NewWorkoutReviewsService(workoutReviewsRepository, workoutsService, purchasesService)
+	workoutCommentsService := services.NewWorkoutCommentsService(workoutCommentsRepository, workoutsService, usersService, mail)
+	followsService := services.NewFollowsService(followsRepository, usersService)
+	moderationService := services.NewModerationService(moderationRepository, usersService, authService)
+	programsService := services.NewProgramsService(programsRepository, purchasesService, subscriptionsService)
+	paymentsService := services.NewPaymentsService(paymentsRepository, paymentGateway, workoutsService, purchasesService, earningsService, promoCodesService)
 
 	// Initialize handlers
-	usersHandler := handlers.NewUsersHandler(usersService, s3Client)
+	usersHandler := handlers.NewUsersHandler(usersService, accountsService, s3Client)
 	authHandler := handlers.NewAuthHandler(authService)
 	exercisesHandler := handlers.NewExercisesHandler(exercisesService)
 	workoutsHandler := handlers.NewWorkoutsHandler(workoutsService)
@@ -95,6 +190,22 @@ func NewContainer(db *sqlx.DB, s3Client *s3.Client, ionet *io.Client) *Container
 	sessionDetailsHandler := handlers.NewSessionDetailsHandler(sessionDetailsService)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
 	nutritionsHandler := handlers.NewNutritionsHandler(nutritionsService)
+	rolesHandler := handlers.NewRolesHandler(rolesService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+	apiKeysHandler := handlers.NewAPIKeysHandler(apiKeysService)
+	accountsHandler := handlers.NewAccountsHandler(accountsService)
+	paymentsHandler := handlers.NewPaymentsHandler(paymentsService, purchasesService)
+	paymentMethodsHandler := handlers.NewPaymentMethodsHandler(paymentMethodsService)
+	subscriptionsHandler := handlers.NewSubscriptionsHandler(subscriptionsService)
+	earningsHandler := handlers.NewEarningsHandler(earningsService)
+	promoCodesHandler := handlers.NewPromoCodesHandler(promoCodesService)
+	workoutReviewsHandler := handlers.NewWorkoutReviewsHandler(workoutReviewsService)
+	workoutCommentsHandler := handlers.NewWorkoutCommentsHandler(workoutCommentsService)
+	followsHandler := handlers.NewFollowsHandler(followsService)
+	moderationHandler := handlers.NewModerationHandler(moderationService)
+	programsHandler := handlers.NewProgramsHandler(programsService)
+	workoutRevisionsHandler := handlers.NewWorkoutRevisionsHandler(workoutRevisionsService, workoutsService)
 
 	return &Container{
 		DB: db,
@@ -110,6 +221,24 @@ func NewContainer(db *sqlx.DB, s3Client *s3.Client, ionet *io.Client) *Container
 		SessionsRepository:         sessionsRepository,
 		SessionDetailsRepository:   sessionDetailsRepository,
 		NutritionsRepository:       nutritionsRepository,
+		RolesRepository:            rolesRepository,
+		TwoFactorRepository:        twoFactorRepository,
+		SignInAttemptsRepository:   signInAttemptsRepository,
+		OIDCRepository:             oidcRepository,
+		APIKeysRepository:          apiKeysRepository,
+		AccountsRepository:         accountsRepository,
+		PaymentsRepository:         paymentsRepository,
+		PurchasesRepository:        purchasesRepository,
+		PaymentMethodsRepository:   paymentMethodsRepository,
+		SubscriptionsRepository:    subscriptionsRepository,
+		EarningsRepository:         earningsRepository,
+		PromoCodesRepository:       promoCodesRepository,
+		WorkoutReviewsRepository:   workoutReviewsRepository,
+		WorkoutCommentsRepository:  workoutCommentsRepository,
+		FollowsRepository:          followsRepository,
+		ModerationRepository:       moderationRepository,
+		ProgramsRepository:         programsRepository,
+		WorkoutRevisionsRepository: workoutRevisionsRepository,
 
 		// Services
 		UsersService:            usersService,
@@ -124,6 +253,24 @@ func NewContainer(db *sqlx.DB, s3Client *s3.Client, ionet *io.Client) *Container
 		SessionDetailsService:   sessionDetailsService,
 		AnalyticsService:        analyticsService,
 		NutritionsService:       nutritionsService,
+		RolesService:            rolesService,
+		TwoFactorService:        twoFactorService,
+		SignInAttemptsService:   signInAttemptsService,
+		OIDCService:             oidcService,
+		APIKeysService:          apiKeysService,
+		AccountsService:         accountsService,
+		PaymentsService:         paymentsService,
+		PurchasesService:        purchasesService,
+		PaymentMethodsService:   paymentMethodsService,
+		SubscriptionsService:    subscriptionsService,
+		EarningsService:         earningsService,
+		PromoCodesService:       promoCodesService,
+		WorkoutReviewsService:   workoutReviewsService,
+		WorkoutCommentsService:  workoutCommentsService,
+		FollowsService:          followsService,
+		ModerationService:       moderationService,
+		ProgramsService:         programsService,
+		WorkoutRevisionsService: workoutRevisionsService,
 
 		// Handlers
 		UsersHandler:            usersHandler,
@@ -138,5 +285,21 @@ func NewContainer(db *sqlx.DB, s3Client *s3.Client, ionet *io.Client) *Container
 		SessionDetailsHandler:   sessionDetailsHandler,
 		AnalyticsHandler:        analyticsHandler,
 		NutritionsHandler:       nutritionsHandler,
+		RolesHandler:            rolesHandler,
+		TwoFactorHandler:        twoFactorHandler,
+		OIDCHandler:             oidcHandler,
+		APIKeysHandler:          apiKeysHandler,
+		AccountsHandler:         accountsHandler,
+		PaymentsHandler:         paymentsHandler,
+		PaymentMethodsHandler:   paymentMethodsHandler,
+		SubscriptionsHandler:    subscriptionsHandler,
+		EarningsHandler:         earningsHandler,
+		PromoCodesHandler:       promoCodesHandler,
+		WorkoutReviewsHandler:   workoutReviewsHandler,
+		WorkoutCommentsHandler:  workoutCommentsHandler,
+		FollowsHandler:          followsHandler,
+		ModerationHandler:       moderationHandler,
+		ProgramsHandler:         programsHandler,
+		WorkoutRevisionsHandler: workoutRevisionsHandler,
 	}
 }
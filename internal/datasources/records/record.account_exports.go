@@ -0,0 +1,13 @@
+package records
+
+import "database/sql"
+
+type AccountExports struct {
+	Record
+	UserID      int          `db:"user_id"`
+	Status      string       `db:"status"`
+	FilePath    string       `db:"file_path"`
+	Error       string       `db:"error"`
+	CompletedAt sql.NullTime `db:"completed_at"`
+	ExpiresAt   sql.NullTime `db:"expires_at"`
+}
@@ -0,0 +1,17 @@
+package records
+
+import (
+	"database/sql"
+	"github.com/lib/pq"
+)
+
+type APIKeys struct {
+	Record
+	UserID     int            `db:"user_id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	KeyHash    string         `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	ExpiresAt  sql.NullTime   `db:"expires_at"`
+	LastUsedAt sql.NullTime   `db:"last_used_at"`
+}
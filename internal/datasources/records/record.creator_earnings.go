@@ -0,0 +1,66 @@
+package records
+
+import (
+	"database/sql"
+	"time"
+)
+
+// CreatorEarnings is a ledger entry of a creator. Amounts are in the
+// currency's minor unit; reversals are negative.
+type CreatorEarnings struct {
+	Record
+	CreatorID    int           `db:"creator_id"`
+	WorkoutID    sql.NullInt64 `db:"workout_id"`
+	WorkoutTitle string        `db:"workout_title"`
+	PaymentID    sql.NullInt64 `db:"payment_id"`
+	EntryType    string        `db:"entry_type"`
+	Gross        int64         `db:"gross"`
+	Fee          int64         `db:"fee"`
+	Net          int64         `db:"net"`
+	FeePercent   float64       `db:"fee_percent"`
+	Currency     string        `db:"currency"`
+	PayoutID     sql.NullInt64 `db:"payout_id"`
+}
+
+type CreatorPayouts struct {
+	Record
+	CreatorID    int          `db:"creator_id"`
+	Amount       int64        `db:"amount"`
+	Currency     string       `db:"currency"`
+	EntriesCount int          `db:"entries_count"`
+	Status       string       `db:"status"`
+	Reference    string       `db:"reference"`
+	PaidAt       sql.NullTime `db:"paid_at"`
+}
+
+type CreatorEarningsSummary struct {
+	Currency string `db:"currency"`
+	Sales    int    `db:"sales"`
+	Refunds  int    `db:"refunds"`
+	Gross    int64  `db:"gross"`
+	Fee      int64  `db:"fee"`
+	Net      int64  `db:"net"`
+	PaidOut  int64  `db:"paid_out"`
+	Unpaid   int64  `db:"unpaid"`
+}
+
+type CreatorEarningsByWorkout struct {
+	WorkoutID    sql.NullInt64 `db:"workout_id"`
+	WorkoutTitle string        `db:"workout_title"`
+	Currency     string        `db:"currency"`
+	Sales        int           `db:"sales"`
+	Refunds      int           `db:"refunds"`
+	Gross        int64         `db:"gross"`
+	Fee          int64         `db:"fee"`
+	Net          int64         `db:"net"`
+}
+
+type CreatorEarningsByMonth struct {
+	Month    time.Time `db:"month"`
+	Currency string    `db:"currency"`
+	Sales    int       `db:"sales"`
+	Refunds  int       `db:"refunds"`
+	Gross    int64     `db:"gross"`
+	Fee      int64     `db:"fee"`
+	Net      int64     `db:"net"`
+}
@@ -1,14 +1,18 @@
 package records
 
+import "database/sql"
+
 type Exercises struct {
 	Record
-	Name string `db:"name"`
+	Name     string       `db:"name"`
+	HiddenAt sql.NullTime `db:"hidden_at"`
 }
 
 // ExercisesWithWorkoutCheck is a struct that is not stored in the database.
 // It is used to check if an exercise is in a workout.
 type ExercisesWithWorkoutCheck struct {
 	Record
-	Name        string `db:"name"`
-	IsInWorkout bool   `db:"is_in_workout"`
+	Name        string       `db:"name"`
+	IsInWorkout bool         `db:"is_in_workout"`
+	HiddenAt    sql.NullTime `db:"hidden_at"`
 }
@@ -0,0 +1,39 @@
+package records
+
+import (
+	"database/sql"
+	"time"
+)
+
+type UserFollows struct {
+	Record
+	FollowerID  int `db:"follower_id"`
+	FollowingID int `db:"following_id"`
+}
+
+// FollowUsers is a follower or followed user, with the follow it came from.
+type FollowUsers struct {
+	ID        int       `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	UserID    int       `db:"user_id"`
+	Username  string    `db:"username"`
+	Bio       string    `db:"bio"`
+	Avatar    string    `db:"avatar"`
+}
+
+// FeedItems is a new workout, a like or a completed session of a followed
+// user. SortKey is unique across the item types, see FindFeed.
+type FeedItems struct {
+	Type          string         `db:"type"`
+	ID            int            `db:"id"`
+	SortKey       int            `db:"sort_key"`
+	ActorID       int            `db:"actor_id"`
+	ActorUsername string         `db:"actor_username"`
+	ActorAvatar   string         `db:"actor_avatar"`
+	WorkoutID     sql.NullInt64  `db:"workout_id"`
+	WorkoutTitle  sql.NullString `db:"workout_title"`
+	ActivityName  sql.NullString `db:"activity_name"`
+	StartTime     sql.NullTime   `db:"start_time"`
+	EndTime       sql.NullTime   `db:"end_time"`
+	OccurredAt    time.Time      `db:"occurred_at"`
+}
@@ -0,0 +1,36 @@
+package records
+
+import "database/sql"
+
+type UserBlocks struct {
+	Record
+	BlockerID int `db:"blocker_id"`
+	BlockedID int `db:"blocked_id"`
+}
+
+// BlockedUsers is a user blocked by the user, with the block it came from.
+type BlockedUsers struct {
+	UserBlocks
+	Username string `db:"username"`
+	Avatar   string `db:"avatar"`
+}
+
+type ContentReports struct {
+	Record
+	ReporterID  int           `db:"reporter_id"`
+	ContentType string        `db:"content_type"`
+	ContentID   int           `db:"content_id"`
+	Reason      string        `db:"reason"`
+	Details     string        `db:"details"`
+	Status      string        `db:"status"`
+	ActionID    sql.NullInt64 `db:"action_id"`
+}
+
+type ModerationActions struct {
+	Record
+	ModeratorID int    `db:"moderator_id"`
+	ContentType string `db:"content_type"`
+	ContentID   int    `db:"content_id"`
+	Action      string `db:"action"`
+	Reason      string `db:"reason"`
+}
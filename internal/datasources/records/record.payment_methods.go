@@ -0,0 +1,15 @@
+package records
+
+import "database/sql"
+
+type PaymentMethods struct {
+	Record
+	UserID    int            `db:"user_id"`
+	Gateway   string         `db:"gateway"`
+	Token     sql.NullString `db:"token"`
+	Brand     string         `db:"brand"`
+	Last4     string         `db:"last4"`
+	ExpMonth  sql.NullInt64  `db:"exp_month"`
+	ExpYear   sql.NullInt64  `db:"exp_year"`
+	IsDefault bool           `db:"is_default"`
+}
@@ -0,0 +1,20 @@
+package records
+
+import "database/sql"
+
+type Payments struct {
+	Record
+	UserID         int           `db:"user_id"`
+	WorkoutID      sql.NullInt64 `db:"workout_id"`
+	Gateway        string        `db:"gateway"`
+	IntentID       string        `db:"intent_id"`
+	Amount         int64         `db:"amount"`
+	OriginalAmount int64         `db:"original_amount"`
+	Discount       int64         `db:"discount"`
+	PromoCode      string        `db:"promo_code"`
+	Currency       string        `db:"currency"`
+	Status         string        `db:"status"`
+	FailureReason  string        `db:"failure_reason"`
+	FulfilledAt    sql.NullTime  `db:"fulfilled_at"`
+	RefundedAt     sql.NullTime  `db:"refunded_at"`
+}
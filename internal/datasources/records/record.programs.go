@@ -0,0 +1,57 @@
+package records
+
+import "database/sql"
+
+// Programs is a multi-week program. WeeksCount and Price are not stored,
+// they are derived from the weeks and the workouts of the schedule.
+type Programs struct {
+	Record
+	OwnerID     int     `db:"owner_id"`
+	Title       string  `db:"title"`
+	Description string  `db:"description"`
+	DaysPerWeek int     `db:"days_per_week"`
+	IsPrivate   bool    `db:"is_private"`
+	WeeksCount  int     `db:"weeks_count"`
+	Price       float64 `db:"price"`
+}
+
+type ProgramWeeks struct {
+	Record
+	ProgramID  int    `db:"program_id"`
+	WeekNumber int    `db:"week_number"`
+	IsDeload   bool   `db:"is_deload"`
+	Notes      string `db:"notes"`
+}
+
+// ProgramDays is a scheduled workout, with the fields of the workout that
+// decide who may follow it.
+type ProgramDays struct {
+	Record
+	ProgramID          int     `db:"program_id"`
+	WeekNumber         int     `db:"week_number"`
+	DayNumber          int     `db:"day_number"`
+	WorkoutID          int     `db:"workout_id"`
+	WorkoutTitle       string  `db:"workout_title"`
+	WorkoutPrice       float64 `db:"workout_price"`
+	WorkoutMembersOnly bool    `db:"workout_members_only"`
+	WorkoutOwnerID     int     `db:"workout_owner_id"`
+}
+
+type ProgramEnrollments struct {
+	Record
+	ProgramID     int          `db:"program_id"`
+	UserID        int          `db:"user_id"`
+	CurrentWeek   int          `db:"current_week"`
+	CurrentDay    int          `db:"current_day"`
+	CompletedDays int          `db:"completed_days"`
+	CompletedAt   sql.NullTime `db:"completed_at"`
+	ProgramTitle  string       `db:"program_title"`
+}
+
+type ProgramCompletions struct {
+	Record
+	EnrollmentID int           `db:"enrollment_id"`
+	WeekNumber   int           `db:"week_number"`
+	DayNumber    int           `db:"day_number"`
+	WorkoutID    sql.NullInt64 `db:"workout_id"`
+}
@@ -0,0 +1,35 @@
+package records
+
+import "database/sql"
+
+type PromoCodes struct {
+	Record
+	Code           string        `db:"code"`
+	OwnerID        sql.NullInt64 `db:"owner_id"`
+	DiscountType   string        `db:"discount_type"`
+	DiscountValue  float64       `db:"discount_value"`
+	Currency       string        `db:"currency"`
+	MaxRedemptions sql.NullInt64 `db:"max_redemptions"`
+	PerUserLimit   int           `db:"per_user_limit"`
+	StartsAt       sql.NullTime  `db:"starts_at"`
+	ExpiresAt      sql.NullTime  `db:"expires_at"`
+	IsActive       bool          `db:"is_active"`
+}
+
+type PromoRedemptions struct {
+	Record
+	PromoCodeID int   `db:"promo_code_id"`
+	UserID      int   `db:"user_id"`
+	PaymentID   int   `db:"payment_id"`
+	Discount    int64 `db:"discount"`
+}
+
+type PromoCodeWorkouts struct {
+	PromoCodeID int `db:"promo_code_id"`
+	WorkoutID   int `db:"workout_id"`
+}
+
+type PromoCodeUsage struct {
+	PromoCodeID int `db:"promo_code_id"`
+	Redemptions int `db:"redemptions"`
+}
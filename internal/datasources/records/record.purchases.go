@@ -0,0 +1,16 @@
+package records
+
+import "database/sql"
+
+type Purchases struct {
+	Record
+	BuyerID       int           `db:"buyer_id"`
+	WorkoutID     sql.NullInt64 `db:"workout_id"`
+	PaymentID     sql.NullInt64 `db:"payment_id"`
+	PricePaid     float64       `db:"price_paid"`
+	OriginalPrice float64       `db:"original_price"`
+	Discount      float64       `db:"discount"`
+	PromoCode     string        `db:"promo_code"`
+	Currency      string        `db:"currency"`
+	Status        string        `db:"status"`
+}
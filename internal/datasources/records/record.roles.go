@@ -0,0 +1,13 @@
+package records
+
+type Roles struct {
+	Record
+	Name        string `db:"name"`
+	Description string `db:"description"`
+}
+
+type Permissions struct {
+	Record
+	Name        string `db:"name"`
+	Description string `db:"description"`
+}
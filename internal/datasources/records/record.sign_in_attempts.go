@@ -0,0 +1,12 @@
+package records
+
+import "database/sql"
+
+type SignInAttempts struct {
+	Record
+	Email     string        `db:"email"`
+	UserID    sql.NullInt64 `db:"user_id"`
+	IPAddress string        `db:"ip_address"`
+	UserAgent string        `db:"user_agent"`
+	Reason    string        `db:"reason"`
+}
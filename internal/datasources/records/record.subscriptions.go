@@ -0,0 +1,34 @@
+package records
+
+import (
+	"database/sql"
+	"time"
+)
+
+type SubscriptionPlans struct {
+	Record
+	Name            string  `db:"name"`
+	Description     string  `db:"description"`
+	Price           float64 `db:"price"`
+	Currency        string  `db:"currency"`
+	BillingInterval string  `db:"billing_interval"`
+	TrialDays       int     `db:"trial_days"`
+	IsActive        bool    `db:"is_active"`
+}
+
+type Subscriptions struct {
+	Record
+	UserID             int           `db:"user_id"`
+	PlanID             int           `db:"plan_id"`
+	PaymentMethodID    sql.NullInt64 `db:"payment_method_id"`
+	Status             string        `db:"status"`
+	TrialEndsAt        sql.NullTime  `db:"trial_ends_at"`
+	CurrentPeriodStart time.Time     `db:"current_period_start"`
+	CurrentPeriodEnd   time.Time     `db:"current_period_end"`
+	CancelAtPeriodEnd  bool          `db:"cancel_at_period_end"`
+	CanceledAt         sql.NullTime  `db:"canceled_at"`
+	PastDueSince       sql.NullTime  `db:"past_due_since"`
+	NextAttemptAt      sql.NullTime  `db:"next_attempt_at"`
+	FailedAttempts     int           `db:"failed_attempts"`
+	LastError          string        `db:"last_error"`
+}
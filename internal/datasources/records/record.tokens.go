@@ -1,7 +1,19 @@
 package records
 
+import (
+	"database/sql"
+	"time"
+)
+
 type Tokens struct {
 	Record
-	Token  string `db:"token"`
-	UserID int    `db:"user_id"`
+	Token      string       `db:"token"`
+	UserID     int          `db:"user_id"`
+	FamilyID   string       `db:"family_id"`
+	RotatedAt  sql.NullTime `db:"rotated_at"`
+	DeviceName string       `db:"device_name"`
+	UserAgent  string       `db:"user_agent"`
+	IPAddress  string       `db:"ip_address"`
+	SignedInAt time.Time    `db:"signed_in_at"`
+	LastUsedAt time.Time    `db:"last_used_at"`
 }
@@ -0,0 +1,11 @@
+package records
+
+import "database/sql"
+
+type TOTPCredentials struct {
+	Record
+	UserID       int          `db:"user_id"`
+	Secret       string       `db:"secret"`
+	ConfirmedAt  sql.NullTime `db:"confirmed_at"`
+	LastUsedStep int64        `db:"last_used_step"`
+}
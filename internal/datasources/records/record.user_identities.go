@@ -0,0 +1,24 @@
+package records
+
+import (
+	"database/sql"
+	"time"
+)
+
+type UserIdentities struct {
+	Record
+	UserID   int    `db:"user_id"`
+	Provider string `db:"provider"`
+	Subject  string `db:"subject"`
+	Email    string `db:"email"`
+}
+
+type OIDCStates struct {
+	Record
+	StateHash    string       `db:"state_hash"`
+	Provider     string       `db:"provider"`
+	Nonce        string       `db:"nonce"`
+	CodeVerifier string       `db:"code_verifier"`
+	ExpiresAt    time.Time    `db:"expires_at"`
+	UsedAt       sql.NullTime `db:"used_at"`
+}
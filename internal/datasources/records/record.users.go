@@ -1,11 +1,20 @@
 package records
 
+import "database/sql"
+
 type Users struct {
 	Record
-	Email    string `db:"email"`
-	Password string `db:"password"`
-	Username string `db:"username"`
-	Bio      string `db:"bio"`
-	Avatar   string `db:"avatar"`
-	CardPAN  string `db:"card_pan"`
+	Email               string         `db:"email"`
+	Password            string         `db:"password"`
+	Username            string         `db:"username"`
+	Bio                 string         `db:"bio"`
+	Avatar              string         `db:"avatar"`
+	CardPAN             sql.NullString `db:"card_pan"` // always NULL, cards are kept in payment_methods
+	EmailVerifiedAt     sql.NullTime   `db:"email_verified_at"`
+	DeletionRequestedAt sql.NullTime   `db:"deletion_requested_at"`
+	DeletionScheduledAt sql.NullTime   `db:"deletion_scheduled_at"`
+	FollowersCount      int            `db:"followers_count"`
+	FollowingCount      int            `db:"following_count"`
+	HiddenAt            sql.NullTime   `db:"hidden_at"`
+	BannedAt            sql.NullTime   `db:"banned_at"`
 }
@@ -0,0 +1,15 @@
+package records
+
+import (
+	"database/sql"
+	"time"
+)
+
+type VerificationTokens struct {
+	Record
+	TokenHash string       `db:"token_hash"`
+	Purpose   string       `db:"purpose"`
+	UserID    int          `db:"user_id"`
+	ExpiresAt time.Time    `db:"expires_at"`
+	UsedAt    sql.NullTime `db:"used_at"`
+}
@@ -0,0 +1,16 @@
+package records
+
+import "database/sql"
+
+type WorkoutComments struct {
+	Record
+	WorkoutID    int           `db:"workout_id"`
+	UserID       int           `db:"user_id"`
+	Username     string        `db:"username"`
+	ParentID     sql.NullInt64 `db:"parent_id"`
+	Body         string        `db:"body"`
+	IsPinned     bool          `db:"is_pinned"`
+	PinnedAt     sql.NullTime  `db:"pinned_at"`
+	RepliesCount int           `db:"replies_count"`
+	EditedAt     sql.NullTime  `db:"edited_at"`
+}
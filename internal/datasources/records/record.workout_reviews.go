@@ -0,0 +1,19 @@
+package records
+
+type WorkoutReviews struct {
+	Record
+	WorkoutID    int    `db:"workout_id"`
+	UserID       int    `db:"user_id"`
+	Username     string `db:"username"`
+	Rating       int    `db:"rating"`
+	Body         string `db:"body"`
+	ReportsCount int    `db:"reports_count"`
+}
+
+type ReviewReports struct {
+	Record
+	ReviewID   int    `db:"review_id"`
+	ReporterID int    `db:"reporter_id"`
+	Reason     string `db:"reason"`
+	Details    string `db:"details"`
+}
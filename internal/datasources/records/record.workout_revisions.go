@@ -0,0 +1,39 @@
+package records
+
+import (
+	"database/sql"
+	"github.com/lib/pq"
+)
+
+// WorkoutRevisions is an immutable snapshot of a workout. RestoredFrom is
+// the revision number it was restored from, if any.
+type WorkoutRevisions struct {
+	Record
+	WorkoutID      int            `db:"workout_id"`
+	RevisionNumber int            `db:"revision_number"`
+	Title          string         `db:"title"`
+	Description    string         `db:"description"`
+	Price          float64        `db:"price"`
+	IsPrivate      bool           `db:"is_private"`
+	MembersOnly    bool           `db:"members_only"`
+	Changelog      pq.StringArray `db:"changelog"`
+	RestoredFrom   sql.NullInt32  `db:"restored_from"`
+	Exercises      []WorkoutRevisionExercises
+}
+
+// WorkoutRevisionExercises is a workout exercise as it was in a revision.
+// Exercises sharing a GroupKey were grouped together.
+type WorkoutRevisionExercises struct {
+	Record
+	Prescription
+	RevisionID       int            `db:"revision_id"`
+	ExerciseID       int            `db:"exercise_id"`
+	ExerciseName     string         `db:"exercise_name"`
+	MainNote         string         `db:"main_note"`
+	SecondaryNote    string         `db:"secondary_note"`
+	Position         int            `db:"position"`
+	GroupKey         sql.NullInt64  `db:"group_key"`
+	GroupType        sql.NullString `db:"group_type"`
+	GroupRounds      sql.NullInt32  `db:"group_rounds"`
+	GroupRestSeconds sql.NullInt32  `db:"group_rest_seconds"`
+}
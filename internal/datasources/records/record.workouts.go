@@ -1,12 +1,36 @@
 package records
 
+import "database/sql"
+
 type Workouts struct {
 	Record
-	Title       string  `db:"title"`
-	Description string  `db:"description"`
-	IsPrivate   bool    `db:"is_private"`
-	Price       float64 `db:"price"`
-	OwnerID     int     `db:"owner_id"`
-	LikesCount  int     `db:"likes_count"`
-	Exercises   []WorkoutExercises
+	Title               string        `db:"title"`
+	Description         string        `db:"description"`
+	IsPrivate           bool          `db:"is_private"`
+	Price               float64       `db:"price"`
+	MembersOnly         bool          `db:"members_only"`
+	OwnerID             int           `db:"owner_id"`
+	LikesCount          int           `db:"likes_count"`
+	RatingAverage       float64       `db:"rating_average"`
+	RatingCount         int           `db:"rating_count"`
+	HiddenAt            sql.NullTime  `db:"hidden_at"`
+	ForkedFromWorkoutID sql.NullInt64 `db:"forked_from_workout_id"`
+	RootWorkoutID       sql.NullInt64 `db:"root_workout_id"`
+	UpstreamRevision    sql.NullInt32 `db:"upstream_revision"`
+	ForksCount          int           `db:"forks_count"`
+	UpstreamChanged     bool          `db:"upstream_changed"`
+	Exercises           []WorkoutExercises
+}
+
+// WorkoutLineage is where a workout was copied from and how often it and its
+// original were copied.
+type WorkoutLineage struct {
+	WorkoutID              int           `db:"workout_id"`
+	ForkedFromWorkoutID    sql.NullInt64 `db:"forked_from_workout_id"`
+	RootWorkoutID          sql.NullInt64 `db:"root_workout_id"`
+	ForksCount             int           `db:"forks_count"`
+	RootForksCount         int           `db:"root_forks_count"`
+	UpstreamRevision       sql.NullInt32 `db:"upstream_revision"`
+	UpstreamLatestRevision sql.NullInt32 `db:"upstream_latest_revision"`
+	UpstreamChanged        bool          `db:"upstream_changed"`
 }
@@ -1,11 +1,48 @@
 package records
 
+import "database/sql"
+
 type WorkoutExercises struct {
 	Record
-	Exercise      Exercises `db:"exercise"`
-	MainNote      string    `db:"main_note"`
-	SecondaryNote string    `db:"secondary_note"`
-	WorkoutID     int       `db:"workout_id"`
-	OwnerID       int       `db:"owner_id"`
-	ExerciseID    int       `db:"exercise_id"`
+	Prescription
+	Exercise      Exercises     `db:"exercise"`
+	MainNote      string        `db:"main_note"`
+	SecondaryNote string        `db:"secondary_note"`
+	WorkoutID     int           `db:"workout_id"`
+	OwnerID       int           `db:"owner_id"`
+	ExerciseID    int           `db:"exercise_id"`
+	Position      int           `db:"position"`
+	GroupID       sql.NullInt64 `db:"group_id"`
+
+	// Joined from the group of the exercise, if it has one.
+	GroupType        sql.NullString `db:"group_type"`
+	GroupRounds      sql.NullInt32  `db:"group_rounds"`
+	GroupRestSeconds sql.NullInt32  `db:"group_rest_seconds"`
+}
+
+// Prescription is the structured target of a workout exercise. Every field
+// is optional, LoadValue is read according to LoadType.
+type Prescription struct {
+	TargetSets    sql.NullInt32   `db:"target_sets"`
+	TargetRepsMin sql.NullInt32   `db:"target_reps_min"`
+	TargetRepsMax sql.NullInt32   `db:"target_reps_max"`
+	LoadType      sql.NullString  `db:"load_type"`
+	LoadValue     sql.NullFloat64 `db:"load_value"`
+	Tempo         sql.NullString  `db:"tempo"`
+	RestSeconds   sql.NullInt32   `db:"rest_seconds"`
+}
+
+type WorkoutExerciseGroups struct {
+	Record
+	WorkoutID   int           `db:"workout_id"`
+	GroupType   string        `db:"group_type"`
+	Rounds      int           `db:"rounds"`
+	RestSeconds sql.NullInt32 `db:"rest_seconds"`
+}
+
+// WorkoutExerciseLayout is an entry of a workout in order: a single exercise,
+// or the exercises of a group when Group is set.
+type WorkoutExerciseLayout struct {
+	Group              *WorkoutExerciseGroups
+	WorkoutExerciseIDs []int
 }
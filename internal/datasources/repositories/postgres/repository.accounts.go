@@ -0,0 +1,466 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresAccountsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresAccountsRepository(db *sqlx.DB) services.AccountsRepository {
+	return &postgresAccountsRepository{db}
+}
+
+// exportQueries lists every table included in a data export, selecting the
+// rows that belong to the user. Credentials, token hashes and card tokens are
+// left out. Tables added by new features have to be listed here as well.
+var exportQueries = []struct {
+	name  string
+	query squirrel.SelectBuilder
+}{
+	{"profile", squirrel.Select("id", "email", "username", "bio", "avatar", "email_verified_at", "created_at", "updated_at").From("users").Where("id = ?")},
+	{"workouts", squirrel.Select("*").From("workouts").Where("owner_id = ?").OrderBy("id ASC")},
+	{"workout_exercises", squirrel.Select("*").From("workout_exercises").Where("owner_id = ?").OrderBy("id ASC")},
+	{"exercise_sets", squirrel.Select("*").From("exercise_sets").Where("owner_id = ?").OrderBy("id ASC")},
+	{"sessions", squirrel.Select("*").From("sessions").Where("owner_id = ?").OrderBy("id ASC")},
+	{"session_details", squirrel.Select("*").From("session_details").Where("session_id IN (SELECT id FROM sessions WHERE owner_id = ?)").OrderBy("id ASC")},
+	{"nutritions", squirrel.Select("*").From("nutritions").Where("owner_id = ?").OrderBy("id ASC")},
+	{"workout_likes", squirrel.Select("*").From("workout_likes").Where("user_id = ?").OrderBy("id ASC")},
+	{"custom_exercises", squirrel.Select("e.*").From("exercises e").Join("user_exercises ue ON ue.exercise_id = e.id").Where("ue.user_id = ?").OrderBy("e.id ASC")},
+	{"login_sessions", squirrel.Select("id", "family_id", "device_name", "user_agent", "ip_address", "signed_in_at", "last_used_at", "created_at", "deleted_at").From("tokens").Where("user_id = ?").OrderBy("id ASC")},
+	{"identities", squirrel.Select("id", "provider", "subject", "email", "created_at").From("user_identities").Where("user_id = ?").OrderBy("id ASC")},
+	{"api_keys", squirrel.Select("id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at", "deleted_at").From("api_keys").Where("user_id = ?").OrderBy("id ASC")},
+	{"payments", squirrel.Select("*").From("payments").Where("user_id = ?").OrderBy("id ASC")},
+	{"purchases", squirrel.Select("*").From("purchases").Where("buyer_id = ?").OrderBy("id ASC")},
+	{"payment_methods", squirrel.Select("id", "gateway", "brand", "last4", "exp_month", "exp_year", "is_default", "created_at", "deleted_at").From("payment_methods").Where("user_id = ?").OrderBy("id ASC")},
+	{"subscriptions", squirrel.Select("*").From("subscriptions").Where("user_id = ?").OrderBy("id ASC")},
+	{"creator_earnings", squirrel.Select("*").From("creator_earnings").Where("creator_id = ?").OrderBy("id ASC")},
+	{"creator_payouts", squirrel.Select("*").From("creator_payouts").Where("creator_id = ?").OrderBy("id ASC")},
+	{"promo_codes", squirrel.Select("*").From("promo_codes").Where("owner_id = ?").OrderBy("id ASC")},
+	{"promo_redemptions", squirrel.Select("*").From("promo_redemptions").Where("user_id = ?").OrderBy("id ASC")},
+	{"workout_reviews", squirrel.Select("*").From("workout_reviews").Where("user_id = ?").OrderBy("id ASC")},
+	{"workout_copies", squirrel.Select("*").From("workout_copies").Where("user_id = ?").OrderBy("id ASC")},
+	{"workout_comments", squirrel.Select("*").From("workout_comments").Where("user_id = ?").OrderBy("id ASC")},
+	{"following", squirrel.Select("*").From("user_follows").Where("follower_id = ?").OrderBy("id ASC")},
+	{"followers", squirrel.Select("*").From("user_follows").Where("following_id = ?").OrderBy("id ASC")},
+	{"blocks", squirrel.Select("*").From("user_blocks").Where("blocker_id = ?").OrderBy("id ASC")},
+	{"reports", squirrel.Select("*").From("content_reports").Where("reporter_id = ?").OrderBy("id ASC")},
+	{"programs", squirrel.Select("*").From("programs").Where("owner_id = ?").OrderBy("id ASC")},
+	{"program_enrollments", squirrel.Select("*").From("program_enrollments").Where("user_id = ?").OrderBy("id ASC")},
+	{"program_completions", squirrel.Select("*").From("program_completions").Where("enrollment_id IN (SELECT id FROM program_enrollments WHERE user_id = ?)").OrderBy("id ASC")},
+	{"workout_exercise_groups", squirrel.Select("*").From("workout_exercise_groups").Where("workout_id IN (SELECT id FROM workouts WHERE owner_id = ?)").OrderBy("id ASC")},
+	{"workout_revisions", squirrel.Select("*").From("workout_revisions").Where("workout_id IN (SELECT id FROM workouts WHERE owner_id = ?)").OrderBy("id ASC")},
+}
+
+func (r *postgresAccountsRepository) SaveExport(userID int) (records.AccountExports, error) {
+	query, args, err := squirrel.
+		Insert("account_exports").
+		Columns("user_id", "status", "created_at").
+		Values(userID, services.AccountExportPending, time.Now()).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.AccountExports{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - SaveExport - squirrel.Insert: %w", err))
+	}
+
+	var export records.AccountExports
+	if err := r.db.Get(&export, query, args...); err != nil {
+		return records.AccountExports{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - SaveExport - db.Get: %w", err))
+	}
+
+	return export, nil
+}
+
+func (r *postgresAccountsRepository) FindExport(userID int, id int) (records.AccountExports, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("account_exports").
+		Where(squirrel.Eq{"id": id, "user_id": userID, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.AccountExports{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FindExport - squirrel.Select: %w", err))
+	}
+
+	var export records.AccountExports
+	if err := r.db.Get(&export, query, args...); err != nil {
+		return records.AccountExports{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FindExport - db.Get: %w", err))
+	}
+
+	return export, nil
+}
+
+func (r *postgresAccountsRepository) FindExportsByUserID(userID int) ([]records.AccountExports, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("account_exports").
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil}).
+		OrderBy("id DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FindExportsByUserID - squirrel.Select: %w", err))
+	}
+
+	var exports []records.AccountExports
+	if err := r.db.Select(&exports, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FindExportsByUserID - db.Select: %w", err))
+	}
+
+	return exports, nil
+}
+
+func (r *postgresAccountsRepository) HasUnfinishedExport(userID int) (bool, error) {
+	query, args, err := squirrel.
+		Select("COUNT(*) > 0").
+		From("account_exports").
+		Where(squirrel.Eq{
+			"user_id":    userID,
+			"status":     []string{services.AccountExportPending, services.AccountExportProcessing},
+			"deleted_at": nil,
+		}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - HasUnfinishedExport - squirrel.Select: %w", err))
+	}
+
+	var unfinished bool
+	if err := r.db.Get(&unfinished, query, args...); err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - HasUnfinishedExport - db.Get: %w", err))
+	}
+
+	return unfinished, nil
+}
+
+// ClaimPendingExports moves up to limit pending exports, and exports stuck in
+// processing since before staleBefore, to processing. Rows locked by another
+// instance are skipped so each export is built once.
+func (r *postgresAccountsRepository) ClaimPendingExports(staleBefore time.Time, limit int) ([]records.AccountExports, error) {
+	claimable, claimableArgs, err := squirrel.
+		Select("id").
+		From("account_exports").
+		Where(squirrel.Or{
+			squirrel.Eq{"status": services.AccountExportPending},
+			squirrel.And{
+				squirrel.Eq{"status": services.AccountExportProcessing},
+				squirrel.Lt{"updated_at": staleBefore},
+			},
+		}).
+		Where(squirrel.Eq{"deleted_at": nil}).
+		OrderBy("id ASC").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - ClaimPendingExports - squirrel.Select: %w", err))
+	}
+
+	query, args, err := squirrel.
+		Update("account_exports").
+		Set("status", services.AccountExportProcessing).
+		Set("updated_at", time.Now()).
+		Where("id IN ("+claimable+")", claimableArgs...).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - ClaimPendingExports - squirrel.Update: %w", err))
+	}
+
+	var exports []records.AccountExports
+	if err := r.db.Select(&exports, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - ClaimPendingExports - db.Select: %w", err))
+	}
+
+	return exports, nil
+}
+
+func (r *postgresAccountsRepository) CompleteExport(id int, filePath string, expiresAt time.Time) error {
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("account_exports").
+		Set("status", services.AccountExportCompleted).
+		Set("file_path", filePath).
+		Set("completed_at", now).
+		Set("expires_at", expiresAt).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - CompleteExport - squirrel.Update: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - CompleteExport - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresAccountsRepository) FailExport(id int, reason string) error {
+	query, args, err := squirrel.
+		Update("account_exports").
+		Set("status", services.AccountExportFailed).
+		Set("error", reason).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FailExport - squirrel.Update: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FailExport - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresAccountsRepository) FindExpiredExports(now time.Time) ([]records.AccountExports, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("account_exports").
+		Where(squirrel.Eq{"status": services.AccountExportCompleted, "deleted_at": nil}).
+		Where(squirrel.Lt{"expires_at": now}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FindExpiredExports - squirrel.Select: %w", err))
+	}
+
+	var exports []records.AccountExports
+	if err := r.db.Select(&exports, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FindExpiredExports - db.Select: %w", err))
+	}
+
+	return exports, nil
+}
+
+func (r *postgresAccountsRepository) ExpireExport(id int) error {
+	query, args, err := squirrel.
+		Update("account_exports").
+		Set("status", services.AccountExportExpired).
+		Set("file_path", "").
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - ExpireExport - squirrel.Update: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - ExpireExport - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresAccountsRepository) FindExportTables(userID int) ([]services.ExportTable, error) {
+	tables := make([]services.ExportTable, 0, len(exportQueries))
+	for _, exportQuery := range exportQueries {
+		query, args, err := exportQuery.query.PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FindExportTables - squirrel.Select: %w", err))
+		}
+		args = append(args, userID)
+
+		table, err := r.findExportTable(exportQuery.name, query, args)
+		if err != nil {
+			return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FindExportTables - %s: %w", exportQuery.name, err))
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+func (r *postgresAccountsRepository) findExportTable(name string, query string, args []interface{}) (services.ExportTable, error) {
+	rows, err := r.db.Queryx(query, args...)
+	if err != nil {
+		return services.ExportTable{}, fmt.Errorf("db.Queryx: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return services.ExportTable{}, fmt.Errorf("rows.Columns: %w", err)
+	}
+
+	table := services.ExportTable{Name: name, Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		row, err := rows.SliceScan()
+		if err != nil {
+			return services.ExportTable{}, fmt.Errorf("rows.SliceScan: %w", err)
+		}
+		// Numeric and text columns come back as raw bytes.
+		for i, value := range row {
+			if b, ok := value.([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return services.ExportTable{}, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return table, nil
+}
+
+func (r *postgresAccountsRepository) ScheduleDeletion(userID int, requestedAt time.Time, scheduledAt time.Time) error {
+	query, args, err := squirrel.
+		Update("users").
+		Set("deletion_requested_at", requestedAt).
+		Set("deletion_scheduled_at", scheduledAt).
+		Set("updated_at", requestedAt).
+		Where(squirrel.Eq{"id": userID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - ScheduleDeletion - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - ScheduleDeletion - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - ScheduleDeletion - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
+
+func (r *postgresAccountsRepository) CancelDeletion(userID int) error {
+	query, args, err := squirrel.
+		Update("users").
+		Set("deletion_requested_at", nil).
+		Set("deletion_scheduled_at", nil).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": userID}).
+		Where(squirrel.NotEq{"deletion_scheduled_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - CancelDeletion - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - CancelDeletion - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - CancelDeletion - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
+
+func (r *postgresAccountsRepository) FindUsersDueForDeletion(now time.Time) ([]records.Users, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("users").
+		Where(squirrel.LtOrEq{"deletion_scheduled_at": now}).
+		OrderBy("deletion_scheduled_at ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FindUsersDueForDeletion - squirrel.Select: %w", err))
+	}
+
+	var users []records.Users
+	if err := r.db.Select(&users, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - FindUsersDueForDeletion - db.Select: %w", err))
+	}
+
+	return users, nil
+}
+
+// Purge hard deletes the user. Sign-in attempts keep the email address after
+// the user is gone, so they are removed explicitly.
+func (r *postgresAccountsRepository) Purge(userID int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - Purge - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Delete("sign_in_attempts").
+		Where(squirrel.Or{
+			squirrel.Eq{"user_id": userID},
+			squirrel.Expr("email = (SELECT email FROM users WHERE id = ?)", userID),
+		}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - Purge - squirrel.Delete: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - Purge - tx.Exec: %w", err))
+	}
+
+	query, args, err = squirrel.
+		Delete("users").
+		Where(squirrel.Eq{"id": userID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - Purge - squirrel.Delete: %w", err))
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - Purge - tx.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - Purge - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return repositories.ErrorRowNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAccountsRepository - Purge - tx.Commit: %w", err))
+	}
+
+	return nil
+}
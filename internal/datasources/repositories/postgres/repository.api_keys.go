@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresAPIKeysRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresAPIKeysRepository(db *sqlx.DB) services.APIKeysRepository {
+	return &postgresAPIKeysRepository{db}
+}
+
+func (r *postgresAPIKeysRepository) Save(apiKey records.APIKeys) (records.APIKeys, error) {
+	query, args, err := squirrel.
+		Insert("api_keys").
+		Columns("user_id", "name", "prefix", "key_hash", "scopes", "expires_at", "created_at").
+		Values(apiKey.UserID, apiKey.Name, apiKey.Prefix, apiKey.KeyHash, apiKey.Scopes, apiKey.ExpiresAt, time.Now()).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.APIKeys{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	var saved records.APIKeys
+	if err := r.db.Get(&saved, query, args...); err != nil {
+		return records.APIKeys{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - Save - db.Get: %w", err))
+	}
+
+	return saved, nil
+}
+
+func (r *postgresAPIKeysRepository) FindAllByUserID(userID int) ([]records.APIKeys, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("api_keys").
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil}).
+		OrderBy("id DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - FindAllByUserID - squirrel.Select: %w", err))
+	}
+
+	var apiKeys []records.APIKeys
+	if err := r.db.Select(&apiKeys, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - FindAllByUserID - db.Select: %w", err))
+	}
+
+	return apiKeys, nil
+}
+
+func (r *postgresAPIKeysRepository) FindByKeyHash(keyHash string) (records.APIKeys, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("api_keys").
+		Where(squirrel.Eq{"key_hash": keyHash, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.APIKeys{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - FindByKeyHash - squirrel.Select: %w", err))
+	}
+
+	var apiKey records.APIKeys
+	if err := r.db.Get(&apiKey, query, args...); err != nil {
+		return records.APIKeys{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - FindByKeyHash - db.Get: %w", err))
+	}
+
+	return apiKey, nil
+}
+
+// TouchLastUsed records the key usage at most once per minute to keep
+// frequently polling integrations from writing on every request.
+func (r *postgresAPIKeysRepository) TouchLastUsed(id int) error {
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("api_keys").
+		Set("last_used_at", now).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Or{
+			squirrel.Eq{"last_used_at": nil},
+			squirrel.Lt{"last_used_at": now.Add(-time.Minute)},
+		}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - TouchLastUsed - squirrel.Update: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - TouchLastUsed - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresAPIKeysRepository) Delete(userID int, id int) error {
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("api_keys").
+		Set("deleted_at", now).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": id, "user_id": userID, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - Delete - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - Delete - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresAPIKeysRepository - Delete - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
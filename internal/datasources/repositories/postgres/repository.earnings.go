@@ -0,0 +1,396 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// earningsTotalsColumns aggregate ledger entries into the totals shared by
+// every earnings report.
+var earningsTotalsColumns = []string{
+	"COUNT(*) FILTER (WHERE creator_earnings.entry_type = 'sale') AS sales",
+	"COUNT(*) FILTER (WHERE creator_earnings.entry_type = 'reversal') AS refunds",
+	"COALESCE(SUM(creator_earnings.gross), 0) AS gross",
+	"COALESCE(SUM(creator_earnings.fee), 0) AS fee",
+	"COALESCE(SUM(creator_earnings.net), 0) AS net",
+}
+
+type postgresEarningsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresEarningsRepository(db *sqlx.DB) services.EarningsRepository {
+	return &postgresEarningsRepository{db}
+}
+
+// SaveSale records the sale of the workout bought with the payment for the
+// workout's owner. A payment is recorded once, so repeated calls are no-ops.
+func (r *postgresEarningsRepository) SaveSale(paymentID int, feePercent float64) error {
+	sale := squirrel.
+		Select().
+		Column("workouts.owner_id").
+		Column("workouts.id").
+		Column("workouts.title").
+		Column("payments.id").
+		Column("?", services.EarningEntrySale).
+		Column("payments.amount").
+		Column("ROUND(payments.amount * ?::numeric / 100)", feePercent).
+		Column("payments.amount - ROUND(payments.amount * ?::numeric / 100)", feePercent).
+		Column("?::numeric", feePercent).
+		Column("payments.currency").
+		Column("?::timestamp", time.Now()).
+		From("payments").
+		Join("workouts ON workouts.id = payments.workout_id").
+		Where(squirrel.Eq{"payments.id": paymentID})
+
+	query, args, err := squirrel.
+		Insert("creator_earnings").
+		Columns("creator_id", "workout_id", "workout_title", "payment_id", "entry_type", "gross", "fee", "net", "fee_percent", "currency", "created_at").
+		Select(sale).
+		Suffix("ON CONFLICT (payment_id, entry_type) DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - SaveSale - squirrel.Insert: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - SaveSale - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+// SaveReversal offsets the sale recorded for the payment with an entry of the
+// opposite amounts, at the fee that was charged on the sale.
+func (r *postgresEarningsRepository) SaveReversal(paymentID int) error {
+	reversal := squirrel.
+		Select().
+		Column("creator_id").
+		Column("workout_id").
+		Column("workout_title").
+		Column("payment_id").
+		Column("?", services.EarningEntryReversal).
+		Column("-gross").
+		Column("-fee").
+		Column("-net").
+		Column("fee_percent").
+		Column("currency").
+		Column("?::timestamp", time.Now()).
+		From("creator_earnings").
+		Where(squirrel.Eq{"payment_id": paymentID, "entry_type": services.EarningEntrySale})
+
+	query, args, err := squirrel.
+		Insert("creator_earnings").
+		Columns("creator_id", "workout_id", "workout_title", "payment_id", "entry_type", "gross", "fee", "net", "fee_percent", "currency", "created_at").
+		Select(reversal).
+		Suffix("ON CONFLICT (payment_id, entry_type) DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - SaveReversal - squirrel.Insert: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - SaveReversal - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresEarningsRepository) FindAllByCreatorID(creatorID int, from time.Time, to time.Time) ([]records.CreatorEarnings, error) {
+	query, args, err := withEarningsPeriod(squirrel.
+		Select("*").
+		From("creator_earnings").
+		Where(squirrel.Eq{"creator_id": creatorID, "deleted_at": nil}), from, to).
+		OrderBy("created_at ASC", "id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindAllByCreatorID - squirrel.Select: %w", err))
+	}
+
+	var earnings []records.CreatorEarnings
+	if err := r.db.Select(&earnings, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindAllByCreatorID - db.Select: %w", err))
+	}
+
+	return earnings, nil
+}
+
+func (r *postgresEarningsRepository) FindSummary(creatorID int) ([]records.CreatorEarningsSummary, error) {
+	query, args, err := squirrel.
+		Select("creator_earnings.currency").
+		Columns(earningsTotalsColumns...).
+		Column("COALESCE(SUM(creator_earnings.net) FILTER (WHERE creator_payouts.status = ?), 0) AS paid_out", services.PayoutStatusPaid).
+		Column("COALESCE(SUM(creator_earnings.net) FILTER (WHERE creator_payouts.status IS DISTINCT FROM ?), 0) AS unpaid", services.PayoutStatusPaid).
+		From("creator_earnings").
+		LeftJoin("creator_payouts ON creator_payouts.id = creator_earnings.payout_id").
+		Where(squirrel.Eq{"creator_earnings.creator_id": creatorID, "creator_earnings.deleted_at": nil}).
+		GroupBy("creator_earnings.currency").
+		OrderBy("creator_earnings.currency ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindSummary - squirrel.Select: %w", err))
+	}
+
+	var summary []records.CreatorEarningsSummary
+	if err := r.db.Select(&summary, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindSummary - db.Select: %w", err))
+	}
+
+	return summary, nil
+}
+
+func (r *postgresEarningsRepository) FindByWorkout(creatorID int, from time.Time, to time.Time) ([]records.CreatorEarningsByWorkout, error) {
+	query, args, err := withEarningsPeriod(squirrel.
+		Select("creator_earnings.workout_id", "MAX(creator_earnings.workout_title) AS workout_title", "creator_earnings.currency").
+		Columns(earningsTotalsColumns...).
+		From("creator_earnings").
+		Where(squirrel.Eq{"creator_earnings.creator_id": creatorID, "creator_earnings.deleted_at": nil}), from, to).
+		GroupBy("creator_earnings.workout_id", "creator_earnings.currency").
+		OrderBy("net DESC", "creator_earnings.workout_id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindByWorkout - squirrel.Select: %w", err))
+	}
+
+	var breakdown []records.CreatorEarningsByWorkout
+	if err := r.db.Select(&breakdown, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindByWorkout - db.Select: %w", err))
+	}
+
+	return breakdown, nil
+}
+
+func (r *postgresEarningsRepository) FindByMonth(creatorID int, from time.Time, to time.Time) ([]records.CreatorEarningsByMonth, error) {
+	query, args, err := withEarningsPeriod(squirrel.
+		Select("DATE_TRUNC('month', creator_earnings.created_at) AS month", "creator_earnings.currency").
+		Columns(earningsTotalsColumns...).
+		From("creator_earnings").
+		Where(squirrel.Eq{"creator_earnings.creator_id": creatorID, "creator_earnings.deleted_at": nil}), from, to).
+		GroupBy("month", "creator_earnings.currency").
+		OrderBy("month DESC", "creator_earnings.currency ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindByMonth - squirrel.Select: %w", err))
+	}
+
+	var breakdown []records.CreatorEarningsByMonth
+	if err := r.db.Select(&breakdown, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindByMonth - db.Select: %w", err))
+	}
+
+	return breakdown, nil
+}
+
+// CreatePayouts batches every unpaid entry created before the cutoff into one
+// payout per creator and currency. Creators whose balance is not positive,
+// e.g. after refunds, are carried over to a later batch. The entries are
+// locked first, so concurrent batches never pay an entry twice.
+func (r *postgresEarningsRepository) CreatePayouts(before time.Time) ([]records.CreatorPayouts, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - CreatePayouts - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	unpaid := squirrel.And{
+		squirrel.Eq{"payout_id": nil, "deleted_at": nil},
+		squirrel.Lt{"created_at": before},
+	}
+
+	query, args, err := squirrel.
+		Select("id").
+		From("creator_earnings").
+		Where(unpaid).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - CreatePayouts - squirrel.Select: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - CreatePayouts - tx.Exec: %w", err))
+	}
+
+	query, args, err = squirrel.
+		Select("creator_id", "currency", "SUM(net) AS amount", "COUNT(*) AS entries_count").
+		From("creator_earnings").
+		Where(unpaid).
+		GroupBy("creator_id", "currency").
+		Having("SUM(net) > 0").
+		OrderBy("creator_id ASC", "currency ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - CreatePayouts - squirrel.Select: %w", err))
+	}
+
+	var balances []records.CreatorPayouts
+	if err := tx.Select(&balances, query, args...); err != nil {
+		tx.Rollback()
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - CreatePayouts - tx.Select: %w", err))
+	}
+
+	now := time.Now()
+	payouts := make([]records.CreatorPayouts, 0, len(balances))
+	for _, balance := range balances {
+		query, args, err = squirrel.
+			Insert("creator_payouts").
+			Columns("creator_id", "amount", "currency", "entries_count", "status", "created_at").
+			Values(balance.CreatorID, balance.Amount, balance.Currency, balance.EntriesCount, services.PayoutStatusPending, now).
+			Suffix("RETURNING *").
+			PlaceholderFormat(squirrel.Dollar).
+			ToSql()
+		if err != nil {
+			tx.Rollback()
+			return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - CreatePayouts - squirrel.Insert: %w", err))
+		}
+
+		var payout records.CreatorPayouts
+		if err := tx.Get(&payout, query, args...); err != nil {
+			tx.Rollback()
+			return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - CreatePayouts - tx.Get: %w", err))
+		}
+
+		query, args, err = squirrel.
+			Update("creator_earnings").
+			Set("payout_id", payout.ID).
+			Set("updated_at", now).
+			Where(unpaid).
+			Where(squirrel.Eq{"creator_id": balance.CreatorID, "currency": balance.Currency}).
+			PlaceholderFormat(squirrel.Dollar).
+			ToSql()
+		if err != nil {
+			tx.Rollback()
+			return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - CreatePayouts - squirrel.Update: %w", err))
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			tx.Rollback()
+			return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - CreatePayouts - tx.Exec: %w", err))
+		}
+
+		payouts = append(payouts, payout)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - CreatePayouts - tx.Commit: %w", err))
+	}
+
+	return payouts, nil
+}
+
+func (r *postgresEarningsRepository) FindPayoutByID(id int) (records.CreatorPayouts, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("creator_payouts").
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.CreatorPayouts{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindPayoutByID - squirrel.Select: %w", err))
+	}
+
+	var payout records.CreatorPayouts
+	if err := r.db.Get(&payout, query, args...); err != nil {
+		return records.CreatorPayouts{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindPayoutByID - db.Get: %w", err))
+	}
+
+	return payout, nil
+}
+
+// FindAllPayouts lists payouts, optionally only those of a creator (creatorID
+// above 0) or in a status (status not empty).
+func (r *postgresEarningsRepository) FindAllPayouts(creatorID int, status string) ([]records.CreatorPayouts, error) {
+	where := squirrel.Eq{"deleted_at": nil}
+	if creatorID > 0 {
+		where["creator_id"] = creatorID
+	}
+	if status != "" {
+		where["status"] = status
+	}
+
+	query, args, err := squirrel.
+		Select("*").
+		From("creator_payouts").
+		Where(where).
+		OrderBy("id DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindAllPayouts - squirrel.Select: %w", err))
+	}
+
+	var payouts []records.CreatorPayouts
+	if err := r.db.Select(&payouts, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - FindAllPayouts - db.Select: %w", err))
+	}
+
+	return payouts, nil
+}
+
+// MarkPayoutPaid settles a pending payout with the reference of the transfer.
+func (r *postgresEarningsRepository) MarkPayoutPaid(id int, reference string) error {
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("creator_payouts").
+		Set("status", services.PayoutStatusPaid).
+		Set("reference", reference).
+		Set("paid_at", now).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": id, "status": services.PayoutStatusPending, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - MarkPayoutPaid - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - MarkPayoutPaid - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresEarningsRepository - MarkPayoutPaid - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
+
+// withEarningsPeriod limits a ledger query to entries created in [from, to).
+// Zero times leave that end of the period open.
+func withEarningsPeriod(query squirrel.SelectBuilder, from time.Time, to time.Time) squirrel.SelectBuilder {
+	if !from.IsZero() {
+		query = query.Where(squirrel.GtOrEq{"creator_earnings.created_at": from})
+	}
+	if !to.IsZero() {
+		query = query.Where(squirrel.Lt{"creator_earnings.created_at": to})
+	}
+
+	return query
+}
@@ -60,6 +60,27 @@ func (r *postgresExerciseSetsRepository) FindAllByWorkoutExerciseID(workoutExerc
 	return exerciseSets, nil
 }
 
+func (r *postgresExerciseSetsRepository) FindAllByWorkoutExerciseIDAndDate(workoutExerciseID int, ownerID int, date time.Time) ([]records.ExerciseSets, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("exercise_sets").
+		Where(squirrel.Eq{"workout_exercise_id": workoutExerciseID, "owner_id": ownerID}).
+		Where(squirrel.Expr("DATE(created_at) = ?", date.Format("2006-01-02"))).
+		OrderBy("id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresExerciseSetsRepository - FindAllByWorkoutExerciseIDAndDate - squirrel.Select: %w", err))
+	}
+
+	var exerciseSets []records.ExerciseSets
+	if err := r.db.Select(&exerciseSets, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresExerciseSetsRepository - FindAllByWorkoutExerciseIDAndDate - db.Select: %w", err))
+	}
+
+	return exerciseSets, nil
+}
+
 func (r *postgresExerciseSetsRepository) FindByID(id int) (records.ExerciseSets, error) {
 	query, args, err := squirrel.
 		Select("id", "reps", "weight", "workout_exercise_id", "owner_id").
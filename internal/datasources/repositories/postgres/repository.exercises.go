@@ -209,6 +209,7 @@ func (r *postgresExercisesRepository) FindAllWithWorkoutCheck(workoutID int) ([]
 		`).
 		From("exercises e").
 		LeftJoin("workout_exercises we ON e.id = we.exercise_id AND we.workout_id = ?", workoutID).
+		Where(squirrel.Eq{"e.hidden_at": nil}).
 		OrderBy("e.id ASC").
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
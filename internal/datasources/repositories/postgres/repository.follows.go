@@ -0,0 +1,284 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresFollowsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresFollowsRepository(db *sqlx.DB) services.FollowsRepository {
+	return &postgresFollowsRepository{db}
+}
+
+// Follow makes the follower follow the user and updates the counts of both.
+// Following a user twice is a no-op.
+func (r *postgresFollowsRepository) Follow(followerID int, followingID int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - Follow - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Insert("user_follows").
+		Columns("follower_id", "following_id", "created_at").
+		Values(followerID, followingID, time.Now()).
+		Suffix("ON CONFLICT DO NOTHING RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - Follow - squirrel.Insert: %w", err))
+	}
+
+	var followID int
+	if err := tx.Get(&followID, query, args...); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - Follow - tx.Get: %w", err))
+	}
+
+	if err := updateFollowCounts(tx, followerID, followingID, 1); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - Follow - %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - Follow - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresFollowsRepository) Unfollow(followerID int, followingID int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - Unfollow - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Delete("user_follows").
+		Where(squirrel.Eq{"follower_id": followerID, "following_id": followingID}).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - Unfollow - squirrel.Delete: %w", err))
+	}
+
+	var followID int
+	if err := tx.Get(&followID, query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - Unfollow - tx.Get: %w", err))
+	}
+
+	if err := updateFollowCounts(tx, followerID, followingID, -1); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - Unfollow - %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - Unfollow - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresFollowsRepository) IsFollowing(followerID int, followingID int) (bool, error) {
+	query, args, err := squirrel.
+		Select("COUNT(*) > 0").
+		From("user_follows").
+		Where(squirrel.Eq{"follower_id": followerID, "following_id": followingID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - IsFollowing - squirrel.Select: %w", err))
+	}
+
+	var following bool
+	if err := r.db.Get(&following, query, args...); err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - IsFollowing - db.Get: %w", err))
+	}
+
+	return following, nil
+}
+
+// FindFollowers lists the users following the user, most recent follow first.
+func (r *postgresFollowsRepository) FindFollowers(userID int, cursor repositories.Cursor) ([]records.FollowUsers, error) {
+	return r.findUsers("FindFollowers", "follower_id", squirrel.Eq{"user_follows.following_id": userID}, cursor)
+}
+
+// FindFollowing lists the users the user follows, most recent follow first.
+func (r *postgresFollowsRepository) FindFollowing(userID int, cursor repositories.Cursor) ([]records.FollowUsers, error) {
+	return r.findUsers("FindFollowing", "following_id", squirrel.Eq{"user_follows.follower_id": userID}, cursor)
+}
+
+// FindFeed merges the public workouts created, the likes of public workouts
+// and the completed sessions of the users the user follows, newest first.
+// The ids of the three kinds of items overlap, so they are ordered by a sort
+// key of the id times three plus the kind.
+func (r *postgresFollowsRepository) FindFeed(userID int, cursor repositories.Cursor) ([]records.FeedItems, error) {
+	following := squirrel.
+		Select("following_id").
+		From("user_follows").
+		Where(squirrel.Eq{"follower_id": userID})
+
+	workouts := squirrel.
+		Select(
+			"'workout' AS type",
+			"workouts.id",
+			"workouts.id * 3 AS sort_key",
+			"workouts.owner_id AS actor_id",
+			"workouts.id AS workout_id",
+			"workouts.title AS workout_title",
+			"NULL::varchar AS activity_name",
+			"NULL::timestamp AS start_time",
+			"NULL::timestamp AS end_time",
+			"workouts.created_at AS occurred_at",
+		).
+		From("workouts").
+		Where(squirrel.Expr("workouts.owner_id IN (?)", following)).
+		Where(squirrel.Eq{"workouts.is_private": false, "workouts.hidden_at": nil})
+
+	likes := squirrel.
+		Select(
+			"'like' AS type",
+			"workout_likes.id",
+			"workout_likes.id * 3 + 1 AS sort_key",
+			"workout_likes.user_id AS actor_id",
+			"workouts.id AS workout_id",
+			"workouts.title AS workout_title",
+			"NULL::varchar AS activity_name",
+			"NULL::timestamp AS start_time",
+			"NULL::timestamp AS end_time",
+			"workout_likes.created_at AS occurred_at",
+		).
+		From("workout_likes").
+		Join("workouts ON workouts.id = workout_likes.workout_id").
+		Where(squirrel.Expr("workout_likes.user_id IN (?)", following)).
+		Where(squirrel.Eq{"workout_likes.deleted_at": nil, "workouts.is_private": false, "workouts.hidden_at": nil})
+
+	sessions := squirrel.
+		Select(
+			"'session' AS type",
+			"sessions.id",
+			"sessions.id * 3 + 2 AS sort_key",
+			"sessions.owner_id AS actor_id",
+			"NULL::int AS workout_id",
+			"NULL::varchar AS workout_title",
+			"activities.name AS activity_name",
+			"sessions.start_time",
+			"sessions.end_time",
+			"sessions.end_time AS occurred_at",
+		).
+		From("sessions").
+		Join("activities ON activities.id = sessions.activity_id").
+		Where(squirrel.Expr("sessions.owner_id IN (?)", following)).
+		Where(squirrel.Eq{"sessions.deleted_at": nil}).
+		Where("sessions.end_time <= NOW()")
+
+	feed := workouts.
+		SuffixExpr(squirrel.ConcatExpr("UNION ALL ", likes)).
+		SuffixExpr(squirrel.ConcatExpr("UNION ALL ", sessions))
+
+	selectQuery := squirrel.
+		Select("feed.*", "users.username AS actor_username", "users.avatar AS actor_avatar").
+		FromSelect(feed, "feed").
+		Join("users ON users.id = feed.actor_id").
+		PlaceholderFormat(squirrel.Dollar)
+	selectQuery = repositories.ApplyCursor(selectQuery, "feed.occurred_at", "feed.sort_key", cursor)
+
+	query, args, err := selectQuery.ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - FindFeed - squirrel.Select: %w", err))
+	}
+
+	var items []records.FeedItems
+	if err := r.db.Select(&items, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - FindFeed - db.Select: %w", err))
+	}
+
+	return items, nil
+}
+
+// findUsers lists the users on the userColumn side of the matching follows.
+func (r *postgresFollowsRepository) findUsers(method string, userColumn string, where squirrel.Sqlizer, cursor repositories.Cursor) ([]records.FollowUsers, error) {
+	selectQuery := squirrel.
+		Select("user_follows.id", "user_follows.created_at", "users.id AS user_id", "users.username", "users.bio", "users.avatar").
+		From("user_follows").
+		Join(fmt.Sprintf("users ON users.id = user_follows.%s", userColumn)).
+		Where(where).
+		PlaceholderFormat(squirrel.Dollar)
+	selectQuery = repositories.ApplyCursor(selectQuery, "user_follows.created_at", "user_follows.id", cursor)
+
+	query, args, err := selectQuery.ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - %s - squirrel.Select: %w", method, err))
+	}
+
+	var users []records.FollowUsers
+	if err := r.db.Select(&users, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresFollowsRepository - %s - db.Select: %w", method, err))
+	}
+
+	return users, nil
+}
+
+func updateFollowCounts(tx *sqlx.Tx, followerID int, followingID int, delta int) error {
+	query, args, err := squirrel.
+		Update("users").
+		Set("following_count", squirrel.Expr("following_count + ?", delta)).
+		Where(squirrel.Eq{"id": followerID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("squirrel.Update: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("tx.Exec: %w", err)
+	}
+
+	query, args, err = squirrel.
+		Update("users").
+		Set("followers_count", squirrel.Expr("followers_count + ?", delta)).
+		Where(squirrel.Eq{"id": followingID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("squirrel.Update: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("tx.Exec: %w", err)
+	}
+
+	return nil
+}
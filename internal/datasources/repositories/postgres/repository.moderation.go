@@ -0,0 +1,394 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// moderatedTables maps the content types that can be reported to the table
+// holding them.
+var moderatedTables = map[string]string{
+	services.ContentTypeWorkout:  "workouts",
+	services.ContentTypeUser:     "users",
+	services.ContentTypeExercise: "exercises",
+}
+
+type postgresModerationRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresModerationRepository(db *sqlx.DB) services.ModerationRepository {
+	return &postgresModerationRepository{db}
+}
+
+// Block makes the blocker block the user and removes the follows between
+// them in both directions. Blocking a user twice is a no-op.
+func (r *postgresModerationRepository) Block(blockerID int, blockedID int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - Block - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Insert("user_blocks").
+		Columns("blocker_id", "blocked_id", "created_at").
+		Values(blockerID, blockedID, time.Now()).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - Block - squirrel.Insert: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - Block - tx.Exec: %w", err))
+	}
+
+	query, args, err = squirrel.
+		Delete("user_follows").
+		Where(squirrel.Or{
+			squirrel.Eq{"follower_id": blockerID, "following_id": blockedID},
+			squirrel.Eq{"follower_id": blockedID, "following_id": blockerID},
+		}).
+		Suffix("RETURNING follower_id, following_id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - Block - squirrel.Delete: %w", err))
+	}
+
+	var follows []records.UserFollows
+	if err := tx.Select(&follows, query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - Block - tx.Select: %w", err))
+	}
+
+	for _, follow := range follows {
+		if err := updateFollowCounts(tx, follow.FollowerID, follow.FollowingID, -1); err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - Block - %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - Block - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresModerationRepository) Unblock(blockerID int, blockedID int) error {
+	query, args, err := squirrel.
+		Delete("user_blocks").
+		Where(squirrel.Eq{"blocker_id": blockerID, "blocked_id": blockedID}).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - Unblock - squirrel.Delete: %w", err))
+	}
+
+	var blockID int
+	if err := r.db.Get(&blockID, query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - Unblock - db.Get: %w", err))
+	}
+
+	return nil
+}
+
+// IsBlocked tells whether either user blocked the other.
+func (r *postgresModerationRepository) IsBlocked(userID int, otherID int) (bool, error) {
+	query, args, err := squirrel.
+		Select("COUNT(*) > 0").
+		From("user_blocks").
+		Where(squirrel.Or{
+			squirrel.Eq{"blocker_id": userID, "blocked_id": otherID},
+			squirrel.Eq{"blocker_id": otherID, "blocked_id": userID},
+		}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - IsBlocked - squirrel.Select: %w", err))
+	}
+
+	var blocked bool
+	if err := r.db.Get(&blocked, query, args...); err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - IsBlocked - db.Get: %w", err))
+	}
+
+	return blocked, nil
+}
+
+// FindBlocked lists the users the blocker blocked, most recent block first.
+func (r *postgresModerationRepository) FindBlocked(blockerID int, cursor repositories.Cursor) ([]records.BlockedUsers, error) {
+	selectQuery := squirrel.
+		Select("user_blocks.*", "users.username", "users.avatar").
+		From("user_blocks").
+		Join("users ON users.id = user_blocks.blocked_id").
+		Where(squirrel.Eq{"user_blocks.blocker_id": blockerID}).
+		PlaceholderFormat(squirrel.Dollar)
+	selectQuery = repositories.ApplyCursor(selectQuery, "user_blocks.created_at", "user_blocks.id", cursor)
+
+	query, args, err := selectQuery.ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindBlocked - squirrel.Select: %w", err))
+	}
+
+	var users []records.BlockedUsers
+	if err := r.db.Select(&users, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindBlocked - db.Select: %w", err))
+	}
+
+	return users, nil
+}
+
+// FindAuthorID finds the user behind the content: the owner of a workout, the
+// creator of a custom exercise or the user themselves. Exercises from the
+// shared catalogue have no author and are not found.
+func (r *postgresModerationRepository) FindAuthorID(contentType string, contentID int) (int, error) {
+	var selectQuery squirrel.SelectBuilder
+	switch contentType {
+	case services.ContentTypeWorkout:
+		selectQuery = squirrel.Select("owner_id").From("workouts").Where(squirrel.Eq{"id": contentID})
+	case services.ContentTypeUser:
+		selectQuery = squirrel.Select("id").From("users").Where(squirrel.Eq{"id": contentID})
+	case services.ContentTypeExercise:
+		selectQuery = squirrel.Select("user_id").From("user_exercises").Where(squirrel.Eq{"exercise_id": contentID})
+	default:
+		return 0, fmt.Errorf("postgresModerationRepository - FindAuthorID - unknown content type %q", contentType)
+	}
+
+	query, args, err := selectQuery.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindAuthorID - squirrel.Select: %w", err))
+	}
+
+	var authorID int
+	if err := r.db.Get(&authorID, query, args...); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindAuthorID - db.Get: %w", err))
+	}
+
+	return authorID, nil
+}
+
+func (r *postgresModerationRepository) SaveReport(report records.ContentReports) (int, error) {
+	query, args, err := squirrel.
+		Insert("content_reports").
+		Columns("reporter_id", "content_type", "content_id", "reason", "details", "status", "created_at").
+		Values(report.ReporterID, report.ContentType, report.ContentID, report.Reason, report.Details, services.ReportStatusOpen, time.Now()).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - SaveReport - squirrel.Insert: %w", err))
+	}
+
+	var reportID int
+	if err := r.db.Get(&reportID, query, args...); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - SaveReport - db.Get: %w", err))
+	}
+
+	return reportID, nil
+}
+
+// FindReports lists the reports with the status, oldest first, so that the
+// queue is worked through in the order it filled up.
+func (r *postgresModerationRepository) FindReports(status string, pagination repositories.Pagination) ([]records.ContentReports, int, error) {
+	queryCountReports := squirrel.
+		Select("COUNT(*)").
+		From("content_reports").
+		Where(squirrel.Eq{"status": status}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := queryCountReports.ToSql()
+	if err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindReports - squirrel.Select: %w", err))
+	}
+
+	var total int
+	if err := r.db.Get(&total, query, args...); err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindReports - db.Get: %w", err))
+	}
+
+	querySelectReports := squirrel.
+		Select("*").
+		From("content_reports").
+		Where(squirrel.Eq{"status": status}).
+		OrderBy("created_at ASC", "id ASC").
+		PlaceholderFormat(squirrel.Dollar)
+	querySelectReports = repositories.ApplyPagination(querySelectReports, pagination.Page, pagination.Limit)
+
+	query, args, err = querySelectReports.ToSql()
+	if err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindReports - squirrel.Select: %w", err))
+	}
+
+	var reports []records.ContentReports
+	if err := r.db.Select(&reports, query, args...); err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindReports - db.Select: %w", err))
+	}
+
+	return reports, total, nil
+}
+
+// SaveAction applies the moderation action to the content, records it and
+// closes the open reports of the content, all as of the action's CreatedAt.
+// A ban applies to the author of the content, and restoring a user lifts both
+// a hide and a ban.
+func (r *postgresModerationRepository) SaveAction(action records.ModerationActions, authorID int) (int, error) {
+	table, ok := moderatedTables[action.ContentType]
+	if !ok {
+		return 0, fmt.Errorf("postgresModerationRepository - SaveAction - unknown content type %q", action.ContentType)
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - SaveAction - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	now := action.CreatedAt
+	var updateQuery squirrel.UpdateBuilder
+	hasUpdate := true
+	switch action.Action {
+	case services.ModerationActionHide:
+		updateQuery = squirrel.Update(table).Set("hidden_at", now).Where(squirrel.Eq{"id": action.ContentID})
+	case services.ModerationActionRestore:
+		updateQuery = squirrel.Update(table).Set("hidden_at", nil).Where(squirrel.Eq{"id": action.ContentID})
+		if action.ContentType == services.ContentTypeUser {
+			updateQuery = updateQuery.Set("banned_at", nil)
+		}
+	case services.ModerationActionBan:
+		updateQuery = squirrel.Update("users").Set("banned_at", now).Where(squirrel.Eq{"id": authorID})
+	default:
+		hasUpdate = false
+	}
+
+	if hasUpdate {
+		query, args, err := updateQuery.Suffix("RETURNING id").PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			tx.Rollback()
+			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - SaveAction - squirrel.Update: %w", err))
+		}
+
+		var contentID int
+		if err := tx.Get(&contentID, query, args...); err != nil {
+			tx.Rollback()
+			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - SaveAction - tx.Get: %w", err))
+		}
+	}
+
+	query, args, err := squirrel.
+		Insert("moderation_actions").
+		Columns("moderator_id", "content_type", "content_id", "action", "reason", "created_at").
+		Values(action.ModeratorID, action.ContentType, action.ContentID, action.Action, action.Reason, now).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - SaveAction - squirrel.Insert: %w", err))
+	}
+
+	var actionID int
+	if err := tx.Get(&actionID, query, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - SaveAction - tx.Get: %w", err))
+	}
+
+	status := services.ReportStatusResolved
+	if action.Action == services.ModerationActionDismiss {
+		status = services.ReportStatusDismissed
+	}
+
+	query, args, err = squirrel.
+		Update("content_reports").
+		SetMap(map[string]interface{}{"status": status, "action_id": actionID, "updated_at": now}).
+		Where(squirrel.Eq{"content_type": action.ContentType, "content_id": action.ContentID, "status": services.ReportStatusOpen}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - SaveAction - squirrel.Update: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - SaveAction - tx.Exec: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - SaveAction - tx.Commit: %w", err))
+	}
+
+	return actionID, nil
+}
+
+// FindActions lists the recorded moderation actions, newest first. Filtering
+// by content type and id is optional.
+func (r *postgresModerationRepository) FindActions(contentType string, contentID int, pagination repositories.Pagination) ([]records.ModerationActions, int, error) {
+	where := squirrel.Eq{}
+	if contentType != "" {
+		where["content_type"] = contentType
+	}
+	if contentID > 0 {
+		where["content_id"] = contentID
+	}
+
+	query, args, err := squirrel.
+		Select("COUNT(*)").
+		From("moderation_actions").
+		Where(where).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindActions - squirrel.Select: %w", err))
+	}
+
+	var total int
+	if err := r.db.Get(&total, query, args...); err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindActions - db.Get: %w", err))
+	}
+
+	querySelectActions := squirrel.
+		Select("*").
+		From("moderation_actions").
+		Where(where).
+		OrderBy("created_at DESC", "id DESC").
+		PlaceholderFormat(squirrel.Dollar)
+	querySelectActions = repositories.ApplyPagination(querySelectActions, pagination.Page, pagination.Limit)
+
+	query, args, err = querySelectActions.ToSql()
+	if err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindActions - squirrel.Select: %w", err))
+	}
+
+	var actions []records.ModerationActions
+	if err := r.db.Select(&actions, query, args...); err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresModerationRepository - FindActions - db.Select: %w", err))
+	}
+
+	return actions, total, nil
+}
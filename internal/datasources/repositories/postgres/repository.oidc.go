@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresOIDCRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresOIDCRepository(db *sqlx.DB) services.OIDCRepository {
+	return &postgresOIDCRepository{db}
+}
+
+func (r *postgresOIDCRepository) SaveState(state records.OIDCStates) error {
+	query, args, err := squirrel.
+		Insert("oidc_states").
+		Columns("state_hash", "provider", "nonce", "code_verifier", "expires_at", "created_at").
+		Values(state.StateHash, state.Provider, state.Nonce, state.CodeVerifier, state.ExpiresAt, time.Now()).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresOIDCRepository - SaveState - squirrel.Insert: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresOIDCRepository - SaveState - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+// ConsumeState marks a pending login as used and returns it, so that every
+// authorization response can only be redeemed once.
+func (r *postgresOIDCRepository) ConsumeState(stateHash string, provider string) (records.OIDCStates, error) {
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("oidc_states").
+		Set("used_at", now).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"state_hash": stateHash, "provider": provider, "used_at": nil, "deleted_at": nil}).
+		Where(squirrel.Gt{"expires_at": now}).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.OIDCStates{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresOIDCRepository - ConsumeState - squirrel.Update: %w", err))
+	}
+
+	var state records.OIDCStates
+	if err := r.db.Get(&state, query, args...); err != nil {
+		return records.OIDCStates{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresOIDCRepository - ConsumeState - db.Get: %w", err))
+	}
+
+	return state, nil
+}
+
+func (r *postgresOIDCRepository) FindIdentity(provider string, subject string) (records.UserIdentities, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("user_identities").
+		Where(squirrel.Eq{"provider": provider, "subject": subject, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.UserIdentities{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresOIDCRepository - FindIdentity - squirrel.Select: %w", err))
+	}
+
+	var identity records.UserIdentities
+	if err := r.db.Get(&identity, query, args...); err != nil {
+		return records.UserIdentities{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresOIDCRepository - FindIdentity - db.Get: %w", err))
+	}
+
+	return identity, nil
+}
+
+func (r *postgresOIDCRepository) SaveIdentity(identity records.UserIdentities) error {
+	query, args, err := squirrel.
+		Insert("user_identities").
+		Columns("user_id", "provider", "subject", "email", "created_at").
+		Values(identity.UserID, identity.Provider, identity.Subject, identity.Email, time.Now()).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresOIDCRepository - SaveIdentity - squirrel.Insert: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresOIDCRepository - SaveIdentity - db.Exec: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresPaymentMethodsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresPaymentMethodsRepository(db *sqlx.DB) services.PaymentMethodsRepository {
+	return &postgresPaymentMethodsRepository{db}
+}
+
+// Save stores the payment method, making it the default when the user has no
+// other one.
+func (r *postgresPaymentMethodsRepository) Save(paymentMethod records.PaymentMethods) (records.PaymentMethods, error) {
+	query, args, err := squirrel.
+		Insert("payment_methods").
+		Columns("user_id", "gateway", "token", "brand", "last4", "exp_month", "exp_year", "is_default", "created_at").
+		Values(
+			paymentMethod.UserID,
+			paymentMethod.Gateway,
+			paymentMethod.Token,
+			paymentMethod.Brand,
+			paymentMethod.Last4,
+			paymentMethod.ExpMonth,
+			paymentMethod.ExpYear,
+			squirrel.Expr("NOT EXISTS (SELECT 1 FROM payment_methods WHERE user_id = ? AND deleted_at IS NULL)", paymentMethod.UserID),
+			time.Now(),
+		).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.PaymentMethods{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	var saved records.PaymentMethods
+	if err := r.db.Get(&saved, query, args...); err != nil {
+		return records.PaymentMethods{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - Save - db.Get: %w", err))
+	}
+
+	return saved, nil
+}
+
+func (r *postgresPaymentMethodsRepository) FindAllByUserID(userID int) ([]records.PaymentMethods, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("payment_methods").
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil}).
+		OrderBy("is_default DESC", "id DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - FindAllByUserID - squirrel.Select: %w", err))
+	}
+
+	var paymentMethods []records.PaymentMethods
+	if err := r.db.Select(&paymentMethods, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - FindAllByUserID - db.Select: %w", err))
+	}
+
+	return paymentMethods, nil
+}
+
+func (r *postgresPaymentMethodsRepository) FindByID(userID int, id int) (records.PaymentMethods, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("payment_methods").
+		Where(squirrel.Eq{"id": id, "user_id": userID, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.PaymentMethods{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - FindByID - squirrel.Select: %w", err))
+	}
+
+	var paymentMethod records.PaymentMethods
+	if err := r.db.Get(&paymentMethod, query, args...); err != nil {
+		return records.PaymentMethods{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - FindByID - db.Get: %w", err))
+	}
+
+	return paymentMethod, nil
+}
+
+func (r *postgresPaymentMethodsRepository) FindDefaultByUserID(userID int) (records.PaymentMethods, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("payment_methods").
+		Where(squirrel.Eq{"user_id": userID, "is_default": true, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.PaymentMethods{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - FindDefaultByUserID - squirrel.Select: %w", err))
+	}
+
+	var paymentMethod records.PaymentMethods
+	if err := r.db.Get(&paymentMethod, query, args...); err != nil {
+		return records.PaymentMethods{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - FindDefaultByUserID - db.Get: %w", err))
+	}
+
+	return paymentMethod, nil
+}
+
+// SetDefault makes the payment method the only default one of the user.
+func (r *postgresPaymentMethodsRepository) SetDefault(userID int, id int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - SetDefault - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("payment_methods").
+		Set("is_default", false).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"user_id": userID, "is_default": true}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - SetDefault - squirrel.Update: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - SetDefault - tx.Exec: %w", err))
+	}
+
+	query, args, err = squirrel.
+		Update("payment_methods").
+		Set("is_default", true).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": id, "user_id": userID, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - SetDefault - squirrel.Update: %w", err))
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - SetDefault - tx.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - SetDefault - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return repositories.ErrorRowNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - SetDefault - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresPaymentMethodsRepository) Delete(userID int, id int) error {
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("payment_methods").
+		Set("is_default", false).
+		Set("deleted_at", now).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": id, "user_id": userID, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - Delete - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - Delete - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentMethodsRepository - Delete - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresPaymentsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresPaymentsRepository(db *sqlx.DB) services.PaymentsRepository {
+	return &postgresPaymentsRepository{db}
+}
+
+func (r *postgresPaymentsRepository) Save(payment records.Payments) (records.Payments, error) {
+	query, args, err := squirrel.
+		Insert("payments").
+		Columns("user_id", "workout_id", "gateway", "intent_id", "amount", "original_amount", "discount", "promo_code", "currency", "status", "created_at").
+		Values(payment.UserID, payment.WorkoutID, payment.Gateway, payment.IntentID, payment.Amount, payment.OriginalAmount, payment.Discount, payment.PromoCode, payment.Currency, payment.Status, time.Now()).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.Payments{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	var saved records.Payments
+	if err := r.db.Get(&saved, query, args...); err != nil {
+		return records.Payments{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - Save - db.Get: %w", err))
+	}
+
+	return saved, nil
+}
+
+func (r *postgresPaymentsRepository) FindByID(id int) (records.Payments, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("payments").
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.Payments{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - FindByID - squirrel.Select: %w", err))
+	}
+
+	var payment records.Payments
+	if err := r.db.Get(&payment, query, args...); err != nil {
+		return records.Payments{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - FindByID - db.Get: %w", err))
+	}
+
+	return payment, nil
+}
+
+func (r *postgresPaymentsRepository) FindByIntentID(gateway string, intentID string) (records.Payments, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("payments").
+		Where(squirrel.Eq{"gateway": gateway, "intent_id": intentID, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.Payments{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - FindByIntentID - squirrel.Select: %w", err))
+	}
+
+	var payment records.Payments
+	if err := r.db.Get(&payment, query, args...); err != nil {
+		return records.Payments{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - FindByIntentID - db.Get: %w", err))
+	}
+
+	return payment, nil
+}
+
+func (r *postgresPaymentsRepository) FindAllByUserID(userID int) ([]records.Payments, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("payments").
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil}).
+		OrderBy("id DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - FindAllByUserID - squirrel.Select: %w", err))
+	}
+
+	var payments []records.Payments
+	if err := r.db.Select(&payments, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - FindAllByUserID - db.Select: %w", err))
+	}
+
+	return payments, nil
+}
+
+// SetIntent attaches the gateway's intent to a payment saved before it was created.
+func (r *postgresPaymentsRepository) SetIntent(id int, intentID string) error {
+	query, args, err := squirrel.
+		Update("payments").
+		Set("intent_id", intentID).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - SetIntent - squirrel.Update: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - SetIntent - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+// FailAbandoned fails the pending payments of the user for the workout that
+// never got an intent and were created before the given time, and reports
+// whether there were any.
+func (r *postgresPaymentsRepository) FailAbandoned(userID int, workoutID int, before time.Time, failureReason string) (bool, error) {
+	query, args, err := squirrel.
+		Update("payments").
+		Set("status", services.PaymentStatusFailed).
+		Set("failure_reason", failureReason).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"user_id": userID, "workout_id": workoutID, "status": services.PaymentStatusPending, "intent_id": ""}).
+		Where(squirrel.Lt{"created_at": before}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - FailAbandoned - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - FailAbandoned - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - FailAbandoned - result.RowsAffected: %w", err))
+	}
+
+	return affected > 0, nil
+}
+
+// UpdateStatus moves the payment to status only while it is in one of the
+// from statuses, and reports whether it did. Concurrent settlements of the
+// same payment therefore apply a transition once.
+func (r *postgresPaymentsRepository) UpdateStatus(id int, from []string, status string, failureReason string) (bool, error) {
+	now := time.Now()
+	update := squirrel.
+		Update("payments").
+		Set("status", status).
+		Set("failure_reason", failureReason).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": id, "status": from})
+	if status == services.PaymentStatusRefunded {
+		update = update.Set("refunded_at", now)
+	}
+
+	query, args, err := update.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - UpdateStatus - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - UpdateStatus - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - UpdateStatus - result.RowsAffected: %w", err))
+	}
+
+	return affected > 0, nil
+}
+
+// ClaimFulfillment marks a succeeded payment as being fulfilled and reports
+// whether this caller got to do it.
+func (r *postgresPaymentsRepository) ClaimFulfillment(id int) (bool, error) {
+	query, args, err := squirrel.
+		Update("payments").
+		Set("fulfilled_at", time.Now()).
+		Where(squirrel.Eq{"id": id, "status": services.PaymentStatusSucceeded, "fulfilled_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - ClaimFulfillment - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - ClaimFulfillment - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - ClaimFulfillment - result.RowsAffected: %w", err))
+	}
+
+	return affected > 0, nil
+}
+
+// ReleaseFulfillment gives up a claim after fulfillment failed so it can be retried.
+func (r *postgresPaymentsRepository) ReleaseFulfillment(id int) error {
+	query, args, err := squirrel.
+		Update("payments").
+		Set("fulfilled_at", nil).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - ReleaseFulfillment - squirrel.Update: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPaymentsRepository - ReleaseFulfillment - db.Exec: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,524 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresProgramsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresProgramsRepository(db *sqlx.DB) services.ProgramsRepository {
+	return &postgresProgramsRepository{db}
+}
+
+// Save creates the program together with its schedule.
+func (r *postgresProgramsRepository) Save(program records.Programs, weeks []records.ProgramWeeks, days []records.ProgramDays) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Save - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Insert("programs").
+		Columns("owner_id", "title", "description", "days_per_week", "is_private", "created_at").
+		Values(program.OwnerID, program.Title, program.Description, program.DaysPerWeek, program.IsPrivate, time.Now()).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	var programID int
+	if err := tx.Get(&programID, query, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Save - tx.Get: %w", err))
+	}
+
+	if err := saveSchedule(tx, programID, weeks, days); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Save - %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Save - tx.Commit: %w", err))
+	}
+
+	return programID, nil
+}
+
+func (r *postgresProgramsRepository) Update(id int, program map[string]interface{}) error {
+	program["updated_at"] = time.Now()
+
+	query, args, err := squirrel.
+		Update("programs").
+		SetMap(program).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Update - squirrel.Update: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Update - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresProgramsRepository) Delete(id int) error {
+	query, args, err := squirrel.
+		Delete("programs").
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Delete - squirrel.Delete: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Delete - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+// ReplaceSchedule swaps the days per week, weeks and days of the program for
+// the given ones at once. Enrollments keep their position, see
+// program_completions.
+func (r *postgresProgramsRepository) ReplaceSchedule(id int, daysPerWeek int, weeks []records.ProgramWeeks, days []records.ProgramDays) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - ReplaceSchedule - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	for _, table := range []string{"program_days", "program_weeks"} {
+		query, args, err := squirrel.
+			Delete(table).
+			Where(squirrel.Eq{"program_id": id}).
+			PlaceholderFormat(squirrel.Dollar).
+			ToSql()
+		if err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - ReplaceSchedule - squirrel.Delete: %w", err))
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - ReplaceSchedule - tx.Exec: %w", err))
+		}
+	}
+
+	if err := saveSchedule(tx, id, weeks, days); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - ReplaceSchedule - %w", err))
+	}
+
+	query, args, err := squirrel.
+		Update("programs").
+		SetMap(map[string]interface{}{"days_per_week": daysPerWeek, "updated_at": time.Now()}).
+		Where(squirrel.Eq{"id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - ReplaceSchedule - squirrel.Update: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - ReplaceSchedule - tx.Exec: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - ReplaceSchedule - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresProgramsRepository) FindByID(id int) (records.Programs, error) {
+	query, args, err := selectPrograms().
+		Where(squirrel.Eq{"programs.id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.Programs{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindByID - squirrel.Select: %w", err))
+	}
+
+	var program records.Programs
+	if err := r.db.Get(&program, query, args...); err != nil {
+		return records.Programs{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindByID - db.Get: %w", err))
+	}
+
+	return program, nil
+}
+
+// FindAll lists the public programs the viewer may see, newest first. Programs
+// of banned owners and of owners who blocked the viewer or were blocked by
+// them are left out.
+func (r *postgresProgramsRepository) FindAll(viewerID int, pagination repositories.Pagination) ([]records.Programs, int, error) {
+	queryCountPrograms := squirrel.
+		Select("COUNT(*)").
+		From("programs").
+		Where(squirrel.Eq{"programs.is_private": false}).
+		PlaceholderFormat(squirrel.Dollar)
+	queryCountPrograms = repositories.ApplyNotBanned(queryCountPrograms, "programs.owner_id")
+	queryCountPrograms = repositories.ApplyNotBlocked(queryCountPrograms, "programs.owner_id", viewerID)
+
+	query, args, err := queryCountPrograms.ToSql()
+	if err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindAll - squirrel.Select: %w", err))
+	}
+
+	var total int
+	if err := r.db.Get(&total, query, args...); err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindAll - db.Get: %w", err))
+	}
+
+	querySelectPrograms := selectPrograms().
+		Where(squirrel.Eq{"programs.is_private": false}).
+		OrderBy("programs.created_at DESC", "programs.id DESC").
+		PlaceholderFormat(squirrel.Dollar)
+	querySelectPrograms = repositories.ApplyNotBanned(querySelectPrograms, "programs.owner_id")
+	querySelectPrograms = repositories.ApplyNotBlocked(querySelectPrograms, "programs.owner_id", viewerID)
+	querySelectPrograms = repositories.ApplyPagination(querySelectPrograms, pagination.Page, pagination.Limit)
+
+	query, args, err = querySelectPrograms.ToSql()
+	if err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindAll - squirrel.Select: %w", err))
+	}
+
+	var programs []records.Programs
+	if err := r.db.Select(&programs, query, args...); err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindAll - db.Select: %w", err))
+	}
+
+	return programs, total, nil
+}
+
+// FindAllByOwnerID lists all the programs of the owner, private ones included.
+func (r *postgresProgramsRepository) FindAllByOwnerID(ownerID int) ([]records.Programs, error) {
+	query, args, err := selectPrograms().
+		Where(squirrel.Eq{"programs.owner_id": ownerID}).
+		OrderBy("programs.created_at DESC", "programs.id DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindAllByOwnerID - squirrel.Select: %w", err))
+	}
+
+	var programs []records.Programs
+	if err := r.db.Select(&programs, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindAllByOwnerID - db.Select: %w", err))
+	}
+
+	return programs, nil
+}
+
+func (r *postgresProgramsRepository) FindWeeks(programID int) ([]records.ProgramWeeks, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("program_weeks").
+		Where(squirrel.Eq{"program_id": programID}).
+		OrderBy("week_number ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindWeeks - squirrel.Select: %w", err))
+	}
+
+	var weeks []records.ProgramWeeks
+	if err := r.db.Select(&weeks, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindWeeks - db.Select: %w", err))
+	}
+
+	return weeks, nil
+}
+
+// FindDays lists the scheduled workouts of the program in the order they are
+// to be done.
+func (r *postgresProgramsRepository) FindDays(programID int) ([]records.ProgramDays, error) {
+	query, args, err := squirrel.
+		Select(
+			"program_days.*",
+			"workouts.title AS workout_title",
+			"workouts.price AS workout_price",
+			"workouts.members_only AS workout_members_only",
+			"workouts.owner_id AS workout_owner_id",
+		).
+		From("program_days").
+		Join("workouts ON workouts.id = program_days.workout_id").
+		Where(squirrel.Eq{"program_days.program_id": programID}).
+		OrderBy("program_days.week_number ASC", "program_days.day_number ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindDays - squirrel.Select: %w", err))
+	}
+
+	var days []records.ProgramDays
+	if err := r.db.Select(&days, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindDays - db.Select: %w", err))
+	}
+
+	return days, nil
+}
+
+// CountOwnedWorkouts counts how many of the workouts belong to the owner.
+func (r *postgresProgramsRepository) CountOwnedWorkouts(ownerID int, workoutIDs []int) (int, error) {
+	query, args, err := squirrel.
+		Select("COUNT(*)").
+		From("workouts").
+		Where(squirrel.Eq{"id": workoutIDs, "owner_id": ownerID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - CountOwnedWorkouts - squirrel.Select: %w", err))
+	}
+
+	var count int
+	if err := r.db.Get(&count, query, args...); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - CountOwnedWorkouts - db.Get: %w", err))
+	}
+
+	return count, nil
+}
+
+func (r *postgresProgramsRepository) Enroll(enrollment records.ProgramEnrollments) (int, error) {
+	query, args, err := squirrel.
+		Insert("program_enrollments").
+		Columns("program_id", "user_id", "current_week", "current_day", "created_at").
+		Values(enrollment.ProgramID, enrollment.UserID, enrollment.CurrentWeek, enrollment.CurrentDay, time.Now()).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Enroll - squirrel.Insert: %w", err))
+	}
+
+	var enrollmentID int
+	if err := r.db.Get(&enrollmentID, query, args...); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Enroll - db.Get: %w", err))
+	}
+
+	return enrollmentID, nil
+}
+
+// Unenroll ends the user's enrollment. Its completions are kept.
+func (r *postgresProgramsRepository) Unenroll(programID int, userID int) error {
+	query, args, err := squirrel.
+		Update("program_enrollments").
+		Set("deleted_at", time.Now()).
+		Where(squirrel.Eq{"program_id": programID, "user_id": userID, "deleted_at": nil}).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Unenroll - squirrel.Update: %w", err))
+	}
+
+	var enrollmentID int
+	if err := r.db.Get(&enrollmentID, query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - Unenroll - db.Get: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresProgramsRepository) FindEnrollment(programID int, userID int) (records.ProgramEnrollments, error) {
+	query, args, err := selectProgramEnrollments().
+		Where(squirrel.Eq{"program_enrollments.program_id": programID, "program_enrollments.user_id": userID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.ProgramEnrollments{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindEnrollment - squirrel.Select: %w", err))
+	}
+
+	var enrollment records.ProgramEnrollments
+	if err := r.db.Get(&enrollment, query, args...); err != nil {
+		return records.ProgramEnrollments{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindEnrollment - db.Get: %w", err))
+	}
+
+	return enrollment, nil
+}
+
+func (r *postgresProgramsRepository) FindEnrollmentsByUserID(userID int) ([]records.ProgramEnrollments, error) {
+	query, args, err := selectProgramEnrollments().
+		Where(squirrel.Eq{"program_enrollments.user_id": userID}).
+		OrderBy("program_enrollments.created_at DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindEnrollmentsByUserID - squirrel.Select: %w", err))
+	}
+
+	var enrollments []records.ProgramEnrollments
+	if err := r.db.Select(&enrollments, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - FindEnrollmentsByUserID - db.Select: %w", err))
+	}
+
+	return enrollments, nil
+}
+
+// CompleteDay records the completion of the enrollment's current day and
+// moves it on to the next day, or marks it completed when next is nil. The
+// enrollment only moves when it is still where the caller saw it, so that a
+// day completed twice at once is only counted once.
+func (r *postgresProgramsRepository) CompleteDay(enrollment records.ProgramEnrollments, completion records.ProgramCompletions, next *records.ProgramDays) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - CompleteDay - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	now := time.Now()
+	enrollmentMap := map[string]interface{}{
+		"completed_days": squirrel.Expr("completed_days + 1"),
+		"updated_at":     now,
+	}
+	if next != nil {
+		enrollmentMap["current_week"] = next.WeekNumber
+		enrollmentMap["current_day"] = next.DayNumber
+	} else {
+		enrollmentMap["completed_at"] = now
+	}
+
+	query, args, err := squirrel.
+		Update("program_enrollments").
+		SetMap(enrollmentMap).
+		Where(squirrel.Eq{
+			"id":           enrollment.ID,
+			"current_week": enrollment.CurrentWeek,
+			"current_day":  enrollment.CurrentDay,
+			"completed_at": nil,
+			"deleted_at":   nil,
+		}).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - CompleteDay - squirrel.Update: %w", err))
+	}
+
+	var enrollmentID int
+	if err := tx.Get(&enrollmentID, query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - CompleteDay - tx.Get: %w", err))
+	}
+
+	query, args, err = squirrel.
+		Insert("program_completions").
+		Columns("enrollment_id", "week_number", "day_number", "workout_id", "created_at").
+		Values(enrollment.ID, completion.WeekNumber, completion.DayNumber, completion.WorkoutID, now).
+		Suffix("ON CONFLICT (enrollment_id, week_number, day_number) DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - CompleteDay - squirrel.Insert: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - CompleteDay - tx.Exec: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresProgramsRepository - CompleteDay - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+// selectPrograms selects the programs with their number of weeks and their
+// price, the sum of the prices of the distinct workouts of the schedule.
+func selectPrograms() squirrel.SelectBuilder {
+	return squirrel.
+		Select("programs.*").
+		Column("(SELECT COUNT(*) FROM program_weeks WHERE program_weeks.program_id = programs.id) AS weeks_count").
+		Column("COALESCE((SELECT SUM(workouts.price) FROM workouts WHERE workouts.id IN (SELECT program_days.workout_id FROM program_days WHERE program_days.program_id = programs.id)), 0) AS price").
+		From("programs")
+}
+
+// selectProgramEnrollments selects the enrollments that were not ended.
+func selectProgramEnrollments() squirrel.SelectBuilder {
+	return squirrel.
+		Select("program_enrollments.*", "programs.title AS program_title").
+		From("program_enrollments").
+		Join("programs ON programs.id = program_enrollments.program_id").
+		Where(squirrel.Eq{"program_enrollments.deleted_at": nil})
+}
+
+func saveSchedule(tx *sqlx.Tx, programID int, weeks []records.ProgramWeeks, days []records.ProgramDays) error {
+	now := time.Now()
+
+	if len(weeks) > 0 {
+		insertWeeks := squirrel.
+			Insert("program_weeks").
+			Columns("program_id", "week_number", "is_deload", "notes", "created_at")
+		for _, week := range weeks {
+			insertWeeks = insertWeeks.Values(programID, week.WeekNumber, week.IsDeload, week.Notes, now)
+		}
+
+		query, args, err := insertWeeks.PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			return fmt.Errorf("squirrel.Insert: %w", err)
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("tx.Exec: %w", err)
+		}
+	}
+
+	if len(days) > 0 {
+		insertDays := squirrel.
+			Insert("program_days").
+			Columns("program_id", "week_number", "day_number", "workout_id", "created_at")
+		for _, day := range days {
+			insertDays = insertDays.Values(programID, day.WeekNumber, day.DayNumber, day.WorkoutID, now)
+		}
+
+		query, args, err := insertDays.PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			return fmt.Errorf("squirrel.Insert: %w", err)
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("tx.Exec: %w", err)
+		}
+	}
+
+	return nil
+}
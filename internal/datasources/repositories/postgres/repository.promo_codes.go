@@ -0,0 +1,398 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"database/sql"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresPromoCodesRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresPromoCodesRepository(db *sqlx.DB) services.PromoCodesRepository {
+	return &postgresPromoCodesRepository{db}
+}
+
+func (r *postgresPromoCodesRepository) Save(promoCode records.PromoCodes, workoutIDs []int) (records.PromoCodes, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return records.PromoCodes{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Save - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Insert("promo_codes").
+		Columns("code", "owner_id", "discount_type", "discount_value", "currency", "max_redemptions", "per_user_limit", "starts_at", "expires_at", "is_active", "created_at").
+		Values(promoCode.Code, promoCode.OwnerID, promoCode.DiscountType, promoCode.DiscountValue, promoCode.Currency, promoCode.MaxRedemptions, promoCode.PerUserLimit, promoCode.StartsAt, promoCode.ExpiresAt, promoCode.IsActive, time.Now()).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return records.PromoCodes{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	var saved records.PromoCodes
+	if err := tx.Get(&saved, query, args...); err != nil {
+		tx.Rollback()
+		return records.PromoCodes{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Save - tx.Get: %w", err))
+	}
+
+	if err := saveWorkoutRestrictions(tx, saved.ID, workoutIDs); err != nil {
+		tx.Rollback()
+		return records.PromoCodes{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Save - %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return records.PromoCodes{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Save - tx.Commit: %w", err))
+	}
+
+	return saved, nil
+}
+
+// Update changes the columns in promoCode. The workouts the code is
+// restricted to are replaced when workoutIDs is not nil.
+func (r *postgresPromoCodesRepository) Update(id int, promoCode map[string]interface{}, workoutIDs []int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Update - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	updateQuery := squirrel.
+		Update("promo_codes").
+		Set("updated_at", time.Now()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	for key, value := range promoCode {
+		updateQuery = updateQuery.Set(key, value)
+	}
+
+	query, args, err := updateQuery.
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Update - squirrel.Update: %w", err))
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Update - tx.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Update - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return repositories.ErrorRowNotFound
+	}
+
+	if workoutIDs != nil {
+		query, args, err = squirrel.
+			Delete("promo_code_workouts").
+			Where(squirrel.Eq{"promo_code_id": id}).
+			PlaceholderFormat(squirrel.Dollar).
+			ToSql()
+		if err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Update - squirrel.Delete: %w", err))
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Update - tx.Exec: %w", err))
+		}
+
+		if err := saveWorkoutRestrictions(tx, id, workoutIDs); err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Update - %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Update - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresPromoCodesRepository) Delete(id int) error {
+	query, args, err := squirrel.
+		Update("promo_codes").
+		Set("is_active", false).
+		Set("deleted_at", time.Now()).
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Delete - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Delete - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - Delete - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
+
+func (r *postgresPromoCodesRepository) FindByID(id int) (records.PromoCodes, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("promo_codes").
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.PromoCodes{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - FindByID - squirrel.Select: %w", err))
+	}
+
+	var promoCode records.PromoCodes
+	if err := r.db.Get(&promoCode, query, args...); err != nil {
+		return records.PromoCodes{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - FindByID - db.Get: %w", err))
+	}
+
+	return promoCode, nil
+}
+
+func (r *postgresPromoCodesRepository) FindByCode(code string) (records.PromoCodes, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("promo_codes").
+		Where(squirrel.Eq{"code": code, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.PromoCodes{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - FindByCode - squirrel.Select: %w", err))
+	}
+
+	var promoCode records.PromoCodes
+	if err := r.db.Get(&promoCode, query, args...); err != nil {
+		return records.PromoCodes{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - FindByCode - db.Get: %w", err))
+	}
+
+	return promoCode, nil
+}
+
+// FindAll lists the promo codes of the owner, or every promo code when
+// ownerID is 0.
+func (r *postgresPromoCodesRepository) FindAll(ownerID int) ([]records.PromoCodes, error) {
+	where := squirrel.Eq{"deleted_at": nil}
+	if ownerID > 0 {
+		where["owner_id"] = ownerID
+	}
+
+	query, args, err := squirrel.
+		Select("*").
+		From("promo_codes").
+		Where(where).
+		OrderBy("id DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - FindAll - squirrel.Select: %w", err))
+	}
+
+	var promoCodes []records.PromoCodes
+	if err := r.db.Select(&promoCodes, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - FindAll - db.Select: %w", err))
+	}
+
+	return promoCodes, nil
+}
+
+func (r *postgresPromoCodesRepository) FindWorkouts(promoCodeIDs []int) ([]records.PromoCodeWorkouts, error) {
+	query, args, err := squirrel.
+		Select("promo_code_id", "workout_id").
+		From("promo_code_workouts").
+		Where(squirrel.Eq{"promo_code_id": promoCodeIDs}).
+		OrderBy("promo_code_id ASC", "workout_id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - FindWorkouts - squirrel.Select: %w", err))
+	}
+
+	var workouts []records.PromoCodeWorkouts
+	if err := r.db.Select(&workouts, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - FindWorkouts - db.Select: %w", err))
+	}
+
+	return workouts, nil
+}
+
+// FindUsage counts the redemptions of each promo code that were not lost to
+// a failed payment.
+func (r *postgresPromoCodesRepository) FindUsage(promoCodeIDs []int) ([]records.PromoCodeUsage, error) {
+	query, args, err := squirrel.
+		Select("promo_redemptions.promo_code_id", "COUNT(*) AS redemptions").
+		From("promo_redemptions").
+		Join("payments ON payments.id = promo_redemptions.payment_id").
+		Where(squirrel.Eq{"promo_redemptions.promo_code_id": promoCodeIDs}).
+		Where(squirrel.NotEq{"payments.status": services.PaymentStatusFailed}).
+		GroupBy("promo_redemptions.promo_code_id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - FindUsage - squirrel.Select: %w", err))
+	}
+
+	var usage []records.PromoCodeUsage
+	if err := r.db.Select(&usage, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - FindUsage - db.Select: %w", err))
+	}
+
+	return usage, nil
+}
+
+// CountRedemptions returns how often the promo code was redeemed in total and
+// by the user, leaving out redemptions of failed payments.
+func (r *postgresPromoCodesRepository) CountRedemptions(promoCodeID int, userID int) (int, int, error) {
+	return countRedemptions(r.db, "CountRedemptions", promoCodeID, userID)
+}
+
+// SaveRedemption redeems the promo code for a payment unless that would go
+// over one of its limits, and reports whether it did. The promo code is locked
+// while counting, so concurrent purchases cannot exceed the limits.
+func (r *postgresPromoCodesRepository) SaveRedemption(redemption records.PromoRedemptions, maxRedemptions sql.NullInt64, perUserLimit int) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - SaveRedemption - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Select("id").
+		From("promo_codes").
+		Where(squirrel.Eq{"id": redemption.PromoCodeID}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - SaveRedemption - squirrel.Select: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - SaveRedemption - tx.Exec: %w", err))
+	}
+
+	total, byUser, err := countRedemptions(tx, "SaveRedemption", redemption.PromoCodeID, redemption.UserID)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if (maxRedemptions.Valid && int64(total) >= maxRedemptions.Int64) || byUser >= perUserLimit {
+		tx.Rollback()
+		return false, nil
+	}
+
+	query, args, err = squirrel.
+		Insert("promo_redemptions").
+		Columns("promo_code_id", "user_id", "payment_id", "discount", "created_at").
+		Values(redemption.PromoCodeID, redemption.UserID, redemption.PaymentID, redemption.Discount, time.Now()).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - SaveRedemption - squirrel.Insert: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - SaveRedemption - tx.Exec: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - SaveRedemption - tx.Commit: %w", err))
+	}
+
+	return true, nil
+}
+
+func countRedemptions(db sqlx.Queryer, method string, promoCodeID int, userID int) (int, int, error) {
+	query, args, err := squirrel.
+		Select("COUNT(*)").
+		Column("COUNT(*) FILTER (WHERE promo_redemptions.user_id = ?)", userID).
+		From("promo_redemptions").
+		Join("payments ON payments.id = promo_redemptions.payment_id").
+		Where(squirrel.Eq{"promo_redemptions.promo_code_id": promoCodeID}).
+		Where(squirrel.NotEq{"payments.status": services.PaymentStatusFailed}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - %s - squirrel.Select: %w", method, err))
+	}
+
+	var total, byUser int
+	if err := db.QueryRowx(query, args...).Scan(&total, &byUser); err != nil {
+		return 0, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresPromoCodesRepository - %s - db.QueryRowx: %w", method, err))
+	}
+
+	return total, byUser, nil
+}
+
+func saveWorkoutRestrictions(tx *sqlx.Tx, promoCodeID int, workoutIDs []int) error {
+	if len(workoutIDs) == 0 {
+		return nil
+	}
+
+	insertQuery := squirrel.
+		Insert("promo_code_workouts").
+		Columns("promo_code_id", "workout_id").
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar)
+	for _, workoutID := range workoutIDs {
+		insertQuery = insertQuery.Values(promoCodeID, workoutID)
+	}
+
+	query, args, err := insertQuery.ToSql()
+	if err != nil {
+		return fmt.Errorf("squirrel.Insert: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("tx.Exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresPurchasesRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresPurchasesRepository(db *sqlx.DB) services.PurchasesRepository {
+	return &postgresPurchasesRepository{db}
+}
+
+func (r *postgresPurchasesRepository) Save(purchase records.Purchases) (records.Purchases, error) {
+	query, args, err := squirrel.
+		Insert("purchases").
+		Columns("buyer_id", "workout_id", "payment_id", "price_paid", "original_price", "discount", "promo_code", "currency", "status", "created_at").
+		Values(purchase.BuyerID, purchase.WorkoutID, purchase.PaymentID, purchase.PricePaid, purchase.OriginalPrice, purchase.Discount, purchase.PromoCode, purchase.Currency, purchase.Status, time.Now()).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.Purchases{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPurchasesRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	var saved records.Purchases
+	if err := r.db.Get(&saved, query, args...); err != nil {
+		return records.Purchases{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresPurchasesRepository - Save - db.Get: %w", err))
+	}
+
+	return saved, nil
+}
+
+func (r *postgresPurchasesRepository) FindAllByBuyerID(buyerID int) ([]records.Purchases, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("purchases").
+		Where(squirrel.Eq{"buyer_id": buyerID, "deleted_at": nil}).
+		OrderBy("id DESC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPurchasesRepository - FindAllByBuyerID - squirrel.Select: %w", err))
+	}
+
+	var purchases []records.Purchases
+	if err := r.db.Select(&purchases, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPurchasesRepository - FindAllByBuyerID - db.Select: %w", err))
+	}
+
+	return purchases, nil
+}
+
+// FindEntitledWorkoutIDs returns which of the workouts the buyer holds an
+// active purchase for.
+func (r *postgresPurchasesRepository) FindEntitledWorkoutIDs(buyerID int, workoutIDs []int) ([]int, error) {
+	query, args, err := squirrel.
+		Select("workout_id").
+		From("purchases").
+		Where(squirrel.Eq{
+			"buyer_id":   buyerID,
+			"workout_id": workoutIDs,
+			"status":     services.PurchaseStatusActive,
+			"deleted_at": nil,
+		}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPurchasesRepository - FindEntitledWorkoutIDs - squirrel.Select: %w", err))
+	}
+
+	var entitled []int
+	if err := r.db.Select(&entitled, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresPurchasesRepository - FindEntitledWorkoutIDs - db.Select: %w", err))
+	}
+
+	return entitled, nil
+}
+
+func (r *postgresPurchasesRepository) ExistsByPaymentID(paymentID int) (bool, error) {
+	query, args, err := squirrel.
+		Select("COUNT(*) > 0").
+		From("purchases").
+		Where(squirrel.Eq{"payment_id": paymentID, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPurchasesRepository - ExistsByPaymentID - squirrel.Select: %w", err))
+	}
+
+	var exists bool
+	if err := r.db.Get(&exists, query, args...); err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresPurchasesRepository - ExistsByPaymentID - db.Get: %w", err))
+	}
+
+	return exists, nil
+}
+
+func (r *postgresPurchasesRepository) UpdateStatusByPaymentID(paymentID int, status string) error {
+	query, args, err := squirrel.
+		Update("purchases").
+		Set("status", status).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"payment_id": paymentID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPurchasesRepository - UpdateStatusByPaymentID - squirrel.Update: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresPurchasesRepository - UpdateStatusByPaymentID - db.Exec: %w", err))
+	}
+
+	return nil
+}
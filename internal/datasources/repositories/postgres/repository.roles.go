@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresRolesRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRolesRepository(db *sqlx.DB) services.RolesRepository {
+	return &postgresRolesRepository{db}
+}
+
+func (r *postgresRolesRepository) FindAll() ([]records.Roles, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("roles").
+		Where(squirrel.Eq{"deleted_at": nil}).
+		OrderBy("id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - FindAll - squirrel.Select: %w", err))
+	}
+
+	var roles []records.Roles
+	if err := r.db.Select(&roles, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - FindAll - db.Select: %w", err))
+	}
+
+	return roles, nil
+}
+
+func (r *postgresRolesRepository) FindByName(name string) (records.Roles, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("roles").
+		Where(squirrel.Eq{"name": name, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.Roles{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - FindByName - squirrel.Select: %w", err))
+	}
+
+	var role records.Roles
+	if err := r.db.Get(&role, query, args...); err != nil {
+		return records.Roles{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - FindByName - db.Get: %w", err))
+	}
+
+	return role, nil
+}
+
+func (r *postgresRolesRepository) FindAllByUserID(userID int) ([]records.Roles, error) {
+	query, args, err := squirrel.
+		Select("roles.*").
+		From("roles").
+		Join("user_roles ON user_roles.role_id = roles.id").
+		Where(squirrel.Eq{"user_roles.user_id": userID, "roles.deleted_at": nil}).
+		OrderBy("roles.id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - FindAllByUserID - squirrel.Select: %w", err))
+	}
+
+	var roles []records.Roles
+	if err := r.db.Select(&roles, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - FindAllByUserID - db.Select: %w", err))
+	}
+
+	return roles, nil
+}
+
+func (r *postgresRolesRepository) FindPermissionsByRoleID(roleID int) ([]records.Permissions, error) {
+	query, args, err := squirrel.
+		Select("permissions.*").
+		From("permissions").
+		Join("role_permissions ON role_permissions.permission_id = permissions.id").
+		Where(squirrel.Eq{"role_permissions.role_id": roleID, "permissions.deleted_at": nil}).
+		OrderBy("permissions.id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - FindPermissionsByRoleID - squirrel.Select: %w", err))
+	}
+
+	var permissions []records.Permissions
+	if err := r.db.Select(&permissions, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - FindPermissionsByRoleID - db.Select: %w", err))
+	}
+
+	return permissions, nil
+}
+
+func (r *postgresRolesRepository) FindPermissionsByUserID(userID int) ([]records.Permissions, error) {
+	query, args, err := squirrel.
+		Select("DISTINCT permissions.*").
+		From("permissions").
+		Join("role_permissions ON role_permissions.permission_id = permissions.id").
+		Join("user_roles ON user_roles.role_id = role_permissions.role_id").
+		Join("roles ON roles.id = user_roles.role_id").
+		Where(squirrel.Eq{"user_roles.user_id": userID, "roles.deleted_at": nil, "permissions.deleted_at": nil}).
+		OrderBy("permissions.id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - FindPermissionsByUserID - squirrel.Select: %w", err))
+	}
+
+	var permissions []records.Permissions
+	if err := r.db.Select(&permissions, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - FindPermissionsByUserID - db.Select: %w", err))
+	}
+
+	return permissions, nil
+}
+
+func (r *postgresRolesRepository) GrantToUser(userID int, roleID int, grantedBy int) error {
+	query, args, err := squirrel.
+		Insert("user_roles").
+		Columns("user_id", "role_id", "granted_by", "created_at").
+		Values(userID, roleID, grantedBy, time.Now()).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - GrantToUser - squirrel.Insert: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - GrantToUser - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresRolesRepository) RevokeFromUser(userID int, roleID int) error {
+	query, args, err := squirrel.
+		Delete("user_roles").
+		Where(squirrel.Eq{"user_id": userID, "role_id": roleID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - RevokeFromUser - squirrel.Delete: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - RevokeFromUser - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresRolesRepository - RevokeFromUser - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
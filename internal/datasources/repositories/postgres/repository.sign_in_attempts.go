@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresSignInAttemptsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresSignInAttemptsRepository(db *sqlx.DB) services.SignInAttemptsRepository {
+	return &postgresSignInAttemptsRepository{db}
+}
+
+func (r *postgresSignInAttemptsRepository) Save(attempt records.SignInAttempts) error {
+	query, args, err := squirrel.
+		Insert("sign_in_attempts").
+		Columns("email", "user_id", "ip_address", "user_agent", "reason", "created_at").
+		Values(attempt.Email, attempt.UserID, attempt.IPAddress, attempt.UserAgent, attempt.Reason, time.Now()).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresSignInAttemptsRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresSignInAttemptsRepository - Save - db.Exec: %w", err))
+	}
+
+	return nil
+}
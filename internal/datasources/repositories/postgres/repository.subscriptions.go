@@ -0,0 +1,316 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresSubscriptionsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresSubscriptionsRepository(db *sqlx.DB) services.SubscriptionsRepository {
+	return &postgresSubscriptionsRepository{db}
+}
+
+func (r *postgresSubscriptionsRepository) SavePlan(plan records.SubscriptionPlans) (records.SubscriptionPlans, error) {
+	query, args, err := squirrel.
+		Insert("subscription_plans").
+		Columns("name", "description", "price", "currency", "billing_interval", "trial_days", "is_active", "created_at").
+		Values(plan.Name, plan.Description, plan.Price, plan.Currency, plan.BillingInterval, plan.TrialDays, plan.IsActive, time.Now()).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.SubscriptionPlans{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - SavePlan - squirrel.Insert: %w", err))
+	}
+
+	var saved records.SubscriptionPlans
+	if err := r.db.Get(&saved, query, args...); err != nil {
+		return records.SubscriptionPlans{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - SavePlan - db.Get: %w", err))
+	}
+
+	return saved, nil
+}
+
+func (r *postgresSubscriptionsRepository) UpdatePlan(id int, plan map[string]interface{}) error {
+	updateQuery := squirrel.
+		Update("subscription_plans").
+		Set("updated_at", time.Now()).
+		PlaceholderFormat(squirrel.Dollar)
+
+	for key, value := range plan {
+		updateQuery = updateQuery.Set(key, value)
+	}
+
+	query, args, err := updateQuery.
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - UpdatePlan - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - UpdatePlan - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - UpdatePlan - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
+
+func (r *postgresSubscriptionsRepository) FindPlanByID(id int) (records.SubscriptionPlans, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("subscription_plans").
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.SubscriptionPlans{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - FindPlanByID - squirrel.Select: %w", err))
+	}
+
+	var plan records.SubscriptionPlans
+	if err := r.db.Get(&plan, query, args...); err != nil {
+		return records.SubscriptionPlans{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - FindPlanByID - db.Get: %w", err))
+	}
+
+	return plan, nil
+}
+
+func (r *postgresSubscriptionsRepository) FindAllPlans(activeOnly bool) ([]records.SubscriptionPlans, error) {
+	selectQuery := squirrel.
+		Select("*").
+		From("subscription_plans").
+		Where(squirrel.Eq{"deleted_at": nil}).
+		OrderBy("price ASC", "id ASC").
+		PlaceholderFormat(squirrel.Dollar)
+	if activeOnly {
+		selectQuery = selectQuery.Where(squirrel.Eq{"is_active": true})
+	}
+
+	query, args, err := selectQuery.ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - FindAllPlans - squirrel.Select: %w", err))
+	}
+
+	var plans []records.SubscriptionPlans
+	if err := r.db.Select(&plans, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - FindAllPlans - db.Select: %w", err))
+	}
+
+	return plans, nil
+}
+
+func (r *postgresSubscriptionsRepository) Save(subscription records.Subscriptions) (records.Subscriptions, error) {
+	query, args, err := squirrel.
+		Insert("subscriptions").
+		Columns("user_id", "plan_id", "payment_method_id", "status", "trial_ends_at", "current_period_start", "current_period_end", "created_at").
+		Values(
+			subscription.UserID,
+			subscription.PlanID,
+			subscription.PaymentMethodID,
+			subscription.Status,
+			subscription.TrialEndsAt,
+			subscription.CurrentPeriodStart,
+			subscription.CurrentPeriodEnd,
+			time.Now(),
+		).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.Subscriptions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	var saved records.Subscriptions
+	if err := r.db.Get(&saved, query, args...); err != nil {
+		return records.Subscriptions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - Save - db.Get: %w", err))
+	}
+
+	return saved, nil
+}
+
+// FindCurrentByUserID returns the subscription of the user that is not canceled.
+func (r *postgresSubscriptionsRepository) FindCurrentByUserID(userID int) (records.Subscriptions, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("subscriptions").
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil}).
+		Where(squirrel.NotEq{"status": services.SubscriptionStatusCanceled}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.Subscriptions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - FindCurrentByUserID - squirrel.Select: %w", err))
+	}
+
+	var subscription records.Subscriptions
+	if err := r.db.Get(&subscription, query, args...); err != nil {
+		return records.Subscriptions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - FindCurrentByUserID - db.Get: %w", err))
+	}
+
+	return subscription, nil
+}
+
+func (r *postgresSubscriptionsRepository) ExistsByUserID(userID int, statuses []string) (bool, error) {
+	selectQuery := squirrel.
+		Select("COUNT(*) > 0").
+		From("subscriptions").
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar)
+	if statuses != nil {
+		selectQuery = selectQuery.Where(squirrel.Eq{"status": statuses})
+	}
+
+	query, args, err := selectQuery.ToSql()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - ExistsByUserID - squirrel.Select: %w", err))
+	}
+
+	var exists bool
+	if err := r.db.Get(&exists, query, args...); err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - ExistsByUserID - db.Get: %w", err))
+	}
+
+	return exists, nil
+}
+
+// FindDue returns subscriptions whose period ended and past due ones whose
+// next payment attempt is due.
+func (r *postgresSubscriptionsRepository) FindDue(now time.Time, limit int) ([]records.Subscriptions, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("subscriptions").
+		Where(squirrel.Eq{"deleted_at": nil}).
+		Where(squirrel.Or{
+			squirrel.And{
+				squirrel.Eq{"status": []string{services.SubscriptionStatusTrial, services.SubscriptionStatusActive}},
+				squirrel.LtOrEq{"current_period_end": now},
+			},
+			squirrel.And{
+				squirrel.Eq{"status": services.SubscriptionStatusPastDue},
+				squirrel.LtOrEq{"next_attempt_at": now},
+			},
+		}).
+		OrderBy("id ASC").
+		Limit(uint64(limit)).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - FindDue - squirrel.Select: %w", err))
+	}
+
+	var subscriptions []records.Subscriptions
+	if err := r.db.Select(&subscriptions, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - FindDue - db.Select: %w", err))
+	}
+
+	return subscriptions, nil
+}
+
+func (r *postgresSubscriptionsRepository) UpdateCancelAtPeriodEnd(id int, cancel bool) error {
+	return r.update("UpdateCancelAtPeriodEnd", squirrel.
+		Update("subscriptions").
+		Set("cancel_at_period_end", cancel).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.NotEq{"status": services.SubscriptionStatusCanceled}))
+}
+
+// UpdatePaymentMethod switches the payment method. Past due subscriptions are
+// retried with it right away.
+func (r *postgresSubscriptionsRepository) UpdatePaymentMethod(id int, paymentMethodID int) error {
+	return r.update("UpdatePaymentMethod", squirrel.
+		Update("subscriptions").
+		Set("payment_method_id", paymentMethodID).
+		Set("next_attempt_at", squirrel.Expr("CASE WHEN status = ? THEN ? ELSE next_attempt_at END", services.SubscriptionStatusPastDue, time.Now())).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.NotEq{"status": services.SubscriptionStatusCanceled}))
+}
+
+// Renew starts a new paid period. It only applies while the subscription is
+// still in the period it was charged for, so concurrent renewals apply once.
+func (r *postgresSubscriptionsRepository) Renew(id int, chargedPeriodEnd time.Time, start time.Time, end time.Time) (bool, error) {
+	return r.transition("Renew", squirrel.
+		Update("subscriptions").
+		Set("status", services.SubscriptionStatusActive).
+		Set("current_period_start", start).
+		Set("current_period_end", end).
+		Set("past_due_since", nil).
+		Set("next_attempt_at", nil).
+		Set("failed_attempts", 0).
+		Set("last_error", "").
+		Where(squirrel.Eq{"id": id, "current_period_end": chargedPeriodEnd}).
+		Where(squirrel.NotEq{"status": services.SubscriptionStatusCanceled}))
+}
+
+func (r *postgresSubscriptionsRepository) MarkPastDue(id int, failedAttempts int, reason string, nextAttemptAt time.Time) (bool, error) {
+	return r.transition("MarkPastDue", squirrel.
+		Update("subscriptions").
+		Set("status", services.SubscriptionStatusPastDue).
+		Set("past_due_since", squirrel.Expr("COALESCE(past_due_since, ?)", time.Now())).
+		Set("next_attempt_at", nextAttemptAt).
+		Set("failed_attempts", failedAttempts+1).
+		Set("last_error", reason).
+		Where(squirrel.Eq{"id": id, "failed_attempts": failedAttempts}).
+		Where(squirrel.NotEq{"status": services.SubscriptionStatusCanceled}))
+}
+
+func (r *postgresSubscriptionsRepository) Cancel(id int, reason string) (bool, error) {
+	return r.transition("Cancel", squirrel.
+		Update("subscriptions").
+		Set("status", services.SubscriptionStatusCanceled).
+		Set("canceled_at", time.Now()).
+		Set("cancel_at_period_end", false).
+		Set("next_attempt_at", nil).
+		Set("last_error", reason).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.NotEq{"status": services.SubscriptionStatusCanceled}))
+}
+
+func (r *postgresSubscriptionsRepository) update(method string, updateQuery squirrel.UpdateBuilder) error {
+	updated, err := r.transition(method, updateQuery)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
+
+// transition runs the update and reports whether it matched a subscription.
+func (r *postgresSubscriptionsRepository) transition(method string, updateQuery squirrel.UpdateBuilder) (bool, error) {
+	query, args, err := updateQuery.
+		Set("updated_at", time.Now()).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - %s - squirrel.Update: %w", method, err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - %s - db.Exec: %w", method, err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresSubscriptionsRepository - %s - result.RowsAffected: %w", method, err))
+	}
+
+	return affected > 0, nil
+}
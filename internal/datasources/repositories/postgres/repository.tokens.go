@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
 	"backend/internal/helpers"
 	"backend/internal/services"
 	"fmt"
@@ -19,9 +20,10 @@ func NewPostgresTokensRepository(db *sqlx.DB) services.TokensRepository {
 }
 
 func (r *postgresTokensRepository) Save(token records.Tokens) error {
+	now := time.Now()
 	query, args, err := squirrel.Insert("tokens").
-		Columns("token", "user_id", "created_at", "updated_at", "deleted_at").
-		Values(token.Token, token.UserID, time.Now(), nil, nil).
+		Columns("token", "user_id", "family_id", "device_name", "user_agent", "ip_address", "signed_in_at", "last_used_at", "created_at", "updated_at", "deleted_at").
+		Values(token.Token, token.UserID, token.FamilyID, token.DeviceName, token.UserAgent, token.IPAddress, now, now, now, nil, nil).
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 	if err != nil {
@@ -67,3 +69,123 @@ func (r *postgresTokensRepository) Delete(token string) error {
 
 	return nil
 }
+
+func (r *postgresTokensRepository) Rotate(oldToken string, newToken records.Tokens) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - Rotate - r.db.Beginx: %w", err))
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	updateQuery, args, err := squirrel.Update("tokens").
+		Set("rotated_at", now).
+		Set("updated_at", now).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"token": oldToken, "rotated_at": nil, "deleted_at": nil}).ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - Rotate - squirrel.Update: %w", err))
+	}
+
+	result, err := tx.Exec(updateQuery, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - Rotate - tx.Exec: %w", err))
+	}
+
+	// Nothing was updated when a concurrent request has already rotated the token.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - Rotate - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	insertQuery, args, err := squirrel.Insert("tokens").
+		Columns("token", "user_id", "family_id", "device_name", "user_agent", "ip_address", "signed_in_at", "last_used_at", "created_at", "updated_at", "deleted_at").
+		Values(newToken.Token, newToken.UserID, newToken.FamilyID, newToken.DeviceName, newToken.UserAgent, newToken.IPAddress, newToken.SignedInAt, now, now, nil, nil).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - Rotate - squirrel.Insert: %w", err))
+	}
+
+	if _, err := tx.Exec(insertQuery, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - Rotate - tx.Exec: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - Rotate - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresTokensRepository) FindActiveByUserID(userID int, issuedAfter time.Time) ([]records.Tokens, error) {
+	query, args, err := squirrel.Select("*").
+		From("tokens").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"user_id": userID, "rotated_at": nil, "deleted_at": nil}).
+		Where(squirrel.Gt{"created_at": issuedAfter}).
+		OrderBy("last_used_at DESC").ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - FindActiveByUserID - squirrel.Select: %w", err))
+	}
+
+	var tokenRecords []records.Tokens
+	if err := r.db.Select(&tokenRecords, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - FindActiveByUserID - db.Select: %w", err))
+	}
+
+	return tokenRecords, nil
+}
+
+func (r *postgresTokensRepository) FindActiveByFamilyID(familyID string) (records.Tokens, error) {
+	query, args, err := squirrel.Select("*").
+		From("tokens").
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"family_id": familyID, "rotated_at": nil, "deleted_at": nil}).
+		Limit(1).ToSql()
+	if err != nil {
+		return records.Tokens{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - FindActiveByFamilyID - squirrel.Select: %w", err))
+	}
+
+	var tokenRecord records.Tokens
+	if err := r.db.Get(&tokenRecord, query, args...); err != nil {
+		return records.Tokens{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - FindActiveByFamilyID - db.Get: %w", err))
+	}
+
+	return tokenRecord, nil
+}
+
+func (r *postgresTokensRepository) DeleteByFamilyID(familyID string) error {
+	query, args, err := squirrel.Update("tokens").
+		Set("deleted_at", time.Now()).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"family_id": familyID, "deleted_at": nil}).ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - DeleteByFamilyID - squirrel.Update: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - DeleteByFamilyID - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresTokensRepository) DeleteByUserID(userID int) error {
+	query, args, err := squirrel.Update("tokens").
+		Set("deleted_at", time.Now()).
+		PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil}).ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - DeleteByUserID - squirrel.Update: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTokensRepository - DeleteByUserID - db.Exec: %w", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresTwoFactorRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresTwoFactorRepository(db *sqlx.DB) services.TwoFactorRepository {
+	return &postgresTwoFactorRepository{db}
+}
+
+func (r *postgresTwoFactorRepository) FindCredentialByUserID(userID int) (records.TOTPCredentials, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("totp_credentials").
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.TOTPCredentials{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - FindCredentialByUserID - squirrel.Select: %w", err))
+	}
+
+	var credential records.TOTPCredentials
+	if err := r.db.Get(&credential, query, args...); err != nil {
+		return records.TOTPCredentials{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - FindCredentialByUserID - db.Get: %w", err))
+	}
+
+	return credential, nil
+}
+
+// SaveCredential stores a new pending secret, replacing any unconfirmed one.
+func (r *postgresTwoFactorRepository) SaveCredential(credential records.TOTPCredentials) error {
+	query, args, err := squirrel.
+		Insert("totp_credentials").
+		Columns("user_id", "secret", "created_at").
+		Values(credential.UserID, credential.Secret, time.Now()).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = 0, updated_at = EXCLUDED.created_at, deleted_at = NULL").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - SaveCredential - squirrel.Insert: %w", err))
+	}
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - SaveCredential - db.Exec: %w", err))
+	}
+
+	return nil
+}
+
+// ConfirmCredential enables the credential and stores its first recovery codes.
+func (r *postgresTwoFactorRepository) ConfirmCredential(userID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - ConfirmCredential - r.db.Beginx: %w", err))
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("totp_credentials").
+		Set("confirmed_at", now).
+		Set("last_used_step", step).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"user_id": userID, "confirmed_at": nil, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - ConfirmCredential - squirrel.Update: %w", err))
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - ConfirmCredential - tx.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - ConfirmCredential - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - ConfirmCredential - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+// UseStep records the time step of an accepted code. It fails when the step
+// is not newer than the last accepted one, which rejects replayed codes.
+func (r *postgresTwoFactorRepository) UseStep(userID int, step int64) error {
+	query, args, err := squirrel.
+		Update("totp_credentials").
+		Set("last_used_step", step).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"user_id": userID, "deleted_at": nil}).
+		Where(squirrel.Lt{"last_used_step": step}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - UseStep - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - UseStep - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - UseStep - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
+
+func (r *postgresTwoFactorRepository) DeleteCredential(userID int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - DeleteCredential - r.db.Beginx: %w", err))
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"totp_recovery_codes", "totp_credentials"} {
+		query, args, err := squirrel.
+			Delete(table).
+			Where(squirrel.Eq{"user_id": userID}).
+			PlaceholderFormat(squirrel.Dollar).
+			ToSql()
+		if err != nil {
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - DeleteCredential - squirrel.Delete: %w", err))
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - DeleteCredential - tx.Exec: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - DeleteCredential - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresTwoFactorRepository) ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - ReplaceRecoveryCodes - r.db.Beginx: %w", err))
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - ReplaceRecoveryCodes - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresTwoFactorRepository) ConsumeRecoveryCode(userID int, codeHash string) error {
+	query, args, err := squirrel.
+		Update("totp_recovery_codes").
+		Set("used_at", time.Now()).
+		Where(squirrel.Eq{"user_id": userID, "code_hash": codeHash, "used_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - ConsumeRecoveryCode - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - ConsumeRecoveryCode - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - ConsumeRecoveryCode - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
+
+func (r *postgresTwoFactorRepository) CountUnusedRecoveryCodes(userID int) (int, error) {
+	query, args, err := squirrel.
+		Select("COUNT(*)").
+		From("totp_recovery_codes").
+		Where(squirrel.Eq{"user_id": userID, "used_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - CountUnusedRecoveryCodes - squirrel.Select: %w", err))
+	}
+
+	var count int
+	if err := r.db.Get(&count, query, args...); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - CountUnusedRecoveryCodes - db.Get: %w", err))
+	}
+
+	return count, nil
+}
+
+func replaceRecoveryCodes(tx *sqlx.Tx, userID int, codeHashes []string) error {
+	query, args, err := squirrel.
+		Delete("totp_recovery_codes").
+		Where(squirrel.Eq{"user_id": userID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - replaceRecoveryCodes - squirrel.Delete: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - replaceRecoveryCodes - tx.Exec: %w", err))
+	}
+
+	insertQuery := squirrel.
+		Insert("totp_recovery_codes").
+		Columns("user_id", "code_hash", "created_at").
+		PlaceholderFormat(squirrel.Dollar)
+
+	now := time.Now()
+	for _, codeHash := range codeHashes {
+		insertQuery = insertQuery.Values(userID, codeHash, now)
+	}
+
+	query, args, err = insertQuery.ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - replaceRecoveryCodes - squirrel.Insert: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresTwoFactorRepository - replaceRecoveryCodes - tx.Exec: %w", err))
+	}
+
+	return nil
+}
@@ -71,11 +71,16 @@ func (r *postgresUsersRepository) FindByEmail(email string) (records.Users, erro
 	return user, nil
 }
 
-func (r *postgresUsersRepository) FindByUsername(username string) (records.Users, error) {
-	query, args, err := squirrel.Select("*").
+// FindByUsername finds the user for the viewer. Hidden and banned users and
+// users who blocked the viewer or were blocked by them are not found.
+func (r *postgresUsersRepository) FindByUsername(username string, viewerID int) (records.Users, error) {
+	selectQuery := squirrel.Select("*").
 		From("users").
 		PlaceholderFormat(squirrel.Dollar).
-		Where(squirrel.Eq{"username": username}).ToSql()
+		Where(squirrel.Eq{"username": username, "hidden_at": nil, "banned_at": nil})
+	selectQuery = repositories.ApplyNotBlocked(selectQuery, "users.id", viewerID)
+
+	query, args, err := selectQuery.ToSql()
 	if err != nil {
 		return records.Users{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresUsersRepository - FindByUsername - squirrel.Select: %w", err))
 	}
@@ -173,7 +178,9 @@ func (r *postgresUsersRepository) UsernameExists(username string) (bool, error)
 	return count > 0, nil
 }
 
-func (r *postgresUsersRepository) FindAllWithFilters(params repositories.QueryParams) ([]records.Users, int, error) {
+// FindAllWithFilters lists the users the viewer may see, leaving out hidden
+// and banned users and users who blocked the viewer or were blocked by them.
+func (r *postgresUsersRepository) FindAllWithFilters(params repositories.QueryParams, viewerID int) ([]records.Users, int, error) {
 	querySelectUsers := squirrel.
 		Select("*").
 		From("users").
@@ -187,6 +194,11 @@ func (r *postgresUsersRepository) FindAllWithFilters(params repositories.QueryPa
 	querySelectUsers = repositories.ApplyFilters(querySelectUsers, params.Filters)
 	queryCountUsers = repositories.ApplyFilters(queryCountUsers, params.Filters)
 
+	querySelectUsers = querySelectUsers.Where(squirrel.Eq{"hidden_at": nil, "banned_at": nil})
+	queryCountUsers = queryCountUsers.Where(squirrel.Eq{"hidden_at": nil, "banned_at": nil})
+	querySelectUsers = repositories.ApplyNotBlocked(querySelectUsers, "users.id", viewerID)
+	queryCountUsers = repositories.ApplyNotBlocked(queryCountUsers, "users.id", viewerID)
+
 	querySelectUsers = querySelectUsers.
 		OrderBy("id ASC")
 
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresVerificationTokensRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresVerificationTokensRepository(db *sqlx.DB) services.VerificationTokensRepository {
+	return &postgresVerificationTokensRepository{db}
+}
+
+// Save stores a new token and invalidates the previous unused tokens issued
+// to the same user for the same purpose.
+func (r *postgresVerificationTokensRepository) Save(token records.VerificationTokens) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresVerificationTokensRepository - Save - r.db.Beginx: %w", err))
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	updateQuery, args, err := squirrel.
+		Update("verification_tokens").
+		Set("deleted_at", now).
+		Where(squirrel.Eq{"user_id": token.UserID, "purpose": token.Purpose, "used_at": nil, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresVerificationTokensRepository - Save - squirrel.Update: %w", err))
+	}
+
+	if _, err := tx.Exec(updateQuery, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresVerificationTokensRepository - Save - tx.Exec: %w", err))
+	}
+
+	insertQuery, args, err := squirrel.
+		Insert("verification_tokens").
+		Columns("token_hash", "purpose", "user_id", "expires_at", "created_at").
+		Values(token.TokenHash, token.Purpose, token.UserID, token.ExpiresAt, now).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresVerificationTokensRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	if _, err := tx.Exec(insertQuery, args...); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresVerificationTokensRepository - Save - tx.Exec: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresVerificationTokensRepository - Save - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+// Consume marks a valid token as used and returns it. Expired, used or
+// invalidated tokens are reported as not found.
+func (r *postgresVerificationTokensRepository) Consume(tokenHash string, purpose string) (records.VerificationTokens, error) {
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("verification_tokens").
+		Set("used_at", now).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"token_hash": tokenHash, "purpose": purpose, "used_at": nil, "deleted_at": nil}).
+		Where(squirrel.Gt{"expires_at": now}).
+		Suffix("RETURNING *").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.VerificationTokens{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresVerificationTokensRepository - Consume - squirrel.Update: %w", err))
+	}
+
+	var token records.VerificationTokens
+	if err := r.db.Get(&token, query, args...); err != nil {
+		return records.VerificationTokens{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresVerificationTokensRepository - Consume - db.Get: %w", err))
+	}
+
+	return token, nil
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"time"
 )
 
 type postgresWorkoutsRepository struct {
@@ -20,14 +21,24 @@ func NewPostgresWorkoutsRepository(db *sqlx.DB) services.WorkoutsRepository {
 	return &postgresWorkoutsRepository{db}
 }
 
+// workoutForkColumns are the lineage fields computed next to workouts.*: the
+// number of direct copies of the workout, and whether its source got new
+// revisions since the copy was last in step with it.
+var workoutForkColumns = []string{
+	"(SELECT COUNT(*) FROM workouts AS forks WHERE forks.forked_from_workout_id = workouts.id) AS forks_count",
+	"COALESCE((SELECT MAX(revision_number) FROM workout_revisions WHERE workout_revisions.workout_id = workouts.forked_from_workout_id) > workouts.upstream_revision, FALSE) AS upstream_changed",
+}
+
 func (r *postgresWorkoutsRepository) FindAll() ([]records.Workouts, error) {
-	query, args, err := squirrel.
+	selectQuery := squirrel.
 		Select("*").
 		From("workouts").
-		Where(squirrel.Eq{"is_private": false}).
+		Where(squirrel.Eq{"is_private": false, "hidden_at": nil}).
 		PlaceholderFormat(squirrel.Dollar).
-		OrderBy("id ASC").
-		ToSql()
+		OrderBy("id ASC")
+	selectQuery = repositories.ApplyNotBanned(selectQuery, "workouts.owner_id")
+
+	query, args, err := selectQuery.ToSql()
 	if err != nil {
 		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - FindAll - squirrel.Select: %w", err))
 	}
@@ -45,6 +56,7 @@ func (r *postgresWorkoutsRepository) FindByID(id int) (records.Workouts, error)
 	query, args, err := squirrel.
 		Select("workouts.*", "COUNT(workout_likes.id) AS likes_count").
 		From("workouts").
+		Columns(workoutForkColumns...).
 		LeftJoin("workout_likes ON workouts.id = workout_likes.workout_id").
 		Where(squirrel.And{
 			squirrel.Eq{"workouts.id": id},
@@ -65,14 +77,22 @@ func (r *postgresWorkoutsRepository) FindByID(id int) (records.Workouts, error)
 	return workout, nil
 }
 
-func (r *postgresWorkoutsRepository) FindAllByOwnerID(ownerID int) ([]records.Workouts, error) {
-	query, args, err := squirrel.
+// FindAllByOwnerID lists the public workouts of the owner. Other viewers do
+// not see hidden workouts, nor any workout when either user blocked the other.
+func (r *postgresWorkoutsRepository) FindAllByOwnerID(ownerID int, viewerID int) ([]records.Workouts, error) {
+	selectQuery := squirrel.
 		Select("*").
+		Columns(workoutForkColumns...).
 		From("workouts").
 		Where(squirrel.Eq{"owner_id": ownerID}, squirrel.Eq{"is_private": false}).
 		PlaceholderFormat(squirrel.Dollar).
-		OrderBy("id ASC").
-		ToSql()
+		OrderBy("id ASC")
+	if viewerID != ownerID {
+		selectQuery = selectQuery.Where(squirrel.Eq{"hidden_at": nil})
+		selectQuery = repositories.ApplyNotBlocked(selectQuery, "workouts.owner_id", viewerID)
+	}
+
+	query, args, err := selectQuery.ToSql()
 	if err != nil {
 		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - FindAll - squirrel.Select: %w", err))
 	}
@@ -88,6 +108,7 @@ func (r *postgresWorkoutsRepository) FindAllByOwnerID(ownerID int) ([]records.Wo
 func (r *postgresWorkoutsRepository) FindAllByCurrentUserID(ownerID int) ([]records.Workouts, error) {
 	query, args, err := squirrel.
 		Select("*").
+		Columns(workoutForkColumns...).
 		From("workouts").
 		Where(squirrel.Eq{"owner_id": ownerID}).
 		PlaceholderFormat(squirrel.Dollar).
@@ -109,8 +130,8 @@ func (r *postgresWorkoutsRepository) Save(workout records.Workouts) (int, error)
 	var workoutID int
 	query, args, err := squirrel.
 		Insert("workouts").
-		Columns("title", "description", "owner_id", "is_private", "price").
-		Values(workout.Title, workout.Description, workout.OwnerID, workout.IsPrivate, workout.Price).
+		Columns("title", "description", "owner_id", "is_private", "price", "members_only").
+		Values(workout.Title, workout.Description, workout.OwnerID, workout.IsPrivate, workout.Price, workout.MembersOnly).
 		Suffix("RETURNING id").
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
@@ -174,9 +195,9 @@ func (r *postgresWorkoutsRepository) Copy(id int, userID int) (int, error) {
 	}
 
 	defer func() {
-		if err != nil {
+		if r := recover(); r != nil {
 			tx.Rollback()
-			panic(err)
+			panic(r)
 		}
 	}()
 
@@ -187,61 +208,135 @@ func (r *postgresWorkoutsRepository) Copy(id int, userID int) (int, error) {
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 	if err != nil {
+		tx.Rollback()
 		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - squirrel.Select: %w", err))
 	}
 
 	var workout records.Workouts
 	if err := tx.Get(&workout, workoutQuery, args...); err != nil {
+		tx.Rollback()
 		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - tx.Get: %w", err))
 	}
 
 	workout.OwnerID = userID
 
+	// The copy credits its source and the original at the top of the chain,
+	// and starts in step with the latest revision of the source.
+	rootWorkoutID := workout.RootWorkoutID
+	if !rootWorkoutID.Valid {
+		rootWorkoutID = sql.NullInt64{Int64: int64(id), Valid: true}
+	}
+
 	queryInsert, args, err := squirrel.
 		Insert("workouts").
-		Columns("title", "description", "owner_id").
-		Values(workout.Title, workout.Description, workout.OwnerID).
+		Columns("title", "description", "owner_id", "forked_from_workout_id", "root_workout_id", "upstream_revision").
+		Values(
+			workout.Title, workout.Description, workout.OwnerID, id, rootWorkoutID,
+			squirrel.Expr("(SELECT MAX(revision_number) FROM workout_revisions WHERE workout_id = ?)", id),
+		).
 		Suffix("RETURNING id").
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 	if err != nil {
+		tx.Rollback()
 		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - squirrel.Insert: %w", err))
 	}
 
 	var workoutID int
 	if err := tx.Get(&workoutID, queryInsert, args...); err != nil {
+		tx.Rollback()
 		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - tx.Get: %w", err))
 	}
 
+	copyQuery, args, err := squirrel.
+		Insert("workout_copies").
+		Columns("workout_id", "copy_id", "user_id").
+		Values(id, workoutID, userID).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - squirrel.Insert: %w", err))
+	}
+
+	if _, err := tx.Exec(copyQuery, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - tx.Exec: %w", err))
+	}
+
+	groupsQuery, args, err := squirrel.
+		Select("*").
+		From("workout_exercise_groups").
+		Where(squirrel.Eq{"workout_id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - squirrel.Select: %w", err))
+	}
+
+	var groups []records.WorkoutExerciseGroups
+	if err := tx.Select(&groups, groupsQuery, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - tx.Select: %w", err))
+	}
+
+	// The copied exercises point to the copies of their groups.
+	groupIDs := make(map[int64]int64, len(groups))
+	for _, group := range groups {
+		groupID, err := saveWorkoutExerciseGroup(tx, workoutID, group)
+		if err != nil {
+			tx.Rollback()
+			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - %w", err))
+		}
+		groupIDs[int64(group.ID)] = int64(groupID)
+	}
+
 	workoutExercisesQuery, args, err := squirrel.
 		Select("*").
 		From("workout_exercises").
 		Where(squirrel.Eq{"workout_id": id}).
+		OrderBy("position ASC", "id ASC").
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 	if err != nil {
+		tx.Rollback()
 		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - squirrel.Select: %w", err))
 	}
 
 	var workoutExercises []records.WorkoutExercises
 	if err := tx.Select(&workoutExercises, workoutExercisesQuery, args...); err != nil {
+		tx.Rollback()
 		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - tx.Select: %w", err))
 	}
 
 	for _, workoutExercise := range workoutExercises {
 		workoutExercise.WorkoutID = workoutID
+		if workoutExercise.GroupID.Valid {
+			workoutExercise.GroupID.Int64 = groupIDs[workoutExercise.GroupID.Int64]
+		}
 
 		queryInsert, args, err := squirrel.
 			Insert("workout_exercises").
-			Columns("workout_id", "exercise_id", "main_note", "secondary_note", "owner_id").
-			Values(workoutExercise.WorkoutID, workoutExercise.ExerciseID, workoutExercise.MainNote, workoutExercise.SecondaryNote, userID).
+			Columns(
+				"workout_id", "exercise_id", "main_note", "secondary_note", "owner_id",
+				"target_sets", "target_reps_min", "target_reps_max", "load_type", "load_value", "tempo", "rest_seconds",
+				"position", "group_id",
+			).
+			Values(
+				workoutExercise.WorkoutID, workoutExercise.ExerciseID, workoutExercise.MainNote, workoutExercise.SecondaryNote, userID,
+				workoutExercise.TargetSets, workoutExercise.TargetRepsMin, workoutExercise.TargetRepsMax, workoutExercise.LoadType, workoutExercise.LoadValue, workoutExercise.Tempo, workoutExercise.RestSeconds,
+				workoutExercise.Position, workoutExercise.GroupID,
+			).
 			PlaceholderFormat(squirrel.Dollar).
 			ToSql()
 		if err != nil {
+			tx.Rollback()
 			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - squirrel.Insert: %w", err))
 		}
 
 		if _, err := tx.Exec(queryInsert, args...); err != nil {
+			tx.Rollback()
 			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - Copy - tx.Exec: %w", err))
 		}
 	}
@@ -253,10 +348,14 @@ func (r *postgresWorkoutsRepository) Copy(id int, userID int) (int, error) {
 	return workoutID, nil
 }
 
-func (r *postgresWorkoutsRepository) FindAllWithFilters(params repositories.QueryParams) ([]records.Workouts, int, error) {
+// FindAllWithFilters lists the public workouts the viewer may see. Hidden
+// workouts, workouts of banned owners and workouts of owners who blocked the
+// viewer or were blocked by them are left out.
+func (r *postgresWorkoutsRepository) FindAllWithFilters(params repositories.QueryParams, viewerID int) ([]records.Workouts, int, error) {
 	// For selecting workouts with like count
 	querySelectWorkouts := squirrel.
 		Select("workouts.*", "COUNT(workout_likes.id) AS likes_count").
+		Columns(workoutForkColumns...).
 		From("workouts").
 		LeftJoin("workout_likes ON workouts.id = workout_likes.workout_id AND workout_likes.deleted_at IS NULL").
 		GroupBy("workouts.id").
@@ -279,6 +378,16 @@ func (r *postgresWorkoutsRepository) FindAllWithFilters(params repositories.Quer
 	queryCountWorkouts = queryCountWorkouts.
 		Where(squirrel.Eq{"workouts.is_private": false})
 
+	// Apply moderation and blocking filters
+	querySelectWorkouts = querySelectWorkouts.
+		Where(squirrel.Eq{"workouts.hidden_at": nil})
+	queryCountWorkouts = queryCountWorkouts.
+		Where(squirrel.Eq{"workouts.hidden_at": nil})
+	querySelectWorkouts = repositories.ApplyNotBanned(querySelectWorkouts, "workouts.owner_id")
+	queryCountWorkouts = repositories.ApplyNotBanned(queryCountWorkouts, "workouts.owner_id")
+	querySelectWorkouts = repositories.ApplyNotBlocked(querySelectWorkouts, "workouts.owner_id", viewerID)
+	queryCountWorkouts = repositories.ApplyNotBlocked(queryCountWorkouts, "workouts.owner_id", viewerID)
+
 	// Get total count
 	var total int
 	query, args, err := queryCountWorkouts.ToSql()
@@ -290,8 +399,13 @@ func (r *postgresWorkoutsRepository) FindAllWithFilters(params repositories.Quer
 		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - FindAllWithFilters - r.db.Get: %w", err))
 	}
 
-	// Order by likes count (descending)
-	querySelectWorkouts = querySelectWorkouts.OrderBy("likes_count DESC")
+	// Order by likes count (descending), or by rating when asked to
+	switch params.Sort {
+	case repositories.SortRating:
+		querySelectWorkouts = querySelectWorkouts.OrderBy("workouts.rating_average DESC", "workouts.rating_count DESC", "likes_count DESC")
+	default:
+		querySelectWorkouts = querySelectWorkouts.OrderBy("likes_count DESC")
+	}
 
 	// Apply pagination if needed
 	if params.Pagination.Limit > 0 {
@@ -372,3 +486,112 @@ func (r *postgresWorkoutsRepository) LikeWorkout(id int, userID int) error {
 
 	return nil
 }
+
+// FindLineage reads where the workout was copied from, how often it was
+// copied and how often its original was copied, copies of copies included.
+func (r *postgresWorkoutsRepository) FindLineage(id int) (records.WorkoutLineage, error) {
+	query, args, err := squirrel.
+		Select(
+			"workouts.id AS workout_id",
+			"workouts.forked_from_workout_id",
+			"workouts.root_workout_id",
+			"workouts.upstream_revision",
+			"(SELECT MAX(revision_number) FROM workout_revisions WHERE workout_revisions.workout_id = workouts.forked_from_workout_id) AS upstream_latest_revision",
+			"(SELECT COUNT(*) FROM workouts AS forks WHERE forks.root_workout_id = COALESCE(workouts.root_workout_id, workouts.id)) AS root_forks_count",
+		).
+		Columns(workoutForkColumns...).
+		From("workouts").
+		Where(squirrel.Eq{"workouts.id": id}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.WorkoutLineage{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - FindLineage - squirrel.Select: %w", err))
+	}
+
+	var lineage records.WorkoutLineage
+	if err := r.db.Get(&lineage, query, args...); err != nil {
+		return records.WorkoutLineage{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - FindLineage - r.db.Get: %w", err))
+	}
+
+	return lineage, nil
+}
+
+// MergeUpstream appends the exercises to the end of the copy, without their
+// groups, and marks the copy as in step with the given revision of its
+// source. Exercises already in the copy are left as they are. It returns the
+// number of exercises added.
+func (r *postgresWorkoutsRepository) MergeUpstream(id int, upstreamRevision int, exercises []records.WorkoutRevisionExercises) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - MergeUpstream - r.db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("workouts").
+		Set("upstream_revision", upstreamRevision).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING owner_id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - MergeUpstream - squirrel.Update: %w", err))
+	}
+
+	var ownerID int
+	if err := tx.Get(&ownerID, query, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - MergeUpstream - tx.Get: %w", err))
+	}
+
+	added := 0
+	for _, exercise := range exercises {
+		query, args, err := squirrel.
+			Insert("workout_exercises").
+			Columns(
+				"workout_id", "exercise_id", "owner_id", "main_note", "secondary_note",
+				"target_sets", "target_reps_min", "target_reps_max", "load_type", "load_value", "tempo", "rest_seconds",
+				"position",
+			).
+			Values(
+				id, exercise.ExerciseID, ownerID, exercise.MainNote, exercise.SecondaryNote,
+				exercise.TargetSets, exercise.TargetRepsMin, exercise.TargetRepsMax, exercise.LoadType, exercise.LoadValue, exercise.Tempo, exercise.RestSeconds,
+				squirrel.Expr("(SELECT COALESCE(MAX(position) + 1, 0) FROM workout_exercises WHERE workout_id = ?)", id),
+			).
+			Suffix("ON CONFLICT (workout_id, exercise_id, owner_id) DO NOTHING").
+			PlaceholderFormat(squirrel.Dollar).
+			ToSql()
+		if err != nil {
+			tx.Rollback()
+			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - MergeUpstream - squirrel.Insert: %w", err))
+		}
+
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			tx.Rollback()
+			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - MergeUpstream - tx.Exec: %w", err))
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - MergeUpstream - result.RowsAffected: %w", err))
+		}
+		added += int(rowsAffected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutsRepository - MergeUpstream - tx.Commit: %w", err))
+	}
+
+	return added, nil
+}
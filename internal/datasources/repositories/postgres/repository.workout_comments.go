@@ -0,0 +1,364 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"database/sql"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresWorkoutCommentsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresWorkoutCommentsRepository(db *sqlx.DB) services.WorkoutCommentsRepository {
+	return &postgresWorkoutCommentsRepository{db}
+}
+
+// Save adds the comment and, for a reply, counts it on its parent.
+func (r *postgresWorkoutCommentsRepository) Save(comment records.WorkoutComments) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Save - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Insert("workout_comments").
+		Columns("workout_id", "user_id", "parent_id", "body", "created_at").
+		Values(comment.WorkoutID, comment.UserID, comment.ParentID, comment.Body, time.Now()).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	var commentID int
+	if err := tx.Get(&commentID, query, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Save - tx.Get: %w", err))
+	}
+
+	if comment.ParentID.Valid {
+		if err := updateRepliesCount(tx, comment.ParentID.Int64, 1); err != nil {
+			tx.Rollback()
+			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Save - %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Save - tx.Commit: %w", err))
+	}
+
+	return commentID, nil
+}
+
+func (r *postgresWorkoutCommentsRepository) UpdateBody(id int, body string) error {
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("workout_comments").
+		Set("body", body).
+		Set("edited_at", now).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - UpdateBody - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - UpdateBody - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - UpdateBody - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
+
+// Delete soft deletes the comment, unpins it and takes it off the replies
+// count of its parent.
+func (r *postgresWorkoutCommentsRepository) Delete(id int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Delete - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Update("workout_comments").
+		Set("deleted_at", time.Now()).
+		Set("is_pinned", false).
+		Set("pinned_at", nil).
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		Suffix("RETURNING parent_id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Delete - squirrel.Update: %w", err))
+	}
+
+	var parentID sql.NullInt64
+	if err := tx.Get(&parentID, query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Delete - tx.Get: %w", err))
+	}
+
+	if parentID.Valid {
+		if err := updateRepliesCount(tx, parentID.Int64, -1); err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Delete - %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Delete - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+// FindByID returns the comment, even when it was deleted.
+func (r *postgresWorkoutCommentsRepository) FindByID(id int) (records.WorkoutComments, error) {
+	query, args, err := selectWorkoutComments().
+		Where(squirrel.Eq{"workout_comments.id": id}).
+		ToSql()
+	if err != nil {
+		return records.WorkoutComments{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - FindByID - squirrel.Select: %w", err))
+	}
+
+	var comment records.WorkoutComments
+	if err := r.db.Get(&comment, query, args...); err != nil {
+		return records.WorkoutComments{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - FindByID - db.Get: %w", err))
+	}
+
+	return comment, nil
+}
+
+// FindAllByWorkoutID lists the top-level comments of the workout that are not
+// pinned, newest first.
+func (r *postgresWorkoutCommentsRepository) FindAllByWorkoutID(workoutID int, cursor repositories.Cursor) ([]records.WorkoutComments, error) {
+	where := squirrel.Eq{
+		"workout_comments.workout_id": workoutID,
+		"workout_comments.parent_id":  nil,
+		"workout_comments.is_pinned":  false,
+	}
+
+	return r.findAll("FindAllByWorkoutID", where, cursor)
+}
+
+// FindReplies lists the direct replies to the comment, newest first.
+func (r *postgresWorkoutCommentsRepository) FindReplies(parentID int, cursor repositories.Cursor) ([]records.WorkoutComments, error) {
+	return r.findAll("FindReplies", squirrel.Eq{"workout_comments.parent_id": parentID}, cursor)
+}
+
+func (r *postgresWorkoutCommentsRepository) FindPinned(workoutID int) ([]records.WorkoutComments, error) {
+	query, args, err := selectWorkoutComments().
+		Where(squirrel.Eq{"workout_comments.workout_id": workoutID, "workout_comments.is_pinned": true}).
+		OrderBy("workout_comments.pinned_at ASC").
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - FindPinned - squirrel.Select: %w", err))
+	}
+
+	var comments []records.WorkoutComments
+	if err := r.db.Select(&comments, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - FindPinned - db.Select: %w", err))
+	}
+
+	return comments, nil
+}
+
+// Pin pins a top-level comment of the workout unless the workout already has
+// maxPinned pinned comments, and reports whether it did. The workout is
+// locked while counting, so concurrent pins cannot exceed the limit.
+func (r *postgresWorkoutCommentsRepository) Pin(id int, workoutID int, maxPinned int) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Pin - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Select("id").
+		From("workouts").
+		Where(squirrel.Eq{"id": workoutID}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Pin - squirrel.Select: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Pin - tx.Exec: %w", err))
+	}
+
+	query, args, err = squirrel.
+		Select("COUNT(*)").
+		From("workout_comments").
+		Where(squirrel.Eq{"workout_id": workoutID, "is_pinned": true}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Pin - squirrel.Select: %w", err))
+	}
+
+	var pinned int
+	if err := tx.Get(&pinned, query, args...); err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Pin - tx.Get: %w", err))
+	}
+	if pinned >= maxPinned {
+		tx.Rollback()
+		return false, nil
+	}
+
+	query, args, err = squirrel.
+		Update("workout_comments").
+		Set("is_pinned", true).
+		Set("pinned_at", time.Now()).
+		Where(squirrel.Eq{"id": id, "workout_id": workoutID, "parent_id": nil, "deleted_at": nil, "is_pinned": false}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Pin - squirrel.Update: %w", err))
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Pin - tx.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Pin - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return false, repositories.ErrorRowNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Pin - tx.Commit: %w", err))
+	}
+
+	return true, nil
+}
+
+func (r *postgresWorkoutCommentsRepository) Unpin(id int) error {
+	query, args, err := squirrel.
+		Update("workout_comments").
+		Set("is_pinned", false).
+		Set("pinned_at", nil).
+		Where(squirrel.Eq{"id": id, "is_pinned": true}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Unpin - squirrel.Update: %w", err))
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Unpin - db.Exec: %w", err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - Unpin - result.RowsAffected: %w", err))
+	}
+	if affected == 0 {
+		return repositories.ErrorRowNotFound
+	}
+
+	return nil
+}
+
+// findAll leaves out deleted comments, unless they have replies that are
+// still shown.
+func (r *postgresWorkoutCommentsRepository) findAll(method string, where squirrel.Sqlizer, cursor repositories.Cursor) ([]records.WorkoutComments, error) {
+	selectQuery := selectWorkoutComments().
+		Where(where).
+		Where(squirrel.Or{
+			squirrel.Eq{"workout_comments.deleted_at": nil},
+			squirrel.Gt{"workout_comments.replies_count": 0},
+		})
+	selectQuery = repositories.ApplyCursor(selectQuery, "workout_comments.created_at", "workout_comments.id", cursor)
+
+	query, args, err := selectQuery.ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - %s - squirrel.Select: %w", method, err))
+	}
+
+	var comments []records.WorkoutComments
+	if err := r.db.Select(&comments, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutCommentsRepository - %s - db.Select: %w", method, err))
+	}
+
+	return comments, nil
+}
+
+func updateRepliesCount(tx *sqlx.Tx, commentID int64, delta int) error {
+	query, args, err := squirrel.
+		Update("workout_comments").
+		Set("replies_count", squirrel.Expr("replies_count + ?", delta)).
+		Where(squirrel.Eq{"id": commentID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("squirrel.Update: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("tx.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func selectWorkoutComments() squirrel.SelectBuilder {
+	return squirrel.
+		Select("workout_comments.*", "users.username").
+		From("workout_comments").
+		Join("users ON users.id = workout_comments.user_id").
+		PlaceholderFormat(squirrel.Dollar)
+}
@@ -2,11 +2,14 @@ package postgres
 
 import (
 	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
 	"backend/internal/helpers"
 	"backend/internal/services"
+	"database/sql"
 	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"time"
 )
 
 type postgresWorkoutExercisesRepository struct {
@@ -20,18 +23,8 @@ func NewPostgresWorkoutExercisesRepository(db *sqlx.DB) services.WorkoutExercise
 }
 
 func (r *postgresWorkoutExercisesRepository) FindAll() ([]records.WorkoutExercises, error) {
-	query, args, err := squirrel.
-		Select(`
-			workout_exercises.*,
-			exercises.id AS "exercise.id",
-			exercises.created_at AS "exercise.created_at",
-			exercises.updated_at AS "exercise.updated_at",
-			exercises.deleted_at AS "exercise.deleted_at",
-			exercises.name AS "exercise.name"
-		`).
-		From("workout_exercises").
-		Join("exercises ON workout_exercises.exercise_id = exercises.id").
-		OrderBy("id ASC").
+	query, args, err := selectWorkoutExercises().
+		OrderBy("workout_exercises.workout_id ASC", "workout_exercises.position ASC", "workout_exercises.id ASC").
 		ToSql()
 	if err != nil {
 		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - FindAll - squirrel.Select: %w", err))
@@ -46,17 +39,7 @@ func (r *postgresWorkoutExercisesRepository) FindAll() ([]records.WorkoutExercis
 }
 
 func (r *postgresWorkoutExercisesRepository) FindByID(id int) (records.WorkoutExercises, error) {
-	query, args, err := squirrel.
-		Select(`
-			workout_exercises.*,
-			exercises.id AS "exercise.id",
-			exercises.created_at AS "exercise.created_at",
-			exercises.updated_at AS "exercise.updated_at",
-			exercises.deleted_at AS "exercise.deleted_at",
-			exercises.name AS "exercise.name"
-		`).
-		From("workout_exercises").
-		Join("exercises ON workout_exercises.exercise_id = exercises.id").
+	query, args, err := selectWorkoutExercises().
 		PlaceholderFormat(squirrel.Dollar).
 		Where(squirrel.Eq{"workout_exercises.id": id}).
 		ToSql()
@@ -73,20 +56,10 @@ func (r *postgresWorkoutExercisesRepository) FindByID(id int) (records.WorkoutEx
 }
 
 func (r *postgresWorkoutExercisesRepository) FindAllByWorkoutID(workoutID int) ([]records.WorkoutExercises, error) {
-	query, args, err := squirrel.
-		Select(`
-			workout_exercises.*,
-			exercises.id AS "exercise.id",
-			exercises.created_at AS "exercise.created_at",
-			exercises.updated_at AS "exercise.updated_at",
-			exercises.deleted_at AS "exercise.deleted_at",
-			exercises.name AS "exercise.name"
-		`).
-		From("workout_exercises").
-		Join("exercises ON workout_exercises.exercise_id = exercises.id").
+	query, args, err := selectWorkoutExercises().
 		PlaceholderFormat(squirrel.Dollar).
-		Where(squirrel.Eq{"workout_id": workoutID}).
-		OrderBy("id ASC").
+		Where(squirrel.Eq{"workout_exercises.workout_id": workoutID}).
+		OrderBy("workout_exercises.position ASC", "workout_exercises.id ASC").
 		ToSql()
 	if err != nil {
 		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - FindAllByWorkoutID - squirrel.Select: %w", err))
@@ -103,8 +76,16 @@ func (r *postgresWorkoutExercisesRepository) FindAllByWorkoutID(workoutID int) (
 func (r *postgresWorkoutExercisesRepository) Save(workoutExercise records.WorkoutExercises) (int, error) {
 	query, args, err := squirrel.
 		Insert("workout_exercises").
-		Columns("main_note", "secondary_note", "workout_id", "owner_id", "exercise_id").
-		Values(workoutExercise.MainNote, workoutExercise.SecondaryNote, workoutExercise.WorkoutID, workoutExercise.OwnerID, workoutExercise.ExerciseID).
+		Columns(
+			"main_note", "secondary_note", "workout_id", "owner_id", "exercise_id",
+			"target_sets", "target_reps_min", "target_reps_max", "load_type", "load_value", "tempo", "rest_seconds",
+			"position",
+		).
+		Values(
+			workoutExercise.MainNote, workoutExercise.SecondaryNote, workoutExercise.WorkoutID, workoutExercise.OwnerID, workoutExercise.ExerciseID,
+			workoutExercise.TargetSets, workoutExercise.TargetRepsMin, workoutExercise.TargetRepsMax, workoutExercise.LoadType, workoutExercise.LoadValue, workoutExercise.Tempo, workoutExercise.RestSeconds,
+			squirrel.Expr("(SELECT COALESCE(MAX(position) + 1, 0) FROM workout_exercises WHERE workout_id = ?)", workoutExercise.WorkoutID),
+		).
 		PlaceholderFormat(squirrel.Dollar).
 		Suffix("RETURNING id").
 		ToSql()
@@ -140,19 +121,181 @@ func (r *postgresWorkoutExercisesRepository) Update(id int, workoutExercise map[
 	return nil
 }
 
+// Delete removes the exercise and the group it leaves with a single
+// exercise, if any.
 func (r *postgresWorkoutExercisesRepository) Delete(id int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Delete - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
 	query, args, err := squirrel.
 		Delete("workout_exercises").
 		PlaceholderFormat(squirrel.Dollar).
 		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING group_id").
 		ToSql()
 	if err != nil {
+		tx.Rollback()
 		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Delete - squirrel.Delete: %w", err))
 	}
 
-	if _, err := r.db.Exec(query, args...); err != nil {
-		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Delete - db.Exec: %w", err))
+	var groupID sql.NullInt64
+	if err := tx.Get(&groupID, query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Delete - tx.Get: %w", err))
+	}
+
+	if groupID.Valid {
+		query, args, err = squirrel.
+			Delete("workout_exercise_groups").
+			PlaceholderFormat(squirrel.Dollar).
+			Where(squirrel.Eq{"id": groupID.Int64}).
+			Where(squirrel.Expr("(SELECT COUNT(*) FROM workout_exercises WHERE group_id = ?) < 2", groupID.Int64)).
+			ToSql()
+		if err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Delete - squirrel.Delete: %w", err))
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Delete - tx.Exec: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Delete - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+// Reorder lays the workout out again in a single transaction: its groups are
+// replaced, and every exercise gets its position and group from the layout.
+func (r *postgresWorkoutExercisesRepository) Reorder(workoutID int, layout []records.WorkoutExerciseLayout) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Reorder - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Delete("workout_exercise_groups").
+		Where(squirrel.Eq{"workout_id": workoutID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Reorder - squirrel.Delete: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Reorder - tx.Exec: %w", err))
+	}
+
+	now := time.Now()
+	position := 0
+	for _, entry := range layout {
+		var groupID sql.NullInt64
+		if entry.Group != nil {
+			id, err := saveWorkoutExerciseGroup(tx, workoutID, *entry.Group)
+			if err != nil {
+				tx.Rollback()
+				return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Reorder - %w", err))
+			}
+			groupID = sql.NullInt64{Int64: int64(id), Valid: true}
+		}
+
+		for _, workoutExerciseID := range entry.WorkoutExerciseIDs {
+			query, args, err := squirrel.
+				Update("workout_exercises").
+				SetMap(map[string]interface{}{"position": position, "group_id": groupID, "updated_at": now}).
+				Where(squirrel.Eq{"id": workoutExerciseID, "workout_id": workoutID}).
+				PlaceholderFormat(squirrel.Dollar).
+				ToSql()
+			if err != nil {
+				tx.Rollback()
+				return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Reorder - squirrel.Update: %w", err))
+			}
+
+			result, err := tx.Exec(query, args...)
+			if err != nil {
+				tx.Rollback()
+				return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Reorder - tx.Exec: %w", err))
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				tx.Rollback()
+				return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Reorder - result.RowsAffected: %w", err))
+			}
+			if affected == 0 {
+				tx.Rollback()
+				return repositories.ErrorRowNotFound
+			}
+
+			position++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutExercisesRepository - Reorder - tx.Commit: %w", err))
 	}
 
 	return nil
 }
+
+// selectWorkoutExercises selects the workout exercises with their exercise
+// and their group, if any.
+func selectWorkoutExercises() squirrel.SelectBuilder {
+	return squirrel.
+		Select(`
+			workout_exercises.*,
+			exercises.id AS "exercise.id",
+			exercises.created_at AS "exercise.created_at",
+			exercises.updated_at AS "exercise.updated_at",
+			exercises.deleted_at AS "exercise.deleted_at",
+			CASE WHEN exercises.hidden_at IS NULL THEN exercises.name ELSE 'Hidden exercise' END AS "exercise.name",
+			workout_exercise_groups.group_type AS group_type,
+			workout_exercise_groups.rounds AS group_rounds,
+			workout_exercise_groups.rest_seconds AS group_rest_seconds
+		`).
+		From("workout_exercises").
+		Join("exercises ON workout_exercises.exercise_id = exercises.id").
+		LeftJoin("workout_exercise_groups ON workout_exercises.group_id = workout_exercise_groups.id")
+}
+
+func saveWorkoutExerciseGroup(tx *sqlx.Tx, workoutID int, group records.WorkoutExerciseGroups) (int, error) {
+	query, args, err := squirrel.
+		Insert("workout_exercise_groups").
+		Columns("workout_id", "group_type", "rounds", "rest_seconds").
+		Values(workoutID, group.GroupType, group.Rounds, group.RestSeconds).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("squirrel.Insert: %w", err)
+	}
+
+	var id int
+	if err := tx.Get(&id, query, args...); err != nil {
+		return 0, fmt.Errorf("tx.Get: %w", err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,305 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresWorkoutReviewsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresWorkoutReviewsRepository(db *sqlx.DB) services.WorkoutReviewsRepository {
+	return &postgresWorkoutReviewsRepository{db}
+}
+
+func (r *postgresWorkoutReviewsRepository) Save(review records.WorkoutReviews) (int, error) {
+	builder := squirrel.
+		Insert("workout_reviews").
+		Columns("workout_id", "user_id", "rating", "body", "created_at").
+		Values(review.WorkoutID, review.UserID, review.Rating, review.Body, time.Now()).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	return r.writeAndRate("Save", review.WorkoutID, builder)
+}
+
+func (r *postgresWorkoutReviewsRepository) Update(id int, workoutID int, review map[string]interface{}) error {
+	builder := squirrel.
+		Update("workout_reviews").
+		Set("updated_at", time.Now()).
+		SetMap(review).
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	_, err := r.writeAndRate("Update", workoutID, builder)
+	return err
+}
+
+func (r *postgresWorkoutReviewsRepository) Delete(id int, workoutID int) error {
+	builder := squirrel.
+		Update("workout_reviews").
+		Set("deleted_at", time.Now()).
+		Where(squirrel.Eq{"id": id, "deleted_at": nil}).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	_, err := r.writeAndRate("Delete", workoutID, builder)
+	return err
+}
+
+func (r *postgresWorkoutReviewsRepository) FindByID(id int) (records.WorkoutReviews, error) {
+	query, args, err := selectWorkoutReviews().
+		Where(squirrel.Eq{"workout_reviews.id": id}).
+		ToSql()
+	if err != nil {
+		return records.WorkoutReviews{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - FindByID - squirrel.Select: %w", err))
+	}
+
+	var review records.WorkoutReviews
+	if err := r.db.Get(&review, query, args...); err != nil {
+		return records.WorkoutReviews{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - FindByID - db.Get: %w", err))
+	}
+
+	return review, nil
+}
+
+func (r *postgresWorkoutReviewsRepository) FindByWorkoutAndUser(workoutID int, userID int) (records.WorkoutReviews, error) {
+	query, args, err := selectWorkoutReviews().
+		Where(squirrel.Eq{"workout_reviews.workout_id": workoutID, "workout_reviews.user_id": userID}).
+		ToSql()
+	if err != nil {
+		return records.WorkoutReviews{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - FindByWorkoutAndUser - squirrel.Select: %w", err))
+	}
+
+	var review records.WorkoutReviews
+	if err := r.db.Get(&review, query, args...); err != nil {
+		return records.WorkoutReviews{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - FindByWorkoutAndUser - db.Get: %w", err))
+	}
+
+	return review, nil
+}
+
+// FindAllByWorkoutID lists the reviews of a workout, newest first, along with
+// the total number of reviews.
+func (r *postgresWorkoutReviewsRepository) FindAllByWorkoutID(workoutID int, pagination repositories.Pagination) ([]records.WorkoutReviews, int, error) {
+	return r.findAll("FindAllByWorkoutID", squirrel.Eq{"workout_reviews.workout_id": workoutID}, "workout_reviews.id DESC", pagination)
+}
+
+// FindAllReported lists the reviews that were reported, most reported first.
+func (r *postgresWorkoutReviewsRepository) FindAllReported(pagination repositories.Pagination) ([]records.WorkoutReviews, int, error) {
+	return r.findAll("FindAllReported", squirrel.Gt{"workout_reviews.reports_count": 0}, "workout_reviews.reports_count DESC", pagination)
+}
+
+func (r *postgresWorkoutReviewsRepository) HasCopied(workoutID int, userID int) (bool, error) {
+	query, args, err := squirrel.
+		Select("COUNT(*) > 0").
+		From("workout_copies").
+		Where(squirrel.Eq{"workout_id": workoutID, "user_id": userID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - HasCopied - squirrel.Select: %w", err))
+	}
+
+	var copied bool
+	if err := r.db.Get(&copied, query, args...); err != nil {
+		return false, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - HasCopied - db.Get: %w", err))
+	}
+
+	return copied, nil
+}
+
+// SaveReport records the report and counts it on the review. A user can
+// report a review only once.
+func (r *postgresWorkoutReviewsRepository) SaveReport(report records.ReviewReports) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - SaveReport - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Insert("review_reports").
+		Columns("review_id", "reporter_id", "reason", "details", "created_at").
+		Values(report.ReviewID, report.ReporterID, report.Reason, report.Details, time.Now()).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - SaveReport - squirrel.Insert: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - SaveReport - tx.Exec: %w", err))
+	}
+
+	query, args, err = squirrel.
+		Update("workout_reviews").
+		Set("reports_count", squirrel.Expr("reports_count + 1")).
+		Where(squirrel.Eq{"id": report.ReviewID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - SaveReport - squirrel.Update: %w", err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - SaveReport - tx.Exec: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - SaveReport - tx.Commit: %w", err))
+	}
+
+	return nil
+}
+
+func (r *postgresWorkoutReviewsRepository) FindReports(reviewID int) ([]records.ReviewReports, error) {
+	query, args, err := squirrel.
+		Select("*").
+		From("review_reports").
+		Where(squirrel.Eq{"review_id": reviewID}).
+		OrderBy("id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - FindReports - squirrel.Select: %w", err))
+	}
+
+	var reports []records.ReviewReports
+	if err := r.db.Select(&reports, query, args...); err != nil {
+		return nil, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - FindReports - db.Select: %w", err))
+	}
+
+	return reports, nil
+}
+
+func (r *postgresWorkoutReviewsRepository) findAll(method string, where squirrel.Sqlizer, orderBy string, pagination repositories.Pagination) ([]records.WorkoutReviews, int, error) {
+	query, args, err := squirrel.
+		Select("COUNT(*)").
+		From("workout_reviews").
+		Where(where).
+		Where(squirrel.Eq{"workout_reviews.deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - squirrel.Select: %w", method, err))
+	}
+
+	var total int
+	if err := r.db.Get(&total, query, args...); err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - db.Get: %w", method, err))
+	}
+
+	selectQuery := selectWorkoutReviews().
+		Where(where).
+		OrderBy(orderBy)
+	selectQuery = repositories.ApplyPagination(selectQuery, pagination.Page, pagination.Limit)
+
+	query, args, err = selectQuery.ToSql()
+	if err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - squirrel.Select: %w", method, err))
+	}
+
+	var reviews []records.WorkoutReviews
+	if err := r.db.Select(&reviews, query, args...); err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - db.Select: %w", method, err))
+	}
+
+	return reviews, total, nil
+}
+
+// writeAndRate runs a write on a review of the workout and recalculates the
+// rating of the workout in the same transaction. The workout is locked first
+// so concurrent reviews cannot leave a stale rating behind.
+func (r *postgresWorkoutReviewsRepository) writeAndRate(method string, workoutID int, builder squirrel.Sqlizer) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - db.Beginx: %w", method, err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Select("id").
+		From("workouts").
+		Where(squirrel.Eq{"id": workoutID}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - squirrel.Select: %w", method, err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - tx.Exec: %w", method, err))
+	}
+
+	query, args, err = builder.ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - squirrel: %w", method, err))
+	}
+
+	var reviewID int
+	if err := tx.Get(&reviewID, query, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - tx.Get: %w", method, err))
+	}
+
+	query, args, err = squirrel.
+		Update("workouts").
+		Set("rating_average", squirrel.Expr("COALESCE((SELECT ROUND(AVG(rating), 2) FROM workout_reviews WHERE workout_id = ? AND deleted_at IS NULL), 0)", workoutID)).
+		Set("rating_count", squirrel.Expr("(SELECT COUNT(*) FROM workout_reviews WHERE workout_id = ? AND deleted_at IS NULL)", workoutID)).
+		Where(squirrel.Eq{"id": workoutID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - squirrel.Update: %w", method, err))
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - tx.Exec: %w", method, err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutReviewsRepository - %s - tx.Commit: %w", method, err))
+	}
+
+	return reviewID, nil
+}
+
+func selectWorkoutReviews() squirrel.SelectBuilder {
+	return squirrel.
+		Select("workout_reviews.*", "users.username").
+		From("workout_reviews").
+		Join("users ON users.id = workout_reviews.user_id").
+		Where(squirrel.Eq{"workout_reviews.deleted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar)
+}
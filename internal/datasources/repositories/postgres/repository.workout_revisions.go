@@ -0,0 +1,387 @@
+package postgres
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/services"
+	"database/sql"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type postgresWorkoutRevisionsRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresWorkoutRevisionsRepository(db *sqlx.DB) services.WorkoutRevisionsRepository {
+	return &postgresWorkoutRevisionsRepository{
+		db: db,
+	}
+}
+
+// FindCurrent reads the workout as it is now, in the shape of a revision
+// that is not saved yet.
+func (r *postgresWorkoutRevisionsRepository) FindCurrent(workoutID int) (records.WorkoutRevisions, error) {
+	query, args, err := squirrel.
+		Select(
+			"id AS workout_id",
+			"title",
+			"COALESCE(description, '') AS description",
+			"COALESCE(price, 0) AS price",
+			"COALESCE(is_private, FALSE) AS is_private",
+			"members_only",
+		).
+		From("workouts").
+		Where(squirrel.Eq{"id": workoutID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.WorkoutRevisions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindCurrent - squirrel.Select: %w", err))
+	}
+
+	var revision records.WorkoutRevisions
+	if err := r.db.Get(&revision, query, args...); err != nil {
+		return records.WorkoutRevisions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindCurrent - db.Get: %w", err))
+	}
+
+	query, args, err = squirrel.
+		Select(`
+			workout_exercises.exercise_id,
+			CASE WHEN exercises.hidden_at IS NULL THEN exercises.name ELSE 'Hidden exercise' END AS exercise_name,
+			workout_exercises.main_note,
+			workout_exercises.secondary_note,
+			workout_exercises.target_sets,
+			workout_exercises.target_reps_min,
+			workout_exercises.target_reps_max,
+			workout_exercises.load_type,
+			workout_exercises.load_value,
+			workout_exercises.tempo,
+			workout_exercises.rest_seconds,
+			workout_exercises.position,
+			workout_exercises.group_id AS group_key,
+			workout_exercise_groups.group_type AS group_type,
+			workout_exercise_groups.rounds AS group_rounds,
+			workout_exercise_groups.rest_seconds AS group_rest_seconds
+		`).
+		From("workout_exercises").
+		Join("exercises ON workout_exercises.exercise_id = exercises.id").
+		LeftJoin("workout_exercise_groups ON workout_exercises.group_id = workout_exercise_groups.id").
+		Where(squirrel.Eq{"workout_exercises.workout_id": workoutID}).
+		OrderBy("workout_exercises.position ASC", "workout_exercises.id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.WorkoutRevisions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindCurrent - squirrel.Select: %w", err))
+	}
+
+	if err := r.db.Select(&revision.Exercises, query, args...); err != nil {
+		return records.WorkoutRevisions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindCurrent - db.Select: %w", err))
+	}
+
+	return revision, nil
+}
+
+// Save stores the revision under the next number of the workout. The
+// workout row is locked so concurrent changes are numbered one after the
+// other.
+func (r *postgresWorkoutRevisionsRepository) Save(revision records.WorkoutRevisions) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Save - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	query, args, err := squirrel.
+		Select("id").
+		From("workouts").
+		Where(squirrel.Eq{"id": revision.WorkoutID}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Save - squirrel.Select: %w", err))
+	}
+
+	var workoutID int
+	if err := tx.Get(&workoutID, query, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Save - tx.Get: %w", err))
+	}
+
+	now := time.Now()
+	query, args, err = squirrel.
+		Insert("workout_revisions").
+		Columns("workout_id", "revision_number", "title", "description", "price", "is_private", "members_only", "changelog", "restored_from", "created_at").
+		Values(
+			revision.WorkoutID,
+			squirrel.Expr("(SELECT COALESCE(MAX(revision_number), 0) + 1 FROM workout_revisions WHERE workout_id = ?)", revision.WorkoutID),
+			revision.Title, revision.Description, revision.Price, revision.IsPrivate, revision.MembersOnly, revision.Changelog, revision.RestoredFrom, now,
+		).
+		Suffix("RETURNING revision_number, id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Save - squirrel.Insert: %w", err))
+	}
+
+	var saved struct {
+		RevisionNumber int `db:"revision_number"`
+		ID             int `db:"id"`
+	}
+	if err := tx.Get(&saved, query, args...); err != nil {
+		tx.Rollback()
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Save - tx.Get: %w", err))
+	}
+
+	if len(revision.Exercises) > 0 {
+		insertExercises := squirrel.
+			Insert("workout_revision_exercises").
+			Columns(
+				"revision_id", "exercise_id", "exercise_name", "main_note", "secondary_note",
+				"target_sets", "target_reps_min", "target_reps_max", "load_type", "load_value", "tempo", "rest_seconds",
+				"position", "group_key", "group_type", "group_rounds", "group_rest_seconds", "created_at",
+			)
+		for _, exercise := range revision.Exercises {
+			insertExercises = insertExercises.Values(
+				saved.ID, exercise.ExerciseID, exercise.ExerciseName, exercise.MainNote, exercise.SecondaryNote,
+				exercise.TargetSets, exercise.TargetRepsMin, exercise.TargetRepsMax, exercise.LoadType, exercise.LoadValue, exercise.Tempo, exercise.RestSeconds,
+				exercise.Position, exercise.GroupKey, exercise.GroupType, exercise.GroupRounds, exercise.GroupRestSeconds, now,
+			)
+		}
+
+		query, args, err := insertExercises.PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			tx.Rollback()
+			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Save - squirrel.Insert: %w", err))
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			tx.Rollback()
+			return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Save - tx.Exec: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Save - tx.Commit: %w", err))
+	}
+
+	return saved.RevisionNumber, nil
+}
+
+func (r *postgresWorkoutRevisionsRepository) FindAll(workoutID int, pagination repositories.Pagination) ([]records.WorkoutRevisions, int, error) {
+	query, args, err := squirrel.
+		Select("COUNT(*)").
+		From("workout_revisions").
+		Where(squirrel.Eq{"workout_id": workoutID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindAll - squirrel.Select: %w", err))
+	}
+
+	var total int
+	if err := r.db.Get(&total, query, args...); err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindAll - db.Get: %w", err))
+	}
+
+	querySelectRevisions := squirrel.
+		Select("*").
+		From("workout_revisions").
+		Where(squirrel.Eq{"workout_id": workoutID}).
+		OrderBy("revision_number DESC").
+		PlaceholderFormat(squirrel.Dollar)
+	querySelectRevisions = repositories.ApplyPagination(querySelectRevisions, pagination.Page, pagination.Limit)
+
+	query, args, err = querySelectRevisions.ToSql()
+	if err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindAll - squirrel.Select: %w", err))
+	}
+
+	var revisions []records.WorkoutRevisions
+	if err := r.db.Select(&revisions, query, args...); err != nil {
+		return nil, 0, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindAll - db.Select: %w", err))
+	}
+
+	return revisions, total, nil
+}
+
+// FindByNumber returns the revision with its exercises. The zero number
+// stands for the latest revision.
+func (r *postgresWorkoutRevisionsRepository) FindByNumber(workoutID int, revisionNumber int) (records.WorkoutRevisions, error) {
+	querySelectRevision := squirrel.
+		Select("*").
+		From("workout_revisions").
+		Where(squirrel.Eq{"workout_id": workoutID}).
+		OrderBy("revision_number DESC").
+		Limit(1).
+		PlaceholderFormat(squirrel.Dollar)
+	if revisionNumber > 0 {
+		querySelectRevision = querySelectRevision.Where(squirrel.Eq{"revision_number": revisionNumber})
+	}
+
+	query, args, err := querySelectRevision.ToSql()
+	if err != nil {
+		return records.WorkoutRevisions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindByNumber - squirrel.Select: %w", err))
+	}
+
+	var revision records.WorkoutRevisions
+	if err := r.db.Get(&revision, query, args...); err != nil {
+		return records.WorkoutRevisions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindByNumber - db.Get: %w", err))
+	}
+
+	query, args, err = squirrel.
+		Select("*").
+		From("workout_revision_exercises").
+		Where(squirrel.Eq{"revision_id": revision.ID}).
+		OrderBy("position ASC", "id ASC").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return records.WorkoutRevisions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindByNumber - squirrel.Select: %w", err))
+	}
+
+	if err := r.db.Select(&revision.Exercises, query, args...); err != nil {
+		return records.WorkoutRevisions{}, helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - FindByNumber - db.Select: %w", err))
+	}
+
+	return revision, nil
+}
+
+// Restore puts the workout back in the state of the revision. Exercises are
+// matched by exercise, so the ones that stay in the workout keep their
+// logged sets; the others are removed or added back.
+func (r *postgresWorkoutRevisionsRepository) Restore(revision records.WorkoutRevisions) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Restore - db.Beginx: %w", err))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	now := time.Now()
+	query, args, err := squirrel.
+		Update("workouts").
+		SetMap(map[string]interface{}{
+			"title":        revision.Title,
+			"description":  revision.Description,
+			"price":        revision.Price,
+			"is_private":   revision.IsPrivate,
+			"members_only": revision.MembersOnly,
+			"updated_at":   now,
+		}).
+		Where(squirrel.Eq{"id": revision.WorkoutID}).
+		Suffix("RETURNING owner_id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Restore - squirrel.Update: %w", err))
+	}
+
+	var ownerID int
+	if err := tx.Get(&ownerID, query, args...); err != nil {
+		tx.Rollback()
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Restore - tx.Get: %w", err))
+	}
+
+	exerciseIDs := make([]int, 0, len(revision.Exercises))
+	for _, exercise := range revision.Exercises {
+		exerciseIDs = append(exerciseIDs, exercise.ExerciseID)
+	}
+
+	deletes := []squirrel.DeleteBuilder{
+		squirrel.Delete("workout_exercise_groups").Where(squirrel.Eq{"workout_id": revision.WorkoutID}),
+		squirrel.Delete("workout_exercises").Where(squirrel.Eq{"workout_id": revision.WorkoutID}).Where(squirrel.NotEq{"exercise_id": exerciseIDs}),
+	}
+	for _, deleteQuery := range deletes {
+		query, args, err := deleteQuery.PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Restore - squirrel.Delete: %w", err))
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Restore - tx.Exec: %w", err))
+		}
+	}
+
+	groupIDs := make(map[int64]sql.NullInt64)
+	for _, exercise := range revision.Exercises {
+		groupID := exercise.GroupKey
+		if exercise.GroupKey.Valid {
+			if _, ok := groupIDs[exercise.GroupKey.Int64]; !ok {
+				id, err := saveWorkoutExerciseGroup(tx, revision.WorkoutID, records.WorkoutExerciseGroups{
+					GroupType:   exercise.GroupType.String,
+					Rounds:      int(exercise.GroupRounds.Int32),
+					RestSeconds: exercise.GroupRestSeconds,
+				})
+				if err != nil {
+					tx.Rollback()
+					return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Restore - %w", err))
+				}
+				groupIDs[exercise.GroupKey.Int64] = sql.NullInt64{Int64: int64(id), Valid: true}
+			}
+			groupID = groupIDs[exercise.GroupKey.Int64]
+		}
+
+		query, args, err := squirrel.
+			Insert("workout_exercises").
+			Columns(
+				"workout_id", "exercise_id", "owner_id", "main_note", "secondary_note",
+				"target_sets", "target_reps_min", "target_reps_max", "load_type", "load_value", "tempo", "rest_seconds",
+				"position", "group_id",
+			).
+			Values(
+				revision.WorkoutID, exercise.ExerciseID, ownerID, exercise.MainNote, exercise.SecondaryNote,
+				exercise.TargetSets, exercise.TargetRepsMin, exercise.TargetRepsMax, exercise.LoadType, exercise.LoadValue, exercise.Tempo, exercise.RestSeconds,
+				exercise.Position, groupID,
+			).
+			Suffix(`ON CONFLICT (workout_id, exercise_id, owner_id) DO UPDATE SET
+				main_note = EXCLUDED.main_note,
+				secondary_note = EXCLUDED.secondary_note,
+				target_sets = EXCLUDED.target_sets,
+				target_reps_min = EXCLUDED.target_reps_min,
+				target_reps_max = EXCLUDED.target_reps_max,
+				load_type = EXCLUDED.load_type,
+				load_value = EXCLUDED.load_value,
+				tempo = EXCLUDED.tempo,
+				rest_seconds = EXCLUDED.rest_seconds,
+				position = EXCLUDED.position,
+				group_id = EXCLUDED.group_id,
+				updated_at = ?`, now).
+			PlaceholderFormat(squirrel.Dollar).
+			ToSql()
+		if err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Restore - squirrel.Insert: %w", err))
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			tx.Rollback()
+			return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Restore - tx.Exec: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return helpers.PostgresErrorTransform(fmt.Errorf("postgresWorkoutRevisionsRepository - Restore - tx.Commit: %w", err))
+	}
+
+	return nil
+}
@@ -4,13 +4,20 @@ import (
 	"fmt"
 	"github.com/Masterminds/squirrel"
 	"strings"
+	"time"
 )
 
 type Filters map[string]interface{}
 
+const (
+	SortLikes  = "likes"
+	SortRating = "rating"
+)
+
 type QueryParams struct {
 	Filters    map[string]interface{}
 	Pagination Pagination
+	Sort       string
 }
 
 type Pagination struct {
@@ -18,6 +25,14 @@ type Pagination struct {
 	Limit int `json:"limit"`
 }
 
+// Cursor points at the last row of the previous page of a listing ordered by
+// newest first. The zero ID starts at the newest row.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
+	Limit     int
+}
+
 func ApplyFilters(queryBuilder squirrel.SelectBuilder, filters map[string]interface{}) squirrel.SelectBuilder {
 	for key, value := range filters {
 		switch {
@@ -48,3 +63,32 @@ func ApplyPagination(builder squirrel.SelectBuilder, page, limit int) squirrel.S
 	}
 	return builder
 }
+
+// ApplyCursor orders the rows newest first and skips the rows up to the
+// cursor. One row more than the limit is selected, so the caller can tell
+// whether there is a next page.
+func ApplyCursor(builder squirrel.SelectBuilder, createdAtColumn string, idColumn string, cursor Cursor) squirrel.SelectBuilder {
+	if cursor.ID > 0 {
+		builder = builder.Where(fmt.Sprintf("(%s, %s) < (?, ?)", createdAtColumn, idColumn), cursor.CreatedAt, cursor.ID)
+	}
+	return builder.
+		OrderBy(createdAtColumn+" DESC", idColumn+" DESC").
+		Limit(uint64(cursor.Limit) + 1)
+}
+
+// ApplyNotBlocked drops the rows of the users in userColumn who blocked the
+// viewer or were blocked by the viewer.
+func ApplyNotBlocked(builder squirrel.SelectBuilder, userColumn string, viewerID int) squirrel.SelectBuilder {
+	return builder.Where(fmt.Sprintf(
+		"NOT EXISTS (SELECT 1 FROM user_blocks WHERE (user_blocks.blocker_id = ? AND user_blocks.blocked_id = %[1]s) OR (user_blocks.blocker_id = %[1]s AND user_blocks.blocked_id = ?))",
+		userColumn,
+	), viewerID, viewerID)
+}
+
+// ApplyNotBanned drops the rows of the users in userColumn who are banned.
+func ApplyNotBanned(builder squirrel.SelectBuilder, userColumn string) squirrel.SelectBuilder {
+	return builder.Where(fmt.Sprintf(
+		"NOT EXISTS (SELECT 1 FROM users banned_users WHERE banned_users.id = %s AND banned_users.banned_at IS NOT NULL)",
+		userColumn,
+	))
+}
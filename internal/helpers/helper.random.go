@@ -0,0 +1,17 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateRandomString returns a hex encoded string built from n random bytes.
+func GenerateRandomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("helpers - GenerateRandomString - rand.Read: %w", err)
+	}
+
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,57 @@
+package data_transfers
+
+import "time"
+
+// EarningsSummaryResponse totals a creator's earnings in one currency. Net is
+// what the creator earned after the platform fee and refunds; PaidOut and
+// Unpaid split it by whether it has been transferred yet.
+type EarningsSummaryResponse struct {
+	Currency   string  `json:"currency"`
+	Sales      int     `json:"sales"`
+	Refunds    int     `json:"refunds"`
+	Gross      float64 `json:"gross"`
+	Fee        float64 `json:"fee"`
+	Net        float64 `json:"net"`
+	PaidOut    float64 `json:"paid_out"`
+	Unpaid     float64 `json:"unpaid"`
+	FeePercent float64 `json:"fee_percent"`
+}
+
+type EarningsByWorkoutResponse struct {
+	WorkoutID    *int    `json:"workout_id"`
+	WorkoutTitle string  `json:"workout_title"`
+	Currency     string  `json:"currency"`
+	Sales        int     `json:"sales"`
+	Refunds      int     `json:"refunds"`
+	Gross        float64 `json:"gross"`
+	Fee          float64 `json:"fee"`
+	Net          float64 `json:"net"`
+}
+
+type EarningsByMonthResponse struct {
+	Month    string  `json:"month"`
+	Currency string  `json:"currency"`
+	Sales    int     `json:"sales"`
+	Refunds  int     `json:"refunds"`
+	Gross    float64 `json:"gross"`
+	Fee      float64 `json:"fee"`
+	Net      float64 `json:"net"`
+}
+
+type PayoutsResponse struct {
+	ID           int        `json:"id"`
+	CreatorID    int        `json:"creator_id"`
+	Amount       float64    `json:"amount"`
+	Currency     string     `json:"currency"`
+	EntriesCount int        `json:"entries_count"`
+	Status       string     `json:"status"`
+	Reference    string     `json:"reference,omitempty"`
+	PaidAt       *time.Time `json:"paid_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// MarkPayoutPaidRequest carries the reference of the bank transfer that paid
+// the payout.
+type MarkPayoutPaidRequest struct {
+	Reference string `json:"reference" validate:"required,max=255"`
+}
@@ -25,3 +25,25 @@ type UpdateExerciseSetsRequest struct {
 	Notes     *string  `json:"notes"`
 	CreatedAt *string  `json:"created_at"`
 }
+
+// ExerciseSetsAdherenceResponse compares the sets logged on a day with the
+// prescription of the workout exercise. A rate is null when the prescription
+// has no target it can be checked against.
+type ExerciseSetsAdherenceResponse struct {
+	WorkoutExerciseID int                    `json:"workout_exercise_id"`
+	Date              string                 `json:"date"`
+	Prescription      PrescriptionResponse   `json:"prescription"`
+	LoggedSets        int                    `json:"logged_sets"`
+	SetsRate          *float64               `json:"sets_rate"`
+	RepsRate          *float64               `json:"reps_rate"`
+	LoadRate          *float64               `json:"load_rate"`
+	Sets              []ExerciseSetAdherence `json:"sets"`
+}
+
+type ExerciseSetAdherence struct {
+	ID           int     `json:"id"`
+	Reps         int     `json:"reps"`
+	Weight       float32 `json:"weight"`
+	RepsOnTarget *bool   `json:"reps_on_target"`
+	LoadOnTarget *bool   `json:"load_on_target"`
+}
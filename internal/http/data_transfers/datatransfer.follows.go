@@ -0,0 +1,28 @@
+package data_transfers
+
+import "time"
+
+type FollowUsersResponse struct {
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	Bio        string    `json:"bio"`
+	Avatar     string    `json:"avatar"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// FeedItemsResponse is one entry of the feed. Type is workout for a new
+// public workout, like for a liked workout and session for a completed
+// session; only the fields of that type are set.
+type FeedItemsResponse struct {
+	Type          string     `json:"type"`
+	ID            int        `json:"id"`
+	ActorID       int        `json:"actor_id"`
+	ActorUsername string     `json:"actor_username"`
+	ActorAvatar   string     `json:"actor_avatar"`
+	WorkoutID     *int       `json:"workout_id,omitempty"`
+	WorkoutTitle  string     `json:"workout_title,omitempty"`
+	ActivityName  string     `json:"activity_name,omitempty"`
+	StartTime     *time.Time `json:"start_time,omitempty"`
+	EndTime       *time.Time `json:"end_time,omitempty"`
+	OccurredAt    time.Time  `json:"occurred_at"`
+}
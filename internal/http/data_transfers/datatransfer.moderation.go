@@ -0,0 +1,49 @@
+package data_transfers
+
+import "time"
+
+type CreateContentReportRequest struct {
+	ContentType string `json:"content_type" validate:"required,oneof=workout user exercise"`
+	ContentID   int    `json:"content_id" validate:"required,min=1"`
+	Reason      string `json:"reason" validate:"required,oneof=spam abuse inappropriate impersonation other"`
+	Details     string `json:"details" validate:"omitempty,max=1000"`
+}
+
+// CreateModerationActionRequest is a moderator's decision on a piece of
+// content. Ban applies to the author of the content.
+type CreateModerationActionRequest struct {
+	ContentType string `json:"content_type" validate:"required,oneof=workout user exercise"`
+	ContentID   int    `json:"content_id" validate:"required,min=1"`
+	Action      string `json:"action" validate:"required,oneof=hide restore ban dismiss"`
+	Reason      string `json:"reason" validate:"omitempty,max=1000"`
+}
+
+type BlockedUsersResponse struct {
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	Avatar    string    `json:"avatar"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+type ContentReportsResponse struct {
+	ID          int        `json:"id"`
+	ReporterID  int        `json:"reporter_id"`
+	ContentType string     `json:"content_type"`
+	ContentID   int        `json:"content_id"`
+	Reason      string     `json:"reason"`
+	Details     string     `json:"details"`
+	Status      string     `json:"status"`
+	ActionID    *int       `json:"action_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+}
+
+type ModerationActionsResponse struct {
+	ID          int       `json:"id"`
+	ModeratorID int       `json:"moderator_id"`
+	ContentType string    `json:"content_type"`
+	ContentID   int       `json:"content_id"`
+	Action      string    `json:"action"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package data_transfers
+
+import "time"
+
+// CreatePaymentMethodRequest takes a payment method token created on the
+// client with the gateway SDK. Card numbers are never accepted.
+type CreatePaymentMethodRequest struct {
+	Token string `json:"token" validate:"required,max=255"`
+}
+
+type PaymentMethodsResponse struct {
+	ID             int       `json:"id"`
+	Brand          string    `json:"brand"`
+	Last4          string    `json:"last4"`
+	ExpMonth       int       `json:"exp_month,omitempty"`
+	ExpYear        int       `json:"exp_year,omitempty"`
+	IsDefault      bool      `json:"is_default"`
+	RequiresUpdate bool      `json:"requires_update"`
+	CreatedAt      time.Time `json:"created_at"`
+}
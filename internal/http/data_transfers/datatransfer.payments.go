@@ -0,0 +1,41 @@
+package data_transfers
+
+import "time"
+
+// PurchaseWorkoutRequest carries a payment method token created on the client
+// with the gateway's SDK; card details never reach the server.
+type PurchaseWorkoutRequest struct {
+	PaymentMethod string `json:"payment_method" validate:"required"`
+	ReturnURL     string `json:"return_url" validate:"omitempty,url"`
+	PromoCode     string `json:"promo_code" validate:"omitempty,max=64"`
+}
+
+type AuthenticateFakePaymentRequest struct {
+	Approve bool `json:"approve"`
+}
+
+type PaymentsResponse struct {
+	ID            int       `json:"id"`
+	WorkoutID     *int      `json:"workout_id"`
+	Amount        float64   `json:"amount"`
+	Discount      float64   `json:"discount,omitempty"`
+	PromoCode     string    `json:"promo_code,omitempty"`
+	Currency      string    `json:"currency"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	NextActionURL string    `json:"next_action_url,omitempty"`
+	ClientSecret  string    `json:"client_secret,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type PurchasesResponse struct {
+	ID            int       `json:"id"`
+	WorkoutID     *int      `json:"workout_id"`
+	PricePaid     float64   `json:"price_paid"`
+	OriginalPrice float64   `json:"original_price"`
+	Discount      float64   `json:"discount"`
+	PromoCode     string    `json:"promo_code,omitempty"`
+	Currency      string    `json:"currency"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+}
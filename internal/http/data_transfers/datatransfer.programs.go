@@ -0,0 +1,79 @@
+package data_transfers
+
+import "time"
+
+type CreateProgramRequest struct {
+	Title       string               `json:"title" validate:"required,max=255"`
+	Description string               `json:"description" validate:"omitempty,max=5000"`
+	IsPrivate   bool                 `json:"is_private" validate:"omitempty"`
+	DaysPerWeek int                  `json:"days_per_week" validate:"required,min=1,max=7"`
+	Weeks       []ProgramWeekRequest `json:"weeks" validate:"required,min=1,max=52,dive"`
+}
+
+type UpdateProgramRequest struct {
+	Title       *string `json:"title" validate:"omitempty,max=255"`
+	Description *string `json:"description" validate:"omitempty,max=5000"`
+	IsPrivate   *bool   `json:"is_private" validate:"omitempty"`
+}
+
+// ReplaceProgramScheduleRequest replaces the whole schedule of a program.
+type ReplaceProgramScheduleRequest struct {
+	DaysPerWeek int                  `json:"days_per_week" validate:"required,min=1,max=7"`
+	Weeks       []ProgramWeekRequest `json:"weeks" validate:"required,min=1,max=52,dive"`
+}
+
+// ProgramWeekRequest is one week of a schedule. Weeks are numbered from 1
+// without gaps, and a week may be left without days, e.g. a rest week.
+type ProgramWeekRequest struct {
+	Week     int                 `json:"week" validate:"required,min=1,max=52"`
+	IsDeload bool                `json:"is_deload" validate:"omitempty"`
+	Notes    string              `json:"notes" validate:"omitempty,max=1000"`
+	Days     []ProgramDayRequest `json:"days" validate:"omitempty,max=7,dive"`
+}
+
+type ProgramDayRequest struct {
+	Day       int `json:"day" validate:"required,min=1,max=7"`
+	WorkoutID int `json:"workout_id" validate:"required,min=1"`
+}
+
+type ProgramsResponse struct {
+	ID          int                    `json:"id"`
+	OwnerID     int                    `json:"owner_id"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	IsPrivate   bool                   `json:"is_private"`
+	DaysPerWeek int                    `json:"days_per_week"`
+	WeeksCount  int                    `json:"weeks_count"`
+	Price       float64                `json:"price"`
+	CreatedAt   time.Time              `json:"created_at"`
+	Weeks       []ProgramWeeksResponse `json:"weeks,omitempty"`
+}
+
+type ProgramWeeksResponse struct {
+	Week     int                   `json:"week"`
+	IsDeload bool                  `json:"is_deload"`
+	Notes    string                `json:"notes"`
+	Days     []ProgramDaysResponse `json:"days"`
+}
+
+type ProgramDaysResponse struct {
+	Day          int    `json:"day"`
+	WorkoutID    int    `json:"workout_id"`
+	WorkoutTitle string `json:"workout_title"`
+}
+
+// ProgramEnrollmentsResponse is a user's progress through a program. The
+// current workout is unset once the program is completed.
+type ProgramEnrollmentsResponse struct {
+	ID               int        `json:"id"`
+	ProgramID        int        `json:"program_id"`
+	ProgramTitle     string     `json:"program_title"`
+	CurrentWeek      int        `json:"current_week"`
+	CurrentDay       int        `json:"current_day"`
+	CurrentWorkoutID *int       `json:"current_workout_id,omitempty"`
+	IsDeloadWeek     bool       `json:"is_deload_week"`
+	CompletedDays    int        `json:"completed_days"`
+	TotalDays        int        `json:"total_days"`
+	EnrolledAt       time.Time  `json:"enrolled_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+}
@@ -0,0 +1,53 @@
+package data_transfers
+
+import "time"
+
+// CreatePromoCodeRequest creates a discount code. Percent discounts take off
+// discount_value percent of the price, fixed ones discount_value in the
+// payments currency. Without workout_ids the code applies to every workout
+// it may be used for.
+type CreatePromoCodeRequest struct {
+	Code           string     `json:"code" validate:"required,min=3,max=64"`
+	DiscountType   string     `json:"discount_type" validate:"required,oneof=percent fixed"`
+	DiscountValue  float64    `json:"discount_value" validate:"required,gt=0"`
+	MaxRedemptions *int       `json:"max_redemptions" validate:"omitempty,min=1"`
+	PerUserLimit   int        `json:"per_user_limit" validate:"omitempty,min=1"`
+	StartsAt       *time.Time `json:"starts_at" validate:"omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at" validate:"omitempty"`
+	WorkoutIDs     []int      `json:"workout_ids" validate:"omitempty,max=100,dive,min=1"`
+}
+
+type UpdatePromoCodeRequest struct {
+	MaxRedemptions *int       `json:"max_redemptions" validate:"omitempty,min=1"`
+	PerUserLimit   *int       `json:"per_user_limit" validate:"omitempty,min=1"`
+	StartsAt       *time.Time `json:"starts_at" validate:"omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at" validate:"omitempty"`
+	IsActive       *bool      `json:"is_active" validate:"omitempty"`
+	WorkoutIDs     *[]int     `json:"workout_ids" validate:"omitempty,max=100,dive,min=1"`
+}
+
+type PromoCodesResponse struct {
+	ID             int        `json:"id"`
+	Code           string     `json:"code"`
+	OwnerID        *int       `json:"owner_id"`
+	DiscountType   string     `json:"discount_type"`
+	DiscountValue  float64    `json:"discount_value"`
+	Currency       string     `json:"currency"`
+	MaxRedemptions *int       `json:"max_redemptions"`
+	PerUserLimit   int        `json:"per_user_limit"`
+	Redemptions    int        `json:"redemptions"`
+	StartsAt       *time.Time `json:"starts_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	IsActive       bool       `json:"is_active"`
+	WorkoutIDs     []int      `json:"workout_ids"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type PricePreviewResponse struct {
+	WorkoutID     int     `json:"workout_id"`
+	OriginalPrice float64 `json:"original_price"`
+	Discount      float64 `json:"discount"`
+	Price         float64 `json:"price"`
+	Currency      string  `json:"currency"`
+	PromoCode     string  `json:"promo_code,omitempty"`
+}
@@ -0,0 +1,12 @@
+package data_transfers
+
+type RolesResponse struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type GrantRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
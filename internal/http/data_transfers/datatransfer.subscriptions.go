@@ -0,0 +1,56 @@
+package data_transfers
+
+import "time"
+
+type CreateSubscriptionPlanRequest struct {
+	Name        string  `json:"name" validate:"required,max=100"`
+	Description string  `json:"description" validate:"omitempty"`
+	Price       float64 `json:"price" validate:"required,gt=0"`
+	Currency    string  `json:"currency" validate:"omitempty,len=3"`
+	Interval    string  `json:"interval" validate:"required,oneof=month year"`
+	TrialDays   int     `json:"trial_days" validate:"omitempty,min=0,max=365"`
+}
+
+type UpdateSubscriptionPlanRequest struct {
+	Name        *string `json:"name" validate:"omitempty,max=100"`
+	Description *string `json:"description" validate:"omitempty"`
+	TrialDays   *int    `json:"trial_days" validate:"omitempty,min=0,max=365"`
+	IsActive    *bool   `json:"is_active" validate:"omitempty"`
+}
+
+type SubscriptionPlansResponse struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Currency    string  `json:"currency"`
+	Interval    string  `json:"interval"`
+	TrialDays   int     `json:"trial_days"`
+	IsActive    bool    `json:"is_active"`
+}
+
+// SubscribeRequest subscribes with the given saved payment method, or the
+// default one when it is omitted.
+type SubscribeRequest struct {
+	PlanID          int `json:"plan_id" validate:"required"`
+	PaymentMethodID int `json:"payment_method_id" validate:"omitempty"`
+}
+
+type UpdateSubscriptionPaymentMethodRequest struct {
+	PaymentMethodID int `json:"payment_method_id" validate:"required"`
+}
+
+type SubscriptionsResponse struct {
+	ID                 int                       `json:"id"`
+	Plan               SubscriptionPlansResponse `json:"plan"`
+	Status             string                    `json:"status"`
+	TrialEndsAt        *time.Time                `json:"trial_ends_at"`
+	CurrentPeriodStart time.Time                 `json:"current_period_start"`
+	CurrentPeriodEnd   time.Time                 `json:"current_period_end"`
+	CancelAtPeriodEnd  bool                      `json:"cancel_at_period_end"`
+	CanceledAt         *time.Time                `json:"canceled_at"`
+	NextAttemptAt      *time.Time                `json:"next_attempt_at"`
+	PaymentMethodID    *int                      `json:"payment_method_id"`
+	LastError          string                    `json:"last_error,omitempty"`
+	CreatedAt          time.Time                 `json:"created_at"`
+}
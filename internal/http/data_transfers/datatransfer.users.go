@@ -6,18 +6,18 @@ type CreateUsersRequest struct {
 }
 
 type UsersResponse struct {
-	ID       int    `json:"id"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Bio      string `json:"bio"`
-	Avatar   string `json:"avatar"`
-	Password string `json:"-"`
-	CardPAN  string `json:"card_pan"`
+	ID             int    `json:"id"`
+	Email          string `json:"email"`
+	Username       string `json:"username"`
+	Bio            string `json:"bio"`
+	Avatar         string `json:"avatar"`
+	FollowersCount int    `json:"followers_count"`
+	FollowingCount int    `json:"following_count"`
+	Password       string `json:"-"`
 }
 
 type UpdateUsersRequest struct {
 	Email    *string `json:"email" validate:"omitempty,email"`
 	Username *string `json:"username" validate:"omitempty"`
 	Bio      *string `json:"bio" validate:"omitempty"`
-	CardPAN  *string `json:"card_pan" validate:"omitempty"`
 }
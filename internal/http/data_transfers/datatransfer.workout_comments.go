@@ -0,0 +1,28 @@
+package data_transfers
+
+import "time"
+
+type CreateWorkoutCommentRequest struct {
+	Body     string `json:"body" validate:"required,max=5000"`
+	ParentID *int   `json:"parent_id" validate:"omitempty,min=1"`
+}
+
+type UpdateWorkoutCommentRequest struct {
+	Body string `json:"body" validate:"required,max=5000"`
+}
+
+// WorkoutCommentsResponse is a comment or reply. Deleted comments are only
+// listed while they have replies, with an empty body.
+type WorkoutCommentsResponse struct {
+	ID           int        `json:"id"`
+	WorkoutID    int        `json:"workout_id"`
+	UserID       int        `json:"user_id"`
+	Username     string     `json:"username"`
+	ParentID     *int       `json:"parent_id"`
+	Body         string     `json:"body"`
+	IsPinned     bool       `json:"is_pinned"`
+	IsDeleted    bool       `json:"is_deleted"`
+	RepliesCount int        `json:"replies_count"`
+	EditedAt     *time.Time `json:"edited_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -1,24 +1,73 @@
 package data_transfers
 
 type WorkoutExercisesResponse struct {
-	Exercise      ExercisesResponse `json:"exercise"`
-	ID            int               `json:"id"`
-	MainNote      string            `json:"main_note"`
-	SecondaryNote string            `json:"secondary_note"`
-	WorkoutID     int               `json:"workout_id"`
-	OwnerID       int               `json:"owner_id"`
-	ExerciseID    int               `json:"exercise_id"`
+	Exercise      ExercisesResponse             `json:"exercise"`
+	ID            int                           `json:"id"`
+	MainNote      string                        `json:"main_note"`
+	SecondaryNote string                        `json:"secondary_note"`
+	WorkoutID     int                           `json:"workout_id"`
+	OwnerID       int                           `json:"owner_id"`
+	ExerciseID    int                           `json:"exercise_id"`
+	Prescription  PrescriptionResponse          `json:"prescription"`
+	Position      int                           `json:"position"`
+	Group         *WorkoutExerciseGroupResponse `json:"group"`
+}
+
+// WorkoutExerciseGroupResponse is shared by the exercises of a superset,
+// giant set or circuit.
+type WorkoutExerciseGroupResponse struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"`
+	Rounds      int    `json:"rounds"`
+	RestSeconds *int   `json:"rest_seconds"`
 }
 
 type CreateWorkoutExercisesRequest struct {
-	MainNote      string `json:"main_note" validate:"omitempty"`
-	SecondaryNote string `json:"secondary_note" validate:"omitempty"`
-	WorkoutID     int    `json:"workout_id" validate:"required"`
-	OwnerID       int    `json:"-"`
-	ExerciseID    int    `json:"exercise_id" validate:"required"`
+	MainNote      string               `json:"main_note" validate:"omitempty"`
+	SecondaryNote string               `json:"secondary_note" validate:"omitempty"`
+	WorkoutID     int                  `json:"workout_id" validate:"required"`
+	OwnerID       int                  `json:"-"`
+	ExerciseID    int                  `json:"exercise_id" validate:"required"`
+	Prescription  *PrescriptionRequest `json:"prescription" validate:"omitempty"`
 }
 
 type UpdateWorkoutExercisesRequest struct {
-	MainNote      *string `json:"main_note" validate:"omitempty"`
-	SecondaryNote *string `json:"secondary_note" validate:"omitempty"`
+	MainNote      *string              `json:"main_note" validate:"omitempty"`
+	SecondaryNote *string              `json:"secondary_note" validate:"omitempty"`
+	Prescription  *PrescriptionRequest `json:"prescription" validate:"omitempty"`
+}
+
+// PrescriptionRequest sets the targets of a workout exercise. On update only
+// the given fields change.
+type PrescriptionRequest struct {
+	TargetSets    *int     `json:"target_sets" validate:"omitempty,min=1,max=50"`
+	TargetRepsMin *int     `json:"target_reps_min" validate:"omitempty,min=1,max=1000"`
+	TargetRepsMax *int     `json:"target_reps_max" validate:"omitempty,min=1,max=1000"`
+	LoadType      *string  `json:"load_type" validate:"omitempty,oneof=absolute percent_1rm rpe"`
+	LoadValue     *float64 `json:"load_value" validate:"omitempty,gt=0,lt=10000"`
+	Tempo         *string  `json:"tempo" validate:"omitempty,max=16"`
+	RestSeconds   *int     `json:"rest_seconds" validate:"omitempty,min=0,max=3600"`
+}
+
+type PrescriptionResponse struct {
+	TargetSets    *int     `json:"target_sets"`
+	TargetRepsMin *int     `json:"target_reps_min"`
+	TargetRepsMax *int     `json:"target_reps_max"`
+	LoadType      *string  `json:"load_type"`
+	LoadValue     *float64 `json:"load_value"`
+	Tempo         *string  `json:"tempo"`
+	RestSeconds   *int     `json:"rest_seconds"`
+}
+
+// ReorderWorkoutExercisesRequest lists every exercise of the workout in its
+// new order. An item with several exercises is a group and needs a type.
+type ReorderWorkoutExercisesRequest struct {
+	Items []WorkoutExerciseLayoutRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type WorkoutExerciseLayoutRequest struct {
+	WorkoutExerciseIDs []int   `json:"workout_exercise_ids" validate:"required,min=1,dive,min=1"`
+	GroupType          *string `json:"group_type" validate:"omitempty,oneof=superset giant_set circuit"`
+	Rounds             *int    `json:"rounds" validate:"omitempty,min=1,max=20"`
+	RestSeconds        *int    `json:"rest_seconds" validate:"omitempty,min=0,max=3600"`
 }
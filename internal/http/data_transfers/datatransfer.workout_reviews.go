@@ -0,0 +1,39 @@
+package data_transfers
+
+import "time"
+
+type CreateWorkoutReviewRequest struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Body   string `json:"body" validate:"omitempty,max=5000"`
+}
+
+type UpdateWorkoutReviewRequest struct {
+	Rating *int    `json:"rating" validate:"omitempty,min=1,max=5"`
+	Body   *string `json:"body" validate:"omitempty,max=5000"`
+}
+
+type ReportWorkoutReviewRequest struct {
+	Reason  string `json:"reason" validate:"required,oneof=spam offensive off_topic other"`
+	Details string `json:"details" validate:"omitempty,max=1000"`
+}
+
+type WorkoutReviewsResponse struct {
+	ID           int        `json:"id"`
+	WorkoutID    int        `json:"workout_id"`
+	UserID       int        `json:"user_id"`
+	Username     string     `json:"username"`
+	Rating       int        `json:"rating"`
+	Body         string     `json:"body"`
+	ReportsCount int        `json:"reports_count,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+type ReviewReportsResponse struct {
+	ID         int       `json:"id"`
+	ReviewID   int       `json:"review_id"`
+	ReporterID int       `json:"reporter_id"`
+	Reason     string    `json:"reason"`
+	Details    string    `json:"details"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,59 @@
+package data_transfers
+
+import "time"
+
+type WorkoutRevisionsResponse struct {
+	RevisionNumber int                                `json:"revision_number"`
+	Title          string                             `json:"title"`
+	Description    string                             `json:"description"`
+	Price          float64                            `json:"price"`
+	IsPrivate      bool                               `json:"is_private"`
+	MembersOnly    bool                               `json:"members_only"`
+	Changelog      []string                           `json:"changelog"`
+	RestoredFrom   *int                               `json:"restored_from"`
+	CreatedAt      time.Time                          `json:"created_at"`
+	Exercises      []WorkoutRevisionExercisesResponse `json:"exercises,omitempty"`
+}
+
+type WorkoutRevisionExercisesResponse struct {
+	ExerciseID    int                           `json:"exercise_id"`
+	ExerciseName  string                        `json:"exercise_name"`
+	MainNote      string                        `json:"main_note"`
+	SecondaryNote string                        `json:"secondary_note"`
+	Position      int                           `json:"position"`
+	Prescription  PrescriptionResponse          `json:"prescription"`
+	Group         *WorkoutRevisionGroupResponse `json:"group"`
+}
+
+// WorkoutRevisionGroupResponse is shared by the exercises grouped together
+// in a revision, they have the same key.
+type WorkoutRevisionGroupResponse struct {
+	Key         int    `json:"key"`
+	Type        string `json:"type"`
+	Rounds      int    `json:"rounds"`
+	RestSeconds *int   `json:"rest_seconds"`
+}
+
+// WorkoutRevisionDiffResponse lists what changed from one revision to
+// another. Exercises are told apart by exercise_id.
+type WorkoutRevisionDiffResponse struct {
+	From      int                                `json:"from"`
+	To        int                                `json:"to"`
+	Fields    []WorkoutRevisionFieldChange       `json:"fields"`
+	Added     []WorkoutRevisionExercisesResponse `json:"added"`
+	Removed   []WorkoutRevisionExercisesResponse `json:"removed"`
+	Changed   []WorkoutRevisionExerciseChange    `json:"changed"`
+	Reordered bool                               `json:"reordered"`
+}
+
+type WorkoutRevisionFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type WorkoutRevisionExerciseChange struct {
+	ExerciseID   int                          `json:"exercise_id"`
+	ExerciseName string                       `json:"exercise_name"`
+	Fields       []WorkoutRevisionFieldChange `json:"fields"`
+}
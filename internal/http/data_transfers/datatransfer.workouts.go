@@ -5,6 +5,7 @@ type CreateWorkoutRequest struct {
 	Description string  `json:"description" validate:"omitempty"`
 	IsPrivate   bool    `json:"is_private" validate:"omitempty"`
 	Price       float64 `json:"price" validate:"omitempty"`
+	MembersOnly bool    `json:"members_only" validate:"omitempty"`
 	OwnerID     int     `json:"-"`
 }
 
@@ -13,17 +14,42 @@ type UpdateWorkoutRequest struct {
 	Description *string  `json:"description" validate:"omitempty"`
 	IsPrivate   *bool    `json:"is_private" validate:"omitempty"`
 	Price       *float64 `json:"price" validate:"omitempty"`
+	MembersOnly *bool    `json:"members_only" validate:"omitempty"`
 }
 
 type WorkoutsResponse struct {
-	ID          int                        `json:"id"`
-	Title       string                     `json:"title"`
-	Description string                     `json:"description"`
-	IsPrivate   bool                       `json:"is_private"`
-	Price       float64                    `json:"price"`
-	OwnerID     int                        `json:"owner_id"`
-	LikesCount  int                        `json:"likes_count"`
-	Exercises   []WorkoutExercisesResponse `json:"exercises"`
+	ID                  int                        `json:"id"`
+	Title               string                     `json:"title"`
+	Description         string                     `json:"description"`
+	IsPrivate           bool                       `json:"is_private"`
+	Price               float64                    `json:"price"`
+	MembersOnly         bool                       `json:"members_only"`
+	OwnerID             int                        `json:"owner_id"`
+	LikesCount          int                        `json:"likes_count"`
+	RatingAverage       float64                    `json:"rating_average"`
+	RatingCount         int                        `json:"rating_count"`
+	ForkedFromWorkoutID *int                       `json:"forked_from_workout_id"`
+	RootWorkoutID       *int                       `json:"root_workout_id"`
+	ForksCount          int                        `json:"forks_count"`
+	UpstreamChanged     bool                       `json:"upstream_changed"`
+	Purchased           bool                       `json:"purchased"`
+	Exercises           []WorkoutExercisesResponse `json:"exercises"`
+}
+
+type WorkoutLineageResponse struct {
+	WorkoutID              int  `json:"workout_id"`
+	ForkedFromWorkoutID    *int `json:"forked_from_workout_id"`
+	RootWorkoutID          *int `json:"root_workout_id"`
+	ForksCount             int  `json:"forks_count"`
+	RootForksCount         int  `json:"root_forks_count"`
+	UpstreamRevision       *int `json:"upstream_revision"`
+	UpstreamLatestRevision *int `json:"upstream_latest_revision"`
+	UpstreamChanged        bool `json:"upstream_changed"`
+}
+
+type WorkoutMergeUpstreamResponse struct {
+	AddedExercises int                    `json:"added_exercises"`
+	Lineage        WorkoutLineageResponse `json:"lineage"`
 }
 
 type WorkoutGenerateRequest struct {
@@ -35,11 +61,3 @@ type WorkoutGenerateRequest struct {
 	Details   string   `json:"details" validate:"omitempty"`
 	OwnerID   int      `json:"-"`
 }
-
-type PurchaseWorkoutRequest struct {
-	WorkoutID       int    `json:"workout_id" validate:"required"`
-	CardNumber      string `json:"card_number" validate:"required"`
-	CardCVV         string `json:"card_cvv" validate:"required"`
-	CardExpiryMonth string `json:"card_expiry_month" validate:"required"`
-	CardExpiryYear  string `json:"card_expiry_year" validate:"required"`
-}
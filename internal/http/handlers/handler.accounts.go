@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type AccountsHandler struct {
+	service *services.AccountsService
+}
+
+func NewAccountsHandler(service *services.AccountsService) *AccountsHandler {
+	return &AccountsHandler{
+		service: service,
+	}
+}
+
+func (h *AccountsHandler) RequestExport(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	export, statusCode, err := h.service.RequestExport(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "export requested successfully", export)
+}
+
+func (h *AccountsHandler) FindExports(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	exports, statusCode, err := h.service.FindExports(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "exports fetched successfully", exports)
+}
+
+func (h *AccountsHandler) FindExport(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid export ID")
+	}
+
+	export, statusCode, err := h.service.FindExport(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "export fetched successfully", export)
+}
+
+func (h *AccountsHandler) DownloadExport(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid export ID")
+	}
+
+	path, statusCode, err := h.service.ExportFile(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return ctx.Attachment(path, fmt.Sprintf("account-export-%d.zip", id))
+}
+
+func (h *AccountsHandler) DeletionStatus(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	deletion, statusCode, err := h.service.DeletionStatus(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "account deletion fetched successfully", deletion)
+}
+
+func (h *AccountsHandler) RequestDeletion(ctx echo.Context) error {
+	var deleteAccountRequest data_transfers.DeleteAccountRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &deleteAccountRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	deletion, statusCode, err := h.service.RequestDeletion(jwtClaims.UserID, deleteAccountRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "account deletion scheduled successfully", deletion)
+}
+
+func (h *AccountsHandler) CancelDeletion(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	statusCode, err := h.service.CancelDeletion(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "account deletion cancelled successfully", nil)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type APIKeysHandler struct {
+	service *services.APIKeysService
+}
+
+func NewAPIKeysHandler(service *services.APIKeysService) *APIKeysHandler {
+	return &APIKeysHandler{
+		service: service,
+	}
+}
+
+func (h *APIKeysHandler) FindAll(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	apiKeys, statusCode, err := h.service.FindAllByUserID(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "api keys fetched successfully", apiKeys)
+}
+
+func (h *APIKeysHandler) Create(ctx echo.Context) error {
+	var createAPIKeyRequest data_transfers.CreateAPIKeyRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &createAPIKeyRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	apiKey, statusCode, err := h.service.Create(jwtClaims.UserID, createAPIKeyRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "api key created successfully, store it now as it will not be shown again", apiKey)
+}
+
+func (h *APIKeysHandler) Revoke(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid api key ID")
+	}
+
+	statusCode, err := h.service.Revoke(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "api key revoked successfully", nil)
+}
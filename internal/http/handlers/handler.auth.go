@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"backend/internal/constants"
 	"backend/internal/helpers"
 	"backend/internal/http/data_transfers"
 	"backend/internal/services"
+	"backend/pkg/jwt"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 )
@@ -27,8 +32,37 @@ func (h *AuthHandler) SignIn(ctx echo.Context) error {
 		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
 	}
 
-	accessToken, refreshToken, statusCode, err := h.service.SignIn(signInRequest)
+	accessToken, refreshToken, challengeToken, statusCode, err := h.service.SignIn(signInRequest, deviceInfo(ctx))
 	if err != nil {
+		setRetryAfter(ctx, err)
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	if challengeToken != "" {
+		return NewSuccessResponse(ctx, statusCode, "two-factor authentication required", map[string]any{
+			"two_factor_required": true,
+			"challenge_token":     challengeToken,
+		})
+	}
+
+	helpers.WriteCookie(ctx, "refresh_token", refreshToken)
+
+	return NewSuccessResponse(ctx, statusCode, "user signed in successfully", map[string]string{
+		"access_token": accessToken,
+	})
+}
+
+func (h *AuthHandler) SignInTwoFactor(ctx echo.Context) error {
+	var signInTwoFactorRequest data_transfers.SignInTwoFactorRequest
+
+	err := helpers.BindAndValidate(ctx, &signInTwoFactorRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	accessToken, refreshToken, statusCode, err := h.service.SignInTwoFactor(signInTwoFactorRequest, deviceInfo(ctx))
+	if err != nil {
+		setRetryAfter(ctx, err)
 		return NewErrorResponse(ctx, statusCode, err.Error())
 	}
 
@@ -70,3 +104,137 @@ func (h *AuthHandler) SignOut(ctx echo.Context) error {
 
 	return NewSuccessResponse(ctx, http.StatusOK, "user signed out successfully", nil)
 }
+
+func (h *AuthHandler) Refresh(ctx echo.Context) error {
+	refreshToken, err := helpers.ReadCookie(ctx, "refresh_token")
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusUnauthorized, "refresh token not found")
+	}
+
+	accessToken, newRefreshToken, statusCode, err := h.service.Refresh(refreshToken, deviceInfo(ctx))
+	if err != nil {
+		if statusCode == http.StatusUnauthorized {
+			helpers.WriteCookie(ctx, "refresh_token", "")
+		}
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	helpers.WriteCookie(ctx, "refresh_token", newRefreshToken)
+
+	return NewSuccessResponse(ctx, statusCode, "token refreshed successfully", map[string]string{
+		"access_token": accessToken,
+	})
+}
+
+func (h *AuthHandler) ForgotPassword(ctx echo.Context) error {
+	var forgotPasswordRequest data_transfers.ForgotPasswordRequest
+
+	err := helpers.BindAndValidate(ctx, &forgotPasswordRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.ForgotPassword(forgotPasswordRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "if the email is registered, a reset link has been sent", nil)
+}
+
+func (h *AuthHandler) ResetPassword(ctx echo.Context) error {
+	var resetPasswordRequest data_transfers.ResetPasswordRequest
+
+	err := helpers.BindAndValidate(ctx, &resetPasswordRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.ResetPassword(resetPasswordRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "password reset successfully", nil)
+}
+
+func (h *AuthHandler) VerifyEmail(ctx echo.Context) error {
+	var verifyEmailRequest data_transfers.VerifyEmailRequest
+
+	err := helpers.BindAndValidate(ctx, &verifyEmailRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.VerifyEmail(verifyEmailRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "email verified successfully", nil)
+}
+
+func (h *AuthHandler) ResendVerificationEmail(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	statusCode, err := h.service.ResendVerificationEmail(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "verification email sent successfully", nil)
+}
+
+func (h *AuthHandler) FindSessions(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	sessions, statusCode, err := h.service.FindSessions(jwtClaims.UserID, jwtClaims.SessionID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "sessions fetched successfully", sessions)
+}
+
+func (h *AuthHandler) RevokeSession(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	statusCode, err := h.service.RevokeSession(jwtClaims.UserID, ctx.Param("sessionID"))
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	if ctx.Param("sessionID") == jwtClaims.SessionID {
+		helpers.WriteCookie(ctx, "refresh_token", "")
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "session revoked successfully", nil)
+}
+
+func (h *AuthHandler) RevokeAllSessions(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	statusCode, err := h.service.RevokeAllSessions(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	helpers.WriteCookie(ctx, "refresh_token", "")
+
+	return NewSuccessResponse(ctx, statusCode, "signed out of all sessions successfully", nil)
+}
+
+func deviceInfo(ctx echo.Context) data_transfers.DeviceInfo {
+	return data_transfers.DeviceInfo{
+		UserAgent: ctx.Request().UserAgent(),
+		IPAddress: ctx.RealIP(),
+	}
+}
+
+func setRetryAfter(ctx echo.Context, err error) {
+	var lockedErr *services.SignInLockedError
+	if errors.As(err, &lockedErr) {
+		seconds := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+		ctx.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+}
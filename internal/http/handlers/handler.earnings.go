@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+type EarningsHandler struct {
+	service *services.EarningsService
+}
+
+func NewEarningsHandler(service *services.EarningsService) *EarningsHandler {
+	return &EarningsHandler{
+		service: service,
+	}
+}
+
+func (h *EarningsHandler) FindSummary(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	summary, statusCode, err := h.service.FindSummary(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "earnings fetched successfully", summary)
+}
+
+func (h *EarningsHandler) FindByWorkout(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	from, to, err := parseEarningsPeriod(ctx)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	breakdown, statusCode, err := h.service.FindByWorkout(jwtClaims.UserID, from, to)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "earnings by workout fetched successfully", breakdown)
+}
+
+func (h *EarningsHandler) FindByMonth(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	from, to, err := parseEarningsPeriod(ctx)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	breakdown, statusCode, err := h.service.FindByMonth(jwtClaims.UserID, from, to)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "earnings by month fetched successfully", breakdown)
+}
+
+func (h *EarningsHandler) Export(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	from, to, err := parseEarningsPeriod(ctx)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	export, statusCode, err := h.service.ExportCSV(jwtClaims.UserID, from, to)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "earnings.csv"))
+	return ctx.Blob(statusCode, "text/csv; charset=utf-8", export)
+}
+
+func (h *EarningsHandler) FindPayouts(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	payouts, statusCode, err := h.service.FindAllPayouts(jwtClaims.UserID, "")
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payouts fetched successfully", payouts)
+}
+
+func (h *EarningsHandler) FindAllPayouts(ctx echo.Context) error {
+	status := ctx.QueryParam("status")
+	if status != "" && status != services.PayoutStatusPending && status != services.PayoutStatusPaid {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid payout status")
+	}
+
+	payouts, statusCode, err := h.service.FindAllPayouts(0, status)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payouts fetched successfully", payouts)
+}
+
+func (h *EarningsHandler) CreatePayouts(ctx echo.Context) error {
+	payouts, statusCode, err := h.service.CreatePayouts()
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payouts created successfully", payouts)
+}
+
+func (h *EarningsHandler) MarkPayoutPaid(ctx echo.Context) error {
+	var markRequest data_transfers.MarkPayoutPaidRequest
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid payout ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &markRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.MarkPayoutPaid(id, markRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payout marked as paid", nil)
+}
+
+// parseEarningsPeriod reads the optional from and to query parameters. Both
+// are inclusive dates, so to is moved to the start of the next day.
+func parseEarningsPeriod(ctx echo.Context) (time.Time, time.Time, error) {
+	layout := "2006-01-02"
+
+	var from, to time.Time
+	var err error
+	if param := ctx.QueryParam("from"); param != "" {
+		from, err = time.Parse(layout, param)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid from format. Expected format: YYYY-MM-DD")
+		}
+	}
+	if param := ctx.QueryParam("to"); param != "" {
+		to, err = time.Parse(layout, param)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid to format. Expected format: YYYY-MM-DD")
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+
+	return from, to, nil
+}
@@ -9,6 +9,7 @@ import (
 	"backend/pkg/jwt"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"time"
 )
 
 type ExerciseSetsHandler struct {
@@ -55,6 +56,30 @@ func (h *ExerciseSetsHandler) FindAllByWorkoutExerciseID(ctx echo.Context) error
 	return NewSuccessResponse(ctx, statusCode, "exercise sets fetched successfully", exerciseSets)
 }
 
+func (h *ExerciseSetsHandler) FindAdherence(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutExerciseID, err := convert.StringToInt(ctx.Param("workoutExerciseID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout exercise ID")
+	}
+
+	date := time.Now()
+	if dateParam := ctx.QueryParam("date"); dateParam != "" {
+		date, err = time.Parse("2006-01-02", dateParam)
+		if err != nil {
+			return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid date format. Expected format: YYYY-MM-DD")
+		}
+	}
+
+	adherence, statusCode, err := h.service.FindAdherence(workoutExerciseID, jwtClaims.UserID, date)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "exercise sets adherence fetched successfully", adherence)
+}
+
 func (h *ExerciseSetsHandler) Update(ctx echo.Context) error {
 	var updateExerciseSetsRequest data_transfers.UpdateExerciseSetsRequest
 	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
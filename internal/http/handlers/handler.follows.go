@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/services"
+	"backend/internal/utils"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type FollowsHandler struct {
+	service *services.FollowsService
+}
+
+func NewFollowsHandler(service *services.FollowsService) *FollowsHandler {
+	return &FollowsHandler{
+		service: service,
+	}
+}
+
+func (h *FollowsHandler) Follow(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	userID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	statusCode, err := h.service.Follow(jwtClaims.UserID, userID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "user followed successfully", nil)
+}
+
+func (h *FollowsHandler) Unfollow(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	userID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	statusCode, err := h.service.Unfollow(jwtClaims.UserID, userID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "user unfollowed successfully", nil)
+}
+
+func (h *FollowsHandler) IsFollowing(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	userID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	following, statusCode, err := h.service.IsFollowing(jwtClaims.UserID, userID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "follow fetched successfully", map[string]bool{"following": following})
+}
+
+func (h *FollowsHandler) FindFollowers(ctx echo.Context) error {
+	userID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	cursor, err := utils.ExtractCursor(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	users, next, statusCode, err := h.service.FindFollowers(userID, cursor)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "followers fetched successfully", map[string]interface{}{
+		"data":        users,
+		"next_cursor": utils.EncodeCursor(next),
+	})
+}
+
+func (h *FollowsHandler) FindFollowing(ctx echo.Context) error {
+	userID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	cursor, err := utils.ExtractCursor(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	users, next, statusCode, err := h.service.FindFollowing(userID, cursor)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "following fetched successfully", map[string]interface{}{
+		"data":        users,
+		"next_cursor": utils.EncodeCursor(next),
+	})
+}
+
+func (h *FollowsHandler) FindFeed(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	cursor, err := utils.ExtractCursor(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	items, next, statusCode, err := h.service.FindFeed(jwtClaims.UserID, cursor)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "feed fetched successfully", map[string]interface{}{
+		"data":        items,
+		"next_cursor": utils.EncodeCursor(next),
+	})
+}
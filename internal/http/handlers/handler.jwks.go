@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type JWKSHandler struct {
+}
+
+func NewJWKSHandler() *JWKSHandler {
+	return &JWKSHandler{}
+}
+
+// JWKS serves the public signing keys as a plain JWK set, the format expected by
+// JWT libraries, instead of the usual response envelope.
+func (h *JWKSHandler) JWKS(ctx echo.Context) error {
+	config, err := jwt.GetConfig()
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	ctx.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return ctx.JSON(http.StatusOK, config.Keyring.JWKS())
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/internal/utils"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type ModerationHandler struct {
+	service *services.ModerationService
+}
+
+func NewModerationHandler(service *services.ModerationService) *ModerationHandler {
+	return &ModerationHandler{
+		service: service,
+	}
+}
+
+func (h *ModerationHandler) Block(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	userID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	statusCode, err := h.service.Block(jwtClaims.UserID, userID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "user blocked successfully", nil)
+}
+
+func (h *ModerationHandler) Unblock(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	userID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	statusCode, err := h.service.Unblock(jwtClaims.UserID, userID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "user unblocked successfully", nil)
+}
+
+func (h *ModerationHandler) IsBlocked(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	userID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	blocked, statusCode, err := h.service.IsBlocked(jwtClaims.UserID, userID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "block fetched successfully", map[string]bool{"blocked": blocked})
+}
+
+func (h *ModerationHandler) FindBlocked(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	cursor, err := utils.ExtractCursor(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	users, next, statusCode, err := h.service.FindBlocked(jwtClaims.UserID, cursor)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "blocked users fetched successfully", map[string]interface{}{
+		"data":        users,
+		"next_cursor": utils.EncodeCursor(next),
+	})
+}
+
+func (h *ModerationHandler) Report(ctx echo.Context) error {
+	var reportRequest data_transfers.CreateContentReportRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &reportRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	id, statusCode, err := h.service.Report(jwtClaims.UserID, reportRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "content reported successfully", map[string]int{"id": id})
+}
+
+func (h *ModerationHandler) FindReportsForAdmin(ctx echo.Context) error {
+	params, err := utils.ExtractQueryParams(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	reports, total, statusCode, err := h.service.FindReports(ctx.QueryParam("status"), params.Pagination)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "reports fetched successfully", map[string]interface{}{
+		"data":  reports,
+		"total": total,
+	})
+}
+
+func (h *ModerationHandler) TakeActionForAdmin(ctx echo.Context) error {
+	var actionRequest data_transfers.CreateModerationActionRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &actionRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	action, statusCode, err := h.service.TakeAction(jwtClaims.UserID, actionRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "moderation action taken successfully", action)
+}
+
+func (h *ModerationHandler) FindActionsForAdmin(ctx echo.Context) error {
+	params, err := utils.ExtractQueryParams(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	contentID := 0
+	if contentIDStr := ctx.QueryParam("content_id"); contentIDStr != "" {
+		contentID, err = convert.StringToInt(contentIDStr)
+		if err != nil {
+			return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid content ID")
+		}
+	}
+
+	actions, total, statusCode, err := h.service.FindActions(ctx.QueryParam("content_type"), contentID, params.Pagination)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "moderation actions fetched successfully", map[string]interface{}{
+		"data":  actions,
+		"total": total,
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type OIDCHandler struct {
+	service *services.OIDCService
+}
+
+func NewOIDCHandler(service *services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		service: service,
+	}
+}
+
+// oidcStateCookie binds a login to the browser that started it.
+const oidcStateCookie = "oidc_state"
+
+func (h *OIDCHandler) Authorize(ctx echo.Context) error {
+	authorizationURL, stateBinding, statusCode, err := h.service.AuthorizationURL(ctx.Param("provider"))
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	helpers.WriteCookie(ctx, oidcStateCookie, stateBinding)
+
+	return NewSuccessResponse(ctx, statusCode, "authorization url created successfully", data_transfers.OIDCAuthorizationResponse{
+		AuthorizationURL: authorizationURL,
+	})
+}
+
+func (h *OIDCHandler) Callback(ctx echo.Context) error {
+	var callbackRequest data_transfers.OIDCCallbackRequest
+
+	err := helpers.BindAndValidate(ctx, &callbackRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	stateBinding, err := helpers.ReadCookie(ctx, oidcStateCookie)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "login was not started from this browser")
+	}
+
+	accessToken, refreshToken, challengeToken, statusCode, err := h.service.SignIn(ctx.Param("provider"), callbackRequest, stateBinding, deviceInfo(ctx))
+	helpers.WriteCookie(ctx, oidcStateCookie, "")
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	if challengeToken != "" {
+		return NewSuccessResponse(ctx, statusCode, "two-factor authentication required", map[string]any{
+			"two_factor_required": true,
+			"challenge_token":     challengeToken,
+		})
+	}
+
+	helpers.WriteCookie(ctx, "refresh_token", refreshToken)
+
+	return NewSuccessResponse(ctx, statusCode, "user signed in successfully", map[string]string{
+		"access_token": accessToken,
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type PaymentMethodsHandler struct {
+	service *services.PaymentMethodsService
+}
+
+func NewPaymentMethodsHandler(service *services.PaymentMethodsService) *PaymentMethodsHandler {
+	return &PaymentMethodsHandler{
+		service: service,
+	}
+}
+
+func (h *PaymentMethodsHandler) FindAll(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	paymentMethods, statusCode, err := h.service.FindAllByUserID(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payment methods fetched successfully", paymentMethods)
+}
+
+func (h *PaymentMethodsHandler) Create(ctx echo.Context) error {
+	var createPaymentMethodRequest data_transfers.CreatePaymentMethodRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &createPaymentMethodRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	paymentMethod, statusCode, err := h.service.Create(jwtClaims.UserID, createPaymentMethodRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payment method added successfully", paymentMethod)
+}
+
+func (h *PaymentMethodsHandler) SetDefault(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid payment method ID")
+	}
+
+	statusCode, err := h.service.SetDefault(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "default payment method updated successfully", nil)
+}
+
+func (h *PaymentMethodsHandler) Delete(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid payment method ID")
+	}
+
+	statusCode, err := h.service.Delete(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payment method removed successfully", nil)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"backend/pkg/logger"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+)
+
+// maxWebhookPayloadSize bounds the size of gateway webhook bodies.
+const maxWebhookPayloadSize = 1 << 20
+
+type PaymentsHandler struct {
+	service          *services.PaymentsService
+	purchasesService *services.PurchasesService
+}
+
+func NewPaymentsHandler(service *services.PaymentsService, purchasesService *services.PurchasesService) *PaymentsHandler {
+	return &PaymentsHandler{
+		service:          service,
+		purchasesService: purchasesService,
+	}
+}
+
+func (h *PaymentsHandler) PurchaseWorkout(ctx echo.Context) error {
+	var purchaseRequest data_transfers.PurchaseWorkoutRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("workoutID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &purchaseRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	payment, statusCode, err := h.service.PurchaseWorkout(jwtClaims.UserID, workoutID, purchaseRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payment "+payment.Status, payment)
+}
+
+func (h *PaymentsHandler) PreviewPrice(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("workoutID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	preview, statusCode, err := h.service.PreviewPrice(jwtClaims.UserID, workoutID, ctx.QueryParam("promo_code"))
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "price fetched successfully", preview)
+}
+
+func (h *PaymentsHandler) FindAll(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	payments, statusCode, err := h.service.FindAllByUserID(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payments fetched successfully", payments)
+}
+
+func (h *PaymentsHandler) FindByID(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid payment ID")
+	}
+
+	payment, statusCode, err := h.service.FindByID(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payment fetched successfully", payment)
+}
+
+func (h *PaymentsHandler) FindAllPurchases(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	purchases, statusCode, err := h.purchasesService.FindAllByBuyerID(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "purchases fetched successfully", purchases)
+}
+
+func (h *PaymentsHandler) Refund(ctx echo.Context) error {
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid payment ID")
+	}
+
+	statusCode, err := h.service.Refund(id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payment refunded successfully", nil)
+}
+
+func (h *PaymentsHandler) Webhook(ctx echo.Context) error {
+	payload, err := io.ReadAll(io.LimitReader(ctx.Request().Body, maxWebhookPayloadSize))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid payload")
+	}
+
+	statusCode, err := h.service.HandleWebhook(payload, ctx.Request().Header)
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("handler - Webhook - service.HandleWebhook: %v", err)
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "event received", nil)
+}
+
+func (h *PaymentsHandler) AuthenticateFakePayment(ctx echo.Context) error {
+	var authenticateRequest data_transfers.AuthenticateFakePaymentRequest
+
+	err := helpers.BindAndValidate(ctx, &authenticateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.AuthenticateFakePayment(ctx.Param("intentID"), authenticateRequest.Approve)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "payment authenticated", nil)
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/internal/utils"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type ProgramsHandler struct {
+	service *services.ProgramsService
+}
+
+func NewProgramsHandler(service *services.ProgramsService) *ProgramsHandler {
+	return &ProgramsHandler{
+		service: service,
+	}
+}
+
+func (h *ProgramsHandler) FindAll(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	params, err := utils.ExtractQueryParams(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	programs, total, statusCode, err := h.service.FindAll(jwtClaims.UserID, params.Pagination)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "programs fetched successfully", map[string]interface{}{
+		"data":  programs,
+		"total": total,
+	})
+}
+
+func (h *ProgramsHandler) FindAllByCurrentUserID(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	programs, statusCode, err := h.service.FindAllByOwnerID(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "programs fetched successfully", programs)
+}
+
+func (h *ProgramsHandler) FindByID(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid program ID")
+	}
+
+	program, statusCode, err := h.service.FindByID(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "program fetched successfully", program)
+}
+
+func (h *ProgramsHandler) Create(ctx echo.Context) error {
+	var createRequest data_transfers.CreateProgramRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &createRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	program, statusCode, err := h.service.Create(jwtClaims.UserID, createRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "program created successfully", program)
+}
+
+func (h *ProgramsHandler) Update(ctx echo.Context) error {
+	var updateRequest data_transfers.UpdateProgramRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid program ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &updateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	program, statusCode, err := h.service.Update(jwtClaims.UserID, id, updateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "program updated successfully", program)
+}
+
+func (h *ProgramsHandler) ReplaceSchedule(ctx echo.Context) error {
+	var scheduleRequest data_transfers.ReplaceProgramScheduleRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid program ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &scheduleRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	program, statusCode, err := h.service.ReplaceSchedule(jwtClaims.UserID, id, scheduleRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "program schedule updated successfully", program)
+}
+
+func (h *ProgramsHandler) Delete(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid program ID")
+	}
+
+	statusCode, err := h.service.Delete(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "program deleted successfully", nil)
+}
+
+func (h *ProgramsHandler) Enroll(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid program ID")
+	}
+
+	enrollment, statusCode, err := h.service.Enroll(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "enrolled successfully", enrollment)
+}
+
+func (h *ProgramsHandler) Unenroll(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid program ID")
+	}
+
+	statusCode, err := h.service.Unenroll(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "unenrolled successfully", nil)
+}
+
+func (h *ProgramsHandler) FindEnrollment(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid program ID")
+	}
+
+	enrollment, statusCode, err := h.service.FindEnrollment(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "enrollment fetched successfully", enrollment)
+}
+
+func (h *ProgramsHandler) FindEnrollments(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	enrollments, statusCode, err := h.service.FindEnrollments(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "enrollments fetched successfully", enrollments)
+}
+
+func (h *ProgramsHandler) CompleteDay(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid program ID")
+	}
+
+	enrollment, statusCode, err := h.service.CompleteDay(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "day completed successfully", enrollment)
+}
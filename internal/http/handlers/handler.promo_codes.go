@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// PromoCodesHandler serves the promo codes of the authenticated creator. The
+// ForAdmin variants act as owner 0, which covers platform codes and every
+// creator's codes.
+type PromoCodesHandler struct {
+	service *services.PromoCodesService
+}
+
+func NewPromoCodesHandler(service *services.PromoCodesService) *PromoCodesHandler {
+	return &PromoCodesHandler{
+		service: service,
+	}
+}
+
+func (h *PromoCodesHandler) FindAll(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+	return h.findAll(ctx, jwtClaims.UserID)
+}
+
+func (h *PromoCodesHandler) FindAllForAdmin(ctx echo.Context) error {
+	return h.findAll(ctx, 0)
+}
+
+func (h *PromoCodesHandler) Create(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+	return h.create(ctx, jwtClaims.UserID)
+}
+
+func (h *PromoCodesHandler) CreateForAdmin(ctx echo.Context) error {
+	return h.create(ctx, 0)
+}
+
+func (h *PromoCodesHandler) Update(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+	return h.update(ctx, jwtClaims.UserID)
+}
+
+func (h *PromoCodesHandler) UpdateForAdmin(ctx echo.Context) error {
+	return h.update(ctx, 0)
+}
+
+func (h *PromoCodesHandler) Delete(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+	return h.remove(ctx, jwtClaims.UserID)
+}
+
+func (h *PromoCodesHandler) DeleteForAdmin(ctx echo.Context) error {
+	return h.remove(ctx, 0)
+}
+
+func (h *PromoCodesHandler) findAll(ctx echo.Context, ownerID int) error {
+	promoCodes, statusCode, err := h.service.FindAll(ownerID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "promo codes fetched successfully", promoCodes)
+}
+
+func (h *PromoCodesHandler) create(ctx echo.Context, ownerID int) error {
+	var createRequest data_transfers.CreatePromoCodeRequest
+
+	err := helpers.BindAndValidate(ctx, &createRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	promoCode, statusCode, err := h.service.Create(ownerID, createRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "promo code created successfully", promoCode)
+}
+
+func (h *PromoCodesHandler) update(ctx echo.Context, ownerID int) error {
+	var updateRequest data_transfers.UpdatePromoCodeRequest
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid promo code ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &updateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.Update(ownerID, id, updateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "promo code updated successfully", nil)
+}
+
+func (h *PromoCodesHandler) remove(ctx echo.Context, ownerID int) error {
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid promo code ID")
+	}
+
+	statusCode, err := h.service.Delete(ownerID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "promo code deleted successfully", nil)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type RolesHandler struct {
+	service *services.RolesService
+}
+
+func NewRolesHandler(service *services.RolesService) *RolesHandler {
+	return &RolesHandler{
+		service: service,
+	}
+}
+
+func (h *RolesHandler) FindAll(ctx echo.Context) error {
+	roles, statusCode, err := h.service.FindAll()
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "roles fetched successfully", roles)
+}
+
+func (h *RolesHandler) FindAllByUserID(ctx echo.Context) error {
+	userID, err := convert.StringToInt(ctx.Param("userID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	roles, statusCode, err := h.service.FindAllByUserID(userID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "user roles fetched successfully", roles)
+}
+
+func (h *RolesHandler) GrantToUser(ctx echo.Context) error {
+	var grantRoleRequest data_transfers.GrantRoleRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	userID, err := convert.StringToInt(ctx.Param("userID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &grantRoleRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.GrantToUser(userID, grantRoleRequest, jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "role granted successfully", nil)
+}
+
+func (h *RolesHandler) RevokeFromUser(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	userID, err := convert.StringToInt(ctx.Param("userID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid user ID")
+	}
+
+	statusCode, err := h.service.RevokeFromUser(userID, ctx.Param("role"), jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "role revoked successfully", nil)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type SubscriptionsHandler struct {
+	service *services.SubscriptionsService
+}
+
+func NewSubscriptionsHandler(service *services.SubscriptionsService) *SubscriptionsHandler {
+	return &SubscriptionsHandler{
+		service: service,
+	}
+}
+
+func (h *SubscriptionsHandler) FindAllPlans(ctx echo.Context) error {
+	plans, statusCode, err := h.service.FindAllPlans(true)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "plans fetched successfully", plans)
+}
+
+func (h *SubscriptionsHandler) FindAllPlansForAdmin(ctx echo.Context) error {
+	plans, statusCode, err := h.service.FindAllPlans(false)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "plans fetched successfully", plans)
+}
+
+func (h *SubscriptionsHandler) CreatePlan(ctx echo.Context) error {
+	var createPlanRequest data_transfers.CreateSubscriptionPlanRequest
+
+	err := helpers.BindAndValidate(ctx, &createPlanRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	plan, statusCode, err := h.service.CreatePlan(createPlanRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "plan created successfully", plan)
+}
+
+func (h *SubscriptionsHandler) UpdatePlan(ctx echo.Context) error {
+	var updatePlanRequest data_transfers.UpdateSubscriptionPlanRequest
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid plan ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &updatePlanRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.UpdatePlan(id, updatePlanRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "plan updated successfully", nil)
+}
+
+func (h *SubscriptionsHandler) FindCurrent(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	subscription, statusCode, err := h.service.FindCurrent(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "membership fetched successfully", subscription)
+}
+
+func (h *SubscriptionsHandler) Subscribe(ctx echo.Context) error {
+	var subscribeRequest data_transfers.SubscribeRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &subscribeRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	subscription, statusCode, err := h.service.Subscribe(jwtClaims.UserID, subscribeRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "membership started successfully", subscription)
+}
+
+func (h *SubscriptionsHandler) Cancel(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	statusCode, err := h.service.Cancel(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "membership canceled successfully", nil)
+}
+
+func (h *SubscriptionsHandler) Resume(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	statusCode, err := h.service.Resume(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "membership resumed successfully", nil)
+}
+
+func (h *SubscriptionsHandler) UpdatePaymentMethod(ctx echo.Context) error {
+	var updateRequest data_transfers.UpdateSubscriptionPaymentMethodRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &updateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.UpdatePaymentMethod(jwtClaims.UserID, updateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "membership payment method updated successfully", nil)
+}
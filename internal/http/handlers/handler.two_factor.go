@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type TwoFactorHandler struct {
+	service *services.TwoFactorService
+}
+
+func NewTwoFactorHandler(service *services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		service: service,
+	}
+}
+
+func (h *TwoFactorHandler) Status(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	status, statusCode, err := h.service.Status(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "two-factor status fetched successfully", status)
+}
+
+func (h *TwoFactorHandler) Enroll(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	enrollment, statusCode, err := h.service.Enroll(jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "two-factor enrollment started successfully", enrollment)
+}
+
+func (h *TwoFactorHandler) Confirm(ctx echo.Context) error {
+	var twoFactorCodeRequest data_transfers.TwoFactorCodeRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &twoFactorCodeRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	recoveryCodes, statusCode, err := h.service.Confirm(jwtClaims.UserID, twoFactorCodeRequest.Code)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "two-factor authentication enabled successfully", data_transfers.RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+func (h *TwoFactorHandler) Disable(ctx echo.Context) error {
+	var twoFactorCodeRequest data_transfers.TwoFactorCodeRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &twoFactorCodeRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.Disable(jwtClaims.UserID, twoFactorCodeRequest.Code)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "two-factor authentication disabled successfully", nil)
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(ctx echo.Context) error {
+	var twoFactorCodeRequest data_transfers.TwoFactorCodeRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	err := helpers.BindAndValidate(ctx, &twoFactorCodeRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	recoveryCodes, statusCode, err := h.service.RegenerateRecoveryCodes(jwtClaims.UserID, twoFactorCodeRequest.Code)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "recovery codes regenerated successfully", data_transfers.RecoveryCodesResponse{
+		RecoveryCodes: recoveryCodes,
+	})
+}
@@ -14,29 +14,34 @@ import (
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"path/filepath"
+	"strings"
 )
 
 type UsersHandler struct {
-	service  *services.UsersService
-	s3Client *s3.Client
+	service         *services.UsersService
+	accountsService *services.AccountsService
+	s3Client        *s3.Client
 }
 
-func NewUsersHandler(service *services.UsersService, s3Client *s3.Client) *UsersHandler {
+func NewUsersHandler(service *services.UsersService, accountsService *services.AccountsService, s3Client *s3.Client) *UsersHandler {
 	return &UsersHandler{
-		service:  service,
-		s3Client: s3Client,
+		service:         service,
+		accountsService: accountsService,
+		s3Client:        s3Client,
 	}
 }
 
 func (h *UsersHandler) FindAll(ctx echo.Context) error {
 	var users []data_transfers.UsersResponse
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
 
 	params, err := utils.ExtractQueryParams(ctx.QueryParams())
 	if err != nil {
 		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
 	}
 
-	users, total, statusCode, err := h.service.FindAllWithFilters(params)
+	users, total, statusCode, err := h.service.FindAllWithFilters(params, jwtClaims.UserID)
 	if err != nil {
 		return NewErrorResponse(ctx, statusCode, err.Error())
 	}
@@ -67,10 +72,11 @@ func (h *UsersHandler) FindByID(ctx echo.Context) error {
 
 func (h *UsersHandler) FindByUsername(ctx echo.Context) error {
 	var user data_transfers.UsersResponse
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
 
 	username := ctx.Param("username")
 
-	user, statusCode, err := h.service.FindByUsername(username)
+	user, statusCode, err := h.service.FindByUsername(username, jwtClaims.UserID)
 	if err != nil {
 		return NewErrorResponse(ctx, statusCode, err.Error())
 	}
@@ -123,6 +129,8 @@ func (h *UsersHandler) Update(ctx echo.Context) error {
 	return NewSuccessResponse(ctx, statusCode, "user updated successfully", nil)
 }
 
+// Delete removes a user immediately. It is reserved for admins; users delete
+// their own account through /me/delete, which has a grace period.
 func (h *UsersHandler) Delete(ctx echo.Context) error {
 	idStr := ctx.Param("id")
 	userId, err := convert.StringToInt(idStr)
@@ -130,12 +138,12 @@ func (h *UsersHandler) Delete(ctx echo.Context) error {
 		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid ID")
 	}
 
-	err = h.service.Delete(userId)
+	statusCode, err := h.accountsService.Purge(userId)
 	if err != nil {
-		return NewErrorResponse(ctx, http.StatusInternalServerError, err.Error())
+		return NewErrorResponse(ctx, statusCode, err.Error())
 	}
 
-	return NewSuccessResponse(ctx, http.StatusOK, "user deleted successfully", nil)
+	return NewSuccessResponse(ctx, statusCode, "user deleted successfully", nil)
 }
 
 func (h *UsersHandler) Me(ctx echo.Context) error {
@@ -165,7 +173,13 @@ func (h *UsersHandler) ChangeAvatar(ctx echo.Context) error {
 	}
 	defer src.Close()
 
-	url, err := h.s3Client.UploadFile(src, avatar.Filename, bucketName)
+	suffix, err := helpers.GenerateRandomString(8)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusInternalServerError, "Failed to upload avatar to S3")
+	}
+	filename := services.AvatarObjectPrefix(jwtClaims.UserID) + suffix + strings.ToLower(filepath.Ext(avatar.Filename))
+
+	url, err := h.s3Client.UploadFile(src, filename, bucketName)
 	if err != nil {
 		fmt.Println(err)
 		return NewErrorResponse(ctx, http.StatusInternalServerError, "Failed to upload avatar to S3")
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/internal/utils"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type WorkoutCommentsHandler struct {
+	service *services.WorkoutCommentsService
+}
+
+func NewWorkoutCommentsHandler(service *services.WorkoutCommentsService) *WorkoutCommentsHandler {
+	return &WorkoutCommentsHandler{
+		service: service,
+	}
+}
+
+func (h *WorkoutCommentsHandler) FindAllByWorkoutID(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("workoutID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	cursor, err := utils.ExtractCursor(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	pinned, comments, next, statusCode, err := h.service.FindAllByWorkoutID(jwtClaims.UserID, workoutID, cursor)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "comments fetched successfully", map[string]interface{}{
+		"pinned":      pinned,
+		"data":        comments,
+		"next_cursor": utils.EncodeCursor(next),
+	})
+}
+
+func (h *WorkoutCommentsHandler) FindReplies(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid comment ID")
+	}
+
+	cursor, err := utils.ExtractCursor(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	replies, next, statusCode, err := h.service.FindReplies(jwtClaims.UserID, id, cursor)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "replies fetched successfully", map[string]interface{}{
+		"data":        replies,
+		"next_cursor": utils.EncodeCursor(next),
+	})
+}
+
+func (h *WorkoutCommentsHandler) Create(ctx echo.Context) error {
+	var createRequest data_transfers.CreateWorkoutCommentRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("workoutID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &createRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	comment, statusCode, err := h.service.Create(jwtClaims.UserID, workoutID, createRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "comment created successfully", comment)
+}
+
+func (h *WorkoutCommentsHandler) Update(ctx echo.Context) error {
+	var updateRequest data_transfers.UpdateWorkoutCommentRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid comment ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &updateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	comment, statusCode, err := h.service.Update(jwtClaims.UserID, id, updateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "comment updated successfully", comment)
+}
+
+func (h *WorkoutCommentsHandler) Delete(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid comment ID")
+	}
+
+	statusCode, err := h.service.Delete(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "comment deleted successfully", nil)
+}
+
+func (h *WorkoutCommentsHandler) Pin(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid comment ID")
+	}
+
+	statusCode, err := h.service.Pin(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "comment pinned successfully", nil)
+}
+
+func (h *WorkoutCommentsHandler) Unpin(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid comment ID")
+	}
+
+	statusCode, err := h.service.Unpin(jwtClaims.UserID, id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "comment unpinned successfully", nil)
+}
@@ -114,6 +114,27 @@ func (h *WorkoutExercisesHandler) Update(ctx echo.Context) error {
 	return NewSuccessResponse(ctx, statusCode, "workout exercise updated successfully", nil)
 }
 
+func (h *WorkoutExercisesHandler) Reorder(ctx echo.Context) error {
+	var reorderRequest data_transfers.ReorderWorkoutExercisesRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("workoutID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	if err := helpers.BindAndValidate(ctx, &reorderRequest); err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	workoutExercises, statusCode, err := h.service.Reorder(jwtClaims.UserID, workoutID, reorderRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "workout exercises reordered successfully", workoutExercises)
+}
+
 func (h *WorkoutExercisesHandler) Delete(ctx echo.Context) error {
 	workoutExerciseIDStr := ctx.Param("workoutExerciseID")
 	workoutExerciseID, err := convert.StringToInt(workoutExerciseIDStr)
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/internal/utils"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type WorkoutReviewsHandler struct {
+	service *services.WorkoutReviewsService
+}
+
+func NewWorkoutReviewsHandler(service *services.WorkoutReviewsService) *WorkoutReviewsHandler {
+	return &WorkoutReviewsHandler{
+		service: service,
+	}
+}
+
+func (h *WorkoutReviewsHandler) FindAllByWorkoutID(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("workoutID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	params, err := utils.ExtractQueryParams(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	reviews, total, statusCode, err := h.service.FindAllByWorkoutID(jwtClaims.UserID, workoutID, params.Pagination)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "reviews fetched successfully", map[string]interface{}{
+		"data":  reviews,
+		"total": total,
+	})
+}
+
+func (h *WorkoutReviewsHandler) Create(ctx echo.Context) error {
+	var createRequest data_transfers.CreateWorkoutReviewRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("workoutID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &createRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	review, statusCode, err := h.service.Create(jwtClaims.UserID, workoutID, createRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "review created successfully", review)
+}
+
+func (h *WorkoutReviewsHandler) Update(ctx echo.Context) error {
+	var updateRequest data_transfers.UpdateWorkoutReviewRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("workoutID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &updateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	review, statusCode, err := h.service.Update(jwtClaims.UserID, workoutID, updateRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "review updated successfully", review)
+}
+
+func (h *WorkoutReviewsHandler) Delete(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("workoutID"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	statusCode, err := h.service.Delete(jwtClaims.UserID, workoutID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "review deleted successfully", nil)
+}
+
+func (h *WorkoutReviewsHandler) Report(ctx echo.Context) error {
+	var reportRequest data_transfers.ReportWorkoutReviewRequest
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid review ID")
+	}
+
+	err = helpers.BindAndValidate(ctx, &reportRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	statusCode, err := h.service.Report(jwtClaims.UserID, id, reportRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "review reported successfully", nil)
+}
+
+func (h *WorkoutReviewsHandler) FindAllReportedForAdmin(ctx echo.Context) error {
+	params, err := utils.ExtractQueryParams(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	reviews, total, statusCode, err := h.service.FindAllReported(params.Pagination)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "reported reviews fetched successfully", map[string]interface{}{
+		"data":  reviews,
+		"total": total,
+	})
+}
+
+func (h *WorkoutReviewsHandler) FindReportsForAdmin(ctx echo.Context) error {
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid review ID")
+	}
+
+	reports, statusCode, err := h.service.FindReports(id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "review reports fetched successfully", reports)
+}
+
+func (h *WorkoutReviewsHandler) DeleteForAdmin(ctx echo.Context) error {
+	id, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid review ID")
+	}
+
+	statusCode, err := h.service.DeleteForAdmin(id)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "review deleted successfully", nil)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/http/data_transfers"
+	"backend/internal/services"
+	"backend/internal/utils"
+	"backend/pkg/convert"
+	"backend/pkg/jwt"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type WorkoutRevisionsHandler struct {
+	service         *services.WorkoutRevisionsService
+	workoutsService *services.WorkoutsService
+}
+
+func NewWorkoutRevisionsHandler(service *services.WorkoutRevisionsService, workoutsService *services.WorkoutsService) *WorkoutRevisionsHandler {
+	return &WorkoutRevisionsHandler{
+		service:         service,
+		workoutsService: workoutsService,
+	}
+}
+
+// FindAll lists the changelog of a workout to anyone who can see it.
+func (h *WorkoutRevisionsHandler) FindAll(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	params, err := utils.ExtractQueryParams(ctx.QueryParams())
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	if _, statusCode, err := h.workoutsService.FindByIDByOwner(workoutID, jwtClaims.UserID); err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	revisions, total, statusCode, err := h.service.FindAll(workoutID, params.Pagination)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "workout revisions fetched successfully", map[string]interface{}{
+		"data":  revisions,
+		"total": total,
+	})
+}
+
+func (h *WorkoutRevisionsHandler) FindByNumber(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	revisionNumber, err := convert.StringToInt(ctx.Param("number"))
+	if err != nil || revisionNumber < 1 {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid revision number")
+	}
+
+	if statusCode, err := h.checkEntitled(workoutID, jwtClaims.UserID); err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	revision, statusCode, err := h.service.FindByNumber(workoutID, revisionNumber)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "workout revision fetched successfully", revision)
+}
+
+// Diff compares the revisions given by the from and to query parameters,
+// by default the latest revision with the one before it.
+func (h *WorkoutRevisionsHandler) Diff(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	var revisionNumbers [2]int
+	for i, param := range []string{"from", "to"} {
+		if value := ctx.QueryParam(param); value != "" {
+			revisionNumbers[i], err = convert.StringToInt(value)
+			if err != nil || revisionNumbers[i] < 1 {
+				return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid revision number")
+			}
+		}
+	}
+
+	if statusCode, err := h.checkEntitled(workoutID, jwtClaims.UserID); err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	var diff data_transfers.WorkoutRevisionDiffResponse
+	diff, statusCode, err := h.service.Diff(workoutID, revisionNumbers[0], revisionNumbers[1])
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, statusCode, "workout revision diff fetched successfully", diff)
+}
+
+func (h *WorkoutRevisionsHandler) Restore(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutID, err := convert.StringToInt(ctx.Param("id"))
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	revisionNumber, err := convert.StringToInt(ctx.Param("number"))
+	if err != nil || revisionNumber < 1 {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid revision number")
+	}
+
+	workout, statusCode, err := h.workoutsService.FindByID(workoutID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	if workout.OwnerID != jwtClaims.UserID && !jwtClaims.IsAdmin {
+		return NewErrorResponse(ctx, http.StatusForbidden, "You are not allowed to restore this workout")
+	}
+
+	revision, statusCode, err := h.service.Restore(workoutID, revisionNumber)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	return NewSuccessResponse(ctx, http.StatusCreated, "workout revision restored successfully", revision)
+}
+
+// checkEntitled lets through the users who may see the exercises of the
+// workout, as the revisions contain them.
+func (h *WorkoutRevisionsHandler) checkEntitled(workoutID int, userID int) (int, error) {
+	workout, statusCode, err := h.workoutsService.FindByIDByOwner(workoutID, userID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	entitled, statusCode, err := h.workoutsService.IsEntitled(workout, userID)
+	if err != nil {
+		return statusCode, err
+	}
+	if !entitled {
+		return http.StatusPaymentRequired, errors.New("you need access to this workout to see its revisions")
+	}
+
+	return http.StatusOK, nil
+}
@@ -8,7 +8,6 @@ import (
 	"backend/internal/utils"
 	"backend/pkg/convert"
 	"backend/pkg/jwt"
-	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
 )
@@ -71,7 +70,7 @@ func (h *WorkoutsHandler) FindAllByOwnerID(ctx echo.Context) error {
 			return NewErrorResponse(ctx, statusCode, err.Error())
 		}
 	} else {
-		workouts, statusCode, err = h.service.FindAllByOwnerID(userID)
+		workouts, statusCode, err = h.service.FindAllByOwnerID(userID, jwtClaims.UserID)
 		if err != nil {
 			return NewErrorResponse(ctx, statusCode, err.Error())
 		}
@@ -163,6 +162,14 @@ func (h *WorkoutsHandler) Copy(ctx echo.Context) error {
 		return NewErrorResponse(ctx, http.StatusForbidden, "You cannot copy a paid or private workout")
 	}
 
+	entitled, statusCode, err := h.service.IsEntitled(workout, jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+	if !entitled {
+		return NewErrorResponse(ctx, http.StatusForbidden, "You need a membership to copy this workout")
+	}
+
 	id, statusCode, err := h.service.Copy(workoutID, jwtClaims.UserID)
 	if err != nil {
 		return NewErrorResponse(ctx, statusCode, err.Error())
@@ -171,26 +178,28 @@ func (h *WorkoutsHandler) Copy(ctx echo.Context) error {
 	return NewSuccessResponse(ctx, statusCode, "workout copied successfully", map[string]int{"id": id})
 }
 
-func (h *WorkoutsHandler) FindAllWithFilters(ctx echo.Context) error {
-	var workouts []data_transfers.WorkoutsResponse
+func (h *WorkoutsHandler) FindLineage(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
 
-	params, err := utils.ExtractQueryParams(ctx.QueryParams())
+	workoutIDStr := ctx.Param("id")
+	workoutID, err := convert.StringToInt(workoutIDStr)
 	if err != nil {
-		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
 	}
 
-	workouts, total, statusCode, err := h.service.FindAllWithFilters(params)
+	if _, statusCode, err := h.service.FindByIDByOwner(workoutID, jwtClaims.UserID); err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	lineage, statusCode, err := h.service.FindLineage(workoutID)
 	if err != nil {
 		return NewErrorResponse(ctx, statusCode, err.Error())
 	}
 
-	return NewSuccessResponse(ctx, http.StatusOK, "Workouts fetched successfully", map[string]interface{}{
-		"data":  workouts,
-		"total": total,
-	})
+	return NewSuccessResponse(ctx, statusCode, "workout lineage fetched successfully", lineage)
 }
 
-func (h *WorkoutsHandler) LikeWorkout(ctx echo.Context) error {
+func (h *WorkoutsHandler) MergeUpstream(ctx echo.Context) error {
 	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
 
 	workoutIDStr := ctx.Param("id")
@@ -199,51 +208,84 @@ func (h *WorkoutsHandler) LikeWorkout(ctx echo.Context) error {
 		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
 	}
 
-	statusCode, err := h.service.LikeWorkout(workoutID, jwtClaims.UserID)
+	merge, statusCode, err := h.service.MergeUpstream(workoutID, jwtClaims.UserID)
 	if err != nil {
 		return NewErrorResponse(ctx, statusCode, err.Error())
 	}
 
-	return NewSuccessResponse(ctx, statusCode, "Workout liked successfully", nil)
+	return NewSuccessResponse(ctx, statusCode, "workout merged successfully", merge)
 }
 
-func (h *WorkoutsHandler) GenerateWorkout(ctx echo.Context) error {
+func (h *WorkoutsHandler) FindAllWithFilters(ctx echo.Context) error {
+	var workouts []data_transfers.WorkoutsResponse
+
 	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
-	var generateWorkoutRequest data_transfers.WorkoutGenerateRequest
 
-	err := helpers.BindAndValidate(ctx, &generateWorkoutRequest)
+	params, err := utils.ExtractQueryParams(ctx.QueryParams())
 	if err != nil {
 		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
 	}
 
-	generateWorkoutRequest.OwnerID = jwtClaims.UserID
-	go h.service.GenerateWorkout(generateWorkoutRequest)
+	workouts, total, statusCode, err := h.service.FindAllWithFilters(params, jwtClaims.UserID)
+	if err != nil {
+		return NewErrorResponse(ctx, statusCode, err.Error())
+	}
 
-	return NewSuccessResponse(ctx, 201, "Workout generated successfully", nil)
+	return NewSuccessResponse(ctx, http.StatusOK, "Workouts fetched successfully", map[string]interface{}{
+		"data":  workouts,
+		"total": total,
+	})
 }
 
-func (h *WorkoutsHandler) PurchaseWorkout(ctx echo.Context) error {
+// FindAllMembersOnly lists the members-only workouts, for members.
+func (h *WorkoutsHandler) FindAllMembersOnly(ctx echo.Context) error {
 	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
 
-	var purchaseRequest data_transfers.PurchaseWorkoutRequest
-	err := helpers.BindAndValidate(ctx, &purchaseRequest)
+	params, err := utils.ExtractQueryParams(ctx.QueryParams())
 	if err != nil {
 		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
 	}
+	params.Filters["workouts.members_only"] = true
 
-	fmt.Println("Purchase request:", purchaseRequest)
-
-	_, statusCode, err := h.service.FindByID(purchaseRequest.WorkoutID)
+	workouts, total, statusCode, err := h.service.FindAllWithFilters(params, jwtClaims.UserID)
 	if err != nil {
 		return NewErrorResponse(ctx, statusCode, err.Error())
 	}
 
-	// TODO: Implement payment processing logic here
+	return NewSuccessResponse(ctx, http.StatusOK, "Workouts fetched successfully", map[string]interface{}{
+		"data":  workouts,
+		"total": total,
+	})
+}
 
-	id, statusCode, err := h.service.Copy(purchaseRequest.WorkoutID, jwtClaims.UserID)
+func (h *WorkoutsHandler) LikeWorkout(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+
+	workoutIDStr := ctx.Param("id")
+	workoutID, err := convert.StringToInt(workoutIDStr)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, "Invalid workout ID")
+	}
+
+	statusCode, err := h.service.LikeWorkout(workoutID, jwtClaims.UserID)
 	if err != nil {
 		return NewErrorResponse(ctx, statusCode, err.Error())
 	}
 
-	return NewSuccessResponse(ctx, statusCode, "workout copied successfully", map[string]int{"id": id})
+	return NewSuccessResponse(ctx, statusCode, "Workout liked successfully", nil)
+}
+
+func (h *WorkoutsHandler) GenerateWorkout(ctx echo.Context) error {
+	jwtClaims := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+	var generateWorkoutRequest data_transfers.WorkoutGenerateRequest
+
+	err := helpers.BindAndValidate(ctx, &generateWorkoutRequest)
+	if err != nil {
+		return NewErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	generateWorkoutRequest.OwnerID = jwtClaims.UserID
+	go h.service.GenerateWorkout(generateWorkoutRequest)
+
+	return NewSuccessResponse(ctx, 201, "Workout generated successfully", nil)
 }
@@ -3,12 +3,49 @@ package middlewares
 import (
 	"backend/internal/constants"
 	"backend/internal/http/handlers"
+	"backend/internal/services"
 	"backend/pkg/jwt"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"strings"
 )
 
+// SessionChecker reports whether the login session an access token belongs to is still active.
+type SessionChecker interface {
+	IsSessionActive(sessionID string) (bool, int, error)
+}
+
+var sessionChecker SessionChecker
+
+// SetSessionChecker enables rejecting access tokens of revoked sessions in RequireAuth.
+func SetSessionChecker(checker SessionChecker) {
+	sessionChecker = checker
+}
+
+// APIKeyAuthenticator resolves personal API keys to their owner and scopes.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key string) (int, []string, int, error)
+}
+
+var apiKeyAuthenticator APIKeyAuthenticator
+
+// SetAPIKeyAuthenticator enables API keys in RequireAuth on routes that allow them.
+func SetAPIKeyAuthenticator(authenticator APIKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+// AllowAPIKey lets RequireAuth accept API keys scoped to the resource. Safe
+// methods need read access, every other method write access. It must be
+// registered before RequireAuth; routes without it only accept JWTs.
+func AllowAPIKey(resource string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			ctx.Set(constants.CtxAPIKeyResourceKey, resource)
+			return next(ctx)
+		}
+	}
+}
+
 func RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
 		authHeader := ctx.Request().Header.Get("Authorization")
@@ -25,12 +62,61 @@ func RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 			return handlers.NewErrorResponse(ctx, http.StatusUnauthorized, "token must content Bearer")
 		}
 
+		if strings.HasPrefix(headerParts[1], constants.APIKeyPrefix) {
+			return authenticateAPIKey(ctx, next, headerParts[1])
+		}
+
 		claims, err := jwt.ParseToken(headerParts[1])
 		if err != nil {
 			return handlers.NewErrorResponse(ctx, http.StatusUnauthorized, "invalid token")
 		}
 
+		// Refresh tokens and tokens that belong to no session, which could
+		// not be revoked, are not accepted as access tokens.
+		if claims.TokenType != jwt.AccessToken {
+			return handlers.NewErrorResponse(ctx, http.StatusUnauthorized, "invalid token type")
+		}
+		if claims.SessionID == "" {
+			return handlers.NewErrorResponse(ctx, http.StatusUnauthorized, "invalid token")
+		}
+
+		if sessionChecker != nil {
+			active, statusCode, err := sessionChecker.IsSessionActive(claims.SessionID)
+			if err != nil {
+				return handlers.NewErrorResponse(ctx, statusCode, err.Error())
+			}
+			if !active {
+				return handlers.NewErrorResponse(ctx, http.StatusUnauthorized, "session has been revoked")
+			}
+		}
+
 		ctx.Set(constants.CtxAuthenticatedUserKey, claims)
 		return next(ctx)
 	}
 }
+
+func authenticateAPIKey(ctx echo.Context, next echo.HandlerFunc, key string) error {
+	resource, _ := ctx.Get(constants.CtxAPIKeyResourceKey).(string)
+	if resource == "" || apiKeyAuthenticator == nil {
+		return handlers.NewErrorResponse(ctx, http.StatusForbidden, "api keys are not accepted for this endpoint")
+	}
+
+	userID, scopes, statusCode, err := apiKeyAuthenticator.AuthenticateAPIKey(key)
+	if err != nil {
+		return handlers.NewErrorResponse(ctx, statusCode, err.Error())
+	}
+
+	access := constants.APIKeyAccessWrite
+	switch ctx.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		access = constants.APIKeyAccessRead
+	}
+
+	if !services.APIKeyAllows(scopes, resource, access) {
+		return handlers.NewErrorResponse(ctx, http.StatusForbidden, "api key is missing the "+resource+":"+access+" scope")
+	}
+
+	// API keys act as the user without any role, so admin routes stay out of reach.
+	ctx.Set(constants.CtxAuthenticatedUserKey, &jwt.Claims{UserID: userID})
+	return next(ctx)
+}
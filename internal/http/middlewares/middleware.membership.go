@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"backend/internal/constants"
+	"backend/internal/http/handlers"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// MembershipChecker reports whether a user has a membership that gives access to members-only content.
+type MembershipChecker interface {
+	IsMember(userID int) (bool, int, error)
+}
+
+var membershipChecker MembershipChecker
+
+// SetMembershipChecker provides the membership lookup used by RequireMembership.
+func SetMembershipChecker(checker MembershipChecker) {
+	membershipChecker = checker
+}
+
+// RequireMembership allows the request only for members. It must be registered after RequireAuth.
+func RequireMembership(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		claims, ok := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+		if !ok {
+			return handlers.NewErrorResponse(ctx, http.StatusUnauthorized, "token not found")
+		}
+
+		if membershipChecker == nil {
+			return handlers.NewErrorResponse(ctx, http.StatusForbidden, "a membership is required")
+		}
+
+		member, statusCode, err := membershipChecker.IsMember(claims.UserID)
+		if err != nil {
+			return handlers.NewErrorResponse(ctx, statusCode, err.Error())
+		}
+		if !member {
+			return handlers.NewErrorResponse(ctx, http.StatusForbidden, "a membership is required")
+		}
+
+		return next(ctx)
+	}
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"backend/internal/constants"
+	"backend/internal/http/handlers"
+	"backend/pkg/jwt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// RequireRole allows the request when the authenticated user has any of the given roles.
+// It must be registered after RequireAuth.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			claims, ok := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+			if !ok {
+				return handlers.NewErrorResponse(ctx, http.StatusUnauthorized, "token not found")
+			}
+
+			for _, role := range roles {
+				if claims.HasRole(role) {
+					return next(ctx)
+				}
+			}
+
+			return handlers.NewErrorResponse(ctx, http.StatusForbidden, "you do not have the required role")
+		}
+	}
+}
+
+// RequirePermission allows the request only when the authenticated user has all the given permissions.
+// It must be registered after RequireAuth.
+func RequirePermission(permissions ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			claims, ok := ctx.Get(constants.CtxAuthenticatedUserKey).(*jwt.Claims)
+			if !ok {
+				return handlers.NewErrorResponse(ctx, http.StatusUnauthorized, "token not found")
+			}
+
+			for _, permission := range permissions {
+				if !claims.HasPermission(permission) {
+					return handlers.NewErrorResponse(ctx, http.StatusForbidden, "you do not have the required permission")
+				}
+			}
+
+			return next(ctx)
+		}
+	}
+}
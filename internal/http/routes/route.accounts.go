@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type AccountsRoute struct {
+	accountsHandler *handlers.AccountsHandler
+	router          *echo.Group
+}
+
+func NewAccountsRoute(container *container.Container, router *echo.Group) *AccountsRoute {
+	return &AccountsRoute{
+		accountsHandler: container.AccountsHandler,
+		router:          router,
+	}
+}
+
+func (r *AccountsRoute) Register() {
+	exports := r.router.Group("/me/export", middlewares.RequireAuth)
+	deletion := r.router.Group("/me/delete", middlewares.RequireAuth)
+
+	exports.GET("", r.accountsHandler.FindExports)
+	exports.POST("", r.accountsHandler.RequestExport)
+	exports.GET("/:id", r.accountsHandler.FindExport)
+	exports.GET("/:id/download", r.accountsHandler.DownloadExport)
+
+	deletion.GET("", r.accountsHandler.DeletionStatus)
+	deletion.POST("", r.accountsHandler.RequestDeletion)
+	deletion.DELETE("", r.accountsHandler.CancelDeletion)
+}
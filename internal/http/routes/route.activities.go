@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -24,7 +25,7 @@ func (r *ActivitiesRoute) Register() {
 	admin := r.router.Group("/admin/activities")
 
 	activities.Use(middlewares.RequireAuth)
-	admin.Use(middlewares.RequireAuth)
+	admin.Use(middlewares.RequireAuth, middlewares.RequirePermission(constants.PermissionCatalogWrite))
 
 	// activities routes
 	activities.GET("", r.activitiesHandler.FindAll)
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -24,7 +25,7 @@ func (r *ActivityGroupsRoute) Register() {
 	admin := r.router.Group("/admin/activity-groups")
 
 	activityGroups.Use(middlewares.RequireAuth)
-	admin.Use(middlewares.RequireAuth)
+	admin.Use(middlewares.RequireAuth, middlewares.RequirePermission(constants.PermissionCatalogWrite))
 
 	// activity_groups routes
 	activityGroups.GET("", r.activityGroupsHandler.FindAll)
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -22,7 +23,7 @@ func NewAnalyticsRoute(container *container.Container, router *echo.Group) *Anal
 func (r *AnalyticsRoute) Register() {
 	analytics := r.router.Group("/analytics")
 
-	analytics.Use(middlewares.RequireAuth)
+	analytics.Use(middlewares.AllowAPIKey(constants.APIKeyResourceAnalytics), middlewares.RequireAuth)
 	analytics.GET("/day-wise", r.analyticsHandler.GetDayWiseAnalytics)
 	analytics.GET("/training-days", r.analyticsHandler.GetTrainedDates)
 }
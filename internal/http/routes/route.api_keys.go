@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type APIKeysRoute struct {
+	apiKeysHandler *handlers.APIKeysHandler
+	router         *echo.Group
+}
+
+func NewAPIKeysRoute(container *container.Container, router *echo.Group) *APIKeysRoute {
+	return &APIKeysRoute{
+		apiKeysHandler: container.APIKeysHandler,
+		router:         router,
+	}
+}
+
+func (r *APIKeysRoute) Register() {
+	apiKeys := r.router.Group("/me/api-keys", middlewares.RequireAuth)
+
+	apiKeys.GET("", r.apiKeysHandler.FindAll)
+	apiKeys.POST("", r.apiKeysHandler.Create)
+	apiKeys.DELETE("/:id", r.apiKeysHandler.Revoke)
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"backend/internal/container"
 	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
 	"github.com/labstack/echo/v4"
 )
 
@@ -22,5 +23,18 @@ func (r *AuthRoute) Register() {
 	auth := r.router.Group("/auth")
 
 	auth.POST("/sign-in", r.authHandler.SignIn)
+	auth.POST("/sign-in/2fa", r.authHandler.SignInTwoFactor)
 	auth.POST("/sign-up", r.authHandler.SignUp)
+	auth.POST("/refresh", r.authHandler.Refresh)
+	auth.POST("/forgot-password", r.authHandler.ForgotPassword)
+	auth.POST("/reset-password", r.authHandler.ResetPassword)
+	auth.POST("/verify-email", r.authHandler.VerifyEmail)
+	auth.POST("/verify-email/resend", r.authHandler.ResendVerificationEmail, middlewares.RequireAuth)
+
+	// signed-in devices routes
+	sessions := r.router.Group("/me/sessions", middlewares.RequireAuth)
+
+	sessions.GET("", r.authHandler.FindSessions)
+	sessions.DELETE("", r.authHandler.RevokeAllSessions)
+	sessions.DELETE("/:sessionID", r.authHandler.RevokeSession)
 }
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"backend/internal/constants"
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type EarningsRoute struct {
+	earningsHandler *handlers.EarningsHandler
+	router          *echo.Group
+}
+
+func NewEarningsRoute(container *container.Container, router *echo.Group) *EarningsRoute {
+	return &EarningsRoute{
+		earningsHandler: container.EarningsHandler,
+		router:          router,
+	}
+}
+
+func (r *EarningsRoute) Register() {
+	me := r.router.Group("/me/earnings", middlewares.RequireAuth)
+	admin := r.router.Group("/admin/payouts", middlewares.RequireAuth, middlewares.RequireRole(constants.RoleAdmin))
+
+	me.GET("", r.earningsHandler.FindSummary)
+	me.GET("/workouts", r.earningsHandler.FindByWorkout)
+	me.GET("/monthly", r.earningsHandler.FindByMonth)
+	me.GET("/export", r.earningsHandler.Export)
+	me.GET("/payouts", r.earningsHandler.FindPayouts)
+
+	admin.GET("", r.earningsHandler.FindAllPayouts)
+	admin.POST("", r.earningsHandler.CreatePayouts)
+	admin.POST("/:id/paid", r.earningsHandler.MarkPayoutPaid)
+}
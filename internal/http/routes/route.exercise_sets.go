@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -23,8 +24,8 @@ func (r *ExerciseSetsRoute) Register() {
 	exerciseSets := r.router.Group("/exercise-sets")
 	workoutExercises := r.router.Group("/workout-exercises")
 
-	exerciseSets.Use(middlewares.RequireAuth)
-	workoutExercises.Use(middlewares.RequireAuth)
+	exerciseSets.Use(middlewares.AllowAPIKey(constants.APIKeyResourceWorkouts), middlewares.RequireAuth)
+	workoutExercises.Use(middlewares.AllowAPIKey(constants.APIKeyResourceWorkouts), middlewares.RequireAuth)
 
 	// exercise_sets routes
 	exerciseSets.POST("", r.exerciseSetsHandler.Save)
@@ -33,4 +34,5 @@ func (r *ExerciseSetsRoute) Register() {
 
 	// workout_exercises routes
 	workoutExercises.GET("/:workoutExerciseID/exercise-sets", r.exerciseSetsHandler.FindAllByWorkoutExerciseID)
+	workoutExercises.GET("/:workoutExerciseID/adherence", r.exerciseSetsHandler.FindAdherence)
 }
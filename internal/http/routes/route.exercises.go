@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -25,10 +26,10 @@ func (r *ExercisesRoute) Register() {
 	admin := r.router.Group("/admin/exercises")
 	workouts := r.router.Group("/workouts")
 
-	exercises.Use(middlewares.RequireAuth)
-	users.Use(middlewares.RequireAuth)
-	admin.Use(middlewares.RequireAuth)
-	workouts.Use(middlewares.RequireAuth)
+	exercises.Use(middlewares.AllowAPIKey(constants.APIKeyResourceExercises), middlewares.RequireAuth)
+	users.Use(middlewares.AllowAPIKey(constants.APIKeyResourceExercises), middlewares.RequireAuth)
+	admin.Use(middlewares.RequireAuth, middlewares.RequirePermission(constants.PermissionCatalogWrite))
+	workouts.Use(middlewares.AllowAPIKey(constants.APIKeyResourceWorkouts), middlewares.RequireAuth)
 
 	// users routes
 	users.GET("/:userID/exercises", r.exercisesHandler.FindAllUserExercises)
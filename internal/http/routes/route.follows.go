@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type FollowsRoute struct {
+	followsHandler *handlers.FollowsHandler
+	router         *echo.Group
+}
+
+func NewFollowsRoute(container *container.Container, router *echo.Group) *FollowsRoute {
+	return &FollowsRoute{
+		followsHandler: container.FollowsHandler,
+		router:         router,
+	}
+}
+
+func (r *FollowsRoute) Register() {
+	users := r.router.Group("/users", middlewares.RequireAuth)
+	feed := r.router.Group("/feed", middlewares.RequireAuth)
+
+	users.GET("/:id/follow", r.followsHandler.IsFollowing)
+	users.POST("/:id/follow", r.followsHandler.Follow)
+	users.DELETE("/:id/follow", r.followsHandler.Unfollow)
+	users.GET("/:id/followers", r.followsHandler.FindFollowers)
+	users.GET("/:id/following", r.followsHandler.FindFollowing)
+
+	feed.GET("", r.followsHandler.FindFeed)
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"backend/internal/http/handlers"
+	"github.com/labstack/echo/v4"
+)
+
+type JWKSRoute struct {
+	jwksHandler *handlers.JWKSHandler
+	router      *echo.Group
+}
+
+func NewJWKSRoute(router *echo.Group) *JWKSRoute {
+	jwksHandler := handlers.NewJWKSHandler()
+
+	return &JWKSRoute{
+		jwksHandler: jwksHandler,
+		router:      router,
+	}
+}
+
+func (r *JWKSRoute) Register() {
+	r.router.GET("/jwks.json", r.jwksHandler.JWKS)
+}
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"backend/internal/constants"
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type ModerationRoute struct {
+	moderationHandler *handlers.ModerationHandler
+	router            *echo.Group
+}
+
+func NewModerationRoute(container *container.Container, router *echo.Group) *ModerationRoute {
+	return &ModerationRoute{
+		moderationHandler: container.ModerationHandler,
+		router:            router,
+	}
+}
+
+func (r *ModerationRoute) Register() {
+	users := r.router.Group("/users", middlewares.RequireAuth)
+	me := r.router.Group("/me", middlewares.RequireAuth)
+	reports := r.router.Group("/reports", middlewares.RequireAuth)
+	admin := r.router.Group("/admin/moderation", middlewares.RequireAuth, middlewares.RequireRole(constants.RoleAdmin))
+
+	users.GET("/:id/block", r.moderationHandler.IsBlocked)
+	users.POST("/:id/block", r.moderationHandler.Block)
+	users.DELETE("/:id/block", r.moderationHandler.Unblock)
+
+	me.GET("/blocks", r.moderationHandler.FindBlocked)
+
+	reports.POST("", r.moderationHandler.Report)
+
+	admin.GET("/reports", r.moderationHandler.FindReportsForAdmin)
+	admin.POST("/actions", r.moderationHandler.TakeActionForAdmin)
+	admin.GET("/actions", r.moderationHandler.FindActionsForAdmin)
+}
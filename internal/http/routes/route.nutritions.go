@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -22,7 +23,7 @@ func NewNutritionsRoute(container *container.Container, router *echo.Group) *Nut
 func (r *NutritionsRoute) Register() {
 	nutritions := r.router.Group("/nutritions")
 
-	nutritions.Use(middlewares.RequireAuth)
+	nutritions.Use(middlewares.AllowAPIKey(constants.APIKeyResourceNutritions), middlewares.RequireAuth)
 	// nutritions routes
 	nutritions.GET("", r.nutritionsHandler.FindAllByOwnerID)
 	nutritions.GET("/:id", r.nutritionsHandler.FindByID)
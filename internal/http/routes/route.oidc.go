@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"github.com/labstack/echo/v4"
+)
+
+type OIDCRoute struct {
+	oidcHandler *handlers.OIDCHandler
+	router      *echo.Group
+}
+
+func NewOIDCRoute(container *container.Container, router *echo.Group) *OIDCRoute {
+	return &OIDCRoute{
+		oidcHandler: container.OIDCHandler,
+		router:      router,
+	}
+}
+
+func (r *OIDCRoute) Register() {
+	oidc := r.router.Group("/auth/oidc")
+
+	oidc.GET("/:provider/authorize", r.oidcHandler.Authorize)
+	oidc.POST("/:provider/callback", r.oidcHandler.Callback)
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type PaymentMethodsRoute struct {
+	paymentMethodsHandler *handlers.PaymentMethodsHandler
+	router                *echo.Group
+}
+
+func NewPaymentMethodsRoute(container *container.Container, router *echo.Group) *PaymentMethodsRoute {
+	return &PaymentMethodsRoute{
+		paymentMethodsHandler: container.PaymentMethodsHandler,
+		router:                router,
+	}
+}
+
+func (r *PaymentMethodsRoute) Register() {
+	paymentMethods := r.router.Group("/me/payment-methods", middlewares.RequireAuth)
+
+	paymentMethods.GET("", r.paymentMethodsHandler.FindAll)
+	paymentMethods.POST("", r.paymentMethodsHandler.Create)
+	paymentMethods.PUT("/:id/default", r.paymentMethodsHandler.SetDefault)
+	paymentMethods.DELETE("/:id", r.paymentMethodsHandler.Delete)
+}
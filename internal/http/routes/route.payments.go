@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"backend/internal/constants"
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type PaymentsRoute struct {
+	paymentsHandler *handlers.PaymentsHandler
+	fakeGateway     bool
+	router          *echo.Group
+}
+
+func NewPaymentsRoute(container *container.Container, router *echo.Group) *PaymentsRoute {
+	return &PaymentsRoute{
+		paymentsHandler: container.PaymentsHandler,
+		fakeGateway:     container.PaymentsService.UsesFakeGateway(),
+		router:          router,
+	}
+}
+
+func (r *PaymentsRoute) Register() {
+	workouts := r.router.Group("/workouts", middlewares.RequireAuth)
+	me := r.router.Group("/me/payments", middlewares.RequireAuth)
+	purchases := r.router.Group("/me/purchases", middlewares.RequireAuth)
+	admin := r.router.Group("/admin/payments", middlewares.RequireAuth, middlewares.RequireRole(constants.RoleAdmin))
+	payments := r.router.Group("/payments")
+
+	workouts.GET("/:workoutID/price", r.paymentsHandler.PreviewPrice)
+	workouts.POST("/:workoutID/purchase", r.paymentsHandler.PurchaseWorkout)
+
+	me.GET("", r.paymentsHandler.FindAll)
+	me.GET("/:id", r.paymentsHandler.FindByID)
+
+	purchases.GET("", r.paymentsHandler.FindAllPurchases)
+
+	admin.POST("/:id/refund", r.paymentsHandler.Refund)
+
+	// called by the gateway, authenticated by the webhook signature
+	payments.POST("/webhook", r.paymentsHandler.Webhook)
+	// 3-D Secure page of the fake gateway, only mounted when it is selected
+	if r.fakeGateway {
+		payments.POST("/fake/authenticate/:intentID", r.paymentsHandler.AuthenticateFakePayment)
+	}
+}
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type ProgramsRoute struct {
+	programsHandler *handlers.ProgramsHandler
+	router          *echo.Group
+}
+
+func NewProgramsRoute(container *container.Container, router *echo.Group) *ProgramsRoute {
+	return &ProgramsRoute{
+		programsHandler: container.ProgramsHandler,
+		router:          router,
+	}
+}
+
+func (r *ProgramsRoute) Register() {
+	programs := r.router.Group("/programs", middlewares.RequireAuth)
+	me := r.router.Group("/me", middlewares.RequireAuth)
+
+	programs.GET("", r.programsHandler.FindAll)
+	programs.POST("", r.programsHandler.Create)
+	programs.GET("/:id", r.programsHandler.FindByID)
+	programs.PATCH("/:id", r.programsHandler.Update)
+	programs.PUT("/:id/schedule", r.programsHandler.ReplaceSchedule)
+	programs.DELETE("/:id", r.programsHandler.Delete)
+
+	programs.GET("/:id/enrollment", r.programsHandler.FindEnrollment)
+	programs.POST("/:id/enrollment", r.programsHandler.Enroll)
+	programs.DELETE("/:id/enrollment", r.programsHandler.Unenroll)
+	programs.POST("/:id/enrollment/complete-day", r.programsHandler.CompleteDay)
+
+	me.GET("/programs", r.programsHandler.FindAllByCurrentUserID)
+	me.GET("/enrollments", r.programsHandler.FindEnrollments)
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"backend/internal/constants"
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type PromoCodesRoute struct {
+	promoCodesHandler *handlers.PromoCodesHandler
+	router            *echo.Group
+}
+
+func NewPromoCodesRoute(container *container.Container, router *echo.Group) *PromoCodesRoute {
+	return &PromoCodesRoute{
+		promoCodesHandler: container.PromoCodesHandler,
+		router:            router,
+	}
+}
+
+func (r *PromoCodesRoute) Register() {
+	me := r.router.Group("/me/promo-codes", middlewares.RequireAuth)
+	admin := r.router.Group("/admin/promo-codes", middlewares.RequireAuth, middlewares.RequireRole(constants.RoleAdmin))
+
+	me.GET("", r.promoCodesHandler.FindAll)
+	me.POST("", r.promoCodesHandler.Create)
+	me.PATCH("/:id", r.promoCodesHandler.Update)
+	me.DELETE("/:id", r.promoCodesHandler.Delete)
+
+	admin.GET("", r.promoCodesHandler.FindAllForAdmin)
+	admin.POST("", r.promoCodesHandler.CreateForAdmin)
+	admin.PATCH("/:id", r.promoCodesHandler.UpdateForAdmin)
+	admin.DELETE("/:id", r.promoCodesHandler.DeleteForAdmin)
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"backend/internal/constants"
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type RolesRoute struct {
+	rolesHandler *handlers.RolesHandler
+	router       *echo.Group
+}
+
+func NewRolesRoute(container *container.Container, router *echo.Group) *RolesRoute {
+	return &RolesRoute{
+		rolesHandler: container.RolesHandler,
+		router:       router,
+	}
+}
+
+func (r *RolesRoute) Register() {
+	roles := r.router.Group("/admin/roles")
+	users := r.router.Group("/admin/users")
+
+	roles.Use(middlewares.RequireAuth, middlewares.RequirePermission(constants.PermissionRolesManage))
+	users.Use(middlewares.RequireAuth, middlewares.RequirePermission(constants.PermissionRolesManage))
+
+	// admin roles routes
+	roles.GET("", r.rolesHandler.FindAll)
+
+	// admin users routes
+	users.GET("/:userID/roles", r.rolesHandler.FindAllByUserID)
+	users.POST("/:userID/roles", r.rolesHandler.GrantToUser)
+	users.DELETE("/:userID/roles/:role", r.rolesHandler.RevokeFromUser)
+}
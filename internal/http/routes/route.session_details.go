@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -24,9 +25,9 @@ func (r *SessionDetailsRoute) Register() {
 	admin := r.router.Group("/admin/session-details")
 	sessions := r.router.Group("/sessions")
 
-	sessionDetails.Use(middlewares.RequireAuth)
-	admin.Use(middlewares.RequireAuth)
-	sessions.Use(middlewares.RequireAuth)
+	sessionDetails.Use(middlewares.AllowAPIKey(constants.APIKeyResourceSessions), middlewares.RequireAuth)
+	admin.Use(middlewares.RequireAuth, middlewares.RequirePermission(constants.PermissionCatalogWrite))
+	sessions.Use(middlewares.AllowAPIKey(constants.APIKeyResourceSessions), middlewares.RequireAuth)
 
 	// session_details routes
 	sessionDetails.GET("", r.sessionDetailsHandler.FindAll)
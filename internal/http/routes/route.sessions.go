@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -22,7 +23,7 @@ func NewSessionsRoute(container *container.Container, router *echo.Group) *Sessi
 func (r *SessionsRoute) Register() {
 	sessions := r.router.Group("/sessions")
 
-	sessions.Use(middlewares.RequireAuth)
+	sessions.Use(middlewares.AllowAPIKey(constants.APIKeyResourceSessions), middlewares.RequireAuth)
 
 	// sessions routes
 	sessions.GET("", r.sessionsHandler.FindAllByOwnerID)
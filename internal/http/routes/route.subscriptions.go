@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"backend/internal/constants"
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type SubscriptionsRoute struct {
+	subscriptionsHandler *handlers.SubscriptionsHandler
+	router               *echo.Group
+}
+
+func NewSubscriptionsRoute(container *container.Container, router *echo.Group) *SubscriptionsRoute {
+	return &SubscriptionsRoute{
+		subscriptionsHandler: container.SubscriptionsHandler,
+		router:               router,
+	}
+}
+
+func (r *SubscriptionsRoute) Register() {
+	plans := r.router.Group("/subscription-plans", middlewares.RequireAuth)
+	admin := r.router.Group("/admin/subscription-plans", middlewares.RequireAuth, middlewares.RequireRole(constants.RoleAdmin))
+	me := r.router.Group("/me/subscription", middlewares.RequireAuth)
+
+	plans.GET("", r.subscriptionsHandler.FindAllPlans)
+
+	admin.GET("", r.subscriptionsHandler.FindAllPlansForAdmin)
+	admin.POST("", r.subscriptionsHandler.CreatePlan)
+	admin.PATCH("/:id", r.subscriptionsHandler.UpdatePlan)
+
+	me.GET("", r.subscriptionsHandler.FindCurrent)
+	me.POST("", r.subscriptionsHandler.Subscribe)
+	me.DELETE("", r.subscriptionsHandler.Cancel)
+	me.POST("/resume", r.subscriptionsHandler.Resume)
+	me.PUT("/payment-method", r.subscriptionsHandler.UpdatePaymentMethod)
+}
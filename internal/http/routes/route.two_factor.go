@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type TwoFactorRoute struct {
+	twoFactorHandler *handlers.TwoFactorHandler
+	router           *echo.Group
+}
+
+func NewTwoFactorRoute(container *container.Container, router *echo.Group) *TwoFactorRoute {
+	return &TwoFactorRoute{
+		twoFactorHandler: container.TwoFactorHandler,
+		router:           router,
+	}
+}
+
+func (r *TwoFactorRoute) Register() {
+	twoFactor := r.router.Group("/me/2fa", middlewares.RequireAuth)
+
+	twoFactor.GET("", r.twoFactorHandler.Status)
+	twoFactor.POST("/totp/enroll", r.twoFactorHandler.Enroll)
+	twoFactor.POST("/totp/confirm", r.twoFactorHandler.Confirm)
+	twoFactor.POST("/disable", r.twoFactorHandler.Disable)
+	twoFactor.POST("/recovery-codes", r.twoFactorHandler.RegenerateRecoveryCodes)
+}
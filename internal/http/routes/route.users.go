@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -31,7 +32,7 @@ func (r *UsersRoute) Register() {
 	users.GET("/:id", r.usersHandler.FindByID)
 	users.POST("", r.usersHandler.Save)
 	users.PATCH("/:id", r.usersHandler.Update)
-	users.DELETE("/:id", r.usersHandler.Delete)
+	users.DELETE("/:id", r.usersHandler.Delete, middlewares.RequireRole(constants.RoleAdmin))
 
 	me.Use(middlewares.RequireAuth)
 	me.GET("", r.usersHandler.Me)
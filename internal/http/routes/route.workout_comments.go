@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type WorkoutCommentsRoute struct {
+	workoutCommentsHandler *handlers.WorkoutCommentsHandler
+	router                 *echo.Group
+}
+
+func NewWorkoutCommentsRoute(container *container.Container, router *echo.Group) *WorkoutCommentsRoute {
+	return &WorkoutCommentsRoute{
+		workoutCommentsHandler: container.WorkoutCommentsHandler,
+		router:                 router,
+	}
+}
+
+func (r *WorkoutCommentsRoute) Register() {
+	workouts := r.router.Group("/workouts/:workoutID/comments", middlewares.RequireAuth)
+	comments := r.router.Group("/comments", middlewares.RequireAuth)
+
+	workouts.GET("", r.workoutCommentsHandler.FindAllByWorkoutID)
+	workouts.POST("", r.workoutCommentsHandler.Create)
+
+	comments.GET("/:id/replies", r.workoutCommentsHandler.FindReplies)
+	comments.PATCH("/:id", r.workoutCommentsHandler.Update)
+	comments.DELETE("/:id", r.workoutCommentsHandler.Delete)
+	comments.POST("/:id/pin", r.workoutCommentsHandler.Pin)
+	comments.DELETE("/:id/pin", r.workoutCommentsHandler.Unpin)
+}
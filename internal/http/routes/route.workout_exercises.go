@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -21,8 +22,10 @@ func NewWorkoutExercisesRoute(container *container.Container, router *echo.Group
 
 func (r *WorkoutExercisesRoute) Register() {
 	workoutExercises := r.router.Group("/workout-exercises")
+	workouts := r.router.Group("/workouts")
 
-	workoutExercises.Use(middlewares.RequireAuth)
+	workoutExercises.Use(middlewares.AllowAPIKey(constants.APIKeyResourceWorkouts), middlewares.RequireAuth)
+	workouts.Use(middlewares.AllowAPIKey(constants.APIKeyResourceWorkouts), middlewares.RequireAuth)
 
 	// workout_exercises routes
 	workoutExercises.POST("", r.workoutExercisesHandler.Save)
@@ -30,4 +33,7 @@ func (r *WorkoutExercisesRoute) Register() {
 	workoutExercises.GET("/:workoutExerciseID", r.workoutExercisesHandler.FindByID)
 	workoutExercises.PATCH("/:workoutExerciseID", r.workoutExercisesHandler.Update)
 	workoutExercises.DELETE("/:workoutExerciseID", r.workoutExercisesHandler.Delete)
+
+	// workouts routes
+	workouts.PUT("/:workoutID/exercise-order", r.workoutExercisesHandler.Reorder)
 }
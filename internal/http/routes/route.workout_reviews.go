@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"backend/internal/constants"
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type WorkoutReviewsRoute struct {
+	workoutReviewsHandler *handlers.WorkoutReviewsHandler
+	router                *echo.Group
+}
+
+func NewWorkoutReviewsRoute(container *container.Container, router *echo.Group) *WorkoutReviewsRoute {
+	return &WorkoutReviewsRoute{
+		workoutReviewsHandler: container.WorkoutReviewsHandler,
+		router:                router,
+	}
+}
+
+func (r *WorkoutReviewsRoute) Register() {
+	workouts := r.router.Group("/workouts/:workoutID/reviews", middlewares.AllowAPIKey(constants.APIKeyResourceWorkouts), middlewares.RequireAuth)
+	reviews := r.router.Group("/reviews", middlewares.RequireAuth)
+	admin := r.router.Group("/admin/reviews", middlewares.RequireAuth, middlewares.RequireRole(constants.RoleAdmin))
+
+	workouts.GET("", r.workoutReviewsHandler.FindAllByWorkoutID)
+	workouts.POST("", r.workoutReviewsHandler.Create)
+	workouts.PATCH("", r.workoutReviewsHandler.Update)
+	workouts.DELETE("", r.workoutReviewsHandler.Delete)
+
+	reviews.POST("/:id/report", r.workoutReviewsHandler.Report)
+
+	admin.GET("/reported", r.workoutReviewsHandler.FindAllReportedForAdmin)
+	admin.GET("/:id/reports", r.workoutReviewsHandler.FindReportsForAdmin)
+	admin.DELETE("/:id", r.workoutReviewsHandler.DeleteForAdmin)
+}
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"backend/internal/constants"
+	"backend/internal/container"
+	"backend/internal/http/handlers"
+	"backend/internal/http/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type WorkoutRevisionsRoute struct {
+	workoutRevisionsHandler *handlers.WorkoutRevisionsHandler
+	router                  *echo.Group
+}
+
+func NewWorkoutRevisionsRoute(container *container.Container, router *echo.Group) *WorkoutRevisionsRoute {
+	return &WorkoutRevisionsRoute{
+		workoutRevisionsHandler: container.WorkoutRevisionsHandler,
+		router:                  router,
+	}
+}
+
+func (r *WorkoutRevisionsRoute) Register() {
+	workouts := r.router.Group("/workouts", middlewares.AllowAPIKey(constants.APIKeyResourceWorkouts), middlewares.RequireAuth)
+
+	workouts.GET("/:id/revisions", r.workoutRevisionsHandler.FindAll)
+	workouts.GET("/:id/revisions/diff", r.workoutRevisionsHandler.Diff)
+	workouts.GET("/:id/revisions/:number", r.workoutRevisionsHandler.FindByNumber)
+	workouts.POST("/:id/revisions/:number/restore", r.workoutRevisionsHandler.Restore)
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"backend/internal/constants"
 	"backend/internal/container"
 	"backend/internal/http/handlers"
 	"backend/internal/http/middlewares"
@@ -23,10 +24,12 @@ func (r *WorkoutsRoute) Register() {
 	workouts := r.router.Group("/workouts")
 	users := r.router.Group("/users")
 	workoutsAi := r.router.Group("/workouts-ai")
+	members := r.router.Group("/members")
 
-	users.Use(middlewares.RequireAuth)
-	workouts.Use(middlewares.RequireAuth)
+	users.Use(middlewares.AllowAPIKey(constants.APIKeyResourceWorkouts), middlewares.RequireAuth)
+	workouts.Use(middlewares.AllowAPIKey(constants.APIKeyResourceWorkouts), middlewares.RequireAuth)
 	workoutsAi.Use(middlewares.RequireAuth)
+	members.Use(middlewares.AllowAPIKey(constants.APIKeyResourceWorkouts), middlewares.RequireAuth, middlewares.RequireMembership)
 
 	// users routes
 	users.GET("/:userID/workouts", r.workoutHandler.FindAllByOwnerID)
@@ -38,8 +41,12 @@ func (r *WorkoutsRoute) Register() {
 	workouts.PATCH("/:id", r.workoutHandler.Update)
 	workouts.DELETE("/:id", r.workoutHandler.Delete)
 	workouts.POST("/:id/like", r.workoutHandler.LikeWorkout)
-	workouts.GET("/:workoutID/copy", r.workoutHandler.Copy)
-	workouts.POST("/:workoutID/purchase", r.workoutHandler.PurchaseWorkout)
+	workouts.POST("/:workoutID/copy", r.workoutHandler.Copy)
+	workouts.GET("/:id/lineage", r.workoutHandler.FindLineage)
+	workouts.POST("/:id/merge-upstream", r.workoutHandler.MergeUpstream)
 
 	workoutsAi.POST("/generate", r.workoutHandler.GenerateWorkout)
+
+	// members routes
+	members.GET("/workouts", r.workoutHandler.FindAllMembersOnly)
 }
@@ -0,0 +1,503 @@
+package services
+
+import (
+	"archive/zip"
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/logger"
+	"backend/pkg/mailer"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	AccountExportPending    = "pending"
+	AccountExportProcessing = "processing"
+	AccountExportCompleted  = "completed"
+	AccountExportFailed     = "failed"
+	AccountExportExpired    = "expired"
+)
+
+// accountExportsBatchSize bounds how many exports a single worker pass builds,
+// and accountExportsStaleAfter is how long an export may stay in processing
+// before it is picked up again, e.g. after a crash.
+const (
+	accountExportsBatchSize  = 5
+	accountExportsStaleAfter = time.Hour
+)
+
+// ExportTable holds the rows of one table that belong to a user, in column order.
+type ExportTable struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+type AccountsRepository interface {
+	SaveExport(userID int) (records.AccountExports, error)
+	FindExport(userID int, id int) (records.AccountExports, error)
+	FindExportsByUserID(userID int) ([]records.AccountExports, error)
+	HasUnfinishedExport(userID int) (bool, error)
+	ClaimPendingExports(staleBefore time.Time, limit int) ([]records.AccountExports, error)
+	CompleteExport(id int, filePath string, expiresAt time.Time) error
+	FailExport(id int, reason string) error
+	FindExpiredExports(now time.Time) ([]records.AccountExports, error)
+	ExpireExport(id int) error
+	FindExportTables(userID int) ([]ExportTable, error)
+	ScheduleDeletion(userID int, requestedAt time.Time, scheduledAt time.Time) error
+	CancelDeletion(userID int) error
+	FindUsersDueForDeletion(now time.Time) ([]records.Users, error)
+	Purge(userID int) error
+}
+
+type AvatarStorage interface {
+	DeleteObject(key string, bucket string) error
+	DeletePrefix(prefix string, bucket string) error
+}
+
+// AccountsService covers the data protection side of an account: exporting
+// everything a user owns and deleting the account after a grace period.
+// Exports and scheduled deletions are carried out by Run in the background.
+type AccountsService struct {
+	repository      AccountsRepository
+	usersRepository UsersRepository
+	authService     *AuthService
+	avatarStorage   AvatarStorage
+	wake            chan struct{}
+}
+
+func NewAccountsService(repository AccountsRepository, usersRepository UsersRepository, authService *AuthService, avatarStorage AvatarStorage) *AccountsService {
+	return &AccountsService{
+		repository:      repository,
+		usersRepository: usersRepository,
+		authService:     authService,
+		avatarStorage:   avatarStorage,
+		wake:            make(chan struct{}, 1),
+	}
+}
+
+// RequestExport queues a ZIP export of the user's data. Only one export may be
+// in progress at a time.
+func (s *AccountsService) RequestExport(userID int) (data_transfers.AccountExportsResponse, int, error) {
+	unfinished, err := s.repository.HasUnfinishedExport(userID)
+	if err != nil {
+		return data_transfers.AccountExportsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - RequestExport - repository.HasUnfinishedExport: %w", err)
+	}
+	if unfinished {
+		return data_transfers.AccountExportsResponse{}, http.StatusConflict, errors.New("an export is already in progress")
+	}
+
+	export, err := s.repository.SaveExport(userID)
+	if err != nil {
+		return data_transfers.AccountExportsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - RequestExport - repository.SaveExport: %w", err)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return toAccountExportsResponse(export), http.StatusAccepted, nil
+}
+
+func (s *AccountsService) FindExports(userID int) ([]data_transfers.AccountExportsResponse, int, error) {
+	exports, err := s.repository.FindExportsByUserID(userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindExports - repository.FindExportsByUserID: %w", err)
+	}
+
+	responses := make([]data_transfers.AccountExportsResponse, 0, len(exports))
+	for _, export := range exports {
+		responses = append(responses, toAccountExportsResponse(export))
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+func (s *AccountsService) FindExport(userID int, id int) (data_transfers.AccountExportsResponse, int, error) {
+	export, err := s.repository.FindExport(userID, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.AccountExportsResponse{}, http.StatusNotFound, errors.New("export not found")
+		}
+		return data_transfers.AccountExportsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - FindExport - repository.FindExport: %w", err)
+	}
+
+	return toAccountExportsResponse(export), http.StatusOK, nil
+}
+
+// ExportFile returns the path of a finished export archive.
+func (s *AccountsService) ExportFile(userID int, id int) (string, int, error) {
+	export, err := s.repository.FindExport(userID, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return "", http.StatusNotFound, errors.New("export not found")
+		}
+		return "", http.StatusInternalServerError, fmt.Errorf("service - ExportFile - repository.FindExport: %w", err)
+	}
+
+	switch export.Status {
+	case AccountExportCompleted:
+	case AccountExportExpired:
+		return "", http.StatusGone, errors.New("export has expired, request a new one")
+	case AccountExportFailed:
+		return "", http.StatusConflict, errors.New("export failed, request a new one")
+	default:
+		return "", http.StatusConflict, errors.New("export is not ready yet")
+	}
+
+	return export.FilePath, http.StatusOK, nil
+}
+
+func (s *AccountsService) DeletionStatus(userID int) (data_transfers.AccountDeletionResponse, int, error) {
+	user, err := s.usersRepository.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.AccountDeletionResponse{}, http.StatusNotFound, errors.New("user not found")
+		}
+		return data_transfers.AccountDeletionResponse{}, http.StatusInternalServerError, fmt.Errorf("service - DeletionStatus - usersRepository.FindByID: %w", err)
+	}
+
+	if !user.DeletionScheduledAt.Valid {
+		return data_transfers.AccountDeletionResponse{}, http.StatusNotFound, errors.New("account deletion is not scheduled")
+	}
+
+	return data_transfers.AccountDeletionResponse{
+		RequestedAt: user.DeletionRequestedAt.Time,
+		ScheduledAt: user.DeletionScheduledAt.Time,
+	}, http.StatusOK, nil
+}
+
+// RequestDeletion schedules the account for deletion once the grace period has
+// passed and signs the user out everywhere. Signing in again and cancelling the
+// request keeps the account.
+func (s *AccountsService) RequestDeletion(userID int, deleteAccountRequest data_transfers.DeleteAccountRequest) (data_transfers.AccountDeletionResponse, int, error) {
+	user, err := s.usersRepository.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.AccountDeletionResponse{}, http.StatusNotFound, errors.New("user not found")
+		}
+		return data_transfers.AccountDeletionResponse{}, http.StatusInternalServerError, fmt.Errorf("service - RequestDeletion - usersRepository.FindByID: %w", err)
+	}
+
+	if user.DeletionScheduledAt.Valid {
+		return data_transfers.AccountDeletionResponse{}, http.StatusConflict, errors.New("account deletion is already scheduled")
+	}
+
+	if !helpers.ValidateHash(deleteAccountRequest.Password, user.Password) {
+		return data_transfers.AccountDeletionResponse{}, http.StatusForbidden, errors.New("invalid password")
+	}
+
+	requestedAt := time.Now()
+	scheduledAt := requestedAt.AddDate(0, 0, config.Config.AccountDeletionGracePeriod)
+	if err := s.repository.ScheduleDeletion(userID, requestedAt, scheduledAt); err != nil {
+		return data_transfers.AccountDeletionResponse{}, http.StatusInternalServerError, fmt.Errorf("service - RequestDeletion - repository.ScheduleDeletion: %w", err)
+	}
+
+	statusCode, err := s.authService.RevokeAllSessions(userID)
+	if err != nil {
+		return data_transfers.AccountDeletionResponse{}, statusCode, err
+	}
+
+	err = s.authService.Mailer.Send(mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Your account is scheduled for deletion",
+		Body: fmt.Sprintf(
+			"We received a request to delete your account.\n\nYour account and all of its data will be permanently deleted on %s. To keep your account, sign in at %s and cancel the deletion before then.\n",
+			scheduledAt.Format("January 2, 2006"), config.Config.AppURL,
+		),
+	})
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - RequestDeletion - Mailer.Send: %v", err)
+	}
+
+	return data_transfers.AccountDeletionResponse{
+		RequestedAt: requestedAt,
+		ScheduledAt: scheduledAt,
+	}, http.StatusAccepted, nil
+}
+
+func (s *AccountsService) CancelDeletion(userID int) (int, error) {
+	err := s.repository.CancelDeletion(userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("account deletion is not scheduled")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - CancelDeletion - repository.CancelDeletion: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Purge permanently deletes the user. Rows owned by the user go with it through
+// the foreign key cascades; avatars and export archives are removed first so a
+// failure leaves the account in place to be retried.
+func (s *AccountsService) Purge(userID int) (int, error) {
+	user, err := s.usersRepository.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("user not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Purge - usersRepository.FindByID: %w", err)
+	}
+
+	if config.Config.AWSBucketName != "" {
+		// Avatars uploaded before they were kept under the user's prefix are
+		// only known through the profile.
+		if key, ok := AvatarObjectKey(user.Avatar, config.Config.AWSBucketName); ok {
+			if err := s.avatarStorage.DeleteObject(key, config.Config.AWSBucketName); err != nil {
+				return http.StatusInternalServerError, fmt.Errorf("service - Purge - avatarStorage.DeleteObject: %w", err)
+			}
+		}
+		if err := s.avatarStorage.DeletePrefix(AvatarObjectPrefix(userID), config.Config.AWSBucketName); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("service - Purge - avatarStorage.DeletePrefix: %w", err)
+		}
+	}
+
+	exports, err := s.repository.FindExportsByUserID(userID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - Purge - repository.FindExportsByUserID: %w", err)
+	}
+	for _, export := range exports {
+		if err := removeExportFile(export.FilePath); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("service - Purge - removeExportFile: %w", err)
+		}
+	}
+
+	if err := s.repository.Purge(userID); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("user not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Purge - repository.Purge: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Run builds queued exports, removes expired archives and purges accounts whose
+// grace period has passed, every interval and whenever an export is requested,
+// until ctx is cancelled.
+func (s *AccountsService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.processExports()
+		s.removeExpiredExports()
+		s.purgeScheduledDeletions()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *AccountsService) processExports() {
+	exports, err := s.repository.ClaimPendingExports(time.Now().Add(-accountExportsStaleAfter), accountExportsBatchSize)
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - processExports - repository.ClaimPendingExports: %v", err)
+		return
+	}
+
+	for _, export := range exports {
+		if err := s.buildExport(export); err != nil {
+			logger.ZeroLogger.Error().Msgf("service - processExports - buildExport %d: %v", export.ID, err)
+			if err := s.repository.FailExport(export.ID, "the export could not be built"); err != nil {
+				logger.ZeroLogger.Error().Msgf("service - processExports - repository.FailExport: %v", err)
+			}
+		}
+	}
+}
+
+func (s *AccountsService) buildExport(export records.AccountExports) error {
+	tables, err := s.repository.FindExportTables(export.UserID)
+	if err != nil {
+		return fmt.Errorf("repository.FindExportTables: %w", err)
+	}
+
+	if err := os.MkdirAll(config.Config.AccountExportsDir, 0o700); err != nil {
+		return fmt.Errorf("os.MkdirAll: %w", err)
+	}
+
+	suffix, err := helpers.GenerateRandomString(8)
+	if err != nil {
+		return fmt.Errorf("helpers.GenerateRandomString: %w", err)
+	}
+	path := filepath.Join(config.Config.AccountExportsDir, fmt.Sprintf("%d-%d-%s.zip", export.UserID, export.ID, suffix))
+
+	if err := writeExportArchive(path, tables); err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("writeExportArchive: %w", err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour * time.Duration(config.Config.AccountExportExpiresIn))
+	if err := s.repository.CompleteExport(export.ID, path, expiresAt); err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("repository.CompleteExport: %w", err)
+	}
+
+	user, err := s.usersRepository.FindByID(export.UserID)
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - buildExport - usersRepository.FindByID: %v", err)
+		return nil
+	}
+
+	err = s.authService.Mailer.Send(mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Your data export is ready",
+		Body: fmt.Sprintf(
+			"The export of your account data is ready.\n\nSign in at %s to download it. The download is available for %d hours.\n",
+			config.Config.AppURL, config.Config.AccountExportExpiresIn,
+		),
+	})
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - buildExport - Mailer.Send: %v", err)
+	}
+
+	return nil
+}
+
+func (s *AccountsService) removeExpiredExports() {
+	exports, err := s.repository.FindExpiredExports(time.Now())
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - removeExpiredExports - repository.FindExpiredExports: %v", err)
+		return
+	}
+
+	for _, export := range exports {
+		if err := removeExportFile(export.FilePath); err != nil {
+			logger.ZeroLogger.Error().Msgf("service - removeExpiredExports - removeExportFile: %v", err)
+			continue
+		}
+		if err := s.repository.ExpireExport(export.ID); err != nil {
+			logger.ZeroLogger.Error().Msgf("service - removeExpiredExports - repository.ExpireExport: %v", err)
+		}
+	}
+}
+
+func (s *AccountsService) purgeScheduledDeletions() {
+	users, err := s.repository.FindUsersDueForDeletion(time.Now())
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - purgeScheduledDeletions - repository.FindUsersDueForDeletion: %v", err)
+		return
+	}
+
+	for _, user := range users {
+		if _, err := s.Purge(user.ID); err != nil {
+			logger.ZeroLogger.Error().Msgf("service - purgeScheduledDeletions - Purge %d: %v", user.ID, err)
+			continue
+		}
+		logger.ZeroLogger.Info().Msgf("Deleted account %d as scheduled", user.ID)
+	}
+}
+
+// writeExportArchive writes every table twice, as <table>.json and <table>.csv.
+func writeExportArchive(path string, tables []ExportTable) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	for _, table := range tables {
+		objects := make([]map[string]interface{}, 0, len(table.Rows))
+		for _, row := range table.Rows {
+			object := make(map[string]interface{}, len(table.Columns))
+			for i, column := range table.Columns {
+				object[column] = row[i]
+			}
+			objects = append(objects, object)
+		}
+
+		jsonFile, err := archive.Create(table.Name + ".json")
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(jsonFile)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(objects); err != nil {
+			return err
+		}
+
+		csvFile, err := archive.Create(table.Name + ".csv")
+		if err != nil {
+			return err
+		}
+		writer := csv.NewWriter(csvFile)
+		if err := writer.Write(table.Columns); err != nil {
+			return err
+		}
+		for _, row := range table.Rows {
+			cells := make([]string, len(row))
+			for i, value := range row {
+				cells[i] = formatExportValue(value)
+			}
+			if err := writer.Write(cells); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+func formatExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func removeExportFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func toAccountExportsResponse(export records.AccountExports) data_transfers.AccountExportsResponse {
+	response := data_transfers.AccountExportsResponse{
+		ID:        export.ID,
+		Status:    export.Status,
+		Error:     export.Error,
+		CreatedAt: export.CreatedAt,
+	}
+	if export.CompletedAt.Valid {
+		response.CompletedAt = &export.CompletedAt.Time
+	}
+	if export.ExpiresAt.Valid {
+		response.ExpiresAt = &export.ExpiresAt.Time
+	}
+
+	return response
+}
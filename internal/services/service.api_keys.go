@@ -0,0 +1,182 @@
+package services
+
+import (
+	"backend/internal/constants"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/logger"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+type APIKeysRepository interface {
+	Save(apiKey records.APIKeys) (records.APIKeys, error)
+	FindAllByUserID(userID int) ([]records.APIKeys, error)
+	FindByKeyHash(keyHash string) (records.APIKeys, error)
+	TouchLastUsed(id int) error
+	Delete(userID int, id int) error
+}
+
+// APIKeysService manages long-lived personal keys for integrations. A key looks
+// like "fyp_<prefix>_<secret>"; the prefix identifies it in listings and only
+// a SHA-256 hash of the whole key is stored.
+type APIKeysService struct {
+	repository   APIKeysRepository
+	usersService *UsersService
+}
+
+func NewAPIKeysService(repository APIKeysRepository, usersService *UsersService) *APIKeysService {
+	return &APIKeysService{repository, usersService}
+}
+
+func (s *APIKeysService) Create(userID int, createRequest data_transfers.CreateAPIKeyRequest) (data_transfers.CreateAPIKeyResponse, int, error) {
+	scopes, err := normalizeAPIKeyScopes(createRequest.Scopes)
+	if err != nil {
+		return data_transfers.CreateAPIKeyResponse{}, http.StatusBadRequest, err
+	}
+
+	prefix, err := helpers.GenerateRandomString(4)
+	if err != nil {
+		return data_transfers.CreateAPIKeyResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - helpers.GenerateRandomString: %w", err)
+	}
+	secret, err := helpers.GenerateRandomString(24)
+	if err != nil {
+		return data_transfers.CreateAPIKeyResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - helpers.GenerateRandomString: %w", err)
+	}
+	key := constants.APIKeyPrefix + prefix + "_" + secret
+
+	apiKey := records.APIKeys{
+		UserID:  userID,
+		Name:    createRequest.Name,
+		Prefix:  prefix,
+		KeyHash: hashVerificationToken(key),
+		Scopes:  scopes,
+	}
+	if createRequest.ExpiresInDays > 0 {
+		apiKey.ExpiresAt = sql.NullTime{Time: time.Now().AddDate(0, 0, createRequest.ExpiresInDays), Valid: true}
+	}
+
+	apiKey, err = s.repository.Save(apiKey)
+	if err != nil {
+		return data_transfers.CreateAPIKeyResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - repository.Save: %w", err)
+	}
+
+	return data_transfers.CreateAPIKeyResponse{
+		APIKeysResponse: toAPIKeysResponse(apiKey),
+		Key:             key,
+	}, http.StatusCreated, nil
+}
+
+func (s *APIKeysService) FindAllByUserID(userID int) ([]data_transfers.APIKeysResponse, int, error) {
+	apiKeys, err := s.repository.FindAllByUserID(userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAllByUserID - repository.FindAllByUserID: %w", err)
+	}
+
+	responses := make([]data_transfers.APIKeysResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		responses = append(responses, toAPIKeysResponse(apiKey))
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+func (s *APIKeysService) Revoke(userID int, id int) (int, error) {
+	err := s.repository.Delete(userID, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("api key not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Revoke - repository.Delete: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// AuthenticateAPIKey resolves a raw key to its owner and scopes. Keys of
+// banned users are turned away like their sign-ins.
+func (s *APIKeysService) AuthenticateAPIKey(key string) (int, []string, int, error) {
+	apiKey, err := s.repository.FindByKeyHash(hashVerificationToken(key))
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return 0, nil, http.StatusUnauthorized, ErrInvalidAPIKey
+		}
+		return 0, nil, http.StatusInternalServerError, fmt.Errorf("service - AuthenticateAPIKey - repository.FindByKeyHash: %w", err)
+	}
+
+	if apiKey.ExpiresAt.Valid && apiKey.ExpiresAt.Time.Before(time.Now()) {
+		return 0, nil, http.StatusUnauthorized, errors.New("api key has expired")
+	}
+
+	banned, statusCode, err := s.usersService.IsBanned(apiKey.UserID)
+	if err != nil {
+		return 0, nil, statusCode, err
+	}
+	if banned {
+		return 0, nil, http.StatusForbidden, ErrUserBanned
+	}
+
+	// Usage tracking is best effort and never blocks the request.
+	if err := s.repository.TouchLastUsed(apiKey.ID); err != nil {
+		logger.ZeroLogger.Error().Msgf("service - AuthenticateAPIKey - repository.TouchLastUsed: %v", err)
+	}
+
+	return apiKey.UserID, apiKey.Scopes, http.StatusOK, nil
+}
+
+// APIKeyAllows reports whether the scopes grant the access to the resource.
+// Write access includes read access.
+func APIKeyAllows(scopes []string, resource string, access string) bool {
+	if slices.Contains(scopes, resource+":"+constants.APIKeyAccessWrite) {
+		return true
+	}
+
+	return access == constants.APIKeyAccessRead && slices.Contains(scopes, resource+":"+constants.APIKeyAccessRead)
+}
+
+func normalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+
+		resource, access, ok := strings.Cut(scope, ":")
+		if !ok || !slices.Contains(constants.APIKeyResources, resource) ||
+			(access != constants.APIKeyAccessRead && access != constants.APIKeyAccessWrite) {
+			return nil, fmt.Errorf("invalid scope %q, expected <resource>:read or <resource>:write with resource one of %s",
+				scope, strings.Join(constants.APIKeyResources, ", "))
+		}
+
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+
+	return normalized, nil
+}
+
+func toAPIKeysResponse(apiKey records.APIKeys) data_transfers.APIKeysResponse {
+	response := data_transfers.APIKeysResponse{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    constants.APIKeyPrefix + apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if apiKey.ExpiresAt.Valid {
+		response.ExpiresAt = &apiKey.ExpiresAt.Time
+	}
+	if apiKey.LastUsedAt.Valid {
+		response.LastUsedAt = &apiKey.LastUsedAt.Time
+	}
+
+	return response
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type memoryAPIKeysRepository struct {
+	APIKeysRepository
+	apiKeys []records.APIKeys
+}
+
+func (r *memoryAPIKeysRepository) Save(apiKey records.APIKeys) (records.APIKeys, error) {
+	apiKey.ID = len(r.apiKeys) + 1
+	apiKey.CreatedAt = time.Now()
+	r.apiKeys = append(r.apiKeys, apiKey)
+	return apiKey, nil
+}
+
+func (r *memoryAPIKeysRepository) FindByKeyHash(keyHash string) (records.APIKeys, error) {
+	for _, apiKey := range r.apiKeys {
+		if apiKey.KeyHash == keyHash {
+			return apiKey, nil
+		}
+	}
+	return records.APIKeys{}, repositories.ErrorRowNotFound
+}
+
+func (r *memoryAPIKeysRepository) TouchLastUsed(id int) error {
+	return nil
+}
+
+func TestAPIKeysServiceRejectsKeysOfBannedUsers(t *testing.T) {
+	users := &memoryUsersRepository{users: map[int]records.Users{
+		1: {Record: records.Record{ID: 1}, Email: "member@example.com"},
+	}}
+	service := NewAPIKeysService(&memoryAPIKeysRepository{}, NewUsersService(users))
+
+	created, statusCode, err := service.Create(1, data_transfers.CreateAPIKeyRequest{Name: "sync", Scopes: []string{"workouts:read"}})
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Create = %d, %v", statusCode, err)
+	}
+
+	userID, _, statusCode, err := service.AuthenticateAPIKey(created.Key)
+	if err != nil || statusCode != http.StatusOK || userID != 1 {
+		t.Fatalf("AuthenticateAPIKey = %d, %d, %v, want user 1", userID, statusCode, err)
+	}
+
+	user := users.users[1]
+	user.BannedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	users.users[1] = user
+
+	_, _, statusCode, err = service.AuthenticateAPIKey(created.Key)
+	if !errors.Is(err, ErrUserBanned) || statusCode != http.StatusForbidden {
+		t.Errorf("AuthenticateAPIKey of a banned user = %d, %v, want 403", statusCode, err)
+	}
+}
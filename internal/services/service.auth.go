@@ -1,51 +1,118 @@
 package services
 
 import (
+	"backend/internal/config"
+	"backend/internal/constants"
 	"backend/internal/datasources/records"
 	"backend/internal/helpers"
 	"backend/internal/http/data_transfers"
 	"backend/pkg/jwt"
+	"backend/pkg/logger"
+	"backend/pkg/mailer"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jinzhu/copier"
 	"net/http"
+	"slices"
+	"time"
 )
 
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+var ErrUserBanned = errors.New("this account has been banned")
+
 type AuthService struct {
-	UsersService  *UsersService
-	TokensService *TokensService
+	UsersService              *UsersService
+	TokensService             *TokensService
+	RolesService              *RolesService
+	VerificationTokensService *VerificationTokensService
+	TwoFactorService          *TwoFactorService
+	SignInAttemptsService     *SignInAttemptsService
+	Mailer                    mailer.Mailer
 }
 
-func NewAuthService(usersService *UsersService, tokensService *TokensService) *AuthService {
+func NewAuthService(
+	usersService *UsersService,
+	tokensService *TokensService,
+	rolesService *RolesService,
+	verificationTokensService *VerificationTokensService,
+	twoFactorService *TwoFactorService,
+	signInAttemptsService *SignInAttemptsService,
+	mailer mailer.Mailer,
+) *AuthService {
 	return &AuthService{
-		UsersService:  usersService,
-		TokensService: tokensService,
+		UsersService:              usersService,
+		TokensService:             tokensService,
+		RolesService:              rolesService,
+		VerificationTokensService: verificationTokensService,
+		TwoFactorService:          twoFactorService,
+		SignInAttemptsService:     signInAttemptsService,
+		Mailer:                    mailer,
 	}
 }
 
-func (s *AuthService) SignIn(signInRequest data_transfers.SignInRequest) (string, string, int, error) {
+// SignIn checks the password. Accounts with two-factor authentication enabled
+// receive a challenge token instead of a token pair, which has to be completed
+// through SignInTwoFactor.
+func (s *AuthService) SignIn(signInRequest data_transfers.SignInRequest, device data_transfers.DeviceInfo) (string, string, string, int, error) {
+	statusCode, err := s.SignInAttemptsService.Check(signInRequest.Email, device.IPAddress)
+	if err != nil {
+		return "", "", "", statusCode, err
+	}
+
 	user, statusCode, err := s.UsersService.FindByEmail(signInRequest.Email)
 	if err != nil {
-		return "", "", statusCode, err
+		if statusCode == http.StatusNotFound {
+			s.registerSignInFailure(signInRequest.Email, 0, device, SignInFailureUnknownEmail)
+			return "", "", "", http.StatusUnauthorized, ErrInvalidCredentials
+		}
+		return "", "", "", statusCode, err
 	}
 
 	if !helpers.ValidateHash(signInRequest.Password, user.Password) {
-		return "", "", http.StatusBadRequest, nil
+		s.registerSignInFailure(user.Email, user.ID, device, SignInFailureInvalidPassword)
+		return "", "", "", http.StatusUnauthorized, ErrInvalidCredentials
 	}
 
-	accessToken, refreshToken, err := jwt.GenerateTokenPair(user.ID, false)
+	device.DeviceName = signInRequest.DeviceName
+	return s.signInUser(user.ID, user.Email, device)
+}
+
+func (s *AuthService) SignInTwoFactor(signInTwoFactorRequest data_transfers.SignInTwoFactorRequest, device data_transfers.DeviceInfo) (string, string, int, error) {
+	claims, err := jwt.ParseChallengeToken(signInTwoFactorRequest.ChallengeToken)
 	if err != nil {
-		return "", "", http.StatusInternalServerError, err
+		return "", "", http.StatusUnauthorized, errors.New("challenge token is invalid or has expired")
 	}
 
-	tokenRecord := records.Tokens{
-		Token:  refreshToken,
-		UserID: user.ID,
+	user, statusCode, err := s.UsersService.FindByID(claims.UserID)
+	if err != nil {
+		return "", "", statusCode, err
 	}
-	statusCode, err = s.TokensService.Save(tokenRecord)
+
+	// Codes count towards the same limits as passwords, otherwise a leaked
+	// password would allow guessing codes indefinitely.
+	statusCode, err = s.SignInAttemptsService.Check(user.Email, device.IPAddress)
 	if err != nil {
 		return "", "", statusCode, err
 	}
 
+	statusCode, err = s.TwoFactorService.VerifyCode(user.ID, signInTwoFactorRequest.Code)
+	if err != nil {
+		if statusCode == http.StatusUnauthorized {
+			s.registerSignInFailure(user.Email, user.ID, device, SignInFailureInvalidTwoFactorCode)
+		}
+		return "", "", statusCode, err
+	}
+
+	device.DeviceName = signInTwoFactorRequest.DeviceName
+	accessToken, refreshToken, statusCode, err := s.issueTokens(user.ID, device)
+	if err != nil {
+		return "", "", statusCode, err
+	}
+
+	s.registerSignInSuccess(user.Email)
+
 	return accessToken, refreshToken, http.StatusOK, nil
 }
 
@@ -62,6 +129,15 @@ func (s *AuthService) SignUp(signUpRequest data_transfers.SignUpRequest) (int, e
 		return statusCode, err
 	}
 
+	// The account is usable right away, so a failed verification email is only logged.
+	user, _, err := s.UsersService.FindByEmail(signUpRequest.Email)
+	if err == nil {
+		_, err = s.sendVerificationEmail(user.ID, user.Email)
+	}
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - SignUp - sendVerificationEmail: %v", err)
+	}
+
 	return http.StatusCreated, nil
 }
 
@@ -73,3 +149,337 @@ func (s *AuthService) SignOut(token string) (int, error) {
 
 	return http.StatusOK, nil
 }
+
+// Refresh exchanges a stored refresh token for a new token pair and rotates it.
+// Presenting a token that has already been rotated revokes its whole family,
+// since that can only happen when the token was stolen and replayed.
+func (s *AuthService) Refresh(refreshToken string, device data_transfers.DeviceInfo) (string, string, int, error) {
+	tokenRecord, statusCode, err := s.TokensService.FindByToken(refreshToken)
+	if err != nil {
+		if statusCode == http.StatusNotFound {
+			return "", "", http.StatusUnauthorized, err
+		}
+		return "", "", statusCode, err
+	}
+
+	if tokenRecord.DeletedAt.Valid {
+		return "", "", http.StatusUnauthorized, errors.New("refresh token has been revoked")
+	}
+
+	if tokenRecord.RotatedAt.Valid {
+		statusCode, err = s.revokeTokenFamily(tokenRecord)
+		if err != nil {
+			return "", "", statusCode, err
+		}
+		return "", "", http.StatusUnauthorized, ErrRefreshTokenReused
+	}
+
+	claims, err := jwt.ParseToken(refreshToken)
+	if err != nil || claims.UserID != tokenRecord.UserID {
+		return "", "", http.StatusUnauthorized, errors.New("invalid refresh token")
+	}
+
+	// Tokens issued before rotation was introduced have no family yet.
+	familyID := tokenRecord.FamilyID
+	if familyID == "" {
+		familyID, err = helpers.GenerateRandomString(16)
+		if err != nil {
+			return "", "", http.StatusInternalServerError, fmt.Errorf("service - Refresh - helpers.GenerateRandomString: %w", err)
+		}
+	}
+
+	// Roles are reloaded so that grants and revocations apply on the next refresh.
+	accessToken, newRefreshToken, statusCode, err := s.generateTokenPair(tokenRecord.UserID, familyID)
+	if err != nil {
+		return "", "", statusCode, err
+	}
+
+	statusCode, err = s.TokensService.Rotate(refreshToken, records.Tokens{
+		Token:      newRefreshToken,
+		UserID:     tokenRecord.UserID,
+		FamilyID:   familyID,
+		DeviceName: tokenRecord.DeviceName,
+		UserAgent:  device.UserAgent,
+		IPAddress:  device.IPAddress,
+		SignedInAt: tokenRecord.SignedInAt,
+	})
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			if revokeStatusCode, revokeErr := s.revokeTokenFamily(tokenRecord); revokeErr != nil {
+				return "", "", revokeStatusCode, revokeErr
+			}
+		}
+		return "", "", statusCode, err
+	}
+
+	return accessToken, newRefreshToken, http.StatusOK, nil
+}
+
+// FindSessions lists the signed-in devices of a user. currentSessionID marks
+// the session the request was made from.
+func (s *AuthService) FindSessions(userID int, currentSessionID string) ([]data_transfers.LoginSessionResponse, int, error) {
+	jwtConfig, err := jwt.GetConfig()
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindSessions - jwt.GetConfig: %w", err)
+	}
+
+	// Refresh tokens older than their TTL can no longer be used, so their sessions are over.
+	tokenRecords, statusCode, err := s.TokensService.FindActiveByUserID(userID, time.Now().Add(-jwtConfig.RefreshTokenTTL))
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	sessions := make([]data_transfers.LoginSessionResponse, 0, len(tokenRecords))
+	for _, tokenRecord := range tokenRecords {
+		sessions = append(sessions, data_transfers.LoginSessionResponse{
+			ID:         tokenRecord.FamilyID,
+			DeviceName: tokenRecord.DeviceName,
+			UserAgent:  tokenRecord.UserAgent,
+			IPAddress:  tokenRecord.IPAddress,
+			SignedInAt: tokenRecord.SignedInAt,
+			LastUsedAt: tokenRecord.LastUsedAt,
+			Current:    tokenRecord.FamilyID == currentSessionID,
+		})
+	}
+
+	return sessions, http.StatusOK, nil
+}
+
+func (s *AuthService) RevokeSession(userID int, sessionID string) (int, error) {
+	tokenRecord, statusCode, err := s.TokensService.FindActiveByFamilyID(sessionID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if tokenRecord.UserID != userID {
+		return http.StatusNotFound, errors.New("session not found")
+	}
+
+	return s.TokensService.DeleteByFamilyID(sessionID)
+}
+
+// RevokeAllSessions signs the user out everywhere, including the current device.
+func (s *AuthService) RevokeAllSessions(userID int) (int, error) {
+	return s.TokensService.DeleteByUserID(userID)
+}
+
+// IsSessionActive reports whether access tokens bound to the session may still be used.
+func (s *AuthService) IsSessionActive(sessionID string) (bool, int, error) {
+	_, statusCode, err := s.TokensService.FindActiveByFamilyID(sessionID)
+	if err != nil {
+		if statusCode == http.StatusNotFound {
+			return false, http.StatusOK, nil
+		}
+		return false, statusCode, err
+	}
+
+	return true, http.StatusOK, nil
+}
+
+// ForgotPassword emails a password reset link. It reports success for unknown
+// emails as well so that the endpoint cannot be used to enumerate accounts.
+func (s *AuthService) ForgotPassword(forgotPasswordRequest data_transfers.ForgotPasswordRequest) (int, error) {
+	user, statusCode, err := s.UsersService.FindByEmail(forgotPasswordRequest.Email)
+	if err != nil {
+		if statusCode == http.StatusNotFound {
+			return http.StatusOK, nil
+		}
+		return statusCode, err
+	}
+
+	ttl := time.Minute * time.Duration(config.Config.PasswordResetExpiresIn)
+	token, statusCode, err := s.VerificationTokensService.Issue(user.ID, VerificationPurposePasswordReset, ttl)
+	if err != nil {
+		return statusCode, err
+	}
+
+	err = s.Mailer.Send(mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"We received a request to reset your password.\n\nOpen the link below to choose a new one:\n%s/reset-password?token=%s\n\nThe link expires in %d minutes. If you did not request a reset, you can ignore this email.\n",
+			config.Config.AppURL, token, config.Config.PasswordResetExpiresIn,
+		),
+	})
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - ForgotPassword - Mailer.Send: %v", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// ResetPassword sets a new password and signs the user out of every device.
+func (s *AuthService) ResetPassword(resetPasswordRequest data_transfers.ResetPasswordRequest) (int, error) {
+	token, statusCode, err := s.VerificationTokensService.Consume(resetPasswordRequest.Token, VerificationPurposePasswordReset)
+	if err != nil {
+		return statusCode, err
+	}
+
+	statusCode, err = s.UsersService.ChangePassword(token.UserID, resetPasswordRequest.Password)
+	if err != nil {
+		return statusCode, err
+	}
+
+	statusCode, err = s.TokensService.DeleteByUserID(token.UserID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *AuthService) VerifyEmail(verifyEmailRequest data_transfers.VerifyEmailRequest) (int, error) {
+	token, statusCode, err := s.VerificationTokensService.Consume(verifyEmailRequest.Token, VerificationPurposeEmailVerification)
+	if err != nil {
+		return statusCode, err
+	}
+
+	return s.UsersService.MarkEmailVerified(token.UserID)
+}
+
+func (s *AuthService) ResendVerificationEmail(userID int) (int, error) {
+	verified, statusCode, err := s.UsersService.IsEmailVerified(userID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if verified {
+		return http.StatusConflict, errors.New("email is already verified")
+	}
+
+	user, statusCode, err := s.UsersService.FindByID(userID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	return s.sendVerificationEmail(user.ID, user.Email)
+}
+
+func (s *AuthService) sendVerificationEmail(userID int, email string) (int, error) {
+	ttl := time.Hour * time.Duration(config.Config.EmailVerificationExpiresIn)
+	token, statusCode, err := s.VerificationTokensService.Issue(userID, VerificationPurposeEmailVerification, ttl)
+	if err != nil {
+		return statusCode, err
+	}
+
+	err = s.Mailer.Send(mailer.Message{
+		To:      []string{email},
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Welcome!\n\nPlease confirm your email address by opening the link below:\n%s/verify-email?token=%s\n\nThe link expires in %d hours.\n",
+			config.Config.AppURL, token, config.Config.EmailVerificationExpiresIn,
+		),
+	})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - sendVerificationEmail - Mailer.Send: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// signInUser finishes a sign-in whose first factor has been checked. Banned
+// users are turned away, and users with two-factor authentication enabled get
+// a challenge token instead of tokens.
+func (s *AuthService) signInUser(userID int, email string, device data_transfers.DeviceInfo) (string, string, string, int, error) {
+	banned, statusCode, err := s.UsersService.IsBanned(userID)
+	if err != nil {
+		return "", "", "", statusCode, err
+	}
+	if banned {
+		return "", "", "", http.StatusForbidden, ErrUserBanned
+	}
+
+	twoFactorEnabled, statusCode, err := s.TwoFactorService.IsEnabled(userID)
+	if err != nil {
+		return "", "", "", statusCode, err
+	}
+
+	if twoFactorEnabled {
+		ttl := time.Minute * time.Duration(config.Config.TwoFactorChallengeExpiresIn)
+		challengeToken, err := jwt.GenerateChallengeToken(userID, ttl)
+		if err != nil {
+			return "", "", "", http.StatusInternalServerError, fmt.Errorf("service - signInUser - jwt.GenerateChallengeToken: %w", err)
+		}
+		return "", "", challengeToken, http.StatusOK, nil
+	}
+
+	accessToken, refreshToken, statusCode, err := s.issueTokens(userID, device)
+	if err != nil {
+		return "", "", "", statusCode, err
+	}
+
+	s.registerSignInSuccess(email)
+
+	return accessToken, refreshToken, "", http.StatusOK, nil
+}
+
+// registerSignInFailure records a failed attempt. Failing to record it is only
+// logged, so that the caller still gets the original error.
+func (s *AuthService) registerSignInFailure(email string, userID int, device data_transfers.DeviceInfo, reason string) {
+	_, err := s.SignInAttemptsService.RegisterFailure(records.SignInAttempts{
+		Email:     email,
+		UserID:    sql.NullInt64{Int64: int64(userID), Valid: userID != 0},
+		IPAddress: device.IPAddress,
+		UserAgent: device.UserAgent,
+		Reason:    reason,
+	})
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - registerSignInFailure - SignInAttemptsService.RegisterFailure: %v", err)
+	}
+}
+
+func (s *AuthService) registerSignInSuccess(email string) {
+	_, err := s.SignInAttemptsService.RegisterSuccess(email)
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - registerSignInSuccess - SignInAttemptsService.RegisterSuccess: %v", err)
+	}
+}
+
+// issueTokens starts a new session, identified by a fresh refresh token family.
+func (s *AuthService) issueTokens(userID int, device data_transfers.DeviceInfo) (string, string, int, error) {
+	familyID, err := helpers.GenerateRandomString(16)
+	if err != nil {
+		return "", "", http.StatusInternalServerError, fmt.Errorf("service - issueTokens - helpers.GenerateRandomString: %w", err)
+	}
+
+	accessToken, refreshToken, statusCode, err := s.generateTokenPair(userID, familyID)
+	if err != nil {
+		return "", "", statusCode, err
+	}
+
+	statusCode, err = s.TokensService.Save(records.Tokens{
+		Token:      refreshToken,
+		UserID:     userID,
+		FamilyID:   familyID,
+		DeviceName: device.DeviceName,
+		UserAgent:  device.UserAgent,
+		IPAddress:  device.IPAddress,
+	})
+	if err != nil {
+		return "", "", statusCode, err
+	}
+
+	return accessToken, refreshToken, http.StatusOK, nil
+}
+
+func (s *AuthService) generateTokenPair(userID int, sessionID string) (string, string, int, error) {
+	roles, permissions, statusCode, err := s.RolesService.FindAccessByUserID(userID)
+	if err != nil {
+		return "", "", statusCode, err
+	}
+
+	accessToken, refreshToken, err := jwt.GenerateTokenPair(userID, slices.Contains(roles, constants.RoleAdmin), roles, permissions, sessionID)
+	if err != nil {
+		return "", "", http.StatusInternalServerError, fmt.Errorf("service - generateTokenPair - jwt.GenerateTokenPair: %w", err)
+	}
+
+	return accessToken, refreshToken, http.StatusOK, nil
+}
+
+func (s *AuthService) revokeTokenFamily(tokenRecord records.Tokens) (int, error) {
+	if tokenRecord.FamilyID == "" {
+		return s.TokensService.Delete(tokenRecord.Token)
+	}
+
+	return s.TokensService.DeleteByFamilyID(tokenRecord.FamilyID)
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/mailer"
+	"database/sql"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type memoryUsersRepository struct {
+	UsersRepository
+	users map[int]records.Users
+}
+
+func (r *memoryUsersRepository) FindByID(id int) (records.Users, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return records.Users{}, repositories.ErrorRowNotFound
+	}
+	return user, nil
+}
+
+func (r *memoryUsersRepository) FindByEmail(email string) (records.Users, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return records.Users{}, repositories.ErrorRowNotFound
+}
+
+func (r *memoryUsersRepository) Update(id int, fields map[string]interface{}) error {
+	user, ok := r.users[id]
+	if !ok {
+		return repositories.ErrorRowNotFound
+	}
+	if password, ok := fields["password"].(string); ok {
+		user.Password = password
+	}
+	if verifiedAt, ok := fields["email_verified_at"].(time.Time); ok {
+		user.EmailVerifiedAt = sql.NullTime{Time: verifiedAt, Valid: true}
+	}
+	r.users[id] = user
+	return nil
+}
+
+type memoryVerificationTokensRepository struct {
+	tokens []records.VerificationTokens
+}
+
+func (r *memoryVerificationTokensRepository) Save(token records.VerificationTokens) error {
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *memoryVerificationTokensRepository) Consume(tokenHash string, purpose string) (records.VerificationTokens, error) {
+	for i, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.Purpose == purpose && !token.UsedAt.Valid && token.ExpiresAt.After(time.Now()) {
+			r.tokens[i].UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			return token, nil
+		}
+	}
+	return records.VerificationTokens{}, repositories.ErrorRowNotFound
+}
+
+type memoryTokensRepository struct {
+	TokensRepository
+	deletedUserIDs []int
+}
+
+func (r *memoryTokensRepository) DeleteByUserID(userID int) error {
+	r.deletedUserIDs = append(r.deletedUserIDs, userID)
+	return nil
+}
+
+var mailedToken = regexp.MustCompile(`token=([0-9a-f]+)`)
+
+func newTestAuthService(t *testing.T) (*AuthService, *memoryUsersRepository, *memoryTokensRepository, *mailer.MemoryMailer) {
+	t.Helper()
+
+	config.Config.PasswordResetExpiresIn = 30
+	config.Config.EmailVerificationExpiresIn = 24
+
+	hash, err := helpers.GenerateHash("old-password")
+	if err != nil {
+		t.Fatalf("GenerateHash: %v", err)
+	}
+
+	users := &memoryUsersRepository{users: map[int]records.Users{
+		1: {Record: records.Record{ID: 1}, Email: "ana@example.com", Password: hash},
+	}}
+	tokens := &memoryTokensRepository{}
+	mail := mailer.NewMemoryMailer()
+
+	service := NewAuthService(
+		NewUsersService(users),
+		NewTokensService(tokens),
+		nil,
+		NewVerificationTokensService(&memoryVerificationTokensRepository{}),
+		nil,
+		nil,
+		mail,
+	)
+
+	return service, users, tokens, mail
+}
+
+func lastMailedToken(t *testing.T, mail *mailer.MemoryMailer, to string) string {
+	t.Helper()
+
+	messages := mail.Messages()
+	if len(messages) == 0 {
+		t.Fatal("no email was sent")
+	}
+	message := messages[len(messages)-1]
+	if len(message.To) != 1 || message.To[0] != to {
+		t.Fatalf("email sent to %v, want %s", message.To, to)
+	}
+
+	match := mailedToken.FindStringSubmatch(message.Body)
+	if match == nil {
+		t.Fatalf("no token in email body %q", message.Body)
+	}
+	return match[1]
+}
+
+func TestAuthServicePasswordReset(t *testing.T) {
+	service, users, tokens, mail := newTestAuthService(t)
+
+	statusCode, err := service.ForgotPassword(data_transfers.ForgotPasswordRequest{Email: "ana@example.com"})
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("ForgotPassword = %d, %v", statusCode, err)
+	}
+	token := lastMailedToken(t, mail, "ana@example.com")
+
+	statusCode, err = service.ResetPassword(data_transfers.ResetPasswordRequest{Token: token, Password: "new-password"})
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("ResetPassword = %d, %v", statusCode, err)
+	}
+	if !helpers.ValidateHash("new-password", users.users[1].Password) {
+		t.Error("password was not changed")
+	}
+	if len(tokens.deletedUserIDs) != 1 || tokens.deletedUserIDs[0] != 1 {
+		t.Errorf("signed out users %v, want [1]", tokens.deletedUserIDs)
+	}
+
+	statusCode, err = service.ResetPassword(data_transfers.ResetPasswordRequest{Token: token, Password: "other-password"})
+	if err == nil || statusCode != http.StatusBadRequest {
+		t.Errorf("ResetPassword with a used token = %d, %v, want 400", statusCode, err)
+	}
+}
+
+func TestAuthServiceForgotPasswordUnknownEmail(t *testing.T) {
+	service, _, _, mail := newTestAuthService(t)
+
+	statusCode, err := service.ForgotPassword(data_transfers.ForgotPasswordRequest{Email: "nobody@example.com"})
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("ForgotPassword = %d, %v", statusCode, err)
+	}
+	if len(mail.Messages()) != 0 {
+		t.Errorf("sent %d emails for an unknown address", len(mail.Messages()))
+	}
+}
+
+func TestAuthServiceEmailVerification(t *testing.T) {
+	service, users, _, mail := newTestAuthService(t)
+
+	statusCode, err := service.ResendVerificationEmail(1)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("ResendVerificationEmail = %d, %v", statusCode, err)
+	}
+	token := lastMailedToken(t, mail, "ana@example.com")
+
+	statusCode, err = service.ResetPassword(data_transfers.ResetPasswordRequest{Token: token, Password: "new-password"})
+	if err == nil || statusCode != http.StatusBadRequest {
+		t.Errorf("ResetPassword with a verification token = %d, %v, want 400", statusCode, err)
+	}
+
+	statusCode, err = service.VerifyEmail(data_transfers.VerifyEmailRequest{Token: token})
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("VerifyEmail = %d, %v", statusCode, err)
+	}
+	if !users.users[1].EmailVerifiedAt.Valid {
+		t.Error("email was not marked as verified")
+	}
+
+	statusCode, err = service.ResendVerificationEmail(1)
+	if err == nil || statusCode != http.StatusConflict {
+		t.Errorf("ResendVerificationEmail after verifying = %d, %v, want 409", statusCode, err)
+	}
+}
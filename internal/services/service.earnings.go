@@ -0,0 +1,264 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	EarningEntrySale     = "sale"
+	EarningEntryReversal = "reversal"
+
+	PayoutStatusPending = "pending"
+	PayoutStatusPaid    = "paid"
+)
+
+type EarningsRepository interface {
+	SaveSale(paymentID int, feePercent float64) error
+	SaveReversal(paymentID int) error
+	FindAllByCreatorID(creatorID int, from time.Time, to time.Time) ([]records.CreatorEarnings, error)
+	FindSummary(creatorID int) ([]records.CreatorEarningsSummary, error)
+	FindByWorkout(creatorID int, from time.Time, to time.Time) ([]records.CreatorEarningsByWorkout, error)
+	FindByMonth(creatorID int, from time.Time, to time.Time) ([]records.CreatorEarningsByMonth, error)
+	CreatePayouts(before time.Time) ([]records.CreatorPayouts, error)
+	FindPayoutByID(id int) (records.CreatorPayouts, error)
+	FindAllPayouts(creatorID int, status string) ([]records.CreatorPayouts, error)
+	MarkPayoutPaid(id int, reference string) error
+}
+
+// EarningsService keeps the ledger of what creators earn from selling their
+// workouts. Every fulfilled payment is a sale, less the platform fee, and a
+// refund adds a reversal of it. Unpaid entries are batched into payouts.
+type EarningsService struct {
+	repository EarningsRepository
+}
+
+func NewEarningsService(repository EarningsRepository) *EarningsService {
+	return &EarningsService{repository}
+}
+
+// RecordSale credits the owner of the bought workout with the payment, at the
+// current platform fee. It is safe to call more than once per payment.
+func (s *EarningsService) RecordSale(payment records.Payments) (int, error) {
+	if err := s.repository.SaveSale(payment.ID, config.Config.PlatformFeePercent); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - RecordSale - repository.SaveSale: %w", err)
+	}
+
+	return http.StatusCreated, nil
+}
+
+// RecordReversal nets a refunded payment out of the creator's earnings. Sales
+// that were already paid out are taken from the next payout.
+func (s *EarningsService) RecordReversal(payment records.Payments) (int, error) {
+	if err := s.repository.SaveReversal(payment.ID); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - RecordReversal - repository.SaveReversal: %w", err)
+	}
+
+	return http.StatusCreated, nil
+}
+
+func (s *EarningsService) FindSummary(creatorID int) ([]data_transfers.EarningsSummaryResponse, int, error) {
+	summary, err := s.repository.FindSummary(creatorID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindSummary - repository.FindSummary: %w", err)
+	}
+
+	responses := make([]data_transfers.EarningsSummaryResponse, 0, len(summary))
+	for _, totals := range summary {
+		responses = append(responses, data_transfers.EarningsSummaryResponse{
+			Currency:   totals.Currency,
+			Sales:      totals.Sales,
+			Refunds:    totals.Refunds,
+			Gross:      minorToAmount(totals.Gross),
+			Fee:        minorToAmount(totals.Fee),
+			Net:        minorToAmount(totals.Net),
+			PaidOut:    minorToAmount(totals.PaidOut),
+			Unpaid:     minorToAmount(totals.Unpaid),
+			FeePercent: config.Config.PlatformFeePercent,
+		})
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+func (s *EarningsService) FindByWorkout(creatorID int, from time.Time, to time.Time) ([]data_transfers.EarningsByWorkoutResponse, int, error) {
+	breakdown, err := s.repository.FindByWorkout(creatorID, from, to)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindByWorkout - repository.FindByWorkout: %w", err)
+	}
+
+	responses := make([]data_transfers.EarningsByWorkoutResponse, 0, len(breakdown))
+	for _, row := range breakdown {
+		response := data_transfers.EarningsByWorkoutResponse{
+			WorkoutTitle: row.WorkoutTitle,
+			Currency:     row.Currency,
+			Sales:        row.Sales,
+			Refunds:      row.Refunds,
+			Gross:        minorToAmount(row.Gross),
+			Fee:          minorToAmount(row.Fee),
+			Net:          minorToAmount(row.Net),
+		}
+		if row.WorkoutID.Valid {
+			workoutID := int(row.WorkoutID.Int64)
+			response.WorkoutID = &workoutID
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+func (s *EarningsService) FindByMonth(creatorID int, from time.Time, to time.Time) ([]data_transfers.EarningsByMonthResponse, int, error) {
+	breakdown, err := s.repository.FindByMonth(creatorID, from, to)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindByMonth - repository.FindByMonth: %w", err)
+	}
+
+	responses := make([]data_transfers.EarningsByMonthResponse, 0, len(breakdown))
+	for _, row := range breakdown {
+		responses = append(responses, data_transfers.EarningsByMonthResponse{
+			Month:    row.Month.Format("2006-01"),
+			Currency: row.Currency,
+			Sales:    row.Sales,
+			Refunds:  row.Refunds,
+			Gross:    minorToAmount(row.Gross),
+			Fee:      minorToAmount(row.Fee),
+			Net:      minorToAmount(row.Net),
+		})
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+// ExportCSV returns the creator's ledger entries in the period as CSV, one
+// row per sale or reversal.
+func (s *EarningsService) ExportCSV(creatorID int, from time.Time, to time.Time) ([]byte, int, error) {
+	earnings, err := s.repository.FindAllByCreatorID(creatorID, from, to)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - ExportCSV - repository.FindAllByCreatorID: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.Write([]string{"date", "type", "workout_id", "workout_title", "payment_id", "currency", "gross", "fee_percent", "fee", "net", "payout_id"}); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - ExportCSV - writer.Write: %w", err)
+	}
+	for _, entry := range earnings {
+		row := []string{
+			entry.CreatedAt.Format(time.RFC3339),
+			entry.EntryType,
+			formatNullInt(entry.WorkoutID),
+			entry.WorkoutTitle,
+			formatNullInt(entry.PaymentID),
+			entry.Currency,
+			formatMinorAmount(entry.Gross),
+			strconv.FormatFloat(entry.FeePercent, 'f', 2, 64),
+			formatMinorAmount(entry.Fee),
+			formatMinorAmount(entry.Net),
+			formatNullInt(entry.PayoutID),
+		}
+		if err := writer.Write(row); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("service - ExportCSV - writer.Write: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - ExportCSV - writer.Flush: %w", err)
+	}
+
+	return buffer.Bytes(), http.StatusOK, nil
+}
+
+// CreatePayouts batches the unpaid earnings that are past the hold period.
+// Holding sales back leaves room for refunds before the money leaves.
+func (s *EarningsService) CreatePayouts() ([]data_transfers.PayoutsResponse, int, error) {
+	before := time.Now().AddDate(0, 0, -config.Config.PayoutHoldDays)
+	payouts, err := s.repository.CreatePayouts(before)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - CreatePayouts - repository.CreatePayouts: %w", err)
+	}
+
+	return toPayoutsResponses(payouts), http.StatusCreated, nil
+}
+
+// FindAllPayouts lists payouts of a creator, or of every creator when
+// creatorID is 0.
+func (s *EarningsService) FindAllPayouts(creatorID int, status string) ([]data_transfers.PayoutsResponse, int, error) {
+	payouts, err := s.repository.FindAllPayouts(creatorID, status)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAllPayouts - repository.FindAllPayouts: %w", err)
+	}
+
+	return toPayoutsResponses(payouts), http.StatusOK, nil
+}
+
+func (s *EarningsService) MarkPayoutPaid(id int, markRequest data_transfers.MarkPayoutPaidRequest) (int, error) {
+	payout, err := s.repository.FindPayoutByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("payout not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - MarkPayoutPaid - repository.FindPayoutByID: %w", err)
+	}
+
+	if payout.Status != PayoutStatusPending {
+		return http.StatusConflict, errors.New("payout is already paid")
+	}
+
+	if err := s.repository.MarkPayoutPaid(id, markRequest.Reference); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusConflict, errors.New("payout is already paid")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - MarkPayoutPaid - repository.MarkPayoutPaid: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func minorToAmount(amount int64) float64 {
+	return float64(amount) / 100
+}
+
+func formatMinorAmount(amount int64) string {
+	return strconv.FormatFloat(minorToAmount(amount), 'f', 2, 64)
+}
+
+func formatNullInt(value sql.NullInt64) string {
+	if !value.Valid {
+		return ""
+	}
+	return strconv.FormatInt(value.Int64, 10)
+}
+
+func toPayoutsResponses(payouts []records.CreatorPayouts) []data_transfers.PayoutsResponse {
+	responses := make([]data_transfers.PayoutsResponse, 0, len(payouts))
+	for _, payout := range payouts {
+		response := data_transfers.PayoutsResponse{
+			ID:           payout.ID,
+			CreatorID:    payout.CreatorID,
+			Amount:       minorToAmount(payout.Amount),
+			Currency:     payout.Currency,
+			EntriesCount: payout.EntriesCount,
+			Status:       payout.Status,
+			Reference:    payout.Reference,
+			CreatedAt:    payout.CreatedAt,
+		}
+		if payout.PaidAt.Valid {
+			response.PaidAt = &payout.PaidAt.Time
+		}
+		responses = append(responses, response)
+	}
+
+	return responses
+}
@@ -6,7 +6,9 @@ import (
 	"backend/internal/http/data_transfers"
 	"backend/pkg/convert"
 	"errors"
+	"fmt"
 	"github.com/jinzhu/copier"
+	"math"
 	"net/http"
 	"time"
 )
@@ -14,6 +16,7 @@ import (
 type ExerciseSetsRepository interface {
 	Save(exerciseSet records.ExerciseSets) (int, error)
 	FindAllByWorkoutExerciseID(workoutExerciseID int) ([]records.ExerciseSets, error)
+	FindAllByWorkoutExerciseIDAndDate(workoutExerciseID int, ownerID int, date time.Time) ([]records.ExerciseSets, error)
 	FindByID(id int) (records.ExerciseSets, error)
 	Update(id int, exerciseSetMap map[string]interface{}) error
 	Delete(id int) error
@@ -26,11 +29,15 @@ type ExerciseSetsRepository interface {
 }
 
 type ExerciseSetsService struct {
-	repository ExerciseSetsRepository
+	repository              ExerciseSetsRepository
+	workoutExercisesService *WorkoutExercisesService
 }
 
-func NewExerciseSetsService(repository ExerciseSetsRepository) *ExerciseSetsService {
-	return &ExerciseSetsService{repository}
+func NewExerciseSetsService(repository ExerciseSetsRepository, workoutExercisesService *WorkoutExercisesService) *ExerciseSetsService {
+	return &ExerciseSetsService{
+		repository:              repository,
+		workoutExercisesService: workoutExercisesService,
+	}
 }
 
 func (s *ExerciseSetsService) Save(createExerciseSetsRequest data_transfers.CreateExerciseSetsRequest) (int, int, error) {
@@ -111,3 +118,82 @@ func (s *ExerciseSetsService) Delete(exerciseSetID int) (int, error) {
 
 	return http.StatusOK, nil
 }
+
+// FindAdherence compares the sets the user logged for a workout exercise on
+// the given day with its prescription. Reps are on target inside the rep
+// range and the load when the weight reaches an absolute target; loads given
+// as %1RM or RPE cannot be checked from the logged sets and are left null.
+func (s *ExerciseSetsService) FindAdherence(workoutExerciseID int, ownerID int, date time.Time) (data_transfers.ExerciseSetsAdherenceResponse, int, error) {
+	workoutExercise, statusCode, err := s.workoutExercisesService.FindByID(workoutExerciseID)
+	if err != nil {
+		return data_transfers.ExerciseSetsAdherenceResponse{}, statusCode, err
+	}
+
+	exerciseSets, err := s.repository.FindAllByWorkoutExerciseIDAndDate(workoutExerciseID, ownerID, date)
+	if err != nil {
+		return data_transfers.ExerciseSetsAdherenceResponse{}, http.StatusInternalServerError, fmt.Errorf("service - FindAdherence - repository.FindAllByWorkoutExerciseIDAndDate: %w", err)
+	}
+
+	prescription := workoutExercise.Prescription
+	response := data_transfers.ExerciseSetsAdherenceResponse{
+		WorkoutExerciseID: workoutExerciseID,
+		Date:              date.Format("2006-01-02"),
+		Prescription:      prescription,
+		LoggedSets:        len(exerciseSets),
+		Sets:              make([]data_transfers.ExerciseSetAdherence, 0, len(exerciseSets)),
+	}
+
+	hasRepsTarget := prescription.TargetRepsMin != nil || prescription.TargetRepsMax != nil
+	hasLoadTarget := prescription.LoadType != nil && *prescription.LoadType == LoadTypeAbsolute
+
+	var repsOnTarget, loadOnTarget int
+	for _, exerciseSet := range exerciseSets {
+		setAdherence := data_transfers.ExerciseSetAdherence{
+			ID:     exerciseSet.ID,
+			Reps:   exerciseSet.Reps,
+			Weight: exerciseSet.Weight,
+		}
+
+		if hasRepsTarget {
+			onTarget := (prescription.TargetRepsMin == nil || exerciseSet.Reps >= *prescription.TargetRepsMin) &&
+				(prescription.TargetRepsMax == nil || exerciseSet.Reps <= *prescription.TargetRepsMax)
+			setAdherence.RepsOnTarget = &onTarget
+			if onTarget {
+				repsOnTarget++
+			}
+		}
+
+		if hasLoadTarget {
+			onTarget := float64(exerciseSet.Weight) >= *prescription.LoadValue
+			setAdherence.LoadOnTarget = &onTarget
+			if onTarget {
+				loadOnTarget++
+			}
+		}
+
+		response.Sets = append(response.Sets, setAdherence)
+	}
+
+	if prescription.TargetSets != nil {
+		response.SetsRate = adherenceRate(min(len(exerciseSets), *prescription.TargetSets), *prescription.TargetSets)
+	}
+	if hasRepsTarget {
+		response.RepsRate = adherenceRate(repsOnTarget, len(exerciseSets))
+	}
+	if hasLoadTarget {
+		response.LoadRate = adherenceRate(loadOnTarget, len(exerciseSets))
+	}
+
+	return response, http.StatusOK, nil
+}
+
+// adherenceRate is the share of hits, rounded to two decimals. It is null
+// when there is nothing to compare yet.
+func adherenceRate(hits int, total int) *float64 {
+	if total == 0 {
+		return nil
+	}
+
+	rate := math.Round(float64(hits)/float64(total)*100) / 100
+	return &rate
+}
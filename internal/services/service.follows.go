@@ -0,0 +1,170 @@
+package services
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type FollowsRepository interface {
+	Follow(followerID int, followingID int) error
+	Unfollow(followerID int, followingID int) error
+	IsFollowing(followerID int, followingID int) (bool, error)
+	FindFollowers(userID int, cursor repositories.Cursor) ([]records.FollowUsers, error)
+	FindFollowing(userID int, cursor repositories.Cursor) ([]records.FollowUsers, error)
+	FindFeed(userID int, cursor repositories.Cursor) ([]records.FeedItems, error)
+}
+
+// FollowsService lets users follow each other and builds the feed of what
+// the users someone follows have been doing.
+type FollowsService struct {
+	repository   FollowsRepository
+	usersService *UsersService
+}
+
+func NewFollowsService(repository FollowsRepository, usersService *UsersService) *FollowsService {
+	return &FollowsService{
+		repository:   repository,
+		usersService: usersService,
+	}
+}
+
+func (s *FollowsService) Follow(followerID int, followingID int) (int, error) {
+	if followerID == followingID {
+		return http.StatusBadRequest, errors.New("you cannot follow yourself")
+	}
+
+	if _, statusCode, err := s.usersService.FindByID(followingID); err != nil {
+		return statusCode, err
+	}
+
+	if err := s.repository.Follow(followerID, followingID); err != nil {
+		if errors.Is(err, repositories.ErrorForeignKeyViolation) {
+			return http.StatusNotFound, errors.New("user not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Follow - repository.Follow: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *FollowsService) Unfollow(followerID int, followingID int) (int, error) {
+	if err := s.repository.Unfollow(followerID, followingID); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("you are not following this user")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Unfollow - repository.Unfollow: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *FollowsService) IsFollowing(followerID int, followingID int) (bool, int, error) {
+	following, err := s.repository.IsFollowing(followerID, followingID)
+	if err != nil {
+		return false, http.StatusInternalServerError, fmt.Errorf("service - IsFollowing - repository.IsFollowing: %w", err)
+	}
+
+	return following, http.StatusOK, nil
+}
+
+func (s *FollowsService) FindFollowers(userID int, cursor repositories.Cursor) ([]data_transfers.FollowUsersResponse, *repositories.Cursor, int, error) {
+	if _, statusCode, err := s.usersService.FindByID(userID); err != nil {
+		return nil, nil, statusCode, err
+	}
+
+	users, err := s.repository.FindFollowers(userID, cursor)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, fmt.Errorf("service - FindFollowers - repository.FindFollowers: %w", err)
+	}
+
+	users, next := followUsersPage(users, cursor.Limit)
+	return toFollowUsersResponses(users), next, http.StatusOK, nil
+}
+
+func (s *FollowsService) FindFollowing(userID int, cursor repositories.Cursor) ([]data_transfers.FollowUsersResponse, *repositories.Cursor, int, error) {
+	if _, statusCode, err := s.usersService.FindByID(userID); err != nil {
+		return nil, nil, statusCode, err
+	}
+
+	users, err := s.repository.FindFollowing(userID, cursor)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, fmt.Errorf("service - FindFollowing - repository.FindFollowing: %w", err)
+	}
+
+	users, next := followUsersPage(users, cursor.Limit)
+	return toFollowUsersResponses(users), next, http.StatusOK, nil
+}
+
+// FindFeed returns a page of the user's feed. Private workouts, and likes of
+// them, never show up in it.
+func (s *FollowsService) FindFeed(userID int, cursor repositories.Cursor) ([]data_transfers.FeedItemsResponse, *repositories.Cursor, int, error) {
+	items, err := s.repository.FindFeed(userID, cursor)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, fmt.Errorf("service - FindFeed - repository.FindFeed: %w", err)
+	}
+
+	var next *repositories.Cursor
+	if len(items) > cursor.Limit {
+		items = items[:cursor.Limit]
+		last := items[len(items)-1]
+		next = &repositories.Cursor{CreatedAt: last.OccurredAt, ID: last.SortKey, Limit: cursor.Limit}
+	}
+
+	responses := make([]data_transfers.FeedItemsResponse, 0, len(items))
+	for _, item := range items {
+		response := data_transfers.FeedItemsResponse{
+			Type:          item.Type,
+			ID:            item.ID,
+			ActorID:       item.ActorID,
+			ActorUsername: item.ActorUsername,
+			ActorAvatar:   item.ActorAvatar,
+			WorkoutTitle:  item.WorkoutTitle.String,
+			ActivityName:  item.ActivityName.String,
+			OccurredAt:    item.OccurredAt,
+		}
+		if item.WorkoutID.Valid {
+			workoutID := int(item.WorkoutID.Int64)
+			response.WorkoutID = &workoutID
+		}
+		if item.StartTime.Valid {
+			response.StartTime = &item.StartTime.Time
+		}
+		if item.EndTime.Valid {
+			response.EndTime = &item.EndTime.Time
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, next, http.StatusOK, nil
+}
+
+// followUsersPage drops the extra row selected by repositories.ApplyCursor and
+// returns the cursor of the next page, if there is one.
+func followUsersPage(users []records.FollowUsers, limit int) ([]records.FollowUsers, *repositories.Cursor) {
+	if len(users) <= limit {
+		return users, nil
+	}
+
+	users = users[:limit]
+	last := users[len(users)-1]
+	return users, &repositories.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Limit: limit}
+}
+
+func toFollowUsersResponses(users []records.FollowUsers) []data_transfers.FollowUsersResponse {
+	responses := make([]data_transfers.FollowUsersResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, data_transfers.FollowUsersResponse{
+			UserID:     user.UserID,
+			Username:   user.Username,
+			Bio:        user.Bio,
+			Avatar:     user.Avatar,
+			FollowedAt: user.CreatedAt,
+		})
+	}
+
+	return responses
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	ContentTypeWorkout  = "workout"
+	ContentTypeUser     = "user"
+	ContentTypeExercise = "exercise"
+
+	ModerationActionHide    = "hide"
+	ModerationActionRestore = "restore"
+	ModerationActionBan     = "ban"
+	ModerationActionDismiss = "dismiss"
+
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+type ModerationRepository interface {
+	Block(blockerID int, blockedID int) error
+	Unblock(blockerID int, blockedID int) error
+	IsBlocked(userID int, otherID int) (bool, error)
+	FindBlocked(blockerID int, cursor repositories.Cursor) ([]records.BlockedUsers, error)
+	FindAuthorID(contentType string, contentID int) (int, error)
+	SaveReport(report records.ContentReports) (int, error)
+	FindReports(status string, pagination repositories.Pagination) ([]records.ContentReports, int, error)
+	SaveAction(action records.ModerationActions, authorID int) (int, error)
+	FindActions(contentType string, contentID int, pagination repositories.Pagination) ([]records.ModerationActions, int, error)
+}
+
+// ModerationService handles abuse: users block each other and report
+// workouts, users and custom exercises, and admins work through the reports
+// by hiding, restoring or banning. Every admin decision is recorded.
+type ModerationService struct {
+	repository   ModerationRepository
+	usersService *UsersService
+	authService  *AuthService
+}
+
+func NewModerationService(repository ModerationRepository, usersService *UsersService, authService *AuthService) *ModerationService {
+	return &ModerationService{
+		repository:   repository,
+		usersService: usersService,
+		authService:  authService,
+	}
+}
+
+// Block hides the users from each other in listings and search, and ends
+// the follows between them.
+func (s *ModerationService) Block(blockerID int, blockedID int) (int, error) {
+	if blockerID == blockedID {
+		return http.StatusBadRequest, errors.New("you cannot block yourself")
+	}
+
+	if _, statusCode, err := s.usersService.FindByID(blockedID); err != nil {
+		return statusCode, err
+	}
+
+	if err := s.repository.Block(blockerID, blockedID); err != nil {
+		if errors.Is(err, repositories.ErrorForeignKeyViolation) {
+			return http.StatusNotFound, errors.New("user not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Block - repository.Block: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *ModerationService) Unblock(blockerID int, blockedID int) (int, error) {
+	if err := s.repository.Unblock(blockerID, blockedID); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("you have not blocked this user")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Unblock - repository.Unblock: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *ModerationService) IsBlocked(userID int, otherID int) (bool, int, error) {
+	blocked, err := s.repository.IsBlocked(userID, otherID)
+	if err != nil {
+		return false, http.StatusInternalServerError, fmt.Errorf("service - IsBlocked - repository.IsBlocked: %w", err)
+	}
+
+	return blocked, http.StatusOK, nil
+}
+
+func (s *ModerationService) FindBlocked(blockerID int, cursor repositories.Cursor) ([]data_transfers.BlockedUsersResponse, *repositories.Cursor, int, error) {
+	users, err := s.repository.FindBlocked(blockerID, cursor)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, fmt.Errorf("service - FindBlocked - repository.FindBlocked: %w", err)
+	}
+
+	var next *repositories.Cursor
+	if len(users) > cursor.Limit {
+		users = users[:cursor.Limit]
+		last := users[len(users)-1]
+		next = &repositories.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Limit: cursor.Limit}
+	}
+
+	responses := make([]data_transfers.BlockedUsersResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, data_transfers.BlockedUsersResponse{
+			UserID:    user.BlockedID,
+			Username:  user.Username,
+			Avatar:    user.Avatar,
+			BlockedAt: user.CreatedAt,
+		})
+	}
+
+	return responses, next, http.StatusOK, nil
+}
+
+func (s *ModerationService) Report(reporterID int, reportRequest data_transfers.CreateContentReportRequest) (int, int, error) {
+	authorID, statusCode, err := s.findAuthorID(reportRequest.ContentType, reportRequest.ContentID)
+	if err != nil {
+		return 0, statusCode, err
+	}
+	if authorID == reporterID {
+		return 0, http.StatusBadRequest, errors.New("you cannot report your own content")
+	}
+
+	id, err := s.repository.SaveReport(records.ContentReports{
+		ReporterID:  reporterID,
+		ContentType: reportRequest.ContentType,
+		ContentID:   reportRequest.ContentID,
+		Reason:      reportRequest.Reason,
+		Details:     reportRequest.Details,
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return 0, http.StatusConflict, errors.New("you already reported this content")
+		}
+		return 0, http.StatusInternalServerError, fmt.Errorf("service - Report - repository.SaveReport: %w", err)
+	}
+
+	return id, http.StatusCreated, nil
+}
+
+// FindReports returns the moderation queue, the open reports by default.
+func (s *ModerationService) FindReports(status string, pagination repositories.Pagination) ([]data_transfers.ContentReportsResponse, int, int, error) {
+	if status == "" {
+		status = ReportStatusOpen
+	}
+	if status != ReportStatusOpen && status != ReportStatusResolved && status != ReportStatusDismissed {
+		return nil, 0, http.StatusBadRequest, errors.New("invalid status")
+	}
+
+	reports, total, err := s.repository.FindReports(status, pagination)
+	if err != nil {
+		return nil, 0, http.StatusInternalServerError, fmt.Errorf("service - FindReports - repository.FindReports: %w", err)
+	}
+
+	responses := make([]data_transfers.ContentReportsResponse, 0, len(reports))
+	for _, report := range reports {
+		response := data_transfers.ContentReportsResponse{
+			ID:          report.ID,
+			ReporterID:  report.ReporterID,
+			ContentType: report.ContentType,
+			ContentID:   report.ContentID,
+			Reason:      report.Reason,
+			Details:     report.Details,
+			Status:      report.Status,
+			CreatedAt:   report.CreatedAt,
+		}
+		if report.ActionID.Valid {
+			actionID := int(report.ActionID.Int64)
+			response.ActionID = &actionID
+		}
+		if report.Status != ReportStatusOpen && report.UpdatedAt.Valid {
+			response.ResolvedAt = &report.UpdatedAt.Time
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, total, http.StatusOK, nil
+}
+
+// TakeAction applies and records a moderator's decision on the content and
+// closes its open reports. Banned users are signed out everywhere and their
+// API keys stop working.
+func (s *ModerationService) TakeAction(moderatorID int, actionRequest data_transfers.CreateModerationActionRequest) (data_transfers.ModerationActionsResponse, int, error) {
+	authorID, statusCode, err := s.findAuthorID(actionRequest.ContentType, actionRequest.ContentID)
+	if err != nil {
+		return data_transfers.ModerationActionsResponse{}, statusCode, err
+	}
+	if actionRequest.Action == ModerationActionBan && authorID == moderatorID {
+		return data_transfers.ModerationActionsResponse{}, http.StatusBadRequest, errors.New("you cannot ban yourself")
+	}
+
+	action := records.ModerationActions{
+		Record:      records.Record{CreatedAt: time.Now()},
+		ModeratorID: moderatorID,
+		ContentType: actionRequest.ContentType,
+		ContentID:   actionRequest.ContentID,
+		Action:      actionRequest.Action,
+		Reason:      actionRequest.Reason,
+	}
+
+	id, err := s.repository.SaveAction(action, authorID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.ModerationActionsResponse{}, http.StatusNotFound, errors.New("content not found")
+		}
+		return data_transfers.ModerationActionsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - TakeAction - repository.SaveAction: %w", err)
+	}
+
+	if action.Action == ModerationActionBan {
+		if statusCode, err := s.authService.RevokeAllSessions(authorID); err != nil {
+			return data_transfers.ModerationActionsResponse{}, statusCode, err
+		}
+	}
+
+	action.ID = id
+	return toModerationActionsResponse(action), http.StatusCreated, nil
+}
+
+func (s *ModerationService) FindActions(contentType string, contentID int, pagination repositories.Pagination) ([]data_transfers.ModerationActionsResponse, int, int, error) {
+	actions, total, err := s.repository.FindActions(contentType, contentID, pagination)
+	if err != nil {
+		return nil, 0, http.StatusInternalServerError, fmt.Errorf("service - FindActions - repository.FindActions: %w", err)
+	}
+
+	responses := make([]data_transfers.ModerationActionsResponse, 0, len(actions))
+	for _, action := range actions {
+		responses = append(responses, toModerationActionsResponse(action))
+	}
+
+	return responses, total, http.StatusOK, nil
+}
+
+// findAuthorID also tells whether the content exists. Exercises from the
+// shared catalogue have no author, so only custom exercises can be moderated.
+func (s *ModerationService) findAuthorID(contentType string, contentID int) (int, int, error) {
+	authorID, err := s.repository.FindAuthorID(contentType, contentID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return 0, http.StatusNotFound, errors.New("content not found")
+		}
+		return 0, http.StatusInternalServerError, fmt.Errorf("service - findAuthorID - repository.FindAuthorID: %w", err)
+	}
+
+	return authorID, http.StatusOK, nil
+}
+
+func toModerationActionsResponse(action records.ModerationActions) data_transfers.ModerationActionsResponse {
+	return data_transfers.ModerationActionsResponse{
+		ID:          action.ID,
+		ModeratorID: action.ModeratorID,
+		ContentType: action.ContentType,
+		ContentID:   action.ContentID,
+		Action:      action.Action,
+		Reason:      action.Reason,
+		CreatedAt:   action.CreatedAt,
+	}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/oidc"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type OIDCRepository interface {
+	SaveState(state records.OIDCStates) error
+	ConsumeState(stateHash string, provider string) (records.OIDCStates, error)
+	FindIdentity(provider string, subject string) (records.UserIdentities, error)
+	SaveIdentity(identity records.UserIdentities) error
+}
+
+// OIDCService signs users in through OpenID Connect providers using the
+// authorization code flow with PKCE.
+type OIDCService struct {
+	repository    OIDCRepository
+	providers     map[string]*oidc.Provider
+	usersService  *UsersService
+	tokensService *TokensService
+	authService   *AuthService
+}
+
+func NewOIDCService(
+	repository OIDCRepository,
+	providers map[string]*oidc.Provider,
+	usersService *UsersService,
+	tokensService *TokensService,
+	authService *AuthService,
+) *OIDCService {
+	return &OIDCService{
+		repository:    repository,
+		providers:     providers,
+		usersService:  usersService,
+		tokensService: tokensService,
+		authService:   authService,
+	}
+}
+
+// AuthorizationURL starts a login. The state, nonce and PKCE verifier are kept
+// server side until the client comes back with the authorization code. The
+// returned binding, a hash of the state, is kept in the browser that started
+// the login, so that a code and state obtained by someone else cannot sign it
+// in to their account.
+func (s *OIDCService) AuthorizationURL(providerName string) (string, string, int, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", http.StatusNotFound, errors.New("unknown identity provider")
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		value, err := oidc.GenerateRandom()
+		if err != nil {
+			return "", "", http.StatusInternalServerError, fmt.Errorf("service - AuthorizationURL - oidc.GenerateRandom: %w", err)
+		}
+		values[i] = value
+	}
+	state, nonce, codeVerifier := values[0], values[1], values[2]
+
+	authorizationURL, err := provider.AuthCodeURL(state, nonce, codeVerifier)
+	if err != nil {
+		return "", "", http.StatusBadGateway, fmt.Errorf("service - AuthorizationURL - provider.AuthCodeURL: %w", err)
+	}
+
+	stateHash := hashVerificationToken(state)
+	err = s.repository.SaveState(records.OIDCStates{
+		StateHash:    stateHash,
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(time.Minute * time.Duration(config.Config.OIDCStateExpiresIn)),
+	})
+	if err != nil {
+		return "", "", http.StatusInternalServerError, fmt.Errorf("service - AuthorizationURL - repository.SaveState: %w", err)
+	}
+
+	return authorizationURL, stateHash, http.StatusOK, nil
+}
+
+// SignIn completes a login with the authorization code returned by the
+// provider. Like AuthService.SignIn it returns a challenge token instead of
+// tokens when the user has two-factor authentication enabled. The state has to
+// match the binding returned by AuthorizationURL to the same browser.
+func (s *OIDCService) SignIn(providerName string, callbackRequest data_transfers.OIDCCallbackRequest, stateBinding string, device data_transfers.DeviceInfo) (string, string, string, int, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", "", http.StatusNotFound, errors.New("unknown identity provider")
+	}
+
+	stateHash := hashVerificationToken(callbackRequest.State)
+	if subtle.ConstantTimeCompare([]byte(stateHash), []byte(stateBinding)) != 1 {
+		return "", "", "", http.StatusBadRequest, errors.New("login was not started from this browser")
+	}
+
+	state, err := s.repository.ConsumeState(stateHash, providerName)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return "", "", "", http.StatusBadRequest, errors.New("login state is invalid or has expired")
+		}
+		return "", "", "", http.StatusInternalServerError, fmt.Errorf("service - SignIn - repository.ConsumeState: %w", err)
+	}
+
+	rawIDToken, err := provider.Exchange(callbackRequest.Code, state.CodeVerifier)
+	if err != nil {
+		return "", "", "", http.StatusBadGateway, fmt.Errorf("service - SignIn - provider.Exchange: %w", err)
+	}
+
+	claims, err := provider.VerifyIDToken(rawIDToken, state.Nonce)
+	if err != nil {
+		return "", "", "", http.StatusUnauthorized, fmt.Errorf("service - SignIn - provider.VerifyIDToken: %w", err)
+	}
+
+	user, statusCode, err := s.findOrCreateUser(providerName, claims)
+	if err != nil {
+		return "", "", "", statusCode, err
+	}
+
+	device.DeviceName = callbackRequest.DeviceName
+	return s.authService.signInUser(user.ID, user.Email, device)
+}
+
+// findOrCreateUser resolves the account of a provider identity. Identities
+// seen before map to their user. New identities are linked to the account
+// with the same email, which requires the provider to have verified it;
+// otherwise a new account is created.
+func (s *OIDCService) findOrCreateUser(providerName string, claims *oidc.IDTokenClaims) (data_transfers.UsersResponse, int, error) {
+	identity, err := s.repository.FindIdentity(providerName, claims.Subject)
+	if err == nil {
+		return s.usersService.FindByID(identity.UserID)
+	}
+	if !errors.Is(err, repositories.ErrorRowNotFound) {
+		return data_transfers.UsersResponse{}, http.StatusInternalServerError, fmt.Errorf("service - findOrCreateUser - repository.FindIdentity: %w", err)
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return data_transfers.UsersResponse{}, http.StatusForbidden, errors.New("the identity provider did not return a verified email")
+	}
+
+	user, statusCode, err := s.usersService.FindByEmail(claims.Email)
+	switch {
+	case err == nil:
+		statusCode, err = s.secureLinkedAccount(user.ID)
+	case statusCode == http.StatusNotFound:
+		user, statusCode, err = s.createUser(claims.Email)
+	}
+	if err != nil {
+		return data_transfers.UsersResponse{}, statusCode, err
+	}
+
+	err = s.repository.SaveIdentity(records.UserIdentities{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		return data_transfers.UsersResponse{}, http.StatusInternalServerError, fmt.Errorf("service - findOrCreateUser - repository.SaveIdentity: %w", err)
+	}
+
+	return user, http.StatusOK, nil
+}
+
+// secureLinkedAccount guards against accounts pre-registered with someone
+// else's email. When the email of the existing account was never verified,
+// whoever chose its password is not trusted: the password is replaced and
+// every session is revoked. The owner can set a new password through the
+// password reset flow.
+func (s *OIDCService) secureLinkedAccount(userID int) (int, error) {
+	verified, statusCode, err := s.usersService.IsEmailVerified(userID)
+	if err != nil {
+		return statusCode, err
+	}
+	if verified {
+		return http.StatusOK, nil
+	}
+
+	password, err := helpers.GenerateRandomString(32)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - secureLinkedAccount - helpers.GenerateRandomString: %w", err)
+	}
+
+	statusCode, err = s.usersService.ChangePassword(userID, password)
+	if err != nil {
+		return statusCode, err
+	}
+
+	statusCode, err = s.tokensService.DeleteByUserID(userID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	return s.usersService.MarkEmailVerified(userID)
+}
+
+// createUser registers an account for a new identity. It gets a random
+// password, which the user can replace through the password reset flow.
+func (s *OIDCService) createUser(email string) (data_transfers.UsersResponse, int, error) {
+	password, err := helpers.GenerateRandomString(32)
+	if err != nil {
+		return data_transfers.UsersResponse{}, http.StatusInternalServerError, fmt.Errorf("service - createUser - helpers.GenerateRandomString: %w", err)
+	}
+
+	statusCode, err := s.usersService.Save(data_transfers.CreateUsersRequest{Email: email, Password: password})
+	if err != nil {
+		return data_transfers.UsersResponse{}, statusCode, err
+	}
+
+	user, statusCode, err := s.usersService.FindByEmail(email)
+	if err != nil {
+		return data_transfers.UsersResponse{}, statusCode, err
+	}
+
+	statusCode, err = s.usersService.MarkEmailVerified(user.ID)
+	if err != nil {
+		return data_transfers.UsersResponse{}, statusCode, err
+	}
+
+	return user, http.StatusCreated, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/oidc"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type memoryOIDCRepository struct {
+	OIDCRepository
+	states []records.OIDCStates
+}
+
+func (r *memoryOIDCRepository) SaveState(state records.OIDCStates) error {
+	r.states = append(r.states, state)
+	return nil
+}
+
+func (r *memoryOIDCRepository) ConsumeState(stateHash string, provider string) (records.OIDCStates, error) {
+	for i, state := range r.states {
+		if state.StateHash == stateHash && state.Provider == provider && state.ExpiresAt.After(time.Now()) {
+			r.states = append(r.states[:i], r.states[i+1:]...)
+			return state, nil
+		}
+	}
+	return records.OIDCStates{}, repositories.ErrorRowNotFound
+}
+
+// newTestOIDCService serves a discovery document whose token endpoint always
+// refuses the code, which is enough to follow the state through a login.
+func newTestOIDCService(t *testing.T) *OIDCService {
+	t.Helper()
+
+	config.Config.OIDCStateExpiresIn = 10
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		Name:        "test",
+		IssuerURL:   server.URL,
+		ClientID:    "client-1",
+		RedirectURL: "https://app.example.com/callback",
+	}, server.Client())
+
+	return NewOIDCService(&memoryOIDCRepository{}, map[string]*oidc.Provider{"test": provider}, nil, nil, nil)
+}
+
+func startTestLogin(t *testing.T, service *OIDCService) (string, string) {
+	t.Helper()
+
+	authorizationURL, binding, statusCode, err := service.AuthorizationURL("test")
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("AuthorizationURL = %d, %v", statusCode, err)
+	}
+
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+	return parsed.Query().Get("state"), binding
+}
+
+func TestOIDCServiceSignInRequiresTheBrowserThatStartedTheLogin(t *testing.T) {
+	service := newTestOIDCService(t)
+
+	state, _ := startTestLogin(t, service)
+	_, otherBinding := startTestLogin(t, service)
+
+	_, _, _, statusCode, err := service.SignIn("test", data_transfers.OIDCCallbackRequest{Code: "code", State: state}, otherBinding, data_transfers.DeviceInfo{})
+	if err == nil || statusCode != http.StatusBadRequest {
+		t.Errorf("SignIn with the binding of another login = %d, %v, want 400", statusCode, err)
+	}
+
+	_, _, _, statusCode, err = service.SignIn("test", data_transfers.OIDCCallbackRequest{Code: "code", State: state}, "", data_transfers.DeviceInfo{})
+	if err == nil || statusCode != http.StatusBadRequest {
+		t.Errorf("SignIn without a binding = %d, %v, want 400", statusCode, err)
+	}
+}
+
+func TestOIDCServiceStateIsSingleUse(t *testing.T) {
+	service := newTestOIDCService(t)
+
+	state, binding := startTestLogin(t, service)
+
+	// The state is consumed before the code is exchanged, so a refused code
+	// still uses it up.
+	_, _, _, statusCode, err := service.SignIn("test", data_transfers.OIDCCallbackRequest{Code: "code", State: state}, binding, data_transfers.DeviceInfo{})
+	if err == nil || statusCode != http.StatusBadGateway {
+		t.Fatalf("SignIn with a refused code = %d, %v, want 502", statusCode, err)
+	}
+
+	_, _, _, statusCode, err = service.SignIn("test", data_transfers.OIDCCallbackRequest{Code: "code", State: state}, binding, data_transfers.DeviceInfo{})
+	if err == nil || statusCode != http.StatusBadRequest {
+		t.Errorf("SignIn with a used state = %d, %v, want 400", statusCode, err)
+	}
+}
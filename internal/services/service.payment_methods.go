@@ -0,0 +1,190 @@
+package services
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/payments"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// PaymentMethodGatewayLegacy marks cards migrated from users.card_pan. They
+// have no token and cannot be charged until the user adds them again.
+const PaymentMethodGatewayLegacy = "legacy"
+
+type PaymentMethodsRepository interface {
+	Save(paymentMethod records.PaymentMethods) (records.PaymentMethods, error)
+	FindAllByUserID(userID int) ([]records.PaymentMethods, error)
+	FindByID(userID int, id int) (records.PaymentMethods, error)
+	FindDefaultByUserID(userID int) (records.PaymentMethods, error)
+	SetDefault(userID int, id int) error
+	Delete(userID int, id int) error
+}
+
+// PaymentMethodsService keeps the cards of a user. Card numbers are tokenized
+// on the client by the gateway; only the token and what is needed to show the
+// card (brand, last 4 digits and expiry) are stored.
+type PaymentMethodsService struct {
+	repository PaymentMethodsRepository
+	vault      payments.Vault
+	gateway    string
+}
+
+func NewPaymentMethodsService(repository PaymentMethodsRepository, gateway payments.Gateway) *PaymentMethodsService {
+	return &PaymentMethodsService{
+		repository: repository,
+		vault:      gateway,
+		gateway:    gateway.Name(),
+	}
+}
+
+func (s *PaymentMethodsService) FindAllByUserID(userID int) ([]data_transfers.PaymentMethodsResponse, int, error) {
+	paymentMethods, err := s.repository.FindAllByUserID(userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAllByUserID - repository.FindAllByUserID: %w", err)
+	}
+
+	responses := make([]data_transfers.PaymentMethodsResponse, 0, len(paymentMethods))
+	for _, paymentMethod := range paymentMethods {
+		responses = append(responses, toPaymentMethodsResponse(paymentMethod))
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+func (s *PaymentMethodsService) Create(userID int, createRequest data_transfers.CreatePaymentMethodRequest) (data_transfers.PaymentMethodsResponse, int, error) {
+	card, err := s.vault.RetrieveCard(context.Background(), createRequest.Token)
+	if err != nil {
+		if errors.Is(err, payments.ErrCardNotFound) {
+			return data_transfers.PaymentMethodsResponse{}, http.StatusBadRequest, errors.New("invalid payment method token")
+		}
+		return data_transfers.PaymentMethodsResponse{}, http.StatusBadGateway, fmt.Errorf("service - Create - vault.RetrieveCard: %w", err)
+	}
+
+	paymentMethod, err := s.repository.Save(records.PaymentMethods{
+		UserID:   userID,
+		Gateway:  s.gateway,
+		Token:    sql.NullString{String: card.Token, Valid: true},
+		Brand:    card.Brand,
+		Last4:    card.Last4,
+		ExpMonth: sql.NullInt64{Int64: int64(card.ExpMonth), Valid: card.ExpMonth > 0},
+		ExpYear:  sql.NullInt64{Int64: int64(card.ExpYear), Valid: card.ExpYear > 0},
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return data_transfers.PaymentMethodsResponse{}, http.StatusConflict, errors.New("payment method already added")
+		}
+		return data_transfers.PaymentMethodsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - repository.Save: %w", err)
+	}
+
+	return toPaymentMethodsResponse(paymentMethod), http.StatusCreated, nil
+}
+
+func (s *PaymentMethodsService) SetDefault(userID int, id int) (int, error) {
+	paymentMethod, err := s.repository.FindByID(userID, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("payment method not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - SetDefault - repository.FindByID: %w", err)
+	}
+
+	if !paymentMethod.Token.Valid {
+		return http.StatusConflict, errors.New("this card must be added again before it can be used")
+	}
+
+	if err := s.repository.SetDefault(userID, id); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("payment method not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - SetDefault - repository.SetDefault: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Delete detaches the card from the gateway and forgets it. When it was the
+// default, the most recently added remaining card takes its place.
+func (s *PaymentMethodsService) Delete(userID int, id int) (int, error) {
+	paymentMethod, err := s.repository.FindByID(userID, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("payment method not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Delete - repository.FindByID: %w", err)
+	}
+
+	if paymentMethod.Token.Valid && paymentMethod.Gateway == s.gateway {
+		err := s.vault.DetachCard(context.Background(), paymentMethod.Token.String)
+		if err != nil && !errors.Is(err, payments.ErrCardNotFound) {
+			return http.StatusBadGateway, fmt.Errorf("service - Delete - vault.DetachCard: %w", err)
+		}
+	}
+
+	if err := s.repository.Delete(userID, id); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("payment method not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Delete - repository.Delete: %w", err)
+	}
+
+	if !paymentMethod.IsDefault {
+		return http.StatusOK, nil
+	}
+
+	remaining, err := s.repository.FindAllByUserID(userID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - Delete - repository.FindAllByUserID: %w", err)
+	}
+	for _, next := range remaining {
+		if next.Token.Valid {
+			if err := s.repository.SetDefault(userID, next.ID); err != nil {
+				return http.StatusInternalServerError, fmt.Errorf("service - Delete - repository.SetDefault: %w", err)
+			}
+			break
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// FindChargeable returns the payment method to charge the user with, the
+// default one when id is 0.
+func (s *PaymentMethodsService) FindChargeable(userID int, id int) (records.PaymentMethods, int, error) {
+	var paymentMethod records.PaymentMethods
+	var err error
+	if id == 0 {
+		paymentMethod, err = s.repository.FindDefaultByUserID(userID)
+	} else {
+		paymentMethod, err = s.repository.FindByID(userID, id)
+	}
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return records.PaymentMethods{}, http.StatusBadRequest, errors.New("add a payment method first")
+		}
+		return records.PaymentMethods{}, http.StatusInternalServerError, fmt.Errorf("service - FindChargeable - repository.FindByID: %w", err)
+	}
+
+	if !paymentMethod.Token.Valid || paymentMethod.Gateway != s.gateway {
+		return records.PaymentMethods{}, http.StatusConflict, errors.New("this card must be added again before it can be used")
+	}
+
+	return paymentMethod, http.StatusOK, nil
+}
+
+func toPaymentMethodsResponse(paymentMethod records.PaymentMethods) data_transfers.PaymentMethodsResponse {
+	return data_transfers.PaymentMethodsResponse{
+		ID:             paymentMethod.ID,
+		Brand:          paymentMethod.Brand,
+		Last4:          paymentMethod.Last4,
+		ExpMonth:       int(paymentMethod.ExpMonth.Int64),
+		ExpYear:        int(paymentMethod.ExpYear.Int64),
+		IsDefault:      paymentMethod.IsDefault,
+		RequiresUpdate: !paymentMethod.Token.Valid,
+		CreatedAt:      paymentMethod.CreatedAt,
+	}
+}
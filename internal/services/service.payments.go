@@ -0,0 +1,533 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/logger"
+	"backend/pkg/payments"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	PaymentStatusPending        = "pending"
+	PaymentStatusRequiresAction = "requires_action"
+	PaymentStatusSucceeded      = "succeeded"
+	PaymentStatusFailed         = "failed"
+	PaymentStatusRefunded       = "refunded"
+)
+
+// unsettledPaymentStatuses are the statuses a payment can still leave through
+// a gateway update.
+var unsettledPaymentStatuses = []string{PaymentStatusPending, PaymentStatusRequiresAction, PaymentStatusFailed}
+
+// paymentsAbandonedAfter is how long a payment may wait for its intent to be
+// created before a new purchase of the workout may replace it.
+const paymentsAbandonedAfter = 15 * time.Minute
+
+type PaymentsRepository interface {
+	Save(payment records.Payments) (records.Payments, error)
+	FindByID(id int) (records.Payments, error)
+	FindByIntentID(gateway string, intentID string) (records.Payments, error)
+	FindAllByUserID(userID int) ([]records.Payments, error)
+	SetIntent(id int, intentID string) error
+	FailAbandoned(userID int, workoutID int, before time.Time, failureReason string) (bool, error)
+	UpdateStatus(id int, from []string, status string, failureReason string) (bool, error)
+	ClaimFulfillment(id int) (bool, error)
+	ReleaseFulfillment(id int) error
+}
+
+// PaymentsService sells paid workouts through a payments.Gateway. A purchase
+// creates and confirms a payment intent; the purchase is only recorded once
+// the gateway reports the intent as succeeded, either through a webhook or
+// when the payment is looked up.
+type PaymentsService struct {
+	repository        PaymentsRepository
+	gateway           payments.Gateway
+	workoutsService   *WorkoutsService
+	purchasesService  *PurchasesService
+	earningsService   *EarningsService
+	promoCodesService *PromoCodesService
+}
+
+func NewPaymentsService(repository PaymentsRepository, gateway payments.Gateway, workoutsService *WorkoutsService, purchasesService *PurchasesService, earningsService *EarningsService, promoCodesService *PromoCodesService) *PaymentsService {
+	return &PaymentsService{
+		repository:        repository,
+		gateway:           gateway,
+		workoutsService:   workoutsService,
+		purchasesService:  purchasesService,
+		earningsService:   earningsService,
+		promoCodesService: promoCodesService,
+	}
+}
+
+func (s *PaymentsService) PurchaseWorkout(userID int, workoutID int, purchaseRequest data_transfers.PurchaseWorkoutRequest) (data_transfers.PaymentsResponse, int, error) {
+	amount, quote, statusCode, err := s.price(userID, workoutID, purchaseRequest.PromoCode)
+	if err != nil {
+		return data_transfers.PaymentsResponse{}, statusCode, err
+	}
+
+	// The payment is saved before the intent is created, so that the unique
+	// index on open payments turns concurrent purchases of the workout away
+	// before any of them reaches the gateway.
+	payment, statusCode, err := s.reserve(records.Payments{
+		UserID:         userID,
+		WorkoutID:      sql.NullInt64{Int64: int64(workoutID), Valid: true},
+		Gateway:        s.gateway.Name(),
+		Amount:         amount - quote.Discount,
+		OriginalAmount: amount,
+		Discount:       quote.Discount,
+		PromoCode:      quote.PromoCode.Code,
+		Currency:       config.Config.PaymentsCurrency,
+		Status:         PaymentStatusPending,
+	})
+	if err != nil {
+		return data_transfers.PaymentsResponse{}, statusCode, err
+	}
+
+	ctx := context.Background()
+	intent, err := s.gateway.CreateIntent(ctx, payments.CreateIntentParams{
+		Amount:   payment.Amount,
+		Currency: payment.Currency,
+		Metadata: map[string]string{
+			"user_id":    strconv.Itoa(userID),
+			"workout_id": strconv.Itoa(workoutID),
+			"payment_id": strconv.Itoa(payment.ID),
+		},
+	})
+	if err != nil {
+		s.fail(payment, "the payment could not be created")
+		return data_transfers.PaymentsResponse{}, http.StatusBadGateway, fmt.Errorf("service - PurchaseWorkout - gateway.CreateIntent: %w", err)
+	}
+
+	if err := s.repository.SetIntent(payment.ID, intent.ID); err != nil {
+		s.fail(payment, "the payment could not be created")
+		return data_transfers.PaymentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - PurchaseWorkout - repository.SetIntent: %w", err)
+	}
+	payment.IntentID = intent.ID
+
+	// The promo code is redeemed before the card is charged, so that a code
+	// used up by a concurrent purchase is never charged at its discount.
+	if quote.PromoCode.ID != 0 {
+		statusCode, err := s.promoCodesService.Redeem(quote, userID, payment.ID)
+		if err != nil {
+			if _, updateErr := s.repository.UpdateStatus(payment.ID, unsettledPaymentStatuses, PaymentStatusFailed, err.Error()); updateErr != nil {
+				logger.ZeroLogger.Error().Msgf("service - PurchaseWorkout - repository.UpdateStatus: %v", updateErr)
+			}
+			return data_transfers.PaymentsResponse{}, statusCode, err
+		}
+	}
+
+	returnURL := purchaseRequest.ReturnURL
+	if returnURL == "" {
+		returnURL = config.Config.PaymentsReturnURL
+	}
+
+	intent, err = s.gateway.ConfirmIntent(ctx, intent.ID, payments.ConfirmIntentParams{
+		PaymentMethod: purchaseRequest.PaymentMethod,
+		ReturnURL:     returnURL,
+	})
+	if err != nil {
+		s.fail(payment, "the payment could not be confirmed")
+		return data_transfers.PaymentsResponse{}, http.StatusBadGateway, fmt.Errorf("service - PurchaseWorkout - gateway.ConfirmIntent: %w", err)
+	}
+
+	payment, err = s.settle(payment, intent)
+	if err != nil {
+		return data_transfers.PaymentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - PurchaseWorkout - settle: %w", err)
+	}
+
+	statusCode = http.StatusAccepted
+	if payment.Status == PaymentStatusSucceeded {
+		statusCode = http.StatusCreated
+	}
+
+	return toPaymentsResponse(payment, intent), statusCode, nil
+}
+
+// PreviewPrice returns what the user would pay for the workout with the
+// promo code, without reserving the code.
+func (s *PaymentsService) PreviewPrice(userID int, workoutID int, promoCode string) (data_transfers.PricePreviewResponse, int, error) {
+	amount, quote, statusCode, err := s.price(userID, workoutID, promoCode)
+	if err != nil {
+		return data_transfers.PricePreviewResponse{}, statusCode, err
+	}
+
+	return data_transfers.PricePreviewResponse{
+		WorkoutID:     workoutID,
+		OriginalPrice: float64(amount) / 100,
+		Discount:      float64(quote.Discount) / 100,
+		Price:         float64(amount-quote.Discount) / 100,
+		Currency:      config.Config.PaymentsCurrency,
+		PromoCode:     quote.PromoCode.Code,
+	}, http.StatusOK, nil
+}
+
+func (s *PaymentsService) FindAllByUserID(userID int) ([]data_transfers.PaymentsResponse, int, error) {
+	paymentRecords, err := s.repository.FindAllByUserID(userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAllByUserID - repository.FindAllByUserID: %w", err)
+	}
+
+	responses := make([]data_transfers.PaymentsResponse, 0, len(paymentRecords))
+	for _, payment := range paymentRecords {
+		responses = append(responses, toPaymentsResponse(payment, payments.Intent{}))
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+// FindByID returns the payment of the user. Payments that are not final are
+// refreshed from the gateway first, in case a webhook was missed.
+func (s *PaymentsService) FindByID(userID int, id int) (data_transfers.PaymentsResponse, int, error) {
+	payment, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.PaymentsResponse{}, http.StatusNotFound, errors.New("payment not found")
+		}
+		return data_transfers.PaymentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - FindByID - repository.FindByID: %w", err)
+	}
+
+	if payment.UserID != userID {
+		return data_transfers.PaymentsResponse{}, http.StatusNotFound, errors.New("payment not found")
+	}
+
+	var intent payments.Intent
+	if payment.IntentID != "" && (payment.Status == PaymentStatusPending || payment.Status == PaymentStatusRequiresAction ||
+		(payment.Status == PaymentStatusSucceeded && !payment.FulfilledAt.Valid)) {
+		intent, err = s.gateway.RetrieveIntent(context.Background(), payment.IntentID)
+		if err != nil {
+			return data_transfers.PaymentsResponse{}, http.StatusBadGateway, fmt.Errorf("service - FindByID - gateway.RetrieveIntent: %w", err)
+		}
+
+		payment, err = s.settle(payment, intent)
+		if err != nil {
+			return data_transfers.PaymentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - FindByID - settle: %w", err)
+		}
+	}
+
+	return toPaymentsResponse(payment, intent), http.StatusOK, nil
+}
+
+// Refund returns the money to the buyer. The purchase is revoked once the
+// gateway confirms the refund.
+func (s *PaymentsService) Refund(id int) (int, error) {
+	payment, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("payment not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Refund - repository.FindByID: %w", err)
+	}
+
+	if payment.Status != PaymentStatusSucceeded {
+		return http.StatusConflict, errors.New("only succeeded payments can be refunded")
+	}
+
+	refund, err := s.gateway.Refund(context.Background(), payment.IntentID)
+	if err != nil {
+		return http.StatusBadGateway, fmt.Errorf("service - Refund - gateway.Refund: %w", err)
+	}
+
+	if refund.Status == payments.StatusSucceeded {
+		if err := s.settleRefund(payment); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("service - Refund - settleRefund: %w", err)
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *PaymentsService) HandleWebhook(payload []byte, header http.Header) (int, error) {
+	event, err := s.gateway.ParseWebhook(payload, header)
+	if err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			return http.StatusBadRequest, err
+		}
+		return http.StatusBadRequest, fmt.Errorf("service - HandleWebhook - gateway.ParseWebhook: %w", err)
+	}
+
+	return s.HandleEvent(event)
+}
+
+// HandleEvent applies a gateway event. Events for unknown intents and of
+// unknown types are acknowledged and ignored.
+func (s *PaymentsService) HandleEvent(event payments.Event) (int, error) {
+	if event.Type != payments.EventPaymentSucceeded && event.Type != payments.EventPaymentFailed && event.Type != payments.EventRefunded {
+		return http.StatusOK, nil
+	}
+
+	payment, err := s.repository.FindByIntentID(s.gateway.Name(), event.IntentID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusOK, nil
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - HandleEvent - repository.FindByIntentID: %w", err)
+	}
+
+	if event.Type == payments.EventRefunded {
+		if err := s.settleRefund(payment); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("service - HandleEvent - settleRefund: %w", err)
+		}
+		return http.StatusOK, nil
+	}
+
+	intent, err := s.gateway.RetrieveIntent(context.Background(), payment.IntentID)
+	if err != nil {
+		return http.StatusBadGateway, fmt.Errorf("service - HandleEvent - gateway.RetrieveIntent: %w", err)
+	}
+
+	if _, err := s.settle(payment, intent); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - HandleEvent - settle: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// UsesFakeGateway reports whether payments go through the in-process fake gateway.
+func (s *PaymentsService) UsesFakeGateway() bool {
+	_, ok := s.gateway.(*payments.FakeGateway)
+	return ok
+}
+
+// AuthenticateFakePayment completes the 3-D Secure step of a payment made
+// through the fake gateway. It does not exist for real gateways.
+func (s *PaymentsService) AuthenticateFakePayment(intentID string, approve bool) (int, error) {
+	fake, ok := s.gateway.(*payments.FakeGateway)
+	if !ok {
+		return http.StatusNotFound, errors.New("not found")
+	}
+
+	if _, err := fake.Authenticate(intentID, approve); err != nil {
+		if errors.Is(err, payments.ErrIntentNotFound) {
+			return http.StatusNotFound, errors.New("payment not found")
+		}
+		return http.StatusConflict, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// reserve saves a new payment for the workout. A conflict with a payment that
+// was abandoned before its intent was created fails that payment and tries
+// once more.
+func (s *PaymentsService) reserve(payment records.Payments) (records.Payments, int, error) {
+	saved, err := s.repository.Save(payment)
+	if errors.Is(err, repositories.ErrorRowExists) {
+		abandoned, failErr := s.repository.FailAbandoned(payment.UserID, int(payment.WorkoutID.Int64), time.Now().Add(-paymentsAbandonedAfter), "the payment was abandoned")
+		if failErr != nil {
+			return records.Payments{}, http.StatusInternalServerError, fmt.Errorf("service - reserve - repository.FailAbandoned: %w", failErr)
+		}
+		if abandoned {
+			saved, err = s.repository.Save(payment)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return records.Payments{}, http.StatusConflict, errors.New("this workout is already purchased or a payment for it is in progress")
+		}
+		return records.Payments{}, http.StatusInternalServerError, fmt.Errorf("service - reserve - repository.Save: %w", err)
+	}
+
+	return saved, http.StatusCreated, nil
+}
+
+// fail marks a payment that could not be completed as failed.
+func (s *PaymentsService) fail(payment records.Payments, failureReason string) {
+	if _, err := s.repository.UpdateStatus(payment.ID, unsettledPaymentStatuses, PaymentStatusFailed, failureReason); err != nil {
+		logger.ZeroLogger.Error().Msgf("service - fail - repository.UpdateStatus: %v", err)
+	}
+}
+
+// settle brings the payment in line with the intent and, when it succeeded,
+// records the purchase for the buyer and the sale for the creator. It is safe
+// to call repeatedly and concurrently.
+func (s *PaymentsService) settle(payment records.Payments, intent payments.Intent) (records.Payments, error) {
+	status, failureReason := paymentStatusFromIntent(intent)
+	if status == PaymentStatusSucceeded && (intent.Amount != payment.Amount || intent.Currency != payment.Currency) {
+		return payment, fmt.Errorf("intent %s amount %d %s does not match payment %d", intent.ID, intent.Amount, intent.Currency, payment.ID)
+	}
+
+	if status != payment.Status && slices.Contains(unsettledPaymentStatuses, payment.Status) {
+		if _, err := s.repository.UpdateStatus(payment.ID, unsettledPaymentStatuses, status, failureReason); err != nil {
+			return payment, fmt.Errorf("repository.UpdateStatus: %w", err)
+		}
+	}
+
+	if status == PaymentStatusSucceeded {
+		if err := s.fulfill(payment); err != nil {
+			return payment, err
+		}
+	}
+
+	payment, err := s.repository.FindByID(payment.ID)
+	if err != nil {
+		return payment, fmt.Errorf("repository.FindByID: %w", err)
+	}
+
+	return payment, nil
+}
+
+func (s *PaymentsService) fulfill(payment records.Payments) error {
+	claimed, err := s.repository.ClaimFulfillment(payment.ID)
+	if err != nil {
+		return fmt.Errorf("repository.ClaimFulfillment: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	statusCode, err := s.purchasesService.Record(payment)
+	if err != nil && statusCode != http.StatusConflict {
+		if releaseErr := s.repository.ReleaseFulfillment(payment.ID); releaseErr != nil {
+			logger.ZeroLogger.Error().Msgf("service - fulfill - repository.ReleaseFulfillment: %v", releaseErr)
+		}
+		return fmt.Errorf("purchasesService.Record: %w", err)
+	}
+
+	// A conflict either comes from a retry of a released claim, which finds
+	// its own purchase recorded, or from a second payment for a workout the
+	// buyer already holds, which is refunded.
+	if statusCode == http.StatusConflict {
+		recorded, _, err := s.purchasesService.IsRecorded(payment.ID)
+		if err != nil {
+			if releaseErr := s.repository.ReleaseFulfillment(payment.ID); releaseErr != nil {
+				logger.ZeroLogger.Error().Msgf("service - fulfill - repository.ReleaseFulfillment: %v", releaseErr)
+			}
+			return fmt.Errorf("purchasesService.IsRecorded: %w", err)
+		}
+		if !recorded {
+			return s.refundDuplicate(payment)
+		}
+	}
+
+	// The sale is credited to the creator after the purchase so that a retry
+	// of a released claim finds the purchase recorded and only adds the sale.
+	if _, err := s.earningsService.RecordSale(payment); err != nil {
+		if releaseErr := s.repository.ReleaseFulfillment(payment.ID); releaseErr != nil {
+			logger.ZeroLogger.Error().Msgf("service - fulfill - repository.ReleaseFulfillment: %v", releaseErr)
+		}
+		return fmt.Errorf("earningsService.RecordSale: %w", err)
+	}
+
+	return nil
+}
+
+// refundDuplicate returns a payment for a workout the buyer already holds.
+// No sale was credited for it, so the refund is not reversed from earnings.
+func (s *PaymentsService) refundDuplicate(payment records.Payments) error {
+	if _, err := s.gateway.Refund(context.Background(), payment.IntentID); err != nil {
+		if releaseErr := s.repository.ReleaseFulfillment(payment.ID); releaseErr != nil {
+			logger.ZeroLogger.Error().Msgf("service - refundDuplicate - repository.ReleaseFulfillment: %v", releaseErr)
+		}
+		return fmt.Errorf("gateway.Refund: %w", err)
+	}
+
+	if _, err := s.repository.UpdateStatus(payment.ID, []string{PaymentStatusSucceeded}, PaymentStatusRefunded, "the workout was already purchased"); err != nil {
+		return fmt.Errorf("repository.UpdateStatus: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PaymentsService) settleRefund(payment records.Payments) error {
+	refunded, err := s.repository.UpdateStatus(payment.ID, []string{PaymentStatusSucceeded}, PaymentStatusRefunded, "")
+	if err != nil {
+		return fmt.Errorf("repository.UpdateStatus: %w", err)
+	}
+	if !refunded {
+		return nil
+	}
+
+	if _, err := s.purchasesService.RevokeByPaymentID(payment.ID); err != nil {
+		return fmt.Errorf("purchasesService.RevokeByPaymentID: %w", err)
+	}
+
+	if _, err := s.earningsService.RecordReversal(payment); err != nil {
+		return fmt.Errorf("earningsService.RecordReversal: %w", err)
+	}
+
+	return nil
+}
+
+// price checks that the user may buy the workout and returns its price in
+// minor units along with the discount of the promo code, if one is given.
+func (s *PaymentsService) price(userID int, workoutID int, promoCode string) (int64, PromoQuote, int, error) {
+	workout, statusCode, err := s.workoutsService.FindForPurchase(workoutID)
+	if err != nil {
+		return 0, PromoQuote{}, statusCode, err
+	}
+
+	if workout.IsPrivate {
+		return 0, PromoQuote{}, http.StatusForbidden, errors.New("this workout is private")
+	}
+	if workout.OwnerID == userID {
+		return 0, PromoQuote{}, http.StatusBadRequest, errors.New("you cannot purchase your own workout")
+	}
+	if workout.Price <= 0 {
+		return 0, PromoQuote{}, http.StatusBadRequest, errors.New("this workout is free, copy it instead")
+	}
+
+	amount := int64(math.Round(workout.Price * 100))
+	if promoCode == "" {
+		return amount, PromoQuote{}, http.StatusOK, nil
+	}
+
+	quote, statusCode, err := s.promoCodesService.Quote(userID, promoCode, workout, amount)
+	if err != nil {
+		return 0, PromoQuote{}, statusCode, err
+	}
+	if quote.Discount >= amount {
+		return 0, PromoQuote{}, http.StatusUnprocessableEntity, errors.New("promo codes cannot make a workout free")
+	}
+
+	return amount, quote, http.StatusOK, nil
+}
+
+func paymentStatusFromIntent(intent payments.Intent) (string, string) {
+	switch intent.Status {
+	case payments.StatusSucceeded:
+		return PaymentStatusSucceeded, ""
+	case payments.StatusRequiresAction:
+		return PaymentStatusRequiresAction, ""
+	case payments.StatusRequiresPaymentMethod, payments.StatusCanceled:
+		if intent.LastError != "" {
+			return PaymentStatusFailed, intent.LastError
+		}
+		return PaymentStatusFailed, "the payment was not completed"
+	default:
+		return PaymentStatusPending, ""
+	}
+}
+
+func toPaymentsResponse(payment records.Payments, intent payments.Intent) data_transfers.PaymentsResponse {
+	response := data_transfers.PaymentsResponse{
+		ID:            payment.ID,
+		Amount:        float64(payment.Amount) / 100,
+		Discount:      float64(payment.Discount) / 100,
+		PromoCode:     payment.PromoCode,
+		Currency:      payment.Currency,
+		Status:        payment.Status,
+		FailureReason: payment.FailureReason,
+		CreatedAt:     payment.CreatedAt,
+	}
+	if payment.WorkoutID.Valid {
+		workoutID := int(payment.WorkoutID.Int64)
+		response.WorkoutID = &workoutID
+	}
+	if payment.Status == PaymentStatusRequiresAction {
+		response.NextActionURL = intent.NextActionURL
+		response.ClientSecret = intent.ClientSecret
+	}
+
+	return response
+}
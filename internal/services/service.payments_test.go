@@ -0,0 +1,501 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/payments"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryWorkoutsRepository struct {
+	WorkoutsRepository
+	workouts map[int]records.Workouts
+}
+
+func (r *memoryWorkoutsRepository) FindByID(id int) (records.Workouts, error) {
+	workout, ok := r.workouts[id]
+	if !ok {
+		return records.Workouts{}, repositories.ErrorRowNotFound
+	}
+	return workout, nil
+}
+
+// memoryPaymentsRepository keeps payments like the payments table, including
+// its unique index on the open payments of a buyer for a workout.
+type memoryPaymentsRepository struct {
+	mu       sync.Mutex
+	payments []records.Payments
+}
+
+func (r *memoryPaymentsRepository) Save(payment records.Payments) (records.Payments, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, saved := range r.payments {
+		if saved.UserID == payment.UserID && saved.WorkoutID == payment.WorkoutID &&
+			slices.Contains([]string{PaymentStatusPending, PaymentStatusRequiresAction, PaymentStatusSucceeded}, saved.Status) {
+			return records.Payments{}, repositories.ErrorRowExists
+		}
+	}
+
+	payment.ID = len(r.payments) + 1
+	payment.CreatedAt = time.Now()
+	r.payments = append(r.payments, payment)
+	return payment, nil
+}
+
+func (r *memoryPaymentsRepository) FindByID(id int) (records.Payments, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id < 1 || id > len(r.payments) {
+		return records.Payments{}, repositories.ErrorRowNotFound
+	}
+	return r.payments[id-1], nil
+}
+
+func (r *memoryPaymentsRepository) FindByIntentID(gateway string, intentID string) (records.Payments, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, payment := range r.payments {
+		if payment.Gateway == gateway && payment.IntentID == intentID && intentID != "" {
+			return payment, nil
+		}
+	}
+	return records.Payments{}, repositories.ErrorRowNotFound
+}
+
+func (r *memoryPaymentsRepository) FindAllByUserID(userID int) ([]records.Payments, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var payments []records.Payments
+	for _, payment := range r.payments {
+		if payment.UserID == userID {
+			payments = append(payments, payment)
+		}
+	}
+	return payments, nil
+}
+
+func (r *memoryPaymentsRepository) SetIntent(id int, intentID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.payments[id-1].IntentID = intentID
+	return nil
+}
+
+func (r *memoryPaymentsRepository) FailAbandoned(userID int, workoutID int, before time.Time, failureReason string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failed := false
+	for i, payment := range r.payments {
+		if payment.UserID == userID && payment.WorkoutID.Int64 == int64(workoutID) && payment.Status == PaymentStatusPending &&
+			payment.IntentID == "" && payment.CreatedAt.Before(before) {
+			r.payments[i].Status = PaymentStatusFailed
+			r.payments[i].FailureReason = failureReason
+			failed = true
+		}
+	}
+	return failed, nil
+}
+
+func (r *memoryPaymentsRepository) UpdateStatus(id int, from []string, status string, failureReason string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment := &r.payments[id-1]
+	if !slices.Contains(from, payment.Status) {
+		return false, nil
+	}
+	payment.Status = status
+	payment.FailureReason = failureReason
+	if status == PaymentStatusRefunded {
+		payment.RefundedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	return true, nil
+}
+
+func (r *memoryPaymentsRepository) ClaimFulfillment(id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment := &r.payments[id-1]
+	if payment.Status != PaymentStatusSucceeded || payment.FulfilledAt.Valid {
+		return false, nil
+	}
+	payment.FulfilledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return true, nil
+}
+
+func (r *memoryPaymentsRepository) ReleaseFulfillment(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.payments[id-1].FulfilledAt = sql.NullTime{}
+	return nil
+}
+
+// memoryPurchasesRepository allows one active purchase per buyer and workout,
+// like the purchases table.
+type memoryPurchasesRepository struct {
+	mu        sync.Mutex
+	purchases []records.Purchases
+}
+
+func (r *memoryPurchasesRepository) Save(purchase records.Purchases) (records.Purchases, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, saved := range r.purchases {
+		if saved.BuyerID == purchase.BuyerID && saved.WorkoutID == purchase.WorkoutID && saved.Status == PurchaseStatusActive {
+			return records.Purchases{}, repositories.ErrorRowExists
+		}
+	}
+
+	purchase.ID = len(r.purchases) + 1
+	r.purchases = append(r.purchases, purchase)
+	return purchase, nil
+}
+
+func (r *memoryPurchasesRepository) FindAllByBuyerID(buyerID int) ([]records.Purchases, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purchases []records.Purchases
+	for _, purchase := range r.purchases {
+		if purchase.BuyerID == buyerID {
+			purchases = append(purchases, purchase)
+		}
+	}
+	return purchases, nil
+}
+
+func (r *memoryPurchasesRepository) FindEntitledWorkoutIDs(buyerID int, workoutIDs []int) ([]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entitled []int
+	for _, purchase := range r.purchases {
+		workoutID := int(purchase.WorkoutID.Int64)
+		if purchase.BuyerID == buyerID && purchase.Status == PurchaseStatusActive && slices.Contains(workoutIDs, workoutID) {
+			entitled = append(entitled, workoutID)
+		}
+	}
+	return entitled, nil
+}
+
+func (r *memoryPurchasesRepository) ExistsByPaymentID(paymentID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, purchase := range r.purchases {
+		if purchase.PaymentID.Valid && purchase.PaymentID.Int64 == int64(paymentID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryPurchasesRepository) UpdateStatusByPaymentID(paymentID int, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, purchase := range r.purchases {
+		if purchase.PaymentID.Valid && purchase.PaymentID.Int64 == int64(paymentID) {
+			r.purchases[i].Status = status
+		}
+	}
+	return nil
+}
+
+// memoryEarningsRepository records which payments were credited and reversed.
+type memoryEarningsRepository struct {
+	EarningsRepository
+	mu        sync.Mutex
+	sales     []int
+	reversals []int
+}
+
+func (r *memoryEarningsRepository) SaveSale(paymentID int, feePercent float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !slices.Contains(r.sales, paymentID) {
+		r.sales = append(r.sales, paymentID)
+	}
+	return nil
+}
+
+func (r *memoryEarningsRepository) SaveReversal(paymentID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if slices.Contains(r.sales, paymentID) && !slices.Contains(r.reversals, paymentID) {
+		r.reversals = append(r.reversals, paymentID)
+	}
+	return nil
+}
+
+const (
+	testBuyerID   = 2
+	testWorkoutID = 10
+)
+
+type testPayments struct {
+	service   *PaymentsService
+	gateway   *payments.FakeGateway
+	events    chan payments.Event
+	early     []payments.Event
+	workouts  *memoryWorkoutsRepository
+	payments  *memoryPaymentsRepository
+	purchases *memoryPurchasesRepository
+	earnings  *memoryEarningsRepository
+}
+
+func newTestPayments(t *testing.T) *testPayments {
+	t.Helper()
+
+	config.Config.PaymentsCurrency = "usd"
+	config.Config.PlatformFeePercent = 20
+
+	tp := &testPayments{
+		gateway: payments.NewFakeGateway("whsec_test", "https://app.example.com/api/v1/payments/fake/authenticate/"),
+		events:  make(chan payments.Event, 16),
+		workouts: &memoryWorkoutsRepository{workouts: map[int]records.Workouts{
+			testWorkoutID: {Record: records.Record{ID: testWorkoutID}, Title: "Push day", Price: 9.99, OwnerID: 1},
+		}},
+		payments:  &memoryPaymentsRepository{},
+		purchases: &memoryPurchasesRepository{},
+		earnings:  &memoryEarningsRepository{},
+	}
+	tp.gateway.OnEvent(func(event payments.Event) {
+		tp.events <- event
+	})
+
+	purchasesService := NewPurchasesService(tp.purchases)
+	workoutsService := NewWorkoutsService(tp.workouts, nil, nil, nil, purchasesService, nil, nil)
+	tp.service = NewPaymentsService(tp.payments, tp.gateway, workoutsService, purchasesService, NewEarningsService(tp.earnings), nil)
+
+	return tp
+}
+
+// deliver posts the next event of the given type to the webhook, signed like
+// the fake gateway signs them. The gateway sends events concurrently, so the
+// ones that arrive ahead of their turn are kept for later calls.
+func (tp *testPayments) deliver(t *testing.T, eventType string) {
+	t.Helper()
+
+	event := tp.next(t, eventType)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write(payload)
+	header := http.Header{}
+	header.Set("Fake-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	statusCode, err := tp.service.HandleWebhook(payload, header)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("HandleWebhook = %d, %v", statusCode, err)
+	}
+}
+
+func (tp *testPayments) next(t *testing.T, eventType string) payments.Event {
+	t.Helper()
+
+	for i, event := range tp.early {
+		if event.Type == eventType {
+			tp.early = slices.Delete(tp.early, i, i+1)
+			return event
+		}
+	}
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event := <-tp.events:
+			if event.Type == eventType {
+				return event
+			}
+			tp.early = append(tp.early, event)
+		case <-timeout:
+			t.Fatalf("no %s event was sent", eventType)
+		}
+	}
+}
+
+func (tp *testPayments) entitled(t *testing.T) bool {
+	t.Helper()
+
+	entitled, _, err := tp.service.purchasesService.IsEntitled(testBuyerID, testWorkoutID)
+	if err != nil {
+		t.Fatalf("IsEntitled: %v", err)
+	}
+	return entitled
+}
+
+func TestPaymentsServicePurchaseWebhookAndRefund(t *testing.T) {
+	tp := newTestPayments(t)
+
+	payment, statusCode, err := tp.service.PurchaseWorkout(testBuyerID, testWorkoutID, data_transfers.PurchaseWorkoutRequest{PaymentMethod: payments.FakeCardRequires3DS})
+	if err != nil || statusCode != http.StatusAccepted {
+		t.Fatalf("PurchaseWorkout = %d, %v", statusCode, err)
+	}
+	if payment.Status != PaymentStatusRequiresAction || payment.NextActionURL == "" {
+		t.Fatalf("payment = %+v, want requires_action with a next action URL", payment)
+	}
+	if tp.entitled(t) {
+		t.Fatal("buyer is entitled before paying")
+	}
+
+	intentID := tp.payments.payments[0].IntentID
+	if _, err := tp.gateway.Authenticate(intentID, true); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	tp.deliver(t, payments.EventPaymentSucceeded)
+
+	payment, statusCode, err = tp.service.FindByID(testBuyerID, payment.ID)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("FindByID = %d, %v", statusCode, err)
+	}
+	if payment.Status != PaymentStatusSucceeded || payment.Amount != 9.99 {
+		t.Errorf("payment = %+v, want succeeded for 9.99", payment)
+	}
+	if !tp.entitled(t) {
+		t.Error("buyer is not entitled after paying")
+	}
+	if !slices.Equal(tp.earnings.sales, []int{payment.ID}) {
+		t.Errorf("sales = %v, want [%d]", tp.earnings.sales, payment.ID)
+	}
+
+	// A redelivered event does not fulfill the payment twice.
+	statusCode, err = tp.service.HandleEvent(payments.Event{Type: payments.EventPaymentSucceeded, IntentID: intentID})
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("HandleEvent = %d, %v", statusCode, err)
+	}
+	if len(tp.purchases.purchases) != 1 {
+		t.Errorf("%d purchases recorded, want 1", len(tp.purchases.purchases))
+	}
+
+	statusCode, err = tp.service.Refund(payment.ID)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("Refund = %d, %v", statusCode, err)
+	}
+	tp.deliver(t, payments.EventRefunded)
+
+	if tp.payments.payments[0].Status != PaymentStatusRefunded {
+		t.Errorf("payment status = %s, want refunded", tp.payments.payments[0].Status)
+	}
+	if tp.entitled(t) {
+		t.Error("buyer is still entitled after the refund")
+	}
+	if !slices.Equal(tp.earnings.reversals, []int{payment.ID}) {
+		t.Errorf("reversals = %v, want [%d]", tp.earnings.reversals, payment.ID)
+	}
+}
+
+func TestPaymentsServiceRejectsSecondOpenPayment(t *testing.T) {
+	tp := newTestPayments(t)
+
+	if _, statusCode, err := tp.service.PurchaseWorkout(testBuyerID, testWorkoutID, data_transfers.PurchaseWorkoutRequest{PaymentMethod: payments.FakeCardRequires3DS}); err != nil {
+		t.Fatalf("PurchaseWorkout = %d, %v", statusCode, err)
+	}
+
+	_, statusCode, err := tp.service.PurchaseWorkout(testBuyerID, testWorkoutID, data_transfers.PurchaseWorkoutRequest{PaymentMethod: payments.FakeCardSucceeds})
+	if err == nil || statusCode != http.StatusConflict {
+		t.Errorf("second PurchaseWorkout = %d, %v, want 409", statusCode, err)
+	}
+	if len(tp.payments.payments) != 1 {
+		t.Errorf("%d payments saved, want 1", len(tp.payments.payments))
+	}
+}
+
+func TestPaymentsServiceDeclinedPaymentCanBeRetried(t *testing.T) {
+	tp := newTestPayments(t)
+
+	payment, statusCode, err := tp.service.PurchaseWorkout(testBuyerID, testWorkoutID, data_transfers.PurchaseWorkoutRequest{PaymentMethod: payments.FakeCardDeclined})
+	if err != nil {
+		t.Fatalf("PurchaseWorkout = %d, %v", statusCode, err)
+	}
+	if payment.Status != PaymentStatusFailed {
+		t.Fatalf("payment status = %s, want failed", payment.Status)
+	}
+
+	payment, statusCode, err = tp.service.PurchaseWorkout(testBuyerID, testWorkoutID, data_transfers.PurchaseWorkoutRequest{PaymentMethod: payments.FakeCardSucceeds})
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("retried PurchaseWorkout = %d, %v", statusCode, err)
+	}
+	if payment.Status != PaymentStatusSucceeded || !tp.entitled(t) {
+		t.Errorf("payment = %+v, entitled = %v", payment, tp.entitled(t))
+	}
+}
+
+func TestPaymentsServiceRefundsPaymentForOwnedWorkout(t *testing.T) {
+	tp := newTestPayments(t)
+
+	// The buyer already holds the workout through a purchase of its own.
+	tp.purchases.purchases = append(tp.purchases.purchases, records.Purchases{
+		Record:    records.Record{ID: 1},
+		BuyerID:   testBuyerID,
+		WorkoutID: sql.NullInt64{Int64: testWorkoutID, Valid: true},
+		Status:    PurchaseStatusActive,
+	})
+
+	payment, _, err := tp.service.PurchaseWorkout(testBuyerID, testWorkoutID, data_transfers.PurchaseWorkoutRequest{PaymentMethod: payments.FakeCardSucceeds})
+	if err != nil {
+		t.Fatalf("PurchaseWorkout: %v", err)
+	}
+	if payment.Status != PaymentStatusRefunded {
+		t.Errorf("payment status = %s, want refunded", payment.Status)
+	}
+	if len(tp.earnings.sales) != 0 {
+		t.Errorf("sales = %v, want none", tp.earnings.sales)
+	}
+
+	tp.deliver(t, payments.EventPaymentSucceeded)
+	tp.deliver(t, payments.EventRefunded)
+	if len(tp.earnings.reversals) != 0 {
+		t.Errorf("reversals = %v, want none", tp.earnings.reversals)
+	}
+	if !tp.entitled(t) {
+		t.Error("buyer lost the workout they already held")
+	}
+}
+
+func TestPaymentsServiceRefusesHiddenWorkout(t *testing.T) {
+	tp := newTestPayments(t)
+
+	workout := tp.workouts.workouts[testWorkoutID]
+	workout.HiddenAt = sql.NullTime{Time: time.Now(), Valid: true}
+	tp.workouts.workouts[testWorkoutID] = workout
+
+	_, statusCode, err := tp.service.PreviewPrice(testBuyerID, testWorkoutID, "")
+	if err == nil || statusCode != http.StatusNotFound {
+		t.Errorf("PreviewPrice = %d, %v, want 404", statusCode, err)
+	}
+
+	_, statusCode, err = tp.service.PurchaseWorkout(testBuyerID, testWorkoutID, data_transfers.PurchaseWorkoutRequest{PaymentMethod: payments.FakeCardSucceeds})
+	if err == nil || statusCode != http.StatusNotFound {
+		t.Errorf("PurchaseWorkout = %d, %v, want 404", statusCode, err)
+	}
+	if len(tp.payments.payments) != 0 {
+		t.Errorf("saved %d payments for a hidden workout", len(tp.payments.payments))
+	}
+}
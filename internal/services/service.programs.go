@@ -0,0 +1,532 @@
+package services
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/convert"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type ProgramsRepository interface {
+	Save(program records.Programs, weeks []records.ProgramWeeks, days []records.ProgramDays) (int, error)
+	Update(id int, program map[string]interface{}) error
+	Delete(id int) error
+	ReplaceSchedule(id int, daysPerWeek int, weeks []records.ProgramWeeks, days []records.ProgramDays) error
+	FindByID(id int) (records.Programs, error)
+	FindAll(viewerID int, pagination repositories.Pagination) ([]records.Programs, int, error)
+	FindAllByOwnerID(ownerID int) ([]records.Programs, error)
+	FindWeeks(programID int) ([]records.ProgramWeeks, error)
+	FindDays(programID int) ([]records.ProgramDays, error)
+	CountOwnedWorkouts(ownerID int, workoutIDs []int) (int, error)
+	Enroll(enrollment records.ProgramEnrollments) (int, error)
+	Unenroll(programID int, userID int) error
+	FindEnrollment(programID int, userID int) (records.ProgramEnrollments, error)
+	FindEnrollmentsByUserID(userID int) ([]records.ProgramEnrollments, error)
+	CompleteDay(enrollment records.ProgramEnrollments, completion records.ProgramCompletions, next *records.ProgramDays) error
+}
+
+// ProgramsService handles multi-week programs: a schedule of the owner's
+// workouts over numbered weeks and days, some weeks being deload weeks.
+// Users enroll in a program and work through it one scheduled day at a time.
+// A program costs what its workouts cost, so enrolling needs the same
+// purchases and membership as following each of its workouts.
+type ProgramsService struct {
+	repository           ProgramsRepository
+	purchasesService     *PurchasesService
+	subscriptionsService *SubscriptionsService
+}
+
+func NewProgramsService(repository ProgramsRepository, purchasesService *PurchasesService, subscriptionsService *SubscriptionsService) *ProgramsService {
+	return &ProgramsService{
+		repository:           repository,
+		purchasesService:     purchasesService,
+		subscriptionsService: subscriptionsService,
+	}
+}
+
+func (s *ProgramsService) FindAll(viewerID int, pagination repositories.Pagination) ([]data_transfers.ProgramsResponse, int, int, error) {
+	programs, total, err := s.repository.FindAll(viewerID, pagination)
+	if err != nil {
+		return nil, 0, http.StatusInternalServerError, fmt.Errorf("service - FindAll - repository.FindAll: %w", err)
+	}
+
+	responses := make([]data_transfers.ProgramsResponse, 0, len(programs))
+	for _, program := range programs {
+		responses = append(responses, toProgramsResponse(program))
+	}
+
+	return responses, total, http.StatusOK, nil
+}
+
+func (s *ProgramsService) FindAllByOwnerID(ownerID int) ([]data_transfers.ProgramsResponse, int, error) {
+	programs, err := s.repository.FindAllByOwnerID(ownerID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAllByOwnerID - repository.FindAllByOwnerID: %w", err)
+	}
+
+	responses := make([]data_transfers.ProgramsResponse, 0, len(programs))
+	for _, program := range programs {
+		responses = append(responses, toProgramsResponse(program))
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+// FindByID returns the program with its schedule. Private programs are only
+// found by their owner.
+func (s *ProgramsService) FindByID(viewerID int, id int) (data_transfers.ProgramsResponse, int, error) {
+	program, statusCode, err := s.findVisible(viewerID, id)
+	if err != nil {
+		return data_transfers.ProgramsResponse{}, statusCode, err
+	}
+
+	weeks, err := s.repository.FindWeeks(id)
+	if err != nil {
+		return data_transfers.ProgramsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - FindByID - repository.FindWeeks: %w", err)
+	}
+
+	days, err := s.repository.FindDays(id)
+	if err != nil {
+		return data_transfers.ProgramsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - FindByID - repository.FindDays: %w", err)
+	}
+
+	response := toProgramsResponse(program)
+	response.Weeks = toProgramWeeksResponses(weeks, days)
+
+	return response, http.StatusOK, nil
+}
+
+func (s *ProgramsService) Create(ownerID int, createRequest data_transfers.CreateProgramRequest) (data_transfers.ProgramsResponse, int, error) {
+	weeks, days, statusCode, err := s.buildSchedule(ownerID, createRequest.DaysPerWeek, createRequest.Weeks)
+	if err != nil {
+		return data_transfers.ProgramsResponse{}, statusCode, err
+	}
+
+	id, err := s.repository.Save(records.Programs{
+		OwnerID:     ownerID,
+		Title:       createRequest.Title,
+		Description: createRequest.Description,
+		IsPrivate:   createRequest.IsPrivate,
+		DaysPerWeek: createRequest.DaysPerWeek,
+	}, weeks, days)
+	if err != nil {
+		return data_transfers.ProgramsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - repository.Save: %w", err)
+	}
+
+	response, _, err := s.FindByID(ownerID, id)
+	if err != nil {
+		return data_transfers.ProgramsResponse{}, http.StatusInternalServerError, err
+	}
+
+	return response, http.StatusCreated, nil
+}
+
+func (s *ProgramsService) Update(ownerID int, id int, updateRequest data_transfers.UpdateProgramRequest) (data_transfers.ProgramsResponse, int, error) {
+	if _, statusCode, err := s.findOwn(ownerID, id); err != nil {
+		return data_transfers.ProgramsResponse{}, statusCode, err
+	}
+
+	programMap, err := convert.StructToMap(updateRequest)
+	if err != nil {
+		return data_transfers.ProgramsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Update - convert.StructToMap: %w", err)
+	}
+	if len(programMap) == 0 {
+		return data_transfers.ProgramsResponse{}, http.StatusBadRequest, errors.New("nothing to update")
+	}
+
+	if err := s.repository.Update(id, programMap); err != nil {
+		return data_transfers.ProgramsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Update - repository.Update: %w", err)
+	}
+
+	return s.FindByID(ownerID, id)
+}
+
+// ReplaceSchedule replaces the whole schedule of the program. Enrolled users
+// carry on from the first scheduled day at or after where they were.
+func (s *ProgramsService) ReplaceSchedule(ownerID int, id int, scheduleRequest data_transfers.ReplaceProgramScheduleRequest) (data_transfers.ProgramsResponse, int, error) {
+	if _, statusCode, err := s.findOwn(ownerID, id); err != nil {
+		return data_transfers.ProgramsResponse{}, statusCode, err
+	}
+
+	weeks, days, statusCode, err := s.buildSchedule(ownerID, scheduleRequest.DaysPerWeek, scheduleRequest.Weeks)
+	if err != nil {
+		return data_transfers.ProgramsResponse{}, statusCode, err
+	}
+
+	if err := s.repository.ReplaceSchedule(id, scheduleRequest.DaysPerWeek, weeks, days); err != nil {
+		return data_transfers.ProgramsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - ReplaceSchedule - repository.ReplaceSchedule: %w", err)
+	}
+
+	return s.FindByID(ownerID, id)
+}
+
+func (s *ProgramsService) Delete(ownerID int, id int) (int, error) {
+	if _, statusCode, err := s.findOwn(ownerID, id); err != nil {
+		return statusCode, err
+	}
+
+	if err := s.repository.Delete(id); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - Delete - repository.Delete: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Enroll starts the user at the first scheduled day of the program. Users
+// must have purchased the paid workouts of the program, and be members when
+// it has members-only workouts, unless the workouts are their own.
+func (s *ProgramsService) Enroll(userID int, programID int) (data_transfers.ProgramEnrollmentsResponse, int, error) {
+	if _, statusCode, err := s.findVisible(userID, programID); err != nil {
+		return data_transfers.ProgramEnrollmentsResponse{}, statusCode, err
+	}
+
+	days, err := s.repository.FindDays(programID)
+	if err != nil {
+		return data_transfers.ProgramEnrollmentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Enroll - repository.FindDays: %w", err)
+	}
+	if len(days) == 0 {
+		return data_transfers.ProgramEnrollmentsResponse{}, http.StatusBadRequest, errors.New("this program has no workouts scheduled yet")
+	}
+
+	statusCode, err := s.checkAccess(userID, days)
+	if err != nil {
+		return data_transfers.ProgramEnrollmentsResponse{}, statusCode, err
+	}
+
+	_, err = s.repository.Enroll(records.ProgramEnrollments{
+		ProgramID:   programID,
+		UserID:      userID,
+		CurrentWeek: days[0].WeekNumber,
+		CurrentDay:  days[0].DayNumber,
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return data_transfers.ProgramEnrollmentsResponse{}, http.StatusConflict, errors.New("you are already enrolled in this program")
+		}
+		return data_transfers.ProgramEnrollmentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Enroll - repository.Enroll: %w", err)
+	}
+
+	response, _, err := s.FindEnrollment(userID, programID)
+	if err != nil {
+		return data_transfers.ProgramEnrollmentsResponse{}, http.StatusInternalServerError, err
+	}
+
+	return response, http.StatusCreated, nil
+}
+
+func (s *ProgramsService) Unenroll(userID int, programID int) (int, error) {
+	if err := s.repository.Unenroll(programID, userID); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("you are not enrolled in this program")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Unenroll - repository.Unenroll: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *ProgramsService) FindEnrollment(userID int, programID int) (data_transfers.ProgramEnrollmentsResponse, int, error) {
+	enrollment, statusCode, err := s.findEnrollment(userID, programID)
+	if err != nil {
+		return data_transfers.ProgramEnrollmentsResponse{}, statusCode, err
+	}
+
+	return s.toProgramEnrollmentsResponse(enrollment)
+}
+
+func (s *ProgramsService) FindEnrollments(userID int) ([]data_transfers.ProgramEnrollmentsResponse, int, error) {
+	enrollments, err := s.repository.FindEnrollmentsByUserID(userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindEnrollments - repository.FindEnrollmentsByUserID: %w", err)
+	}
+
+	responses := make([]data_transfers.ProgramEnrollmentsResponse, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		response, statusCode, err := s.toProgramEnrollmentsResponse(enrollment)
+		if err != nil {
+			return nil, statusCode, err
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+// CompleteDay marks the user's current day of the program as done and moves
+// on to the next scheduled day. Completing the last day completes the program.
+func (s *ProgramsService) CompleteDay(userID int, programID int) (data_transfers.ProgramEnrollmentsResponse, int, error) {
+	enrollment, statusCode, err := s.findEnrollment(userID, programID)
+	if err != nil {
+		return data_transfers.ProgramEnrollmentsResponse{}, statusCode, err
+	}
+	if enrollment.CompletedAt.Valid {
+		return data_transfers.ProgramEnrollmentsResponse{}, http.StatusConflict, errors.New("you already completed this program")
+	}
+
+	days, err := s.repository.FindDays(programID)
+	if err != nil {
+		return data_transfers.ProgramEnrollmentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - CompleteDay - repository.FindDays: %w", err)
+	}
+
+	// The schedule may have changed since the user got here, so the current
+	// day is the first scheduled day at or after their position.
+	completion := records.ProgramCompletions{
+		EnrollmentID: enrollment.ID,
+		WeekNumber:   enrollment.CurrentWeek,
+		DayNumber:    enrollment.CurrentDay,
+	}
+	var next *records.ProgramDays
+	if current := currentProgramDay(days, enrollment); current >= 0 {
+		completion.WeekNumber = days[current].WeekNumber
+		completion.DayNumber = days[current].DayNumber
+		completion.WorkoutID = sql.NullInt64{Int64: int64(days[current].WorkoutID), Valid: true}
+		if current+1 < len(days) {
+			next = &days[current+1]
+		}
+	}
+
+	if err := s.repository.CompleteDay(enrollment, completion, next); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.ProgramEnrollmentsResponse{}, http.StatusConflict, errors.New("this day was already completed")
+		}
+		return data_transfers.ProgramEnrollmentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - CompleteDay - repository.CompleteDay: %w", err)
+	}
+
+	return s.FindEnrollment(userID, programID)
+}
+
+// buildSchedule checks the weeks of a schedule request and turns them into
+// records. Weeks are numbered 1 to n, days fit in the days per week and every
+// workout belongs to the owner of the program.
+func (s *ProgramsService) buildSchedule(ownerID int, daysPerWeek int, weekRequests []data_transfers.ProgramWeekRequest) ([]records.ProgramWeeks, []records.ProgramDays, int, error) {
+	weekRequests = append([]data_transfers.ProgramWeekRequest(nil), weekRequests...)
+	sort.Slice(weekRequests, func(i, j int) bool { return weekRequests[i].Week < weekRequests[j].Week })
+
+	var weeks []records.ProgramWeeks
+	var days []records.ProgramDays
+	workoutIDs := make(map[int]bool)
+	for i, weekRequest := range weekRequests {
+		if weekRequest.Week != i+1 {
+			return nil, nil, http.StatusBadRequest, errors.New("weeks must be numbered from 1 without gaps or repeats")
+		}
+
+		weeks = append(weeks, records.ProgramWeeks{
+			WeekNumber: weekRequest.Week,
+			IsDeload:   weekRequest.IsDeload,
+			Notes:      weekRequest.Notes,
+		})
+
+		seen := make(map[int]bool)
+		for _, dayRequest := range weekRequest.Days {
+			if dayRequest.Day > daysPerWeek {
+				return nil, nil, http.StatusBadRequest, fmt.Errorf("week %d has day %d but the program has %d days per week", weekRequest.Week, dayRequest.Day, daysPerWeek)
+			}
+			if seen[dayRequest.Day] {
+				return nil, nil, http.StatusBadRequest, fmt.Errorf("week %d has day %d more than once", weekRequest.Week, dayRequest.Day)
+			}
+			seen[dayRequest.Day] = true
+
+			days = append(days, records.ProgramDays{
+				WeekNumber: weekRequest.Week,
+				DayNumber:  dayRequest.Day,
+				WorkoutID:  dayRequest.WorkoutID,
+			})
+			workoutIDs[dayRequest.WorkoutID] = true
+		}
+	}
+
+	if len(workoutIDs) > 0 {
+		ids := make([]int, 0, len(workoutIDs))
+		for id := range workoutIDs {
+			ids = append(ids, id)
+		}
+
+		owned, err := s.repository.CountOwnedWorkouts(ownerID, ids)
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, fmt.Errorf("service - buildSchedule - repository.CountOwnedWorkouts: %w", err)
+		}
+		if owned != len(ids) {
+			return nil, nil, http.StatusForbidden, errors.New("programs can only schedule your own workouts")
+		}
+	}
+
+	return weeks, days, http.StatusOK, nil
+}
+
+// checkAccess tells the user which workouts of the schedule they still have
+// to purchase, or that they need a membership.
+func (s *ProgramsService) checkAccess(userID int, days []records.ProgramDays) (int, error) {
+	var paidWorkoutIDs []int
+	membersOnly := false
+	seen := make(map[int]bool)
+	for _, day := range days {
+		if day.WorkoutOwnerID == userID || seen[day.WorkoutID] {
+			continue
+		}
+		seen[day.WorkoutID] = true
+
+		if day.WorkoutPrice != float64(0) {
+			paidWorkoutIDs = append(paidWorkoutIDs, day.WorkoutID)
+		}
+		membersOnly = membersOnly || day.WorkoutMembersOnly
+	}
+
+	purchased, statusCode, err := s.purchasesService.EntitledWorkoutIDs(userID, paidWorkoutIDs)
+	if err != nil {
+		return statusCode, err
+	}
+
+	var missing []string
+	for _, workoutID := range paidWorkoutIDs {
+		if !purchased[workoutID] {
+			missing = append(missing, fmt.Sprint(workoutID))
+		}
+	}
+	if len(missing) > 0 {
+		return http.StatusPaymentRequired, fmt.Errorf("purchase workouts %s to enroll in this program", strings.Join(missing, ", "))
+	}
+
+	if membersOnly {
+		member, statusCode, err := s.subscriptionsService.IsMember(userID)
+		if err != nil {
+			return statusCode, err
+		}
+		if !member {
+			return http.StatusPaymentRequired, errors.New("this program has members-only workouts")
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *ProgramsService) findVisible(viewerID int, id int) (records.Programs, int, error) {
+	program, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return records.Programs{}, http.StatusNotFound, errors.New("program not found")
+		}
+		return records.Programs{}, http.StatusInternalServerError, fmt.Errorf("service - findVisible - repository.FindByID: %w", err)
+	}
+	if program.IsPrivate && program.OwnerID != viewerID {
+		return records.Programs{}, http.StatusNotFound, errors.New("program not found")
+	}
+
+	return program, http.StatusOK, nil
+}
+
+func (s *ProgramsService) findOwn(ownerID int, id int) (records.Programs, int, error) {
+	program, statusCode, err := s.findVisible(ownerID, id)
+	if err != nil {
+		return records.Programs{}, statusCode, err
+	}
+	if program.OwnerID != ownerID {
+		return records.Programs{}, http.StatusForbidden, errors.New("this program is not yours")
+	}
+
+	return program, http.StatusOK, nil
+}
+
+func (s *ProgramsService) findEnrollment(userID int, programID int) (records.ProgramEnrollments, int, error) {
+	enrollment, err := s.repository.FindEnrollment(programID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return records.ProgramEnrollments{}, http.StatusNotFound, errors.New("you are not enrolled in this program")
+		}
+		return records.ProgramEnrollments{}, http.StatusInternalServerError, fmt.Errorf("service - findEnrollment - repository.FindEnrollment: %w", err)
+	}
+
+	return enrollment, http.StatusOK, nil
+}
+
+func (s *ProgramsService) toProgramEnrollmentsResponse(enrollment records.ProgramEnrollments) (data_transfers.ProgramEnrollmentsResponse, int, error) {
+	days, err := s.repository.FindDays(enrollment.ProgramID)
+	if err != nil {
+		return data_transfers.ProgramEnrollmentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - toProgramEnrollmentsResponse - repository.FindDays: %w", err)
+	}
+
+	weeks, err := s.repository.FindWeeks(enrollment.ProgramID)
+	if err != nil {
+		return data_transfers.ProgramEnrollmentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - toProgramEnrollmentsResponse - repository.FindWeeks: %w", err)
+	}
+
+	response := data_transfers.ProgramEnrollmentsResponse{
+		ID:            enrollment.ID,
+		ProgramID:     enrollment.ProgramID,
+		ProgramTitle:  enrollment.ProgramTitle,
+		CurrentWeek:   enrollment.CurrentWeek,
+		CurrentDay:    enrollment.CurrentDay,
+		CompletedDays: enrollment.CompletedDays,
+		TotalDays:     len(days),
+		EnrolledAt:    enrollment.CreatedAt,
+	}
+	if enrollment.CompletedAt.Valid {
+		response.CompletedAt = &enrollment.CompletedAt.Time
+	} else if current := currentProgramDay(days, enrollment); current >= 0 {
+		response.CurrentWeek = days[current].WeekNumber
+		response.CurrentDay = days[current].DayNumber
+		response.CurrentWorkoutID = &days[current].WorkoutID
+	}
+	for _, week := range weeks {
+		if week.WeekNumber == response.CurrentWeek {
+			response.IsDeloadWeek = week.IsDeload
+		}
+	}
+
+	return response, http.StatusOK, nil
+}
+
+// currentProgramDay returns the index of the first of the ordered days at or
+// after the enrollment's position, or -1 when there is none left.
+func currentProgramDay(days []records.ProgramDays, enrollment records.ProgramEnrollments) int {
+	for i, day := range days {
+		if day.WeekNumber > enrollment.CurrentWeek || (day.WeekNumber == enrollment.CurrentWeek && day.DayNumber >= enrollment.CurrentDay) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func toProgramsResponse(program records.Programs) data_transfers.ProgramsResponse {
+	return data_transfers.ProgramsResponse{
+		ID:          program.ID,
+		OwnerID:     program.OwnerID,
+		Title:       program.Title,
+		Description: program.Description,
+		IsPrivate:   program.IsPrivate,
+		DaysPerWeek: program.DaysPerWeek,
+		WeeksCount:  program.WeeksCount,
+		Price:       program.Price,
+		CreatedAt:   program.CreatedAt,
+	}
+}
+
+func toProgramWeeksResponses(weeks []records.ProgramWeeks, days []records.ProgramDays) []data_transfers.ProgramWeeksResponse {
+	responses := make([]data_transfers.ProgramWeeksResponse, 0, len(weeks))
+	for _, week := range weeks {
+		response := data_transfers.ProgramWeeksResponse{
+			Week:     week.WeekNumber,
+			IsDeload: week.IsDeload,
+			Notes:    week.Notes,
+			Days:     []data_transfers.ProgramDaysResponse{},
+		}
+		for _, day := range days {
+			if day.WeekNumber == week.WeekNumber {
+				response.Days = append(response.Days, data_transfers.ProgramDaysResponse{
+					Day:          day.DayNumber,
+					WorkoutID:    day.WorkoutID,
+					WorkoutTitle: day.WorkoutTitle,
+				})
+			}
+		}
+		responses = append(responses, response)
+	}
+
+	return responses
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/convert"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	PromoDiscountPercent = "percent"
+	PromoDiscountFixed   = "fixed"
+)
+
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,64}$`)
+
+type PromoCodesRepository interface {
+	Save(promoCode records.PromoCodes, workoutIDs []int) (records.PromoCodes, error)
+	Update(id int, promoCode map[string]interface{}, workoutIDs []int) error
+	Delete(id int) error
+	FindByID(id int) (records.PromoCodes, error)
+	FindByCode(code string) (records.PromoCodes, error)
+	FindAll(ownerID int) ([]records.PromoCodes, error)
+	FindWorkouts(promoCodeIDs []int) ([]records.PromoCodeWorkouts, error)
+	FindUsage(promoCodeIDs []int) ([]records.PromoCodeUsage, error)
+	CountRedemptions(promoCodeID int, userID int) (int, int, error)
+	SaveRedemption(redemption records.PromoRedemptions, maxRedemptions sql.NullInt64, perUserLimit int) (bool, error)
+}
+
+// PromoQuote is the discount a promo code gives on a workout, in the
+// currency's minor unit.
+type PromoQuote struct {
+	PromoCode records.PromoCodes
+	Discount  int64
+}
+
+// PromoCodesService manages discount codes for workout purchases. Creators
+// create codes for their own workouts; admins create platform codes, which
+// have no owner and apply to any paid workout.
+type PromoCodesService struct {
+	repository      PromoCodesRepository
+	workoutsService *WorkoutsService
+}
+
+func NewPromoCodesService(repository PromoCodesRepository, workoutsService *WorkoutsService) *PromoCodesService {
+	return &PromoCodesService{
+		repository:      repository,
+		workoutsService: workoutsService,
+	}
+}
+
+// Create adds a promo code owned by ownerID, or a platform code when ownerID
+// is 0.
+func (s *PromoCodesService) Create(ownerID int, createRequest data_transfers.CreatePromoCodeRequest) (data_transfers.PromoCodesResponse, int, error) {
+	code := strings.ToUpper(strings.TrimSpace(createRequest.Code))
+	if !promoCodePattern.MatchString(code) {
+		return data_transfers.PromoCodesResponse{}, http.StatusBadRequest, errors.New("promo codes may only contain letters, digits, dashes and underscores")
+	}
+	if createRequest.DiscountType == PromoDiscountPercent && createRequest.DiscountValue > 100 {
+		return data_transfers.PromoCodesResponse{}, http.StatusBadRequest, errors.New("a percent discount cannot be above 100")
+	}
+	if createRequest.StartsAt != nil && createRequest.ExpiresAt != nil && !createRequest.StartsAt.Before(*createRequest.ExpiresAt) {
+		return data_transfers.PromoCodesResponse{}, http.StatusBadRequest, errors.New("starts_at must be before expires_at")
+	}
+
+	statusCode, err := s.checkWorkouts(ownerID, createRequest.WorkoutIDs)
+	if err != nil {
+		return data_transfers.PromoCodesResponse{}, statusCode, err
+	}
+
+	promoCode := records.PromoCodes{
+		Code:          code,
+		DiscountType:  createRequest.DiscountType,
+		DiscountValue: math.Round(createRequest.DiscountValue*100) / 100,
+		Currency:      config.Config.PaymentsCurrency,
+		PerUserLimit:  createRequest.PerUserLimit,
+		IsActive:      true,
+	}
+	if ownerID > 0 {
+		promoCode.OwnerID = sql.NullInt64{Int64: int64(ownerID), Valid: true}
+	}
+	if promoCode.PerUserLimit == 0 {
+		promoCode.PerUserLimit = 1
+	}
+	if createRequest.MaxRedemptions != nil {
+		promoCode.MaxRedemptions = sql.NullInt64{Int64: int64(*createRequest.MaxRedemptions), Valid: true}
+	}
+	if createRequest.StartsAt != nil {
+		promoCode.StartsAt = sql.NullTime{Time: *createRequest.StartsAt, Valid: true}
+	}
+	if createRequest.ExpiresAt != nil {
+		promoCode.ExpiresAt = sql.NullTime{Time: *createRequest.ExpiresAt, Valid: true}
+	}
+
+	saved, err := s.repository.Save(promoCode, createRequest.WorkoutIDs)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return data_transfers.PromoCodesResponse{}, http.StatusConflict, errors.New("promo code already exists")
+		}
+		return data_transfers.PromoCodesResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - repository.Save: %w", err)
+	}
+
+	return toPromoCodesResponse(saved, createRequest.WorkoutIDs, 0), http.StatusCreated, nil
+}
+
+// Update changes the limits, period or workouts of a promo code. Owners may
+// only update their own codes; admins, with ownerID 0, may update any code.
+func (s *PromoCodesService) Update(ownerID int, id int, updateRequest data_transfers.UpdatePromoCodeRequest) (int, error) {
+	promoCode, statusCode, err := s.findForOwner(ownerID, id)
+	if err != nil {
+		return statusCode, err
+	}
+
+	var workoutIDs []int
+	if updateRequest.WorkoutIDs != nil {
+		workoutIDs = *updateRequest.WorkoutIDs
+		if workoutIDs == nil {
+			workoutIDs = []int{}
+		}
+		statusCode, err := s.checkWorkouts(int(promoCode.OwnerID.Int64), workoutIDs)
+		if err != nil {
+			return statusCode, err
+		}
+	}
+
+	startsAt, expiresAt := promoCode.StartsAt, promoCode.ExpiresAt
+	if updateRequest.StartsAt != nil {
+		startsAt = sql.NullTime{Time: *updateRequest.StartsAt, Valid: true}
+	}
+	if updateRequest.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *updateRequest.ExpiresAt, Valid: true}
+	}
+	if startsAt.Valid && expiresAt.Valid && !startsAt.Time.Before(expiresAt.Time) {
+		return http.StatusBadRequest, errors.New("starts_at must be before expires_at")
+	}
+
+	promoCodeMap, err := convert.StructToMap(updateRequest)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - Update - convert.StructToMap: %w", err)
+	}
+	delete(promoCodeMap, "workout_ids")
+	if len(promoCodeMap) == 0 && workoutIDs == nil {
+		return http.StatusBadRequest, errors.New("nothing to update")
+	}
+
+	if err := s.repository.Update(id, promoCodeMap, workoutIDs); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("promo code not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Update - repository.Update: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Delete retires a promo code. Purchases made with it keep their discount.
+func (s *PromoCodesService) Delete(ownerID int, id int) (int, error) {
+	if _, statusCode, err := s.findForOwner(ownerID, id); err != nil {
+		return statusCode, err
+	}
+
+	if err := s.repository.Delete(id); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("promo code not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Delete - repository.Delete: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// FindAll lists the promo codes of the owner, or all promo codes when
+// ownerID is 0.
+func (s *PromoCodesService) FindAll(ownerID int) ([]data_transfers.PromoCodesResponse, int, error) {
+	promoCodes, err := s.repository.FindAll(ownerID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAll - repository.FindAll: %w", err)
+	}
+
+	responses := make([]data_transfers.PromoCodesResponse, 0, len(promoCodes))
+	if len(promoCodes) == 0 {
+		return responses, http.StatusOK, nil
+	}
+
+	ids := make([]int, 0, len(promoCodes))
+	for _, promoCode := range promoCodes {
+		ids = append(ids, promoCode.ID)
+	}
+
+	restrictions, err := s.repository.FindWorkouts(ids)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAll - repository.FindWorkouts: %w", err)
+	}
+	workoutIDs := make(map[int][]int)
+	for _, restriction := range restrictions {
+		workoutIDs[restriction.PromoCodeID] = append(workoutIDs[restriction.PromoCodeID], restriction.WorkoutID)
+	}
+
+	usage, err := s.repository.FindUsage(ids)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAll - repository.FindUsage: %w", err)
+	}
+	redemptions := make(map[int]int)
+	for _, used := range usage {
+		redemptions[used.PromoCodeID] = used.Redemptions
+	}
+
+	for _, promoCode := range promoCodes {
+		responses = append(responses, toPromoCodesResponse(promoCode, workoutIDs[promoCode.ID], redemptions[promoCode.ID]))
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+// Quote checks that the user may use the promo code on the workout and
+// works out the discount on amount, the workout price in minor units.
+func (s *PromoCodesService) Quote(userID int, code string, workout data_transfers.WorkoutsResponse, amount int64) (PromoQuote, int, error) {
+	errInvalid := errors.New("promo code is not valid for this workout")
+
+	promoCode, err := s.repository.FindByCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return PromoQuote{}, http.StatusNotFound, errors.New("promo code not found")
+		}
+		return PromoQuote{}, http.StatusInternalServerError, fmt.Errorf("service - Quote - repository.FindByCode: %w", err)
+	}
+
+	now := time.Now()
+	if !promoCode.IsActive || (promoCode.StartsAt.Valid && now.Before(promoCode.StartsAt.Time)) {
+		return PromoQuote{}, http.StatusUnprocessableEntity, errors.New("promo code is not active")
+	}
+	if promoCode.ExpiresAt.Valid && !now.Before(promoCode.ExpiresAt.Time) {
+		return PromoQuote{}, http.StatusUnprocessableEntity, errors.New("promo code has expired")
+	}
+	if promoCode.OwnerID.Valid && int(promoCode.OwnerID.Int64) != workout.OwnerID {
+		return PromoQuote{}, http.StatusUnprocessableEntity, errInvalid
+	}
+	if promoCode.DiscountType == PromoDiscountFixed && !strings.EqualFold(promoCode.Currency, config.Config.PaymentsCurrency) {
+		return PromoQuote{}, http.StatusUnprocessableEntity, errInvalid
+	}
+
+	restrictions, err := s.repository.FindWorkouts([]int{promoCode.ID})
+	if err != nil {
+		return PromoQuote{}, http.StatusInternalServerError, fmt.Errorf("service - Quote - repository.FindWorkouts: %w", err)
+	}
+	if len(restrictions) > 0 {
+		restricted := true
+		for _, restriction := range restrictions {
+			if restriction.WorkoutID == workout.ID {
+				restricted = false
+				break
+			}
+		}
+		if restricted {
+			return PromoQuote{}, http.StatusUnprocessableEntity, errInvalid
+		}
+	}
+
+	total, byUser, err := s.repository.CountRedemptions(promoCode.ID, userID)
+	if err != nil {
+		return PromoQuote{}, http.StatusInternalServerError, fmt.Errorf("service - Quote - repository.CountRedemptions: %w", err)
+	}
+	if promoCode.MaxRedemptions.Valid && int64(total) >= promoCode.MaxRedemptions.Int64 {
+		return PromoQuote{}, http.StatusUnprocessableEntity, errors.New("promo code has been used up")
+	}
+	if byUser >= promoCode.PerUserLimit {
+		return PromoQuote{}, http.StatusUnprocessableEntity, errors.New("you have already used this promo code")
+	}
+
+	return PromoQuote{PromoCode: promoCode, Discount: promoDiscount(promoCode, amount)}, http.StatusOK, nil
+}
+
+// Redeem records the use of a quoted promo code by a payment. It answers 409
+// when the code was used up since it was quoted.
+func (s *PromoCodesService) Redeem(quote PromoQuote, userID int, paymentID int) (int, error) {
+	redeemed, err := s.repository.SaveRedemption(records.PromoRedemptions{
+		PromoCodeID: quote.PromoCode.ID,
+		UserID:      userID,
+		PaymentID:   paymentID,
+		Discount:    quote.Discount,
+	}, quote.PromoCode.MaxRedemptions, quote.PromoCode.PerUserLimit)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - Redeem - repository.SaveRedemption: %w", err)
+	}
+	if !redeemed {
+		return http.StatusConflict, errors.New("promo code has been used up")
+	}
+
+	return http.StatusCreated, nil
+}
+
+func (s *PromoCodesService) findForOwner(ownerID int, id int) (records.PromoCodes, int, error) {
+	promoCode, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return records.PromoCodes{}, http.StatusNotFound, errors.New("promo code not found")
+		}
+		return records.PromoCodes{}, http.StatusInternalServerError, fmt.Errorf("service - findForOwner - repository.FindByID: %w", err)
+	}
+
+	if ownerID > 0 && (!promoCode.OwnerID.Valid || int(promoCode.OwnerID.Int64) != ownerID) {
+		return records.PromoCodes{}, http.StatusNotFound, errors.New("promo code not found")
+	}
+
+	return promoCode, http.StatusOK, nil
+}
+
+// checkWorkouts makes sure a promo code is only restricted to paid workouts,
+// and for creator codes only to workouts of the creator.
+func (s *PromoCodesService) checkWorkouts(ownerID int, workoutIDs []int) (int, error) {
+	for _, workoutID := range workoutIDs {
+		workout, statusCode, err := s.workoutsService.FindByID(workoutID)
+		if err != nil {
+			if statusCode == http.StatusNotFound {
+				return http.StatusBadRequest, fmt.Errorf("workout %d not found", workoutID)
+			}
+			return statusCode, err
+		}
+		if ownerID > 0 && workout.OwnerID != ownerID {
+			return http.StatusForbidden, fmt.Errorf("workout %d is not yours", workoutID)
+		}
+		if workout.Price <= 0 {
+			return http.StatusBadRequest, fmt.Errorf("workout %d is free", workoutID)
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// promoDiscount is the discount in minor units, never more than amount.
+func promoDiscount(promoCode records.PromoCodes, amount int64) int64 {
+	var discount int64
+	if promoCode.DiscountType == PromoDiscountPercent {
+		discount = int64(math.Round(float64(amount) * promoCode.DiscountValue / 100))
+	} else {
+		discount = int64(math.Round(promoCode.DiscountValue * 100))
+	}
+
+	return min(discount, amount)
+}
+
+func toPromoCodesResponse(promoCode records.PromoCodes, workoutIDs []int, redemptions int) data_transfers.PromoCodesResponse {
+	response := data_transfers.PromoCodesResponse{
+		ID:            promoCode.ID,
+		Code:          promoCode.Code,
+		DiscountType:  promoCode.DiscountType,
+		DiscountValue: promoCode.DiscountValue,
+		Currency:      promoCode.Currency,
+		PerUserLimit:  promoCode.PerUserLimit,
+		Redemptions:   redemptions,
+		IsActive:      promoCode.IsActive,
+		WorkoutIDs:    workoutIDs,
+		CreatedAt:     promoCode.CreatedAt,
+	}
+	if response.WorkoutIDs == nil {
+		response.WorkoutIDs = []int{}
+	}
+	if promoCode.OwnerID.Valid {
+		ownerID := int(promoCode.OwnerID.Int64)
+		response.OwnerID = &ownerID
+	}
+	if promoCode.MaxRedemptions.Valid {
+		maxRedemptions := int(promoCode.MaxRedemptions.Int64)
+		response.MaxRedemptions = &maxRedemptions
+	}
+	if promoCode.StartsAt.Valid {
+		response.StartsAt = &promoCode.StartsAt.Time
+	}
+	if promoCode.ExpiresAt.Valid {
+		response.ExpiresAt = &promoCode.ExpiresAt.Time
+	}
+
+	return response
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	PurchaseStatusActive   = "active"
+	PurchaseStatusRefunded = "refunded"
+)
+
+type PurchasesRepository interface {
+	Save(purchase records.Purchases) (records.Purchases, error)
+	FindAllByBuyerID(buyerID int) ([]records.Purchases, error)
+	FindEntitledWorkoutIDs(buyerID int, workoutIDs []int) ([]int, error)
+	ExistsByPaymentID(paymentID int) (bool, error)
+	UpdateStatusByPaymentID(paymentID int, status string) error
+}
+
+// PurchasesService keeps the ledger of bought workouts. An active purchase
+// entitles the buyer to the full original workout, so later edits by the
+// creator reach every buyer.
+type PurchasesService struct {
+	repository PurchasesRepository
+}
+
+func NewPurchasesService(repository PurchasesRepository) *PurchasesService {
+	return &PurchasesService{repository}
+}
+
+// Record adds a purchase paid through the payment, along with the promo code
+// it was bought with. Amounts are in the currency's minor unit, as charged by
+// the gateway.
+func (s *PurchasesService) Record(payment records.Payments) (int, error) {
+	_, err := s.repository.Save(records.Purchases{
+		BuyerID:       payment.UserID,
+		WorkoutID:     payment.WorkoutID,
+		PaymentID:     sql.NullInt64{Int64: int64(payment.ID), Valid: true},
+		PricePaid:     float64(payment.Amount) / 100,
+		OriginalPrice: float64(payment.OriginalAmount) / 100,
+		Discount:      float64(payment.Discount) / 100,
+		PromoCode:     payment.PromoCode,
+		Currency:      payment.Currency,
+		Status:        PurchaseStatusActive,
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return http.StatusConflict, errors.New("workout is already purchased")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Record - repository.Save: %w", err)
+	}
+
+	return http.StatusCreated, nil
+}
+
+// IsRecorded reports whether a purchase was recorded for the payment.
+func (s *PurchasesService) IsRecorded(paymentID int) (bool, int, error) {
+	exists, err := s.repository.ExistsByPaymentID(paymentID)
+	if err != nil {
+		return false, http.StatusInternalServerError, fmt.Errorf("service - IsRecorded - repository.ExistsByPaymentID: %w", err)
+	}
+
+	return exists, http.StatusOK, nil
+}
+
+// RevokeByPaymentID ends the entitlement bought with a refunded payment.
+func (s *PurchasesService) RevokeByPaymentID(paymentID int) (int, error) {
+	err := s.repository.UpdateStatusByPaymentID(paymentID, PurchaseStatusRefunded)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - RevokeByPaymentID - repository.UpdateStatusByPaymentID: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *PurchasesService) FindAllByBuyerID(buyerID int) ([]data_transfers.PurchasesResponse, int, error) {
+	purchases, err := s.repository.FindAllByBuyerID(buyerID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAllByBuyerID - repository.FindAllByBuyerID: %w", err)
+	}
+
+	responses := make([]data_transfers.PurchasesResponse, 0, len(purchases))
+	for _, purchase := range purchases {
+		var workoutID *int
+		if purchase.WorkoutID.Valid {
+			id := int(purchase.WorkoutID.Int64)
+			workoutID = &id
+		}
+		responses = append(responses, data_transfers.PurchasesResponse{
+			ID:            purchase.ID,
+			WorkoutID:     workoutID,
+			PricePaid:     purchase.PricePaid,
+			OriginalPrice: purchase.OriginalPrice,
+			Discount:      purchase.Discount,
+			PromoCode:     purchase.PromoCode,
+			Currency:      purchase.Currency,
+			Status:        purchase.Status,
+			CreatedAt:     purchase.CreatedAt,
+		})
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+// EntitledWorkoutIDs returns the subset of the workouts the buyer has paid for.
+func (s *PurchasesService) EntitledWorkoutIDs(buyerID int, workoutIDs []int) (map[int]bool, int, error) {
+	entitled := make(map[int]bool)
+	if len(workoutIDs) == 0 {
+		return entitled, http.StatusOK, nil
+	}
+
+	ids, err := s.repository.FindEntitledWorkoutIDs(buyerID, workoutIDs)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - EntitledWorkoutIDs - repository.FindEntitledWorkoutIDs: %w", err)
+	}
+	for _, id := range ids {
+		entitled[id] = true
+	}
+
+	return entitled, http.StatusOK, nil
+}
+
+func (s *PurchasesService) IsEntitled(buyerID int, workoutID int) (bool, int, error) {
+	entitled, statusCode, err := s.EntitledWorkoutIDs(buyerID, []int{workoutID})
+	if err != nil {
+		return false, statusCode, err
+	}
+
+	return entitled[workoutID], http.StatusOK, nil
+}
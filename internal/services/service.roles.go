@@ -0,0 +1,144 @@
+package services
+
+import (
+	"backend/internal/constants"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type RolesRepository interface {
+	FindAll() ([]records.Roles, error)
+	FindByName(name string) (records.Roles, error)
+	FindAllByUserID(userID int) ([]records.Roles, error)
+	FindPermissionsByRoleID(roleID int) ([]records.Permissions, error)
+	FindPermissionsByUserID(userID int) ([]records.Permissions, error)
+	GrantToUser(userID int, roleID int, grantedBy int) error
+	RevokeFromUser(userID int, roleID int) error
+}
+
+type RolesService struct {
+	repository RolesRepository
+}
+
+func NewRolesService(repository RolesRepository) *RolesService {
+	return &RolesService{repository}
+}
+
+func (s *RolesService) FindAll() ([]data_transfers.RolesResponse, int, error) {
+	roles, err := s.repository.FindAll()
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAll - repository.FindAll: %w", err)
+	}
+
+	return s.toRolesResponse(roles)
+}
+
+func (s *RolesService) FindAllByUserID(userID int) ([]data_transfers.RolesResponse, int, error) {
+	roles, err := s.repository.FindAllByUserID(userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAllByUserID - repository.FindAllByUserID: %w", err)
+	}
+
+	return s.toRolesResponse(roles)
+}
+
+// FindAccessByUserID returns the role and permission names that are embedded
+// into the user's access tokens.
+func (s *RolesService) FindAccessByUserID(userID int) ([]string, []string, int, error) {
+	roles, err := s.repository.FindAllByUserID(userID)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, fmt.Errorf("service - FindAccessByUserID - repository.FindAllByUserID: %w", err)
+	}
+
+	permissions, err := s.repository.FindPermissionsByUserID(userID)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, fmt.Errorf("service - FindAccessByUserID - repository.FindPermissionsByUserID: %w", err)
+	}
+
+	roleNames := make([]string, 0, len(roles))
+	for _, role := range roles {
+		roleNames = append(roleNames, role.Name)
+	}
+
+	permissionNames := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		permissionNames = append(permissionNames, permission.Name)
+	}
+
+	return roleNames, permissionNames, http.StatusOK, nil
+}
+
+func (s *RolesService) GrantToUser(userID int, grantRoleRequest data_transfers.GrantRoleRequest, grantedBy int) (int, error) {
+	role, err := s.repository.FindByName(grantRoleRequest.Role)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("role not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - GrantToUser - repository.FindByName: %w", err)
+	}
+
+	err = s.repository.GrantToUser(userID, role.ID, grantedBy)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return http.StatusConflict, errors.New("user already has this role")
+		}
+		if errors.Is(err, repositories.ErrorForeignKeyViolation) {
+			return http.StatusNotFound, errors.New("user not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - GrantToUser - repository.GrantToUser: %w", err)
+	}
+
+	return http.StatusCreated, nil
+}
+
+func (s *RolesService) RevokeFromUser(userID int, roleName string, revokedBy int) (int, error) {
+	if userID == revokedBy && roleName == constants.RoleAdmin {
+		return http.StatusBadRequest, errors.New("you cannot revoke your own admin role")
+	}
+
+	role, err := s.repository.FindByName(roleName)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("role not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - RevokeFromUser - repository.FindByName: %w", err)
+	}
+
+	err = s.repository.RevokeFromUser(userID, role.ID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("user does not have this role")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - RevokeFromUser - repository.RevokeFromUser: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *RolesService) toRolesResponse(roles []records.Roles) ([]data_transfers.RolesResponse, int, error) {
+	rolesResponse := make([]data_transfers.RolesResponse, 0, len(roles))
+	for _, role := range roles {
+		permissions, err := s.repository.FindPermissionsByRoleID(role.ID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("service - toRolesResponse - repository.FindPermissionsByRoleID: %w", err)
+		}
+
+		permissionNames := make([]string, 0, len(permissions))
+		for _, permission := range permissions {
+			permissionNames = append(permissionNames, permission.Name)
+		}
+
+		rolesResponse = append(rolesResponse, data_transfers.RolesResponse{
+			ID:          role.ID,
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: permissionNames,
+		})
+	}
+
+	return rolesResponse, http.StatusOK, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/pkg/attempts"
+	"backend/pkg/logger"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	SignInFailureUnknownEmail         = "unknown_email"
+	SignInFailureInvalidPassword      = "invalid_password"
+	SignInFailureInvalidTwoFactorCode = "invalid_two_factor_code"
+)
+
+// SignInLockedError is returned while an account or an IP address is locked
+// out after too many failed sign-in attempts.
+type SignInLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *SignInLockedError) Error() string {
+	return "too many failed sign-in attempts, please try again later"
+}
+
+type SignInAttemptsRepository interface {
+	Save(attempt records.SignInAttempts) error
+}
+
+// SignInAttemptsService counts failed sign-ins per account and per IP address.
+// Once a limit is reached the key is locked, and every further failure doubles
+// the lockout up to the configured maximum.
+type SignInAttemptsService struct {
+	repository SignInAttemptsRepository
+	store      attempts.Store
+}
+
+func NewSignInAttemptsService(repository SignInAttemptsRepository, store attempts.Store) *SignInAttemptsService {
+	return &SignInAttemptsService{
+		repository: repository,
+		store:      store,
+	}
+}
+
+// Check fails with a SignInLockedError when the account or the IP address is locked.
+// The counters store being unavailable does not block sign-ins.
+func (s *SignInAttemptsService) Check(email string, ipAddress string) (int, error) {
+	var retryAfter time.Duration
+	for _, key := range []string{accountAttemptsKey(email), ipAttemptsKey(ipAddress)} {
+		lockedFor, err := s.store.LockedFor(key)
+		if err != nil {
+			logger.ZeroLogger.Error().Msgf("service - Check - store.LockedFor: %v", err)
+			continue
+		}
+
+		retryAfter = max(retryAfter, lockedFor)
+	}
+
+	if retryAfter > 0 {
+		return http.StatusTooManyRequests, &SignInLockedError{RetryAfter: retryAfter}
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *SignInAttemptsService) RegisterFailure(attempt records.SignInAttempts) (int, error) {
+	window := time.Minute * time.Duration(config.Config.SignInAttemptsWindow)
+
+	limits := map[string]int64{
+		accountAttemptsKey(attempt.Email): int64(config.Config.SignInMaxAttemptsPerAccount),
+		ipAttemptsKey(attempt.IPAddress):  int64(config.Config.SignInMaxAttemptsPerIP),
+	}
+	for key, limit := range limits {
+		failures, err := s.store.Increment(key, window)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("service - RegisterFailure - store.Increment: %w", err)
+		}
+
+		if failures < limit {
+			continue
+		}
+
+		err = s.store.Lock(key, lockoutDuration(failures-limit))
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("service - RegisterFailure - store.Lock: %w", err)
+		}
+	}
+
+	err := s.repository.Save(attempt)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - RegisterFailure - repository.Save: %w", err)
+	}
+
+	return http.StatusCreated, nil
+}
+
+// RegisterSuccess clears the account counters. The IP address counters are
+// kept so that one valid account cannot be used to reset them.
+func (s *SignInAttemptsService) RegisterSuccess(email string) (int, error) {
+	err := s.store.Reset(accountAttemptsKey(email))
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - RegisterSuccess - store.Reset: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func lockoutDuration(excessFailures int64) time.Duration {
+	base := time.Second * time.Duration(config.Config.SignInLockoutBase)
+	maximum := time.Minute * time.Duration(config.Config.SignInLockoutMax)
+
+	lockout := base
+	for i := int64(0); i < excessFailures && lockout < maximum; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, maximum)
+}
+
+func accountAttemptsKey(email string) string {
+	return "sign-in:account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptsKey(ipAddress string) string {
+	return "sign-in:ip:" + ipAddress
+}
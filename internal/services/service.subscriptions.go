@@ -0,0 +1,453 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/billing"
+	"backend/pkg/convert"
+	"backend/pkg/logger"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SubscriptionStatusTrial    = "trial"
+	SubscriptionStatusActive   = "active"
+	SubscriptionStatusPastDue  = "past_due"
+	SubscriptionStatusCanceled = "canceled"
+
+	SubscriptionIntervalMonth = "month"
+	SubscriptionIntervalYear  = "year"
+
+	subscriptionsBatchSize = 50
+)
+
+var errNoPaymentMethod = errors.New("no usable payment method")
+
+// memberSubscriptionStatuses give access to members-only content. Past due
+// members keep it while their payment is retried.
+var memberSubscriptionStatuses = []string{SubscriptionStatusTrial, SubscriptionStatusActive, SubscriptionStatusPastDue}
+
+type SubscriptionsRepository interface {
+	SavePlan(plan records.SubscriptionPlans) (records.SubscriptionPlans, error)
+	UpdatePlan(id int, plan map[string]interface{}) error
+	FindPlanByID(id int) (records.SubscriptionPlans, error)
+	FindAllPlans(activeOnly bool) ([]records.SubscriptionPlans, error)
+	Save(subscription records.Subscriptions) (records.Subscriptions, error)
+	FindCurrentByUserID(userID int) (records.Subscriptions, error)
+	ExistsByUserID(userID int, statuses []string) (bool, error)
+	FindDue(now time.Time, limit int) ([]records.Subscriptions, error)
+	UpdateCancelAtPeriodEnd(id int, cancel bool) error
+	UpdatePaymentMethod(id int, paymentMethodID int) error
+	Renew(id int, chargedPeriodEnd time.Time, start time.Time, end time.Time) (bool, error)
+	MarkPastDue(id int, failedAttempts int, reason string, nextAttemptAt time.Time) (bool, error)
+	Cancel(id int, reason string) (bool, error)
+}
+
+// SubscriptionsService sells memberships. A subscription starts in trial when
+// the plan has one and the user never subscribed before, otherwise it is
+// charged upfront. Run renews subscriptions at the end of each period through
+// a billing.Biller; failed renewals are past due and retried until the grace
+// period is over, then the subscription is canceled.
+type SubscriptionsService struct {
+	repository            SubscriptionsRepository
+	paymentMethodsService *PaymentMethodsService
+	biller                billing.Biller
+}
+
+func NewSubscriptionsService(repository SubscriptionsRepository, paymentMethodsService *PaymentMethodsService, biller billing.Biller) *SubscriptionsService {
+	return &SubscriptionsService{
+		repository:            repository,
+		paymentMethodsService: paymentMethodsService,
+		biller:                biller,
+	}
+}
+
+func (s *SubscriptionsService) CreatePlan(createRequest data_transfers.CreateSubscriptionPlanRequest) (data_transfers.SubscriptionPlansResponse, int, error) {
+	currency := strings.ToLower(createRequest.Currency)
+	if currency == "" {
+		currency = config.Config.PaymentsCurrency
+	}
+
+	plan, err := s.repository.SavePlan(records.SubscriptionPlans{
+		Name:            createRequest.Name,
+		Description:     createRequest.Description,
+		Price:           createRequest.Price,
+		Currency:        currency,
+		BillingInterval: createRequest.Interval,
+		TrialDays:       createRequest.TrialDays,
+		IsActive:        true,
+	})
+	if err != nil {
+		return data_transfers.SubscriptionPlansResponse{}, http.StatusInternalServerError, fmt.Errorf("service - CreatePlan - repository.SavePlan: %w", err)
+	}
+
+	return toSubscriptionPlansResponse(plan), http.StatusCreated, nil
+}
+
+// UpdatePlan changes how a plan is presented and sold. Prices are fixed so
+// that existing subscribers keep paying what they signed up for.
+func (s *SubscriptionsService) UpdatePlan(id int, updateRequest data_transfers.UpdateSubscriptionPlanRequest) (int, error) {
+	planMap, err := convert.StructToMap(updateRequest)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - UpdatePlan - convert.StructToMap: %w", err)
+	}
+	if len(planMap) == 0 {
+		return http.StatusBadRequest, errors.New("nothing to update")
+	}
+
+	if err := s.repository.UpdatePlan(id, planMap); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("plan not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - UpdatePlan - repository.UpdatePlan: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *SubscriptionsService) FindAllPlans(activeOnly bool) ([]data_transfers.SubscriptionPlansResponse, int, error) {
+	plans, err := s.repository.FindAllPlans(activeOnly)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindAllPlans - repository.FindAllPlans: %w", err)
+	}
+
+	responses := make([]data_transfers.SubscriptionPlansResponse, 0, len(plans))
+	for _, plan := range plans {
+		responses = append(responses, toSubscriptionPlansResponse(plan))
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+func (s *SubscriptionsService) Subscribe(userID int, subscribeRequest data_transfers.SubscribeRequest) (data_transfers.SubscriptionsResponse, int, error) {
+	plan, err := s.repository.FindPlanByID(subscribeRequest.PlanID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.SubscriptionsResponse{}, http.StatusNotFound, errors.New("plan not found")
+		}
+		return data_transfers.SubscriptionsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Subscribe - repository.FindPlanByID: %w", err)
+	}
+	if !plan.IsActive {
+		return data_transfers.SubscriptionsResponse{}, http.StatusNotFound, errors.New("plan not found")
+	}
+
+	subscribed, err := s.repository.ExistsByUserID(userID, memberSubscriptionStatuses)
+	if err != nil {
+		return data_transfers.SubscriptionsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Subscribe - repository.ExistsByUserID: %w", err)
+	}
+	if subscribed {
+		return data_transfers.SubscriptionsResponse{}, http.StatusConflict, errors.New("you already have a membership")
+	}
+
+	paymentMethod, statusCode, err := s.paymentMethodsService.FindChargeable(userID, subscribeRequest.PaymentMethodID)
+	if err != nil {
+		return data_transfers.SubscriptionsResponse{}, statusCode, err
+	}
+
+	// Trials are only offered to users who never subscribed.
+	hadSubscription, err := s.repository.ExistsByUserID(userID, nil)
+	if err != nil {
+		return data_transfers.SubscriptionsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Subscribe - repository.ExistsByUserID: %w", err)
+	}
+
+	now := time.Now()
+	subscription := records.Subscriptions{
+		UserID:             userID,
+		PlanID:             plan.ID,
+		PaymentMethodID:    sql.NullInt64{Int64: int64(paymentMethod.ID), Valid: true},
+		CurrentPeriodStart: now,
+	}
+
+	if plan.TrialDays > 0 && !hadSubscription {
+		subscription.Status = SubscriptionStatusTrial
+		subscription.CurrentPeriodEnd = now.AddDate(0, 0, plan.TrialDays)
+		subscription.TrialEndsAt = sql.NullTime{Time: subscription.CurrentPeriodEnd, Valid: true}
+	} else {
+		_, err := s.biller.Charge(context.Background(), billing.ChargeParams{
+			PaymentMethod:  paymentMethod.Token.String,
+			Amount:         planAmount(plan),
+			Currency:       plan.Currency,
+			Metadata:       map[string]string{"user_id": strconv.Itoa(userID), "plan_id": strconv.Itoa(plan.ID)},
+			IdempotencyKey: fmt.Sprintf("subscribe-%d-%d-%d", userID, plan.ID, now.Unix()),
+		})
+		if err != nil {
+			if errors.Is(err, billing.ErrDeclined) {
+				return data_transfers.SubscriptionsResponse{}, http.StatusPaymentRequired, err
+			}
+			return data_transfers.SubscriptionsResponse{}, http.StatusBadGateway, fmt.Errorf("service - Subscribe - biller.Charge: %w", err)
+		}
+
+		subscription.Status = SubscriptionStatusActive
+		subscription.CurrentPeriodEnd = addBillingInterval(now, plan.BillingInterval)
+	}
+
+	subscription, err = s.repository.Save(subscription)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return data_transfers.SubscriptionsResponse{}, http.StatusConflict, errors.New("you already have a membership")
+		}
+		return data_transfers.SubscriptionsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Subscribe - repository.Save: %w", err)
+	}
+
+	return toSubscriptionsResponse(subscription, plan), http.StatusCreated, nil
+}
+
+func (s *SubscriptionsService) FindCurrent(userID int) (data_transfers.SubscriptionsResponse, int, error) {
+	subscription, plan, statusCode, err := s.findCurrent(userID)
+	if err != nil {
+		return data_transfers.SubscriptionsResponse{}, statusCode, err
+	}
+
+	return toSubscriptionsResponse(subscription, plan), http.StatusOK, nil
+}
+
+// Cancel stops the renewal of the membership, which stays usable until the
+// end of the period. Past due memberships end right away.
+func (s *SubscriptionsService) Cancel(userID int) (int, error) {
+	subscription, _, statusCode, err := s.findCurrent(userID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if subscription.Status == SubscriptionStatusPastDue {
+		if _, err := s.repository.Cancel(subscription.ID, ""); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("service - Cancel - repository.Cancel: %w", err)
+		}
+		return http.StatusOK, nil
+	}
+
+	if err := s.repository.UpdateCancelAtPeriodEnd(subscription.ID, true); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - Cancel - repository.UpdateCancelAtPeriodEnd: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *SubscriptionsService) Resume(userID int) (int, error) {
+	subscription, _, statusCode, err := s.findCurrent(userID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if err := s.repository.UpdateCancelAtPeriodEnd(subscription.ID, false); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - Resume - repository.UpdateCancelAtPeriodEnd: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *SubscriptionsService) UpdatePaymentMethod(userID int, updateRequest data_transfers.UpdateSubscriptionPaymentMethodRequest) (int, error) {
+	subscription, _, statusCode, err := s.findCurrent(userID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	paymentMethod, statusCode, err := s.paymentMethodsService.FindChargeable(userID, updateRequest.PaymentMethodID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	if err := s.repository.UpdatePaymentMethod(subscription.ID, paymentMethod.ID); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - UpdatePaymentMethod - repository.UpdatePaymentMethod: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// IsMember reports whether the user may see members-only content.
+func (s *SubscriptionsService) IsMember(userID int) (bool, int, error) {
+	member, err := s.repository.ExistsByUserID(userID, memberSubscriptionStatuses)
+	if err != nil {
+		return false, http.StatusInternalServerError, fmt.Errorf("service - IsMember - repository.ExistsByUserID: %w", err)
+	}
+
+	return member, http.StatusOK, nil
+}
+
+// Run renews due subscriptions every interval until ctx is done.
+func (s *SubscriptionsService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.renewDueSubscriptions()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *SubscriptionsService) renewDueSubscriptions() {
+	subscriptions, err := s.repository.FindDue(time.Now(), subscriptionsBatchSize)
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - renewDueSubscriptions - repository.FindDue: %v", err)
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		if err := s.renew(subscription); err != nil {
+			logger.ZeroLogger.Error().Msgf("service - renewDueSubscriptions - renew %d: %v", subscription.ID, err)
+		}
+	}
+}
+
+// renew charges the next period of the subscription. Every instance may run
+// it; the charge is idempotent per period and attempt, and the state only
+// moves when the subscription is still as it was read.
+func (s *SubscriptionsService) renew(subscription records.Subscriptions) error {
+	if subscription.CancelAtPeriodEnd {
+		if _, err := s.repository.Cancel(subscription.ID, ""); err != nil {
+			return fmt.Errorf("repository.Cancel: %w", err)
+		}
+		return nil
+	}
+
+	plan, err := s.repository.FindPlanByID(subscription.PlanID)
+	if err != nil {
+		return fmt.Errorf("repository.FindPlanByID: %w", err)
+	}
+
+	now := time.Now()
+	chargeErr := s.charge(subscription, plan)
+	if chargeErr == nil {
+		// Late renewals start a full period from the payment.
+		start := subscription.CurrentPeriodEnd
+		if start.Before(now) && subscription.Status == SubscriptionStatusPastDue {
+			start = now
+		}
+		if _, err := s.repository.Renew(subscription.ID, subscription.CurrentPeriodEnd, start, addBillingInterval(start, plan.BillingInterval)); err != nil {
+			return fmt.Errorf("repository.Renew: %w", err)
+		}
+		return nil
+	}
+
+	// Errors other than declines are transient and retried on the next run.
+	if !errors.Is(chargeErr, billing.ErrDeclined) && !errors.Is(chargeErr, errNoPaymentMethod) {
+		return fmt.Errorf("charge: %w", chargeErr)
+	}
+
+	reason := truncate(chargeErr.Error(), 255)
+	gracePeriod := time.Duration(config.Config.SubscriptionGracePeriod) * 24 * time.Hour
+	if subscription.PastDueSince.Valid && now.Sub(subscription.PastDueSince.Time) >= gracePeriod {
+		if _, err := s.repository.Cancel(subscription.ID, reason); err != nil {
+			return fmt.Errorf("repository.Cancel: %w", err)
+		}
+		return nil
+	}
+
+	nextAttemptAt := now.Add(time.Duration(config.Config.SubscriptionRetryInterval) * time.Hour)
+	if _, err := s.repository.MarkPastDue(subscription.ID, subscription.FailedAttempts, reason, nextAttemptAt); err != nil {
+		return fmt.Errorf("repository.MarkPastDue: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SubscriptionsService) charge(subscription records.Subscriptions, plan records.SubscriptionPlans) error {
+	paymentMethod, _, err := s.paymentMethodsService.FindChargeable(subscription.UserID, int(subscription.PaymentMethodID.Int64))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errNoPaymentMethod, err)
+	}
+
+	_, err = s.biller.Charge(context.Background(), billing.ChargeParams{
+		PaymentMethod: paymentMethod.Token.String,
+		Amount:        planAmount(plan),
+		Currency:      plan.Currency,
+		Metadata: map[string]string{
+			"user_id":         strconv.Itoa(subscription.UserID),
+			"subscription_id": strconv.Itoa(subscription.ID),
+		},
+		IdempotencyKey: fmt.Sprintf("subscription-%d-%d-%d", subscription.ID, subscription.CurrentPeriodEnd.Unix(), subscription.FailedAttempts),
+	})
+
+	return err
+}
+
+func (s *SubscriptionsService) findCurrent(userID int) (records.Subscriptions, records.SubscriptionPlans, int, error) {
+	subscription, err := s.repository.FindCurrentByUserID(userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return records.Subscriptions{}, records.SubscriptionPlans{}, http.StatusNotFound, errors.New("you have no membership")
+		}
+		return records.Subscriptions{}, records.SubscriptionPlans{}, http.StatusInternalServerError, fmt.Errorf("service - findCurrent - repository.FindCurrentByUserID: %w", err)
+	}
+
+	plan, err := s.repository.FindPlanByID(subscription.PlanID)
+	if err != nil {
+		return records.Subscriptions{}, records.SubscriptionPlans{}, http.StatusInternalServerError, fmt.Errorf("service - findCurrent - repository.FindPlanByID: %w", err)
+	}
+
+	return subscription, plan, http.StatusOK, nil
+}
+
+func planAmount(plan records.SubscriptionPlans) int64 {
+	return int64(math.Round(plan.Price * 100))
+}
+
+func addBillingInterval(t time.Time, interval string) time.Time {
+	if interval == SubscriptionIntervalYear {
+		return t.AddDate(1, 0, 0)
+	}
+	return t.AddDate(0, 1, 0)
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return value[:length]
+}
+
+func toSubscriptionPlansResponse(plan records.SubscriptionPlans) data_transfers.SubscriptionPlansResponse {
+	return data_transfers.SubscriptionPlansResponse{
+		ID:          plan.ID,
+		Name:        plan.Name,
+		Description: plan.Description,
+		Price:       plan.Price,
+		Currency:    plan.Currency,
+		Interval:    plan.BillingInterval,
+		TrialDays:   plan.TrialDays,
+		IsActive:    plan.IsActive,
+	}
+}
+
+func toSubscriptionsResponse(subscription records.Subscriptions, plan records.SubscriptionPlans) data_transfers.SubscriptionsResponse {
+	response := data_transfers.SubscriptionsResponse{
+		ID:                 subscription.ID,
+		Plan:               toSubscriptionPlansResponse(plan),
+		Status:             subscription.Status,
+		CurrentPeriodStart: subscription.CurrentPeriodStart,
+		CurrentPeriodEnd:   subscription.CurrentPeriodEnd,
+		CancelAtPeriodEnd:  subscription.CancelAtPeriodEnd,
+		LastError:          subscription.LastError,
+		CreatedAt:          subscription.CreatedAt,
+	}
+	if subscription.TrialEndsAt.Valid {
+		response.TrialEndsAt = &subscription.TrialEndsAt.Time
+	}
+	if subscription.CanceledAt.Valid {
+		response.CanceledAt = &subscription.CanceledAt.Time
+	}
+	if subscription.NextAttemptAt.Valid {
+		response.NextAttemptAt = &subscription.NextAttemptAt.Time
+	}
+	if subscription.PaymentMethodID.Valid {
+		paymentMethodID := int(subscription.PaymentMethodID.Int64)
+		response.PaymentMethodID = &paymentMethodID
+	}
+
+	return response
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/billing"
+	"backend/pkg/payments"
+	"database/sql"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+type memorySubscriptionsRepository struct {
+	SubscriptionsRepository
+	plans         map[int]records.SubscriptionPlans
+	subscriptions []records.Subscriptions
+}
+
+func (r *memorySubscriptionsRepository) FindPlanByID(id int) (records.SubscriptionPlans, error) {
+	plan, ok := r.plans[id]
+	if !ok {
+		return records.SubscriptionPlans{}, repositories.ErrorRowNotFound
+	}
+	return plan, nil
+}
+
+func (r *memorySubscriptionsRepository) Save(subscription records.Subscriptions) (records.Subscriptions, error) {
+	subscription.ID = len(r.subscriptions) + 1
+	subscription.CreatedAt = time.Now()
+	r.subscriptions = append(r.subscriptions, subscription)
+	return subscription, nil
+}
+
+func (r *memorySubscriptionsRepository) FindCurrentByUserID(userID int) (records.Subscriptions, error) {
+	for i := len(r.subscriptions) - 1; i >= 0; i-- {
+		if r.subscriptions[i].UserID == userID {
+			return r.subscriptions[i], nil
+		}
+	}
+	return records.Subscriptions{}, repositories.ErrorRowNotFound
+}
+
+func (r *memorySubscriptionsRepository) ExistsByUserID(userID int, statuses []string) (bool, error) {
+	for _, subscription := range r.subscriptions {
+		if subscription.UserID == userID && (statuses == nil || slices.Contains(statuses, subscription.Status)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memorySubscriptionsRepository) Renew(id int, chargedPeriodEnd time.Time, start time.Time, end time.Time) (bool, error) {
+	subscription := &r.subscriptions[id-1]
+	if !subscription.CurrentPeriodEnd.Equal(chargedPeriodEnd) || subscription.Status == SubscriptionStatusCanceled {
+		return false, nil
+	}
+	subscription.Status = SubscriptionStatusActive
+	subscription.CurrentPeriodStart = start
+	subscription.CurrentPeriodEnd = end
+	subscription.PastDueSince = sql.NullTime{}
+	subscription.NextAttemptAt = sql.NullTime{}
+	subscription.FailedAttempts = 0
+	subscription.LastError = ""
+	return true, nil
+}
+
+func (r *memorySubscriptionsRepository) MarkPastDue(id int, failedAttempts int, reason string, nextAttemptAt time.Time) (bool, error) {
+	subscription := &r.subscriptions[id-1]
+	if subscription.FailedAttempts != failedAttempts || subscription.Status == SubscriptionStatusCanceled {
+		return false, nil
+	}
+	subscription.Status = SubscriptionStatusPastDue
+	if !subscription.PastDueSince.Valid {
+		subscription.PastDueSince = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	subscription.NextAttemptAt = sql.NullTime{Time: nextAttemptAt, Valid: true}
+	subscription.FailedAttempts = failedAttempts + 1
+	subscription.LastError = reason
+	return true, nil
+}
+
+func (r *memorySubscriptionsRepository) Cancel(id int, reason string) (bool, error) {
+	subscription := &r.subscriptions[id-1]
+	if subscription.Status == SubscriptionStatusCanceled {
+		return false, nil
+	}
+	subscription.Status = SubscriptionStatusCanceled
+	subscription.CanceledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	subscription.CancelAtPeriodEnd = false
+	subscription.NextAttemptAt = sql.NullTime{}
+	subscription.LastError = reason
+	return true, nil
+}
+
+type memoryPaymentMethodsRepository struct {
+	PaymentMethodsRepository
+	paymentMethods []records.PaymentMethods
+}
+
+func (r *memoryPaymentMethodsRepository) FindByID(userID int, id int) (records.PaymentMethods, error) {
+	for _, paymentMethod := range r.paymentMethods {
+		if paymentMethod.UserID == userID && paymentMethod.ID == id {
+			return paymentMethod, nil
+		}
+	}
+	return records.PaymentMethods{}, repositories.ErrorRowNotFound
+}
+
+func (r *memoryPaymentMethodsRepository) FindDefaultByUserID(userID int) (records.PaymentMethods, error) {
+	for _, paymentMethod := range r.paymentMethods {
+		if paymentMethod.UserID == userID && paymentMethod.IsDefault {
+			return paymentMethod, nil
+		}
+	}
+	return records.PaymentMethods{}, repositories.ErrorRowNotFound
+}
+
+const testPlanID = 1
+
+// membersOnlyWorkout is free, so only the membership decides who sees it.
+var membersOnlyWorkout = data_transfers.WorkoutsResponse{ID: 7, Title: "Members leg day", OwnerID: 1, MembersOnly: true}
+
+type testSubscriptions struct {
+	service       *SubscriptionsService
+	workouts      *WorkoutsService
+	biller        *billing.FakeBiller
+	subscriptions *memorySubscriptionsRepository
+}
+
+// newTestSubscriptions gives user 2 a card that is charged and user 3 a card
+// that is declined.
+func newTestSubscriptions(t *testing.T) *testSubscriptions {
+	t.Helper()
+
+	config.Config.SubscriptionGracePeriod = 3
+	config.Config.SubscriptionRetryInterval = 24
+
+	gateway := payments.NewFakeGateway("whsec_test", "https://app.example.com/api/v1/payments/fake/authenticate/")
+	paymentMethods := &memoryPaymentMethodsRepository{paymentMethods: []records.PaymentMethods{
+		{Record: records.Record{ID: 1}, UserID: 2, Gateway: gateway.Name(), Token: sql.NullString{String: payments.FakeCardSucceeds, Valid: true}, IsDefault: true},
+		{Record: records.Record{ID: 2}, UserID: 3, Gateway: gateway.Name(), Token: sql.NullString{String: payments.FakeCardDeclined, Valid: true}, IsDefault: true},
+	}}
+
+	ts := &testSubscriptions{
+		biller: billing.NewFakeBiller(),
+		subscriptions: &memorySubscriptionsRepository{plans: map[int]records.SubscriptionPlans{
+			testPlanID: {Record: records.Record{ID: testPlanID}, Name: "Monthly", Price: 4.99, Currency: "usd", BillingInterval: SubscriptionIntervalMonth, IsActive: true},
+		}},
+	}
+	ts.service = NewSubscriptionsService(ts.subscriptions, NewPaymentMethodsService(paymentMethods, gateway), ts.biller)
+	ts.workouts = NewWorkoutsService(nil, nil, nil, nil, nil, ts.service, nil)
+
+	return ts
+}
+
+func (ts *testSubscriptions) isEntitled(t *testing.T, userID int) bool {
+	t.Helper()
+
+	entitled, statusCode, err := ts.workouts.IsEntitled(membersOnlyWorkout, userID)
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("IsEntitled = %d, %v", statusCode, err)
+	}
+	return entitled
+}
+
+// renewNow makes the current period of the subscription end and renews it.
+func (ts *testSubscriptions) renewNow(t *testing.T, id int) records.Subscriptions {
+	t.Helper()
+
+	ts.subscriptions.subscriptions[id-1].CurrentPeriodEnd = time.Now().Add(-time.Minute)
+	if err := ts.service.renew(ts.subscriptions.subscriptions[id-1]); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+	return ts.subscriptions.subscriptions[id-1]
+}
+
+func TestSubscriptionsServiceMembersOnlyWorkoutsRequireAMembership(t *testing.T) {
+	ts := newTestSubscriptions(t)
+
+	if !ts.isEntitled(t, membersOnlyWorkout.OwnerID) {
+		t.Error("the owner is not entitled to their workout")
+	}
+	if ts.isEntitled(t, 2) {
+		t.Error("a user without a membership is entitled")
+	}
+
+	subscription, statusCode, err := ts.service.Subscribe(2, data_transfers.SubscribeRequest{PlanID: testPlanID})
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Subscribe = %d, %v", statusCode, err)
+	}
+	if subscription.Status != SubscriptionStatusActive {
+		t.Errorf("status = %s, want %s", subscription.Status, SubscriptionStatusActive)
+	}
+	if charges := ts.biller.Charges(); len(charges) != 1 || charges[0].Amount != 499 {
+		t.Errorf("charges = %+v, want one of 499", charges)
+	}
+
+	if !ts.isEntitled(t, 2) {
+		t.Error("a member is not entitled")
+	}
+
+	_, statusCode, err = ts.service.Subscribe(2, data_transfers.SubscribeRequest{PlanID: testPlanID})
+	if err == nil || statusCode != http.StatusConflict {
+		t.Errorf("Subscribe again = %d, %v, want 409", statusCode, err)
+	}
+}
+
+func TestSubscriptionsServiceDeclinedCardGivesNoMembership(t *testing.T) {
+	ts := newTestSubscriptions(t)
+
+	_, statusCode, err := ts.service.Subscribe(3, data_transfers.SubscribeRequest{PlanID: testPlanID})
+	if err == nil || statusCode != http.StatusPaymentRequired {
+		t.Fatalf("Subscribe with a declined card = %d, %v, want 402", statusCode, err)
+	}
+	if len(ts.subscriptions.subscriptions) != 0 {
+		t.Errorf("saved %d subscriptions", len(ts.subscriptions.subscriptions))
+	}
+	if ts.isEntitled(t, 3) {
+		t.Error("a user whose card was declined is entitled")
+	}
+}
+
+func TestSubscriptionsServiceDeclinedRenewalEndsTheMembershipAfterTheGracePeriod(t *testing.T) {
+	ts := newTestSubscriptions(t)
+
+	subscription, statusCode, err := ts.service.Subscribe(2, data_transfers.SubscribeRequest{PlanID: testPlanID})
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Subscribe = %d, %v", statusCode, err)
+	}
+
+	ts.biller.Decline(payments.FakeCardSucceeds, true)
+
+	renewed := ts.renewNow(t, subscription.ID)
+	if renewed.Status != SubscriptionStatusPastDue || renewed.FailedAttempts != 1 {
+		t.Fatalf("after a declined renewal status = %s with %d failed attempts, want past due with 1", renewed.Status, renewed.FailedAttempts)
+	}
+	if !ts.isEntitled(t, 2) {
+		t.Error("a past due member lost access during the grace period")
+	}
+
+	ts.subscriptions.subscriptions[subscription.ID-1].PastDueSince.Time = time.Now().Add(-4 * 24 * time.Hour)
+	renewed = ts.renewNow(t, subscription.ID)
+	if renewed.Status != SubscriptionStatusCanceled {
+		t.Fatalf("after the grace period status = %s, want %s", renewed.Status, SubscriptionStatusCanceled)
+	}
+	if ts.isEntitled(t, 2) {
+		t.Error("a canceled member is entitled")
+	}
+}
+
+func TestSubscriptionsServicePastDueRenewalRestoresTheMembership(t *testing.T) {
+	ts := newTestSubscriptions(t)
+
+	subscription, statusCode, err := ts.service.Subscribe(2, data_transfers.SubscribeRequest{PlanID: testPlanID})
+	if err != nil || statusCode != http.StatusCreated {
+		t.Fatalf("Subscribe = %d, %v", statusCode, err)
+	}
+
+	ts.biller.Decline(payments.FakeCardSucceeds, true)
+	ts.renewNow(t, subscription.ID)
+
+	ts.biller.Decline(payments.FakeCardSucceeds, false)
+	renewed := ts.renewNow(t, subscription.ID)
+	if renewed.Status != SubscriptionStatusActive || renewed.FailedAttempts != 0 || renewed.PastDueSince.Valid {
+		t.Errorf("after a paid retry subscription = %+v, want active", renewed)
+	}
+	if !renewed.CurrentPeriodEnd.After(time.Now()) {
+		t.Errorf("period ends %v, want in the future", renewed.CurrentPeriodEnd)
+	}
+	if !ts.isEntitled(t, 2) {
+		t.Error("a renewed member is not entitled")
+	}
+}
+
+func TestWorkoutsServiceMergeUpstreamFromMembersOnlyWorkoutRequiresAMembership(t *testing.T) {
+	ts := newTestSubscriptions(t)
+
+	workouts := &memoryWorkoutsRepository{workouts: map[int]records.Workouts{
+		membersOnlyWorkout.ID: {Record: records.Record{ID: membersOnlyWorkout.ID}, Title: membersOnlyWorkout.Title, OwnerID: membersOnlyWorkout.OwnerID, MembersOnly: true},
+		8:                     {Record: records.Record{ID: 8}, Title: "Members leg day", OwnerID: 2, ForkedFromWorkoutID: sql.NullInt64{Int64: int64(membersOnlyWorkout.ID), Valid: true}},
+	}}
+	service := NewWorkoutsService(workouts, nil, nil, nil, nil, ts.service, nil)
+
+	_, statusCode, err := service.MergeUpstream(8, 2)
+	if err == nil || statusCode != http.StatusForbidden {
+		t.Errorf("MergeUpstream without a membership = %d, %v, want 403", statusCode, err)
+	}
+}
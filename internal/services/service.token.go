@@ -6,12 +6,20 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
 type TokensRepository interface {
 	Save(token records.Tokens) error
 	FindByToken(token string) (records.Tokens, error)
 	Delete(token string) error
+	Rotate(oldToken string, newToken records.Tokens) error
+	FindActiveByUserID(userID int, issuedAfter time.Time) ([]records.Tokens, error)
+	FindActiveByFamilyID(familyID string) (records.Tokens, error)
+	DeleteByFamilyID(familyID string) error
+	DeleteByUserID(userID int) error
 }
 
 type TokensService struct {
@@ -51,3 +59,54 @@ func (s *TokensService) Delete(token string) (int, error) {
 
 	return http.StatusOK, nil
 }
+
+func (s *TokensService) Rotate(oldToken string, newToken records.Tokens) (int, error) {
+	err := s.repository.Rotate(oldToken, newToken)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusUnauthorized, ErrRefreshTokenReused
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Rotate - repository.Rotate: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *TokensService) FindActiveByUserID(userID int, issuedAfter time.Time) ([]records.Tokens, int, error) {
+	tokenRecords, err := s.repository.FindActiveByUserID(userID, issuedAfter)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindActiveByUserID - repository.FindActiveByUserID: %w", err)
+	}
+
+	return tokenRecords, http.StatusOK, nil
+}
+
+func (s *TokensService) FindActiveByFamilyID(familyID string) (records.Tokens, int, error) {
+	tokenRecord, err := s.repository.FindActiveByFamilyID(familyID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return records.Tokens{}, http.StatusNotFound, errors.New("session not found")
+		}
+		return records.Tokens{}, http.StatusInternalServerError, fmt.Errorf("service - FindActiveByFamilyID - repository.FindActiveByFamilyID: %w", err)
+	}
+
+	return tokenRecord, http.StatusOK, nil
+}
+
+func (s *TokensService) DeleteByFamilyID(familyID string) (int, error) {
+	err := s.repository.DeleteByFamilyID(familyID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - DeleteByFamilyID - repository.DeleteByFamilyID: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *TokensService) DeleteByUserID(userID int) (int, error) {
+	err := s.repository.DeleteByUserID(userID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - DeleteByUserID - repository.DeleteByUserID: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
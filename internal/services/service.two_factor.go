@@ -0,0 +1,240 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/totp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const recoveryCodesCount = 10
+
+var ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+type TwoFactorRepository interface {
+	FindCredentialByUserID(userID int) (records.TOTPCredentials, error)
+	SaveCredential(credential records.TOTPCredentials) error
+	ConfirmCredential(userID int, step int64, recoveryCodeHashes []string) error
+	UseStep(userID int, step int64) error
+	DeleteCredential(userID int) error
+	ReplaceRecoveryCodes(userID int, codeHashes []string) error
+	ConsumeRecoveryCode(userID int, codeHash string) error
+	CountUnusedRecoveryCodes(userID int) (int, error)
+}
+
+type TwoFactorService struct {
+	repository   TwoFactorRepository
+	usersService *UsersService
+}
+
+func NewTwoFactorService(repository TwoFactorRepository, usersService *UsersService) *TwoFactorService {
+	return &TwoFactorService{
+		repository:   repository,
+		usersService: usersService,
+	}
+}
+
+func (s *TwoFactorService) Status(userID int) (data_transfers.TwoFactorStatusResponse, int, error) {
+	enabled, statusCode, err := s.IsEnabled(userID)
+	if err != nil {
+		return data_transfers.TwoFactorStatusResponse{}, statusCode, err
+	}
+
+	if !enabled {
+		return data_transfers.TwoFactorStatusResponse{}, http.StatusOK, nil
+	}
+
+	remaining, err := s.repository.CountUnusedRecoveryCodes(userID)
+	if err != nil {
+		return data_transfers.TwoFactorStatusResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Status - repository.CountUnusedRecoveryCodes: %w", err)
+	}
+
+	return data_transfers.TwoFactorStatusResponse{
+		Enabled:                true,
+		RecoveryCodesRemaining: remaining,
+	}, http.StatusOK, nil
+}
+
+func (s *TwoFactorService) IsEnabled(userID int) (bool, int, error) {
+	credential, err := s.repository.FindCredentialByUserID(userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return false, http.StatusOK, nil
+		}
+		return false, http.StatusInternalServerError, fmt.Errorf("service - IsEnabled - repository.FindCredentialByUserID: %w", err)
+	}
+
+	return credential.ConfirmedAt.Valid, http.StatusOK, nil
+}
+
+// Enroll creates a pending TOTP secret. Two-factor authentication is only
+// enabled once a code generated from it is confirmed.
+func (s *TwoFactorService) Enroll(userID int) (data_transfers.TOTPEnrollmentResponse, int, error) {
+	enabled, statusCode, err := s.IsEnabled(userID)
+	if err != nil {
+		return data_transfers.TOTPEnrollmentResponse{}, statusCode, err
+	}
+	if enabled {
+		return data_transfers.TOTPEnrollmentResponse{}, http.StatusConflict, errors.New("two-factor authentication is already enabled")
+	}
+
+	user, statusCode, err := s.usersService.FindByID(userID)
+	if err != nil {
+		return data_transfers.TOTPEnrollmentResponse{}, statusCode, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return data_transfers.TOTPEnrollmentResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Enroll - totp.GenerateSecret: %w", err)
+	}
+
+	err = s.repository.SaveCredential(records.TOTPCredentials{
+		UserID: userID,
+		Secret: secret,
+	})
+	if err != nil {
+		return data_transfers.TOTPEnrollmentResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Enroll - repository.SaveCredential: %w", err)
+	}
+
+	return data_transfers.TOTPEnrollmentResponse{
+		Secret:     secret,
+		OtpauthURI: totp.URI(config.Config.TOTPIssuer, user.Email, secret),
+	}, http.StatusCreated, nil
+}
+
+// Confirm enables two-factor authentication and returns the recovery codes.
+// The codes are only stored hashed, so this is the only time they are shown.
+func (s *TwoFactorService) Confirm(userID int, code string) ([]string, int, error) {
+	credential, err := s.repository.FindCredentialByUserID(userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return nil, http.StatusNotFound, errors.New("two-factor enrollment not started")
+		}
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - Confirm - repository.FindCredentialByUserID: %w", err)
+	}
+	if credential.ConfirmedAt.Valid {
+		return nil, http.StatusConflict, errors.New("two-factor authentication is already enabled")
+	}
+
+	step, ok := totp.Validate(credential.Secret, code, time.Now(), 1)
+	if !ok {
+		return nil, http.StatusUnauthorized, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - Confirm - generateRecoveryCodes: %w", err)
+	}
+
+	err = s.repository.ConfirmCredential(userID, step, hashes)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return nil, http.StatusConflict, errors.New("two-factor authentication is already enabled")
+		}
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - Confirm - repository.ConfirmCredential: %w", err)
+	}
+
+	return codes, http.StatusOK, nil
+}
+
+func (s *TwoFactorService) Disable(userID int, code string) (int, error) {
+	statusCode, err := s.VerifyCode(userID, code)
+	if err != nil {
+		return statusCode, err
+	}
+
+	err = s.repository.DeleteCredential(userID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - Disable - repository.DeleteCredential: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID int, code string) ([]string, int, error) {
+	statusCode, err := s.VerifyCode(userID, code)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - RegenerateRecoveryCodes - generateRecoveryCodes: %w", err)
+	}
+
+	err = s.repository.ReplaceRecoveryCodes(userID, hashes)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - RegenerateRecoveryCodes - repository.ReplaceRecoveryCodes: %w", err)
+	}
+
+	return codes, http.StatusOK, nil
+}
+
+// VerifyCode accepts either a current TOTP code or an unused recovery code.
+// Each TOTP time step and each recovery code can only be used once.
+func (s *TwoFactorService) VerifyCode(userID int, code string) (int, error) {
+	credential, err := s.repository.FindCredentialByUserID(userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusBadRequest, errors.New("two-factor authentication is not enabled")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - VerifyCode - repository.FindCredentialByUserID: %w", err)
+	}
+	if !credential.ConfirmedAt.Valid {
+		return http.StatusBadRequest, errors.New("two-factor authentication is not enabled")
+	}
+
+	if step, ok := totp.Validate(credential.Secret, code, time.Now(), 1); ok {
+		err = s.repository.UseStep(userID, step)
+		if err != nil {
+			if errors.Is(err, repositories.ErrorRowNotFound) {
+				return http.StatusUnauthorized, ErrInvalidTwoFactorCode
+			}
+			return http.StatusInternalServerError, fmt.Errorf("service - VerifyCode - repository.UseStep: %w", err)
+		}
+		return http.StatusOK, nil
+	}
+
+	err = s.repository.ConsumeRecoveryCode(userID, hashRecoveryCode(code))
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusUnauthorized, ErrInvalidTwoFactorCode
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - VerifyCode - repository.ConsumeRecoveryCode: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		raw, err := helpers.GenerateRandomString(5)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashRecoveryCode(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case and dashes so that codes can be typed loosely.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
@@ -12,20 +12,22 @@ import (
 	"fmt"
 	"github.com/jinzhu/copier"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 )
 
 type UsersRepository interface {
 	FindAll() ([]records.Users, error)
 	FindByID(id int) (records.Users, error)
 	FindByEmail(email string) (records.Users, error)
-	FindByUsername(username string) (records.Users, error)
+	FindByUsername(username string, viewerID int) (records.Users, error)
 	Save(user records.Users) error
 	Update(id int, user map[string]interface{}) error
 	ChangeAvatar(id int, avatar string) error
 	Delete(id int) error
 	UsernameExists(username string) (bool, error)
-	FindAllWithFilters(params repositories.QueryParams) ([]records.Users, int, error)
+	FindAllWithFilters(params repositories.QueryParams, viewerID int) ([]records.Users, int, error)
 }
 
 type UsersService struct {
@@ -54,9 +56,9 @@ func (s *UsersService) FindAll() ([]data_transfers.UsersResponse, int, error) {
 	return usersResponse, http.StatusOK, nil
 }
 
-func (s *UsersService) FindAllWithFilters(params repositories.QueryParams) ([]data_transfers.UsersResponse, int, int, error) {
+func (s *UsersService) FindAllWithFilters(params repositories.QueryParams, viewerID int) ([]data_transfers.UsersResponse, int, int, error) {
 	var usersResponse []data_transfers.UsersResponse
-	users, total, err := s.repository.FindAllWithFilters(params)
+	users, total, err := s.repository.FindAllWithFilters(params, viewerID)
 	if err != nil {
 		if errors.Is(err, repositories.ErrorRowNotFound) {
 			return nil, 0, http.StatusNotFound, errors.New("users not found")
@@ -102,9 +104,9 @@ func (s *UsersService) FindByEmail(email string) (data_transfers.UsersResponse,
 	return userResponse, http.StatusOK, nil
 }
 
-func (s *UsersService) FindByUsername(username string) (data_transfers.UsersResponse, int, error) {
+func (s *UsersService) FindByUsername(username string, viewerID int) (data_transfers.UsersResponse, int, error) {
 	var userResponse data_transfers.UsersResponse
-	user, err := s.repository.FindByUsername(username)
+	user, err := s.repository.FindByUsername(username, viewerID)
 	if err != nil {
 		if errors.Is(err, repositories.ErrorRowNotFound) {
 			return userResponse, http.StatusNotFound, errors.New("user not found")
@@ -148,8 +150,16 @@ func (s *UsersService) Update(id int, user data_transfers.UpdateUsersRequest) (i
 		return http.StatusInternalServerError, fmt.Errorf("service - Update - convert.StructToMap: %w", err)
 	}
 
+	// A changed email address has to be verified again.
+	if user.Email != nil {
+		userMap["email_verified_at"] = nil
+	}
+
 	err = s.repository.Update(id, userMap)
 	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return http.StatusConflict, errors.New("user with this email already exists")
+		}
 		if errors.Is(err, repositories.ErrorRowNotFound) {
 			return http.StatusNotFound, errors.New("user not found")
 		}
@@ -171,6 +181,53 @@ func (s *UsersService) Delete(id int) error {
 	return nil
 }
 
+func (s *UsersService) ChangePassword(id int, password string) (int, error) {
+	hash, err := helpers.GenerateHash(password)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - ChangePassword - helpers.GenerateHash: %w", err)
+	}
+
+	err = s.repository.Update(id, map[string]interface{}{"password": hash, "updated_at": time.Now()})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - ChangePassword - repository.Update: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *UsersService) IsEmailVerified(id int) (bool, int, error) {
+	user, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return false, http.StatusNotFound, errors.New("user not found")
+		}
+		return false, http.StatusInternalServerError, fmt.Errorf("service - IsEmailVerified - repository.FindByID: %w", err)
+	}
+
+	return user.EmailVerifiedAt.Valid, http.StatusOK, nil
+}
+
+func (s *UsersService) IsBanned(id int) (bool, int, error) {
+	user, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return false, http.StatusNotFound, errors.New("user not found")
+		}
+		return false, http.StatusInternalServerError, fmt.Errorf("service - IsBanned - repository.FindByID: %w", err)
+	}
+
+	return user.BannedAt.Valid, http.StatusOK, nil
+}
+
+func (s *UsersService) MarkEmailVerified(id int) (int, error) {
+	err := s.repository.Update(id, map[string]interface{}{"email_verified_at": time.Now()})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - MarkEmailVerified - repository.Update: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
 func (s *UsersService) ChangeAvatar(id int, avatar string) (int, error) {
 	err := s.repository.ChangeAvatar(id, avatar)
 	if err != nil {
@@ -228,3 +285,21 @@ func generateFallbackUsername(input string) string {
 	hashString := hex.EncodeToString(hash[:])
 	return "user" + hashString[:10]
 }
+
+// AvatarObjectPrefix is the storage prefix under which all avatars of a user
+// are uploaded, so they can be removed together when the account is deleted.
+func AvatarObjectPrefix(userID int) string {
+	return fmt.Sprintf("avatars/%d/", userID)
+}
+
+// AvatarObjectKey returns the storage key of an avatar URL saved on a
+// profile, if the URL points into the bucket.
+func AvatarObjectKey(avatarURL string, bucket string) (string, bool) {
+	parsed, err := url.Parse(avatarURL)
+	if err != nil || !strings.HasPrefix(parsed.Host, bucket+".") {
+		return "", false
+	}
+
+	key := strings.TrimPrefix(parsed.Path, "/")
+	return key, key != ""
+}
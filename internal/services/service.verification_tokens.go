@@ -0,0 +1,69 @@
+package services
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/helpers"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	VerificationPurposePasswordReset     = "password_reset"
+	VerificationPurposeEmailVerification = "email_verification"
+)
+
+type VerificationTokensRepository interface {
+	Save(token records.VerificationTokens) error
+	Consume(tokenHash string, purpose string) (records.VerificationTokens, error)
+}
+
+// VerificationTokensService issues single-use, expiring tokens that are sent
+// to users by email. Only a SHA-256 hash of each token is stored.
+type VerificationTokensService struct {
+	repository VerificationTokensRepository
+}
+
+func NewVerificationTokensService(repository VerificationTokensRepository) *VerificationTokensService {
+	return &VerificationTokensService{repository}
+}
+
+func (s *VerificationTokensService) Issue(userID int, purpose string, ttl time.Duration) (string, int, error) {
+	token, err := helpers.GenerateRandomString(32)
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("service - Issue - helpers.GenerateRandomString: %w", err)
+	}
+
+	err = s.repository.Save(records.VerificationTokens{
+		TokenHash: hashVerificationToken(token),
+		Purpose:   purpose,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("service - Issue - repository.Save: %w", err)
+	}
+
+	return token, http.StatusCreated, nil
+}
+
+func (s *VerificationTokensService) Consume(token string, purpose string) (records.VerificationTokens, int, error) {
+	tokenRecord, err := s.repository.Consume(hashVerificationToken(token), purpose)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return records.VerificationTokens{}, http.StatusBadRequest, errors.New("token is invalid or has expired")
+		}
+		return records.VerificationTokens{}, http.StatusInternalServerError, fmt.Errorf("service - Consume - repository.Consume: %w", err)
+	}
+
+	return tokenRecord, http.StatusOK, nil
+}
+
+func hashVerificationToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/logger"
+	"backend/pkg/mailer"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const maxPinnedComments = 3
+
+type WorkoutCommentsRepository interface {
+	Save(comment records.WorkoutComments) (int, error)
+	UpdateBody(id int, body string) error
+	Delete(id int) error
+	FindByID(id int) (records.WorkoutComments, error)
+	FindAllByWorkoutID(workoutID int, cursor repositories.Cursor) ([]records.WorkoutComments, error)
+	FindReplies(parentID int, cursor repositories.Cursor) ([]records.WorkoutComments, error)
+	FindPinned(workoutID int) ([]records.WorkoutComments, error)
+	Pin(id int, workoutID int, maxPinned int) (bool, error)
+	Unpin(id int) error
+}
+
+// WorkoutCommentsService handles the comment threads of workouts. Anyone who
+// can see a workout can comment on it or reply to a comment, and the owner of
+// the workout is notified of new comments. Owners can pin a few top-level
+// comments and remove any comment on their workouts.
+type WorkoutCommentsService struct {
+	repository      WorkoutCommentsRepository
+	workoutsService *WorkoutsService
+	usersService    *UsersService
+	mailer          mailer.Mailer
+}
+
+func NewWorkoutCommentsService(repository WorkoutCommentsRepository, workoutsService *WorkoutsService, usersService *UsersService, mailer mailer.Mailer) *WorkoutCommentsService {
+	return &WorkoutCommentsService{
+		repository:      repository,
+		workoutsService: workoutsService,
+		usersService:    usersService,
+		mailer:          mailer,
+	}
+}
+
+// FindAllByWorkoutID lists a page of top-level comments. The pinned comments
+// come with the first page only.
+func (s *WorkoutCommentsService) FindAllByWorkoutID(userID int, workoutID int, cursor repositories.Cursor) ([]data_transfers.WorkoutCommentsResponse, []data_transfers.WorkoutCommentsResponse, *repositories.Cursor, int, error) {
+	if _, statusCode, err := s.findVisibleWorkout(userID, workoutID); err != nil {
+		return nil, nil, nil, statusCode, err
+	}
+
+	pinned := []data_transfers.WorkoutCommentsResponse{}
+	if cursor.ID == 0 {
+		pinnedComments, err := s.repository.FindPinned(workoutID)
+		if err != nil {
+			return nil, nil, nil, http.StatusInternalServerError, fmt.Errorf("service - FindAllByWorkoutID - repository.FindPinned: %w", err)
+		}
+		pinned = toWorkoutCommentsResponses(pinnedComments)
+	}
+
+	comments, err := s.repository.FindAllByWorkoutID(workoutID, cursor)
+	if err != nil {
+		return nil, nil, nil, http.StatusInternalServerError, fmt.Errorf("service - FindAllByWorkoutID - repository.FindAllByWorkoutID: %w", err)
+	}
+
+	comments, next := commentsPage(comments, cursor.Limit)
+	return pinned, toWorkoutCommentsResponses(comments), next, http.StatusOK, nil
+}
+
+func (s *WorkoutCommentsService) FindReplies(userID int, id int, cursor repositories.Cursor) ([]data_transfers.WorkoutCommentsResponse, *repositories.Cursor, int, error) {
+	comment, statusCode, err := s.findComment(id)
+	if err != nil {
+		return nil, nil, statusCode, err
+	}
+	if _, statusCode, err := s.findVisibleWorkout(userID, comment.WorkoutID); err != nil {
+		return nil, nil, statusCode, err
+	}
+
+	replies, err := s.repository.FindReplies(id, cursor)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, fmt.Errorf("service - FindReplies - repository.FindReplies: %w", err)
+	}
+
+	replies, next := commentsPage(replies, cursor.Limit)
+	return toWorkoutCommentsResponses(replies), next, http.StatusOK, nil
+}
+
+func (s *WorkoutCommentsService) Create(userID int, workoutID int, createRequest data_transfers.CreateWorkoutCommentRequest) (data_transfers.WorkoutCommentsResponse, int, error) {
+	workout, statusCode, err := s.findVisibleWorkout(userID, workoutID)
+	if err != nil {
+		return data_transfers.WorkoutCommentsResponse{}, statusCode, err
+	}
+
+	comment := records.WorkoutComments{
+		WorkoutID: workoutID,
+		UserID:    userID,
+		Body:      createRequest.Body,
+	}
+	if createRequest.ParentID != nil {
+		parent, err := s.repository.FindByID(*createRequest.ParentID)
+		if err != nil && !errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.WorkoutCommentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - repository.FindByID: %w", err)
+		}
+		if err != nil || parent.WorkoutID != workoutID || parent.DeletedAt.Valid {
+			return data_transfers.WorkoutCommentsResponse{}, http.StatusNotFound, errors.New("parent comment not found")
+		}
+		comment.ParentID = sql.NullInt64{Int64: int64(parent.ID), Valid: true}
+	}
+
+	id, err := s.repository.Save(comment)
+	if err != nil {
+		return data_transfers.WorkoutCommentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - repository.Save: %w", err)
+	}
+
+	comment, err = s.repository.FindByID(id)
+	if err != nil {
+		return data_transfers.WorkoutCommentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - repository.FindByID: %w", err)
+	}
+
+	if workout.OwnerID != userID {
+		s.notifyOwner(workout, comment)
+	}
+
+	return toWorkoutCommentsResponse(comment), http.StatusCreated, nil
+}
+
+// Update edits the body of the user's own comment, within the edit window.
+func (s *WorkoutCommentsService) Update(userID int, id int, updateRequest data_transfers.UpdateWorkoutCommentRequest) (data_transfers.WorkoutCommentsResponse, int, error) {
+	comment, statusCode, err := s.findComment(id)
+	if err != nil {
+		return data_transfers.WorkoutCommentsResponse{}, statusCode, err
+	}
+	if comment.DeletedAt.Valid || comment.UserID != userID {
+		return data_transfers.WorkoutCommentsResponse{}, http.StatusNotFound, errors.New("comment not found")
+	}
+
+	editWindow := time.Duration(config.Config.CommentEditWindow) * time.Minute
+	if time.Since(comment.CreatedAt) > editWindow {
+		return data_transfers.WorkoutCommentsResponse{}, http.StatusForbidden, fmt.Errorf("comments can only be edited within %d minutes", config.Config.CommentEditWindow)
+	}
+
+	if err := s.repository.UpdateBody(id, updateRequest.Body); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.WorkoutCommentsResponse{}, http.StatusNotFound, errors.New("comment not found")
+		}
+		return data_transfers.WorkoutCommentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Update - repository.UpdateBody: %w", err)
+	}
+
+	comment, err = s.repository.FindByID(id)
+	if err != nil {
+		return data_transfers.WorkoutCommentsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Update - repository.FindByID: %w", err)
+	}
+
+	return toWorkoutCommentsResponse(comment), http.StatusOK, nil
+}
+
+// Delete removes a comment of the user, or any comment on the user's
+// workouts. Replies to it stay in the thread.
+func (s *WorkoutCommentsService) Delete(userID int, id int) (int, error) {
+	comment, statusCode, err := s.findComment(id)
+	if err != nil {
+		return statusCode, err
+	}
+	if comment.DeletedAt.Valid {
+		return http.StatusNotFound, errors.New("comment not found")
+	}
+
+	if comment.UserID != userID {
+		workout, statusCode, err := s.workoutsService.FindByID(comment.WorkoutID)
+		if err != nil {
+			return statusCode, err
+		}
+		if workout.OwnerID != userID {
+			return http.StatusForbidden, errors.New("you cannot delete this comment")
+		}
+	}
+
+	if err := s.repository.Delete(id); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("comment not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Delete - repository.Delete: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// Pin pins a top-level comment on one of the user's workouts.
+func (s *WorkoutCommentsService) Pin(userID int, id int) (int, error) {
+	comment, statusCode, err := s.findOwnedComment(userID, id)
+	if err != nil {
+		return statusCode, err
+	}
+	if comment.ParentID.Valid {
+		return http.StatusBadRequest, errors.New("replies cannot be pinned")
+	}
+	if comment.IsPinned {
+		return http.StatusOK, nil
+	}
+
+	pinned, err := s.repository.Pin(id, comment.WorkoutID, maxPinnedComments)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("comment not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Pin - repository.Pin: %w", err)
+	}
+	if !pinned {
+		return http.StatusConflict, fmt.Errorf("a workout can have at most %d pinned comments", maxPinnedComments)
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *WorkoutCommentsService) Unpin(userID int, id int) (int, error) {
+	comment, statusCode, err := s.findOwnedComment(userID, id)
+	if err != nil {
+		return statusCode, err
+	}
+	if !comment.IsPinned {
+		return http.StatusOK, nil
+	}
+
+	if err := s.repository.Unpin(id); err != nil && !errors.Is(err, repositories.ErrorRowNotFound) {
+		return http.StatusInternalServerError, fmt.Errorf("service - Unpin - repository.Unpin: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// findVisibleWorkout returns the workout if it is public or owned by the user.
+func (s *WorkoutCommentsService) findVisibleWorkout(userID int, workoutID int) (data_transfers.WorkoutsResponse, int, error) {
+	workout, statusCode, err := s.workoutsService.FindByID(workoutID)
+	if err != nil {
+		return data_transfers.WorkoutsResponse{}, statusCode, err
+	}
+	if workout.IsPrivate && workout.OwnerID != userID {
+		return data_transfers.WorkoutsResponse{}, http.StatusNotFound, errors.New("workout not found")
+	}
+
+	return workout, http.StatusOK, nil
+}
+
+func (s *WorkoutCommentsService) findComment(id int) (records.WorkoutComments, int, error) {
+	comment, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return records.WorkoutComments{}, http.StatusNotFound, errors.New("comment not found")
+		}
+		return records.WorkoutComments{}, http.StatusInternalServerError, fmt.Errorf("service - findComment - repository.FindByID: %w", err)
+	}
+
+	return comment, http.StatusOK, nil
+}
+
+// findOwnedComment returns a comment that is not deleted on one of the user's
+// workouts.
+func (s *WorkoutCommentsService) findOwnedComment(userID int, id int) (records.WorkoutComments, int, error) {
+	comment, statusCode, err := s.findComment(id)
+	if err != nil {
+		return records.WorkoutComments{}, statusCode, err
+	}
+	if comment.DeletedAt.Valid {
+		return records.WorkoutComments{}, http.StatusNotFound, errors.New("comment not found")
+	}
+
+	workout, statusCode, err := s.workoutsService.FindByID(comment.WorkoutID)
+	if err != nil {
+		return records.WorkoutComments{}, statusCode, err
+	}
+	if workout.OwnerID != userID {
+		return records.WorkoutComments{}, http.StatusForbidden, errors.New("only the owner of the workout can pin comments")
+	}
+
+	return comment, http.StatusOK, nil
+}
+
+// notifyOwner emails the owner of the workout about a new comment. Failures
+// are logged and do not fail the comment.
+func (s *WorkoutCommentsService) notifyOwner(workout data_transfers.WorkoutsResponse, comment records.WorkoutComments) {
+	owner, _, err := s.usersService.FindByID(workout.OwnerID)
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - notifyOwner - usersService.FindByID: %v", err)
+		return
+	}
+
+	subject := fmt.Sprintf("New comment on %s", workout.Title)
+	if comment.ParentID.Valid {
+		subject = fmt.Sprintf("New reply on %s", workout.Title)
+	}
+
+	err = s.mailer.Send(mailer.Message{
+		To:      []string{owner.Email},
+		Subject: subject,
+		Body: fmt.Sprintf(
+			"%s commented on your workout \"%s\":\n\n%s\n\nReply at %s/workouts/%d\n",
+			comment.Username, workout.Title, comment.Body, config.Config.AppURL, workout.ID,
+		),
+	})
+	if err != nil {
+		logger.ZeroLogger.Error().Msgf("service - notifyOwner - Mailer.Send: %v", err)
+	}
+}
+
+// commentsPage drops the extra row selected by repositories.ApplyCursor and
+// returns the cursor of the next page, if there is one.
+func commentsPage(comments []records.WorkoutComments, limit int) ([]records.WorkoutComments, *repositories.Cursor) {
+	if len(comments) <= limit {
+		return comments, nil
+	}
+
+	comments = comments[:limit]
+	last := comments[len(comments)-1]
+	return comments, &repositories.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Limit: limit}
+}
+
+func toWorkoutCommentsResponse(comment records.WorkoutComments) data_transfers.WorkoutCommentsResponse {
+	response := data_transfers.WorkoutCommentsResponse{
+		ID:           comment.ID,
+		WorkoutID:    comment.WorkoutID,
+		UserID:       comment.UserID,
+		Username:     comment.Username,
+		Body:         comment.Body,
+		IsPinned:     comment.IsPinned,
+		IsDeleted:    comment.DeletedAt.Valid,
+		RepliesCount: comment.RepliesCount,
+		CreatedAt:    comment.CreatedAt,
+	}
+	if comment.ParentID.Valid {
+		parentID := int(comment.ParentID.Int64)
+		response.ParentID = &parentID
+	}
+	if comment.EditedAt.Valid {
+		response.EditedAt = &comment.EditedAt.Time
+	}
+	if comment.DeletedAt.Valid {
+		response.Body = ""
+	}
+
+	return response
+}
+
+func toWorkoutCommentsResponses(comments []records.WorkoutComments) []data_transfers.WorkoutCommentsResponse {
+	responses := make([]data_transfers.WorkoutCommentsResponse, 0, len(comments))
+	for _, comment := range comments {
+		responses = append(responses, toWorkoutCommentsResponse(comment))
+	}
+
+	return responses
+}
@@ -5,12 +5,27 @@ import (
 	"backend/internal/datasources/repositories"
 	"backend/internal/http/data_transfers"
 	"backend/pkg/convert"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/jinzhu/copier"
 	"net/http"
+	"regexp"
 )
 
+const (
+	LoadTypeAbsolute   = "absolute"
+	LoadTypePercent1RM = "percent_1rm"
+	LoadTypeRPE        = "rpe"
+
+	WorkoutExerciseGroupSuperset = "superset"
+	WorkoutExerciseGroupGiantSet = "giant_set"
+	WorkoutExerciseGroupCircuit  = "circuit"
+)
+
+// tempoPattern accepts the four phases of a rep, e.g. "3-1-2-0" or "31X0".
+var tempoPattern = regexp.MustCompile(`^[0-9Xx](-?[0-9Xx]){3}$`)
+
 type WorkoutExercisesRepository interface {
 	FindAll() ([]records.WorkoutExercises, error)
 	FindByID(id int) (records.WorkoutExercises, error)
@@ -18,14 +33,19 @@ type WorkoutExercisesRepository interface {
 	Save(workoutExercise records.WorkoutExercises) (int, error)
 	Update(id int, workoutExercise map[string]interface{}) error
 	Delete(id int) error
+	Reorder(workoutID int, layout []records.WorkoutExerciseLayout) error
 }
 
 type WorkoutExercisesService struct {
-	repository WorkoutExercisesRepository
+	repository       WorkoutExercisesRepository
+	revisionsService *WorkoutRevisionsService
 }
 
-func NewWorkoutExercisesService(repository WorkoutExercisesRepository) *WorkoutExercisesService {
-	return &WorkoutExercisesService{repository}
+func NewWorkoutExercisesService(repository WorkoutExercisesRepository, revisionsService *WorkoutRevisionsService) *WorkoutExercisesService {
+	return &WorkoutExercisesService{
+		repository:       repository,
+		revisionsService: revisionsService,
+	}
 }
 
 func (s *WorkoutExercisesService) FindAll() ([]data_transfers.WorkoutExercisesResponse, int, error) {
@@ -40,6 +60,9 @@ func (s *WorkoutExercisesService) FindAll() ([]data_transfers.WorkoutExercisesRe
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
+	for i, workoutExercise := range workoutExercises {
+		workoutExercisesResponse[i].Group = toWorkoutExerciseGroupResponse(workoutExercise)
+	}
 
 	return workoutExercisesResponse, http.StatusOK, nil
 }
@@ -59,6 +82,7 @@ func (s *WorkoutExercisesService) FindByID(id int) (data_transfers.WorkoutExerci
 	if err != nil {
 		return workoutExerciseResponse, http.StatusInternalServerError, err
 	}
+	workoutExerciseResponse.Group = toWorkoutExerciseGroupResponse(workoutExercise)
 
 	return workoutExerciseResponse, http.StatusOK, nil
 }
@@ -75,6 +99,9 @@ func (s *WorkoutExercisesService) FindAllByWorkoutID(workoutID int) ([]data_tran
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
+	for i, workoutExercise := range workoutExercises {
+		workoutExercisesResponse[i].Group = toWorkoutExerciseGroupResponse(workoutExercise)
+	}
 
 	return workoutExercisesResponse, http.StatusOK, nil
 }
@@ -87,33 +114,245 @@ func (s *WorkoutExercisesService) Save(workoutExercise data_transfers.CreateWork
 		return 0, http.StatusInternalServerError, err
 	}
 
+	if workoutExercise.Prescription != nil {
+		if err := validatePrescription(*workoutExercise.Prescription); err != nil {
+			return 0, http.StatusBadRequest, err
+		}
+
+		err = copier.Copy(&workoutExercises.Prescription, workoutExercise.Prescription)
+		if err != nil {
+			return 0, http.StatusInternalServerError, err
+		}
+	}
+
 	id, err := s.repository.Save(workoutExercises)
 	if err != nil {
 		return 0, http.StatusInternalServerError, err
 	}
 
+	if statusCode, err := s.revisionsService.Record(workoutExercises.WorkoutID); err != nil {
+		return 0, statusCode, err
+	}
+
 	return id, http.StatusCreated, nil
 }
 
+// Update changes the notes and the given prescription fields. The
+// prescription is validated as it will be stored, merged with its current
+// targets.
 func (s *WorkoutExercisesService) Update(id int, workoutExercise data_transfers.UpdateWorkoutExercisesRequest) (int, error) {
+	current, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("workout exercise not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Update - repository.FindByID: %w", err)
+	}
+
+	prescription := workoutExercise.Prescription
+	workoutExercise.Prescription = nil
+
 	workoutExerciseMap, err := convert.StructToMap(workoutExercise)
 	if err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("service - Update - convert.StructToMap: %w", err)
 	}
 
+	if prescription != nil {
+		var merged data_transfers.PrescriptionRequest
+		if err := copier.Copy(&merged, &current.Prescription); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if err := copier.CopyWithOption(&merged, prescription, copier.Option{IgnoreEmpty: true}); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if err := validatePrescription(merged); err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		prescriptionMap, err := convert.StructToMap(prescription)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("service - Update - convert.StructToMap: %w", err)
+		}
+		for key, value := range prescriptionMap {
+			workoutExerciseMap[key] = value
+		}
+	}
+
+	if len(workoutExerciseMap) == 0 {
+		return http.StatusOK, nil
+	}
+
 	err = s.repository.Update(id, workoutExerciseMap)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
 
+	if statusCode, err := s.revisionsService.Record(current.WorkoutID); err != nil {
+		return statusCode, err
+	}
+
 	return http.StatusOK, nil
 }
 
 func (s *WorkoutExercisesService) Delete(id int) (int, error) {
-	err := s.repository.Delete(id)
+	workoutExercise, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("workout exercise not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Delete - repository.FindByID: %w", err)
+	}
+
+	err = s.repository.Delete(id)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
 
+	if statusCode, err := s.revisionsService.Record(workoutExercise.WorkoutID); err != nil {
+		return statusCode, err
+	}
+
 	return http.StatusOK, nil
 }
+
+// Reorder moves the exercises of the workout and regroups them at once. The
+// layout must list every exercise of the workout exactly once.
+func (s *WorkoutExercisesService) Reorder(ownerID int, workoutID int, reorderRequest data_transfers.ReorderWorkoutExercisesRequest) ([]data_transfers.WorkoutExercisesResponse, int, error) {
+	workoutExercises, err := s.repository.FindAllByWorkoutID(workoutID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - Reorder - repository.FindAllByWorkoutID: %w", err)
+	}
+	if len(workoutExercises) == 0 {
+		return nil, http.StatusNotFound, errors.New("workout exercises not found")
+	}
+
+	remaining := make(map[int]bool, len(workoutExercises))
+	for _, workoutExercise := range workoutExercises {
+		if workoutExercise.OwnerID != ownerID {
+			return nil, http.StatusForbidden, errors.New("you are not authorized to reorder this workout")
+		}
+		remaining[workoutExercise.ID] = true
+	}
+
+	layout := make([]records.WorkoutExerciseLayout, 0, len(reorderRequest.Items))
+	for _, item := range reorderRequest.Items {
+		for _, workoutExerciseID := range item.WorkoutExerciseIDs {
+			if !remaining[workoutExerciseID] {
+				return nil, http.StatusBadRequest, fmt.Errorf("workout exercise %d is not in the workout or is listed twice", workoutExerciseID)
+			}
+			delete(remaining, workoutExerciseID)
+		}
+
+		group, err := toWorkoutExerciseGroup(item)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		layout = append(layout, records.WorkoutExerciseLayout{Group: group, WorkoutExerciseIDs: item.WorkoutExerciseIDs})
+	}
+	if len(remaining) > 0 {
+		return nil, http.StatusBadRequest, errors.New("the order must list every exercise of the workout")
+	}
+
+	if err := s.repository.Reorder(workoutID, layout); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return nil, http.StatusConflict, errors.New("the workout changed while reordering, try again")
+		}
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - Reorder - repository.Reorder: %w", err)
+	}
+
+	if statusCode, err := s.revisionsService.Record(workoutID); err != nil {
+		return nil, statusCode, err
+	}
+
+	return s.FindAllByWorkoutID(workoutID)
+}
+
+// toWorkoutExerciseGroup checks the size of a group against its type: a
+// superset pairs two exercises, a giant set has three or more and a circuit
+// two or more. Single exercises take no group settings.
+func toWorkoutExerciseGroup(item data_transfers.WorkoutExerciseLayoutRequest) (*records.WorkoutExerciseGroups, error) {
+	size := len(item.WorkoutExerciseIDs)
+	if item.GroupType == nil {
+		if size > 1 {
+			return nil, errors.New("several exercises in one item need a group_type")
+		}
+		if item.Rounds != nil || item.RestSeconds != nil {
+			return nil, errors.New("rounds and rest_seconds only apply to groups")
+		}
+		return nil, nil
+	}
+
+	switch *item.GroupType {
+	case WorkoutExerciseGroupSuperset:
+		if size != 2 {
+			return nil, errors.New("a superset has exactly 2 exercises")
+		}
+	case WorkoutExerciseGroupGiantSet:
+		if size < 3 {
+			return nil, errors.New("a giant set has at least 3 exercises")
+		}
+	case WorkoutExerciseGroupCircuit:
+		if size < 2 {
+			return nil, errors.New("a circuit has at least 2 exercises")
+		}
+	}
+
+	group := records.WorkoutExerciseGroups{GroupType: *item.GroupType, Rounds: 1}
+	if item.Rounds != nil {
+		group.Rounds = *item.Rounds
+	}
+	if item.RestSeconds != nil {
+		group.RestSeconds = sql.NullInt32{Int32: int32(*item.RestSeconds), Valid: true}
+	}
+
+	return &group, nil
+}
+
+func toWorkoutExerciseGroupResponse(workoutExercise records.WorkoutExercises) *data_transfers.WorkoutExerciseGroupResponse {
+	if !workoutExercise.GroupID.Valid {
+		return nil
+	}
+
+	group := &data_transfers.WorkoutExerciseGroupResponse{
+		ID:     int(workoutExercise.GroupID.Int64),
+		Type:   workoutExercise.GroupType.String,
+		Rounds: int(workoutExercise.GroupRounds.Int32),
+	}
+	if workoutExercise.GroupRestSeconds.Valid {
+		restSeconds := int(workoutExercise.GroupRestSeconds.Int32)
+		group.RestSeconds = &restSeconds
+	}
+
+	return group
+}
+
+// validatePrescription checks the targets against each other: the rep range
+// must be ordered, a load needs both its type and value, and percentages and
+// RPE must stay on their scale.
+func validatePrescription(prescription data_transfers.PrescriptionRequest) error {
+	if prescription.TargetRepsMin != nil && prescription.TargetRepsMax != nil && *prescription.TargetRepsMin > *prescription.TargetRepsMax {
+		return errors.New("target_reps_min cannot be greater than target_reps_max")
+	}
+
+	if (prescription.LoadType == nil) != (prescription.LoadValue == nil) {
+		return errors.New("load_type and load_value must be set together")
+	}
+	if prescription.LoadType != nil {
+		switch *prescription.LoadType {
+		case LoadTypePercent1RM:
+			if *prescription.LoadValue > 100 {
+				return errors.New("a percent_1rm load cannot be above 100")
+			}
+		case LoadTypeRPE:
+			if *prescription.LoadValue < 1 || *prescription.LoadValue > 10 {
+				return errors.New("an rpe load must be between 1 and 10")
+			}
+		}
+	}
+
+	if prescription.Tempo != nil && !tempoPattern.MatchString(*prescription.Tempo) {
+		return errors.New("tempo must have four phases, e.g. 3-1-2-0 or 31X0")
+	}
+
+	return nil
+}
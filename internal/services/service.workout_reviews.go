@@ -0,0 +1,279 @@
+package services
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"backend/pkg/convert"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type WorkoutReviewsRepository interface {
+	Save(review records.WorkoutReviews) (int, error)
+	Update(id int, workoutID int, review map[string]interface{}) error
+	Delete(id int, workoutID int) error
+	FindByID(id int) (records.WorkoutReviews, error)
+	FindByWorkoutAndUser(workoutID int, userID int) (records.WorkoutReviews, error)
+	FindAllByWorkoutID(workoutID int, pagination repositories.Pagination) ([]records.WorkoutReviews, int, error)
+	FindAllReported(pagination repositories.Pagination) ([]records.WorkoutReviews, int, error)
+	HasCopied(workoutID int, userID int) (bool, error)
+	SaveReport(report records.ReviewReports) error
+	FindReports(reviewID int) ([]records.ReviewReports, error)
+}
+
+// WorkoutReviewsService handles star ratings and reviews of public workouts.
+// Only users who copied or purchased a workout may review it, once each. The
+// repository keeps the rating average and count on the workout up to date.
+type WorkoutReviewsService struct {
+	repository       WorkoutReviewsRepository
+	workoutsService  *WorkoutsService
+	purchasesService *PurchasesService
+}
+
+func NewWorkoutReviewsService(repository WorkoutReviewsRepository, workoutsService *WorkoutsService, purchasesService *PurchasesService) *WorkoutReviewsService {
+	return &WorkoutReviewsService{
+		repository:       repository,
+		workoutsService:  workoutsService,
+		purchasesService: purchasesService,
+	}
+}
+
+func (s *WorkoutReviewsService) FindAllByWorkoutID(userID int, workoutID int, pagination repositories.Pagination) ([]data_transfers.WorkoutReviewsResponse, int, int, error) {
+	workout, statusCode, err := s.workoutsService.FindByID(workoutID)
+	if err != nil {
+		return nil, 0, statusCode, err
+	}
+	if workout.IsPrivate && workout.OwnerID != userID {
+		return nil, 0, http.StatusNotFound, errors.New("workout not found")
+	}
+
+	reviews, total, err := s.repository.FindAllByWorkoutID(workoutID, pagination)
+	if err != nil {
+		return nil, 0, http.StatusInternalServerError, fmt.Errorf("service - FindAllByWorkoutID - repository.FindAllByWorkoutID: %w", err)
+	}
+
+	return toWorkoutReviewsResponses(reviews), total, http.StatusOK, nil
+}
+
+func (s *WorkoutReviewsService) Create(userID int, workoutID int, createRequest data_transfers.CreateWorkoutReviewRequest) (data_transfers.WorkoutReviewsResponse, int, error) {
+	workout, statusCode, err := s.workoutsService.FindByID(workoutID)
+	if err != nil {
+		return data_transfers.WorkoutReviewsResponse{}, statusCode, err
+	}
+	if workout.IsPrivate {
+		return data_transfers.WorkoutReviewsResponse{}, http.StatusNotFound, errors.New("workout not found")
+	}
+	if workout.OwnerID == userID {
+		return data_transfers.WorkoutReviewsResponse{}, http.StatusForbidden, errors.New("you cannot review your own workout")
+	}
+
+	statusCode, err = s.checkEligible(userID, workoutID)
+	if err != nil {
+		return data_transfers.WorkoutReviewsResponse{}, statusCode, err
+	}
+
+	id, err := s.repository.Save(records.WorkoutReviews{
+		WorkoutID: workoutID,
+		UserID:    userID,
+		Rating:    createRequest.Rating,
+		Body:      createRequest.Body,
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return data_transfers.WorkoutReviewsResponse{}, http.StatusConflict, errors.New("you already reviewed this workout")
+		}
+		return data_transfers.WorkoutReviewsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - repository.Save: %w", err)
+	}
+
+	review, err := s.repository.FindByID(id)
+	if err != nil {
+		return data_transfers.WorkoutReviewsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Create - repository.FindByID: %w", err)
+	}
+
+	return toWorkoutReviewsResponse(review), http.StatusCreated, nil
+}
+
+// Update edits the user's own review of the workout.
+func (s *WorkoutReviewsService) Update(userID int, workoutID int, updateRequest data_transfers.UpdateWorkoutReviewRequest) (data_transfers.WorkoutReviewsResponse, int, error) {
+	review, statusCode, err := s.findOwn(userID, workoutID)
+	if err != nil {
+		return data_transfers.WorkoutReviewsResponse{}, statusCode, err
+	}
+
+	reviewMap, err := convert.StructToMap(updateRequest)
+	if err != nil {
+		return data_transfers.WorkoutReviewsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Update - convert.StructToMap: %w", err)
+	}
+	if len(reviewMap) == 0 {
+		return data_transfers.WorkoutReviewsResponse{}, http.StatusBadRequest, errors.New("nothing to update")
+	}
+
+	if err := s.repository.Update(review.ID, workoutID, reviewMap); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.WorkoutReviewsResponse{}, http.StatusNotFound, errors.New("review not found")
+		}
+		return data_transfers.WorkoutReviewsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Update - repository.Update: %w", err)
+	}
+
+	review, err = s.repository.FindByID(review.ID)
+	if err != nil {
+		return data_transfers.WorkoutReviewsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Update - repository.FindByID: %w", err)
+	}
+
+	return toWorkoutReviewsResponse(review), http.StatusOK, nil
+}
+
+// Delete removes the user's own review of the workout.
+func (s *WorkoutReviewsService) Delete(userID int, workoutID int) (int, error) {
+	review, statusCode, err := s.findOwn(userID, workoutID)
+	if err != nil {
+		return statusCode, err
+	}
+
+	return s.remove(review)
+}
+
+// DeleteForAdmin removes any review, typically one that was reported.
+func (s *WorkoutReviewsService) DeleteForAdmin(id int) (int, error) {
+	review, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("review not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - DeleteForAdmin - repository.FindByID: %w", err)
+	}
+
+	return s.remove(review)
+}
+
+func (s *WorkoutReviewsService) Report(userID int, id int, reportRequest data_transfers.ReportWorkoutReviewRequest) (int, error) {
+	review, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("review not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Report - repository.FindByID: %w", err)
+	}
+	if review.UserID == userID {
+		return http.StatusBadRequest, errors.New("you cannot report your own review")
+	}
+
+	err = s.repository.SaveReport(records.ReviewReports{
+		ReviewID:   review.ID,
+		ReporterID: userID,
+		Reason:     reportRequest.Reason,
+		Details:    reportRequest.Details,
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowExists) {
+			return http.StatusConflict, errors.New("you already reported this review")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - Report - repository.SaveReport: %w", err)
+	}
+
+	return http.StatusCreated, nil
+}
+
+func (s *WorkoutReviewsService) FindAllReported(pagination repositories.Pagination) ([]data_transfers.WorkoutReviewsResponse, int, int, error) {
+	reviews, total, err := s.repository.FindAllReported(pagination)
+	if err != nil {
+		return nil, 0, http.StatusInternalServerError, fmt.Errorf("service - FindAllReported - repository.FindAllReported: %w", err)
+	}
+
+	return toWorkoutReviewsResponses(reviews), total, http.StatusOK, nil
+}
+
+func (s *WorkoutReviewsService) FindReports(id int) ([]data_transfers.ReviewReportsResponse, int, error) {
+	reports, err := s.repository.FindReports(id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("service - FindReports - repository.FindReports: %w", err)
+	}
+
+	responses := make([]data_transfers.ReviewReportsResponse, 0, len(reports))
+	for _, report := range reports {
+		responses = append(responses, data_transfers.ReviewReportsResponse{
+			ID:         report.ID,
+			ReviewID:   report.ReviewID,
+			ReporterID: report.ReporterID,
+			Reason:     report.Reason,
+			Details:    report.Details,
+			CreatedAt:  report.CreatedAt,
+		})
+	}
+
+	return responses, http.StatusOK, nil
+}
+
+// checkEligible lets users review a workout they hold a purchase of or made
+// a copy of.
+func (s *WorkoutReviewsService) checkEligible(userID int, workoutID int) (int, error) {
+	purchased, statusCode, err := s.purchasesService.IsEntitled(userID, workoutID)
+	if err != nil {
+		return statusCode, err
+	}
+	if purchased {
+		return http.StatusOK, nil
+	}
+
+	copied, err := s.repository.HasCopied(workoutID, userID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("service - checkEligible - repository.HasCopied: %w", err)
+	}
+	if !copied {
+		return http.StatusForbidden, errors.New("only users who copied or purchased this workout can review it")
+	}
+
+	return http.StatusOK, nil
+}
+
+func (s *WorkoutReviewsService) findOwn(userID int, workoutID int) (records.WorkoutReviews, int, error) {
+	review, err := s.repository.FindByWorkoutAndUser(workoutID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return records.WorkoutReviews{}, http.StatusNotFound, errors.New("review not found")
+		}
+		return records.WorkoutReviews{}, http.StatusInternalServerError, fmt.Errorf("service - findOwn - repository.FindByWorkoutAndUser: %w", err)
+	}
+
+	return review, http.StatusOK, nil
+}
+
+func (s *WorkoutReviewsService) remove(review records.WorkoutReviews) (int, error) {
+	if err := s.repository.Delete(review.ID, review.WorkoutID); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return http.StatusNotFound, errors.New("review not found")
+		}
+		return http.StatusInternalServerError, fmt.Errorf("service - remove - repository.Delete: %w", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func toWorkoutReviewsResponse(review records.WorkoutReviews) data_transfers.WorkoutReviewsResponse {
+	response := data_transfers.WorkoutReviewsResponse{
+		ID:           review.ID,
+		WorkoutID:    review.WorkoutID,
+		UserID:       review.UserID,
+		Username:     review.Username,
+		Rating:       review.Rating,
+		Body:         review.Body,
+		ReportsCount: review.ReportsCount,
+		CreatedAt:    review.CreatedAt,
+	}
+	if review.UpdatedAt.Valid {
+		response.UpdatedAt = &review.UpdatedAt.Time
+	}
+
+	return response
+}
+
+func toWorkoutReviewsResponses(reviews []records.WorkoutReviews) []data_transfers.WorkoutReviewsResponse {
+	responses := make([]data_transfers.WorkoutReviewsResponse, 0, len(reviews))
+	for _, review := range reviews {
+		responses = append(responses, toWorkoutReviewsResponse(review))
+	}
+
+	return responses
+}
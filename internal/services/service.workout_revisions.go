@@ -0,0 +1,361 @@
+package services
+
+import (
+	"backend/internal/datasources/records"
+	"backend/internal/datasources/repositories"
+	"backend/internal/http/data_transfers"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jinzhu/copier"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+type WorkoutRevisionsRepository interface {
+	FindCurrent(workoutID int) (records.WorkoutRevisions, error)
+	Save(revision records.WorkoutRevisions) (int, error)
+	FindAll(workoutID int, pagination repositories.Pagination) ([]records.WorkoutRevisions, int, error)
+	FindByNumber(workoutID int, revisionNumber int) (records.WorkoutRevisions, error)
+	Restore(revision records.WorkoutRevisions) error
+}
+
+// WorkoutRevisionsService keeps the history of a workout. Every change to a
+// workout or its exercises is saved as an immutable revision, along with a
+// changelog computed against the previous revision.
+type WorkoutRevisionsService struct {
+	repository WorkoutRevisionsRepository
+}
+
+func NewWorkoutRevisionsService(repository WorkoutRevisionsRepository) *WorkoutRevisionsService {
+	return &WorkoutRevisionsService{repository}
+}
+
+// Record saves the workout as a new revision if it changed since the latest
+// one.
+func (s *WorkoutRevisionsService) Record(workoutID int) (int, error) {
+	_, statusCode, err := s.record(workoutID, "", 0)
+	return statusCode, err
+}
+
+// RecordWithNote saves the workout as a new revision with the note at the
+// top of its changelog, even if nothing else changed.
+func (s *WorkoutRevisionsService) RecordWithNote(workoutID int, note string) (int, error) {
+	_, statusCode, err := s.record(workoutID, note, 0)
+	return statusCode, err
+}
+
+func (s *WorkoutRevisionsService) FindAll(workoutID int, pagination repositories.Pagination) ([]data_transfers.WorkoutRevisionsResponse, int, int, error) {
+	revisions, total, err := s.repository.FindAll(workoutID, pagination)
+	if err != nil {
+		return nil, 0, http.StatusInternalServerError, fmt.Errorf("service - FindAll - repository.FindAll: %w", err)
+	}
+
+	responses := make([]data_transfers.WorkoutRevisionsResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		response, err := toWorkoutRevisionsResponse(revision)
+		if err != nil {
+			return nil, 0, http.StatusInternalServerError, err
+		}
+		responses = append(responses, response)
+	}
+
+	return responses, total, http.StatusOK, nil
+}
+
+func (s *WorkoutRevisionsService) FindByNumber(workoutID int, revisionNumber int) (data_transfers.WorkoutRevisionsResponse, int, error) {
+	revision, statusCode, err := s.findByNumber(workoutID, revisionNumber)
+	if err != nil {
+		return data_transfers.WorkoutRevisionsResponse{}, statusCode, err
+	}
+
+	response, err := toWorkoutRevisionsResponse(revision)
+	if err != nil {
+		return data_transfers.WorkoutRevisionsResponse{}, http.StatusInternalServerError, err
+	}
+
+	return response, http.StatusOK, nil
+}
+
+// Diff compares two revisions of the workout. Without a target the latest
+// revision is used, and without a base the revision before the target.
+func (s *WorkoutRevisionsService) Diff(workoutID int, from int, to int) (data_transfers.WorkoutRevisionDiffResponse, int, error) {
+	toRevision, statusCode, err := s.findByNumber(workoutID, to)
+	if err != nil {
+		return data_transfers.WorkoutRevisionDiffResponse{}, statusCode, err
+	}
+	if from == 0 {
+		from = max(toRevision.RevisionNumber-1, 1)
+	}
+
+	fromRevision, statusCode, err := s.findByNumber(workoutID, from)
+	if err != nil {
+		return data_transfers.WorkoutRevisionDiffResponse{}, statusCode, err
+	}
+
+	fromResponse, err := toWorkoutRevisionsResponse(fromRevision)
+	if err != nil {
+		return data_transfers.WorkoutRevisionDiffResponse{}, http.StatusInternalServerError, err
+	}
+	toResponse, err := toWorkoutRevisionsResponse(toRevision)
+	if err != nil {
+		return data_transfers.WorkoutRevisionDiffResponse{}, http.StatusInternalServerError, err
+	}
+
+	return diffWorkoutRevisions(fromResponse, toResponse), http.StatusOK, nil
+}
+
+// Restore puts the workout back in the state of an older revision. The
+// restore is itself saved as a new revision, so nothing is lost.
+func (s *WorkoutRevisionsService) Restore(workoutID int, revisionNumber int) (data_transfers.WorkoutRevisionsResponse, int, error) {
+	revision, statusCode, err := s.findByNumber(workoutID, revisionNumber)
+	if err != nil {
+		return data_transfers.WorkoutRevisionsResponse{}, statusCode, err
+	}
+
+	if err := s.repository.Restore(revision); err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return data_transfers.WorkoutRevisionsResponse{}, http.StatusNotFound, errors.New("workout not found")
+		}
+		if errors.Is(err, repositories.ErrorForeignKeyViolation) {
+			return data_transfers.WorkoutRevisionsResponse{}, http.StatusConflict, errors.New("an exercise of this revision no longer exists")
+		}
+		return data_transfers.WorkoutRevisionsResponse{}, http.StatusInternalServerError, fmt.Errorf("service - Restore - repository.Restore: %w", err)
+	}
+
+	restoredNumber, statusCode, err := s.record(workoutID, fmt.Sprintf("Restored revision %d", revision.RevisionNumber), revision.RevisionNumber)
+	if err != nil {
+		return data_transfers.WorkoutRevisionsResponse{}, statusCode, err
+	}
+
+	return s.FindByNumber(workoutID, restoredNumber)
+}
+
+// record compares the workout with its latest revision and saves a new one
+// with the changelog, unless nothing changed. A revision with a note, such as
+// a restore, is always saved.
+func (s *WorkoutRevisionsService) record(workoutID int, note string, restoredFrom int) (int, int, error) {
+	current, err := s.repository.FindCurrent(workoutID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return 0, http.StatusNotFound, errors.New("workout not found")
+		}
+		return 0, http.StatusInternalServerError, fmt.Errorf("service - record - repository.FindCurrent: %w", err)
+	}
+
+	changelog := []string{"Created the workout"}
+	latest, err := s.repository.FindByNumber(workoutID, 0)
+	if err != nil && !errors.Is(err, repositories.ErrorRowNotFound) {
+		return 0, http.StatusInternalServerError, fmt.Errorf("service - record - repository.FindByNumber: %w", err)
+	}
+	if err == nil {
+		latestResponse, err := toWorkoutRevisionsResponse(latest)
+		if err != nil {
+			return 0, http.StatusInternalServerError, err
+		}
+		currentResponse, err := toWorkoutRevisionsResponse(current)
+		if err != nil {
+			return 0, http.StatusInternalServerError, err
+		}
+
+		changelog = describeWorkoutRevisionDiff(diffWorkoutRevisions(latestResponse, currentResponse))
+		if len(changelog) == 0 && note == "" {
+			return latest.RevisionNumber, http.StatusOK, nil
+		}
+	}
+
+	if note != "" {
+		changelog = append([]string{note}, changelog...)
+	}
+	if restoredFrom > 0 {
+		current.RestoredFrom = sql.NullInt32{Int32: int32(restoredFrom), Valid: true}
+	}
+	current.Changelog = changelog
+
+	revisionNumber, err := s.repository.Save(current)
+	if err != nil {
+		return 0, http.StatusInternalServerError, fmt.Errorf("service - record - repository.Save: %w", err)
+	}
+
+	return revisionNumber, http.StatusCreated, nil
+}
+
+func (s *WorkoutRevisionsService) findByNumber(workoutID int, revisionNumber int) (records.WorkoutRevisions, int, error) {
+	revision, err := s.repository.FindByNumber(workoutID, revisionNumber)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return records.WorkoutRevisions{}, http.StatusNotFound, errors.New("revision not found")
+		}
+		return records.WorkoutRevisions{}, http.StatusInternalServerError, fmt.Errorf("service - findByNumber - repository.FindByNumber: %w", err)
+	}
+
+	return revision, http.StatusOK, nil
+}
+
+func toWorkoutRevisionsResponse(revision records.WorkoutRevisions) (data_transfers.WorkoutRevisionsResponse, error) {
+	var response data_transfers.WorkoutRevisionsResponse
+	if err := copier.Copy(&response, &revision); err != nil {
+		return response, err
+	}
+
+	for i, exercise := range revision.Exercises {
+		if !exercise.GroupKey.Valid {
+			continue
+		}
+
+		group := &data_transfers.WorkoutRevisionGroupResponse{
+			Key:    int(exercise.GroupKey.Int64),
+			Type:   exercise.GroupType.String,
+			Rounds: int(exercise.GroupRounds.Int32),
+		}
+		if exercise.GroupRestSeconds.Valid {
+			restSeconds := int(exercise.GroupRestSeconds.Int32)
+			group.RestSeconds = &restSeconds
+		}
+		response.Exercises[i].Group = group
+	}
+
+	return response, nil
+}
+
+// diffWorkoutRevisions compares the workout fields, then the exercises by
+// exercise: added, removed, changed, and whether the ones in both revisions
+// are in another order.
+func diffWorkoutRevisions(from data_transfers.WorkoutRevisionsResponse, to data_transfers.WorkoutRevisionsResponse) data_transfers.WorkoutRevisionDiffResponse {
+	diff := data_transfers.WorkoutRevisionDiffResponse{
+		From:    from.RevisionNumber,
+		To:      to.RevisionNumber,
+		Fields:  make([]data_transfers.WorkoutRevisionFieldChange, 0),
+		Added:   make([]data_transfers.WorkoutRevisionExercisesResponse, 0),
+		Removed: make([]data_transfers.WorkoutRevisionExercisesResponse, 0),
+		Changed: make([]data_transfers.WorkoutRevisionExerciseChange, 0),
+	}
+
+	diff.Fields = appendFieldChange(diff.Fields, "title", from.Title, to.Title)
+	diff.Fields = appendFieldChange(diff.Fields, "description", from.Description, to.Description)
+	diff.Fields = appendFieldChange(diff.Fields, "price", from.Price, to.Price)
+	diff.Fields = appendFieldChange(diff.Fields, "is_private", from.IsPrivate, to.IsPrivate)
+	diff.Fields = appendFieldChange(diff.Fields, "members_only", from.MembersOnly, to.MembersOnly)
+
+	fromExercises := make(map[int]data_transfers.WorkoutRevisionExercisesResponse, len(from.Exercises))
+	for _, exercise := range from.Exercises {
+		fromExercises[exercise.ExerciseID] = exercise
+	}
+	toExercises := make(map[int]data_transfers.WorkoutRevisionExercisesResponse, len(to.Exercises))
+	for _, exercise := range to.Exercises {
+		toExercises[exercise.ExerciseID] = exercise
+	}
+
+	var fromOrder, toOrder []int
+	for _, exercise := range from.Exercises {
+		if _, ok := toExercises[exercise.ExerciseID]; !ok {
+			diff.Removed = append(diff.Removed, exercise)
+			continue
+		}
+		fromOrder = append(fromOrder, exercise.ExerciseID)
+	}
+	for _, exercise := range to.Exercises {
+		fromExercise, ok := fromExercises[exercise.ExerciseID]
+		if !ok {
+			diff.Added = append(diff.Added, exercise)
+			continue
+		}
+		toOrder = append(toOrder, exercise.ExerciseID)
+
+		if fields := diffWorkoutRevisionExercises(fromExercise, exercise); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, data_transfers.WorkoutRevisionExerciseChange{
+				ExerciseID:   exercise.ExerciseID,
+				ExerciseName: exercise.ExerciseName,
+				Fields:       fields,
+			})
+		}
+	}
+	diff.Reordered = !reflect.DeepEqual(fromOrder, toOrder)
+
+	return diff
+}
+
+// diffWorkoutRevisionExercises compares the notes, the prescription and the
+// group of an exercise. Group keys differ between revisions, so only the
+// settings of the groups are compared.
+func diffWorkoutRevisionExercises(from data_transfers.WorkoutRevisionExercisesResponse, to data_transfers.WorkoutRevisionExercisesResponse) []data_transfers.WorkoutRevisionFieldChange {
+	var fields []data_transfers.WorkoutRevisionFieldChange
+
+	fields = appendFieldChange(fields, "main_note", from.MainNote, to.MainNote)
+	fields = appendFieldChange(fields, "secondary_note", from.SecondaryNote, to.SecondaryNote)
+	fields = appendFieldChange(fields, "target_sets", from.Prescription.TargetSets, to.Prescription.TargetSets)
+	fields = appendFieldChange(fields, "target_reps_min", from.Prescription.TargetRepsMin, to.Prescription.TargetRepsMin)
+	fields = appendFieldChange(fields, "target_reps_max", from.Prescription.TargetRepsMax, to.Prescription.TargetRepsMax)
+	fields = appendFieldChange(fields, "load_type", from.Prescription.LoadType, to.Prescription.LoadType)
+	fields = appendFieldChange(fields, "load_value", from.Prescription.LoadValue, to.Prescription.LoadValue)
+	fields = appendFieldChange(fields, "tempo", from.Prescription.Tempo, to.Prescription.Tempo)
+	fields = appendFieldChange(fields, "rest_seconds", from.Prescription.RestSeconds, to.Prescription.RestSeconds)
+
+	fromGroup, toGroup := from.Group, to.Group
+	if fromGroup != nil && toGroup != nil {
+		fromSettings, toSettings := *fromGroup, *toGroup
+		fromSettings.Key, toSettings.Key = 0, 0
+		fromGroup, toGroup = &fromSettings, &toSettings
+	}
+	if !reflect.DeepEqual(fromGroup, toGroup) {
+		fields = append(fields, data_transfers.WorkoutRevisionFieldChange{Field: "group", From: from.Group, To: to.Group})
+	}
+
+	return fields
+}
+
+func appendFieldChange(fields []data_transfers.WorkoutRevisionFieldChange, field string, from interface{}, to interface{}) []data_transfers.WorkoutRevisionFieldChange {
+	if reflect.DeepEqual(from, to) {
+		return fields
+	}
+
+	return append(fields, data_transfers.WorkoutRevisionFieldChange{Field: field, From: from, To: to})
+}
+
+// describeWorkoutRevisionDiff turns a diff into the lines of a changelog.
+func describeWorkoutRevisionDiff(diff data_transfers.WorkoutRevisionDiffResponse) []string {
+	var changelog []string
+
+	for _, field := range diff.Fields {
+		switch field.Field {
+		case "title":
+			changelog = append(changelog, fmt.Sprintf("Renamed to %q", field.To))
+		case "price":
+			changelog = append(changelog, fmt.Sprintf("Changed the price from %.2f to %.2f", field.From, field.To))
+		case "is_private":
+			if field.To == true {
+				changelog = append(changelog, "Made the workout private")
+			} else {
+				changelog = append(changelog, "Made the workout public")
+			}
+		case "members_only":
+			if field.To == true {
+				changelog = append(changelog, "Made the workout members-only")
+			} else {
+				changelog = append(changelog, "Opened the workout to everyone")
+			}
+		default:
+			changelog = append(changelog, fmt.Sprintf("Changed the %s", strings.ReplaceAll(field.Field, "_", " ")))
+		}
+	}
+
+	for _, exercise := range diff.Added {
+		changelog = append(changelog, fmt.Sprintf("Added %s", exercise.ExerciseName))
+	}
+	for _, exercise := range diff.Removed {
+		changelog = append(changelog, fmt.Sprintf("Removed %s", exercise.ExerciseName))
+	}
+	for _, exercise := range diff.Changed {
+		fields := make([]string, 0, len(exercise.Fields))
+		for _, field := range exercise.Fields {
+			fields = append(fields, field.Field)
+		}
+		changelog = append(changelog, fmt.Sprintf("Updated %s: %s", exercise.ExerciseName, strings.Join(fields, ", ")))
+	}
+	if diff.Reordered {
+		changelog = append(changelog, "Reordered the exercises")
+	}
+
+	return changelog
+}
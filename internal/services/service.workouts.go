@@ -15,29 +15,37 @@ import (
 type WorkoutsRepository interface {
 	FindAll() ([]records.Workouts, error)
 	FindByID(id int) (records.Workouts, error)
-	FindAllByOwnerID(ownerID int) ([]records.Workouts, error)
+	FindAllByOwnerID(ownerID int, viewerID int) ([]records.Workouts, error)
 	FindAllByCurrentUserID(ownerID int) ([]records.Workouts, error)
 	Save(workout records.Workouts) (int, error)
 	Update(id int, workout map[string]interface{}) error
 	Delete(id int) error
 	Copy(id int, userID int) (int, error)
-	FindAllWithFilters(params repositories.QueryParams) ([]records.Workouts, int, error)
+	FindAllWithFilters(params repositories.QueryParams, viewerID int) ([]records.Workouts, int, error)
 	LikeWorkout(id int, userID int) error
+	FindLineage(id int) (records.WorkoutLineage, error)
+	MergeUpstream(id int, upstreamRevision int, exercises []records.WorkoutRevisionExercises) (int, error)
 }
 
 type WorkoutsService struct {
 	repository              WorkoutsRepository
 	workoutExercisesService *WorkoutExercisesService
 	exercisesService        *ExercisesService
+	purchasesService        *PurchasesService
+	subscriptionsService    *SubscriptionsService
+	revisionsService        *WorkoutRevisionsService
 	ionet                   *io.Client
 }
 
-func NewWorkoutsService(repository WorkoutsRepository, workoutExercisesService *WorkoutExercisesService, ionet *io.Client, exercisesService *ExercisesService) *WorkoutsService {
+func NewWorkoutsService(repository WorkoutsRepository, workoutExercisesService *WorkoutExercisesService, ionet *io.Client, exercisesService *ExercisesService, purchasesService *PurchasesService, subscriptionsService *SubscriptionsService, revisionsService *WorkoutRevisionsService) *WorkoutsService {
 	return &WorkoutsService{
 		repository:              repository,
 		workoutExercisesService: workoutExercisesService,
 		ionet:                   ionet,
 		exercisesService:        exercisesService,
+		purchasesService:        purchasesService,
+		subscriptionsService:    subscriptionsService,
+		revisionsService:        revisionsService,
 	}
 }
 
@@ -58,7 +66,7 @@ func (s *WorkoutsService) FindAll() ([]data_transfers.WorkoutsResponse, int, err
 	}
 
 	for i, workout := range workoutsResponse {
-		if workout.Price == float64(0) {
+		if workout.Price == float64(0) && !workout.MembersOnly {
 			workoutExercises, statusCode, err := s.workoutExercisesService.FindAllByWorkoutID(workout.ID)
 			if err != nil {
 				return nil, statusCode, err
@@ -86,7 +94,9 @@ func (s *WorkoutsService) FindByID(id int) (data_transfers.WorkoutsResponse, int
 		return workoutResponse, http.StatusInternalServerError, err
 	}
 
-	if workout.Price == float64(0) {
+	// Without a user, the exercises of paid and members-only workouts are
+	// left out, see attachExercises.
+	if workout.Price == float64(0) && !workout.MembersOnly {
 		workoutExercises, statusCode, err := s.workoutExercisesService.FindAllByWorkoutID(workout.ID)
 		if err != nil {
 			return workoutResponse, statusCode, err
@@ -107,27 +117,28 @@ func (s *WorkoutsService) FindByIDByOwner(id int, userID int) (data_transfers.Wo
 		}
 		return workoutResponse, http.StatusInternalServerError, err
 	}
+	if workout.HiddenAt.Valid && workout.OwnerID != userID {
+		return workoutResponse, http.StatusNotFound, errors.New("workout not found")
+	}
 
 	err = copier.Copy(&workoutResponse, &workout)
 	if err != nil {
 		return workoutResponse, http.StatusInternalServerError, err
 	}
 
-	if workout.Price == float64(0) || workout.OwnerID == userID {
-		workoutExercises, statusCode, err := s.workoutExercisesService.FindAllByWorkoutID(workout.ID)
-		if err != nil {
-			return workoutResponse, statusCode, err
-		}
-		workoutResponse.Exercises = workoutExercises
+	workoutsResponse := []data_transfers.WorkoutsResponse{workoutResponse}
+	statusCode, err := s.attachExercises(workoutsResponse, userID)
+	if err != nil {
+		return workoutResponse, statusCode, err
 	}
 
-	return workoutResponse, http.StatusOK, nil
+	return workoutsResponse[0], http.StatusOK, nil
 }
 
-func (s *WorkoutsService) FindAllByOwnerID(ownerID int) ([]data_transfers.WorkoutsResponse, int, error) {
+func (s *WorkoutsService) FindAllByOwnerID(ownerID int, userID int) ([]data_transfers.WorkoutsResponse, int, error) {
 	var workoutsResponse []data_transfers.WorkoutsResponse
 
-	workouts, err := s.repository.FindAllByOwnerID(ownerID)
+	workouts, err := s.repository.FindAllByOwnerID(ownerID, userID)
 	if err != nil {
 		if errors.Is(err, repositories.ErrorRowNotFound) {
 			return nil, http.StatusNotFound, errors.New("workouts not found")
@@ -147,14 +158,9 @@ func (s *WorkoutsService) FindAllByOwnerID(ownerID int) ([]data_transfers.Workou
 		return nil, http.StatusInternalServerError, err
 	}
 
-	for i, workout := range workoutsResponse {
-		if workout.Price == float64(0) {
-			workoutExercises, statusCode, err := s.workoutExercisesService.FindAllByWorkoutID(workout.ID)
-			if err != nil {
-				return nil, statusCode, err
-			}
-			workoutsResponse[i].Exercises = workoutExercises
-		}
+	statusCode, err := s.attachExercises(workoutsResponse, userID)
+	if err != nil {
+		return nil, statusCode, err
 	}
 
 	return workoutsResponse, http.StatusOK, nil
@@ -163,7 +169,7 @@ func (s *WorkoutsService) FindAllByOwnerID(ownerID int) ([]data_transfers.Workou
 func (s *WorkoutsService) FindAllByCurrentUserID(ownerID int) ([]data_transfers.WorkoutsResponse, int, error) {
 	var workoutsResponse []data_transfers.WorkoutsResponse
 
-	workouts, err := s.repository.FindAllByOwnerID(ownerID)
+	workouts, err := s.repository.FindAllByOwnerID(ownerID, ownerID)
 	if err != nil {
 		if errors.Is(err, repositories.ErrorRowNotFound) {
 			return nil, http.StatusNotFound, errors.New("workouts not found")
@@ -199,6 +205,10 @@ func (s *WorkoutsService) Save(workout data_transfers.CreateWorkoutRequest) (int
 		return 0, http.StatusInternalServerError, err
 	}
 
+	if statusCode, err := s.revisionsService.Record(id); err != nil {
+		return 0, statusCode, err
+	}
+
 	return id, http.StatusCreated, nil
 }
 
@@ -213,6 +223,10 @@ func (s *WorkoutsService) Update(id int, workout data_transfers.UpdateWorkoutReq
 		return http.StatusInternalServerError, err
 	}
 
+	if statusCode, err := s.revisionsService.Record(id); err != nil {
+		return statusCode, err
+	}
+
 	return http.StatusOK, nil
 }
 
@@ -225,30 +239,192 @@ func (s *WorkoutsService) Delete(id int) (int, error) {
 	return http.StatusOK, nil
 }
 
+// FindForPurchase returns the workout without its exercises. Workouts hidden
+// by moderation cannot be bought, like they cannot be copied.
+func (s *WorkoutsService) FindForPurchase(id int) (data_transfers.WorkoutsResponse, int, error) {
+	var workoutResponse data_transfers.WorkoutsResponse
+
+	workout, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return workoutResponse, http.StatusNotFound, errors.New("workout not found")
+		}
+		return workoutResponse, http.StatusInternalServerError, fmt.Errorf("service - FindForPurchase - repository.FindByID: %w", err)
+	}
+	if workout.HiddenAt.Valid {
+		return workoutResponse, http.StatusNotFound, errors.New("workout not found")
+	}
+
+	err = copier.Copy(&workoutResponse, &workout)
+	if err != nil {
+		return workoutResponse, http.StatusInternalServerError, err
+	}
+
+	return workoutResponse, http.StatusOK, nil
+}
+
+// Copy copies the workout for the user. Workouts hidden by moderation cannot
+// be copied, not even by their owner.
 func (s *WorkoutsService) Copy(id int, userID int) (int, int, error) {
-	id, err := s.repository.Copy(id, userID)
+	source, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return 0, http.StatusNotFound, errors.New("workout not found")
+		}
+		return 0, http.StatusInternalServerError, fmt.Errorf("service - Copy - repository.FindByID: %w", err)
+	}
+	if source.HiddenAt.Valid {
+		return 0, http.StatusNotFound, errors.New("workout not found")
+	}
+
+	copyID, err := s.repository.Copy(id, userID)
 	if err != nil {
 		return 0, http.StatusInternalServerError, err
 	}
 
-	return id, http.StatusCreated, nil
+	if statusCode, err := s.revisionsService.RecordWithNote(copyID, fmt.Sprintf("Copied from workout %d", id)); err != nil {
+		return 0, statusCode, err
+	}
+
+	return copyID, http.StatusCreated, nil
 }
 
-func (s *WorkoutsService) PurchaseWorkout(workoutID int, userID int) (int, int, error) {
-	// TODO: purchase workout
+func (s *WorkoutsService) FindLineage(id int) (data_transfers.WorkoutLineageResponse, int, error) {
+	var lineageResponse data_transfers.WorkoutLineageResponse
 
-	id, err := s.repository.Copy(workoutID, userID)
+	lineage, err := s.repository.FindLineage(id)
 	if err != nil {
-		return 0, http.StatusInternalServerError, err
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return lineageResponse, http.StatusNotFound, errors.New("workout not found")
+		}
+		return lineageResponse, http.StatusInternalServerError, fmt.Errorf("service - FindLineage - repository.FindLineage: %w", err)
 	}
 
-	return id, http.StatusCreated, nil
+	err = copier.Copy(&lineageResponse, &lineage)
+	if err != nil {
+		return lineageResponse, http.StatusInternalServerError, err
+	}
+
+	return lineageResponse, http.StatusOK, nil
+}
+
+// MergeUpstream pulls into a copy the exercises added to its source since
+// the copy was last in step with it. Exercises the owner removed from the
+// copy before that are not added back, and the copy keeps its own notes and
+// prescriptions for the exercises it already has.
+func (s *WorkoutsService) MergeUpstream(id int, ownerID int) (data_transfers.WorkoutMergeUpstreamResponse, int, error) {
+	var mergeResponse data_transfers.WorkoutMergeUpstreamResponse
+
+	workout, err := s.repository.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return mergeResponse, http.StatusNotFound, errors.New("workout not found")
+		}
+		return mergeResponse, http.StatusInternalServerError, fmt.Errorf("service - MergeUpstream - repository.FindByID: %w", err)
+	}
+	if workout.OwnerID != ownerID {
+		return mergeResponse, http.StatusForbidden, errors.New("you are not allowed to merge into this workout")
+	}
+	if !workout.ForkedFromWorkoutID.Valid {
+		return mergeResponse, http.StatusBadRequest, errors.New("workout is not a copy of another workout")
+	}
+
+	sourceID := int(workout.ForkedFromWorkoutID.Int64)
+	source, err := s.repository.FindByID(sourceID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrorRowNotFound) {
+			return mergeResponse, http.StatusNotFound, errors.New("original workout not found")
+		}
+		return mergeResponse, http.StatusInternalServerError, fmt.Errorf("service - MergeUpstream - repository.FindByID: %w", err)
+	}
+	if source.HiddenAt.Valid {
+		return mergeResponse, http.StatusNotFound, errors.New("original workout not found")
+	}
+	if source.Price > float64(0) || source.IsPrivate {
+		return mergeResponse, http.StatusForbidden, errors.New("you cannot merge from a paid or private workout")
+	}
+
+	var sourceResponse data_transfers.WorkoutsResponse
+	if err := copier.Copy(&sourceResponse, &source); err != nil {
+		return mergeResponse, http.StatusInternalServerError, err
+	}
+	entitled, statusCode, err := s.IsEntitled(sourceResponse, ownerID)
+	if err != nil {
+		return mergeResponse, statusCode, err
+	}
+	if !entitled {
+		return mergeResponse, http.StatusForbidden, errors.New("you need a membership to merge from this workout")
+	}
+
+	latest, statusCode, err := s.revisionsService.findByNumber(sourceID, 0)
+	if err != nil {
+		return mergeResponse, statusCode, err
+	}
+
+	if !workout.UpstreamRevision.Valid || latest.RevisionNumber > int(workout.UpstreamRevision.Int32) {
+		// Exercises the source already had at the last merge, and the ones
+		// in the copy, are not new.
+		known := make(map[int]bool)
+		if workout.UpstreamRevision.Valid {
+			base, statusCode, err := s.revisionsService.findByNumber(sourceID, int(workout.UpstreamRevision.Int32))
+			if err != nil && statusCode != http.StatusNotFound {
+				return mergeResponse, statusCode, err
+			}
+			for _, exercise := range base.Exercises {
+				known[exercise.ExerciseID] = true
+			}
+		}
+
+		current, err := s.revisionsService.repository.FindCurrent(id)
+		if err != nil {
+			return mergeResponse, http.StatusInternalServerError, fmt.Errorf("service - MergeUpstream - revisionsService.repository.FindCurrent: %w", err)
+		}
+		for _, exercise := range current.Exercises {
+			known[exercise.ExerciseID] = true
+		}
+
+		var exercises []records.WorkoutRevisionExercises
+		for _, exercise := range latest.Exercises {
+			if !known[exercise.ExerciseID] {
+				exercises = append(exercises, exercise)
+			}
+		}
+
+		added, err := s.repository.MergeUpstream(id, latest.RevisionNumber, exercises)
+		if err != nil {
+			if errors.Is(err, repositories.ErrorRowNotFound) {
+				return mergeResponse, http.StatusNotFound, errors.New("workout not found")
+			}
+			if errors.Is(err, repositories.ErrorForeignKeyViolation) {
+				return mergeResponse, http.StatusConflict, errors.New("an exercise of the original workout no longer exists")
+			}
+			return mergeResponse, http.StatusInternalServerError, fmt.Errorf("service - MergeUpstream - repository.MergeUpstream: %w", err)
+		}
+		mergeResponse.AddedExercises = added
+
+		if added > 0 {
+			note := fmt.Sprintf("Merged revision %d of workout %d", latest.RevisionNumber, sourceID)
+			if statusCode, err := s.revisionsService.RecordWithNote(id, note); err != nil {
+				return mergeResponse, statusCode, err
+			}
+		}
+	}
+
+	lineage, statusCode, err := s.FindLineage(id)
+	if err != nil {
+		return mergeResponse, statusCode, err
+	}
+	mergeResponse.Lineage = lineage
+
+	return mergeResponse, http.StatusOK, nil
 }
 
-func (s *WorkoutsService) FindAllWithFilters(params repositories.QueryParams) ([]data_transfers.WorkoutsResponse, int, int, error) {
+// FindAllWithFilters lists public workouts. Exercises are only included for
+// the workouts the user may see, see attachExercises.
+func (s *WorkoutsService) FindAllWithFilters(params repositories.QueryParams, userID int) ([]data_transfers.WorkoutsResponse, int, int, error) {
 	var workoutsResponse []data_transfers.WorkoutsResponse
 
-	workouts, total, err := s.repository.FindAllWithFilters(params)
+	workouts, total, err := s.repository.FindAllWithFilters(params, userID)
 	if err != nil {
 		if errors.Is(err, repositories.ErrorRowNotFound) {
 			return nil, 0, http.StatusNotFound, errors.New("workouts not found")
@@ -256,28 +432,97 @@ func (s *WorkoutsService) FindAllWithFilters(params repositories.QueryParams) ([
 		return nil, 0, http.StatusInternalServerError, err
 	}
 
-	var freeWorkouts []records.Workouts
-	for _, workout := range workouts {
-		if workout.Price == float64(0) {
-			freeWorkouts = append(freeWorkouts, workout)
+	err = copier.Copy(&workoutsResponse, &workouts)
+	if err != nil {
+		return nil, 0, http.StatusInternalServerError, err
+	}
+
+	statusCode, err := s.attachExercises(workoutsResponse, userID)
+	if err != nil {
+		return nil, 0, statusCode, err
+	}
+
+	return workoutsResponse, total, http.StatusOK, nil
+}
+
+// attachExercises fills in the exercises of the workouts the user may see.
+// Owners see all of their workouts. Other users see paid workouts once they
+// purchased them and members-only workouts while they are members.
+func (s *WorkoutsService) attachExercises(workoutsResponse []data_transfers.WorkoutsResponse, userID int) (int, error) {
+	var paidWorkoutIDs []int
+	membersOnly := false
+	for _, workout := range workoutsResponse {
+		if workout.OwnerID == userID {
+			continue
+		}
+		if workout.Price != float64(0) {
+			paidWorkoutIDs = append(paidWorkoutIDs, workout.ID)
 		}
+		membersOnly = membersOnly || workout.MembersOnly
 	}
 
-	err = copier.Copy(&workoutsResponse, &freeWorkouts)
+	purchased, statusCode, err := s.purchasesService.EntitledWorkoutIDs(userID, paidWorkoutIDs)
 	if err != nil {
-		return nil, 0, http.StatusInternalServerError, err
+		return statusCode, err
+	}
+
+	member := false
+	if membersOnly {
+		member, statusCode, err = s.subscriptionsService.IsMember(userID)
+		if err != nil {
+			return statusCode, err
+		}
 	}
 
 	for i, workout := range workoutsResponse {
+		workoutsResponse[i].Purchased = purchased[workout.ID]
+		if workout.OwnerID != userID {
+			if workout.Price != float64(0) && !purchased[workout.ID] {
+				continue
+			}
+			if workout.MembersOnly && !member {
+				continue
+			}
+		}
+
 		workoutExercises, statusCode, err := s.workoutExercisesService.FindAllByWorkoutID(workout.ID)
 		if err != nil {
-			return nil, 0, statusCode, err
+			return statusCode, err
 		}
 		workoutsResponse[i].Exercises = workoutExercises
+	}
 
+	return http.StatusOK, nil
+}
+
+// IsEntitled tells whether the user may see the exercises of the workout,
+// with the same rules as attachExercises.
+func (s *WorkoutsService) IsEntitled(workout data_transfers.WorkoutsResponse, userID int) (bool, int, error) {
+	if workout.OwnerID == userID {
+		return true, http.StatusOK, nil
 	}
 
-	return workoutsResponse, total, http.StatusOK, nil
+	if workout.Price != float64(0) {
+		purchased, statusCode, err := s.purchasesService.EntitledWorkoutIDs(userID, []int{workout.ID})
+		if err != nil {
+			return false, statusCode, err
+		}
+		if !purchased[workout.ID] {
+			return false, http.StatusOK, nil
+		}
+	}
+
+	if workout.MembersOnly {
+		member, statusCode, err := s.subscriptionsService.IsMember(userID)
+		if err != nil {
+			return false, statusCode, err
+		}
+		if !member {
+			return false, http.StatusOK, nil
+		}
+	}
+
+	return true, http.StatusOK, nil
 }
 
 func (s *WorkoutsService) LikeWorkout(id int, userID int) (int, error) {
@@ -323,10 +568,11 @@ func (s *WorkoutsService) GenerateWorkout(generateRequest data_transfers.Workout
 				return statusCode, fmt.Errorf("service - GenerateWorkout - CreateCustomExercise: %w", err)
 			}
 			workoutExercise := data_transfers.CreateWorkoutExercisesRequest{
-				WorkoutID:  workoutID,
-				ExerciseID: exerciseID,
-				MainNote:   exercise.Description,
-				OwnerID:    generateRequest.OwnerID,
+				WorkoutID:    workoutID,
+				ExerciseID:   exerciseID,
+				MainNote:     exercise.Description,
+				OwnerID:      generateRequest.OwnerID,
+				Prescription: toGeneratedPrescription(exercise),
 			}
 
 			_, statusCode, err = s.workoutExercisesService.Save(workoutExercise)
@@ -339,10 +585,11 @@ func (s *WorkoutsService) GenerateWorkout(generateRequest data_transfers.Workout
 		} else {
 			fmt.Println("Exercise found", exercise.Name)
 			workoutExercise := data_transfers.CreateWorkoutExercisesRequest{
-				WorkoutID:  workoutID,
-				ExerciseID: exercise2.ID,
-				MainNote:   exercise.Description,
-				OwnerID:    generateRequest.OwnerID,
+				WorkoutID:    workoutID,
+				ExerciseID:   exercise2.ID,
+				MainNote:     exercise.Description,
+				OwnerID:      generateRequest.OwnerID,
+				Prescription: toGeneratedPrescription(exercise),
 			}
 			_, statusCode, err = s.workoutExercisesService.Save(workoutExercise)
 			if err != nil {
@@ -355,3 +602,25 @@ func (s *WorkoutsService) GenerateWorkout(generateRequest data_transfers.Workout
 
 	return http.StatusCreated, nil
 }
+
+// toGeneratedPrescription keeps the targets of a generated exercise that make
+// sense and drops the rest, so a sloppy answer does not fail the generation.
+func toGeneratedPrescription(exercise io.Exercise) *data_transfers.PrescriptionRequest {
+	var prescription data_transfers.PrescriptionRequest
+
+	if exercise.Sets > 0 {
+		prescription.TargetSets = &exercise.Sets
+	}
+	if exercise.RepsMin > 0 && exercise.RepsMax >= exercise.RepsMin {
+		prescription.TargetRepsMin = &exercise.RepsMin
+		prescription.TargetRepsMax = &exercise.RepsMax
+	}
+	if tempoPattern.MatchString(exercise.Tempo) {
+		prescription.Tempo = &exercise.Tempo
+	}
+	if exercise.RestSeconds > 0 {
+		prescription.RestSeconds = &exercise.RestSeconds
+	}
+
+	return &prescription
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"backend/internal/datasources/repositories"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCursorLimit = 20
+	maxCursorLimit     = 100
+)
+
+// ExtractCursor reads the cursor and limit query parameters of listings that
+// use cursor pagination.
+func ExtractCursor(query url.Values) (repositories.Cursor, error) {
+	cursor := repositories.Cursor{Limit: defaultCursorLimit}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxCursorLimit {
+			return repositories.Cursor{}, fmt.Errorf("invalid limit number")
+		}
+		cursor.Limit = limit
+	}
+
+	value := query.Get("cursor")
+	if value == "" {
+		return cursor, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return repositories.Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	createdAt, id, found := strings.Cut(string(decoded), "|")
+	if !found {
+		return repositories.Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return repositories.Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	cursor.ID, err = strconv.Atoi(id)
+	if err != nil || cursor.ID < 1 {
+		return repositories.Cursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+// EncodeCursor returns the opaque value of the cursor for the next page, or
+// an empty string on the last page.
+func EncodeCursor(cursor *repositories.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor.CreatedAt.Format(time.RFC3339Nano) + "|" + strconv.Itoa(cursor.ID)))
+}
@@ -14,6 +14,7 @@ func ExtractQueryParams(query url.Values) (repositories.QueryParams, error) {
 		Page:  1,
 		Limit: 10,
 	}
+	sort := repositories.SortLikes
 
 	for key, values := range query {
 		if len(values) == 0 {
@@ -34,6 +35,11 @@ func ExtractQueryParams(query url.Values) (repositories.QueryParams, error) {
 				return repositories.QueryParams{}, fmt.Errorf("invalid limit number")
 			}
 			pagination.Limit = limit
+		case key == "sort":
+			if value != repositories.SortLikes && value != repositories.SortRating {
+				return repositories.QueryParams{}, fmt.Errorf("invalid sort")
+			}
+			sort = value
 		case strings.HasPrefix(key, "min_"):
 			filters[strings.TrimPrefix(key, "min_")+">="] = value
 		case strings.HasPrefix(key, "max_"):
@@ -48,5 +54,6 @@ func ExtractQueryParams(query url.Values) (repositories.QueryParams, error) {
 	return repositories.QueryParams{
 		Filters:    filters,
 		Pagination: pagination,
+		Sort:       sort,
 	}, nil
 }
@@ -0,0 +1,15 @@
+package attempts
+
+import "time"
+
+// Store keeps expiring counters and locks shared by every API instance.
+type Store interface {
+	// Increment adds one to the counter and restarts its expiry window.
+	Increment(key string, window time.Duration) (int64, error)
+	// Lock marks the key as locked for the given duration.
+	Lock(key string, duration time.Duration) error
+	// LockedFor returns how long the key stays locked, or zero when it is not.
+	LockedFor(key string) (time.Duration, error)
+	// Reset removes the counters and locks of the given keys.
+	Reset(keys ...string) error
+}
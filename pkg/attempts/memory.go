@@ -0,0 +1,95 @@
+package attempts
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. It is meant for single
+// instance deployments and local development.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]memoryEntry
+	locks    map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]memoryEntry),
+		locks:    make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryStore) Increment(key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.evictExpired(now)
+
+	entry := s.counters[key]
+	entry.count++
+	entry.expiresAt = now.Add(window)
+	s.counters[key] = entry
+
+	return entry.count, nil
+}
+
+func (s *MemoryStore) Lock(key string, duration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locks[key] = time.Now().Add(duration)
+	return nil
+}
+
+func (s *MemoryStore) LockedFor(key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.locks[key]
+	if !ok {
+		return 0, nil
+	}
+
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		delete(s.locks, key)
+		return 0, nil
+	}
+
+	return remaining, nil
+}
+
+func (s *MemoryStore) Reset(keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.counters, key)
+		delete(s.locks, key)
+	}
+
+	return nil
+}
+
+// evictExpired keeps the maps from growing with keys that are never reset,
+// such as the addresses of one-off attackers.
+func (s *MemoryStore) evictExpired(now time.Time) {
+	for key, entry := range s.counters {
+		if now.After(entry.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+
+	for key, until := range s.locks {
+		if now.After(until) {
+			delete(s.locks, key)
+		}
+	}
+}
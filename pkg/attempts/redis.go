@@ -0,0 +1,76 @@
+package attempts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisCounterPrefix = "attempts:count:"
+	redisLockPrefix    = "attempts:lock:"
+)
+
+// RedisStore keeps counters in Redis so that limits hold across instances.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Increment(key string, window time.Duration) (int64, error) {
+	ctx := context.Background()
+
+	pipe := s.client.TxPipeline()
+	incr := pipe.Incr(ctx, redisCounterPrefix+key)
+	pipe.Expire(ctx, redisCounterPrefix+key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("attempts - RedisStore - Increment: %w", err)
+	}
+
+	return incr.Val(), nil
+}
+
+func (s *RedisStore) Lock(key string, duration time.Duration) error {
+	err := s.client.Set(context.Background(), redisLockPrefix+key, 1, duration).Err()
+	if err != nil {
+		return fmt.Errorf("attempts - RedisStore - Lock: %w", err)
+	}
+
+	return nil
+}
+
+func (s *RedisStore) LockedFor(key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(context.Background(), redisLockPrefix+key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("attempts - RedisStore - LockedFor: %w", err)
+	}
+
+	// PTTL reports negative values for missing keys and keys without expiry.
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+func (s *RedisStore) Reset(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	redisKeys := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		redisKeys = append(redisKeys, redisCounterPrefix+key, redisLockPrefix+key)
+	}
+
+	if err := s.client.Del(context.Background(), redisKeys...).Err(); err != nil {
+		return fmt.Errorf("attempts - RedisStore - Reset: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+// Package billing charges saved payment methods for recurring amounts, such
+// as membership renewals, without the customer being present.
+package billing
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDeclined is wrapped by errors of charges the payment method refused.
+// Other errors mean the charge could not be attempted and may be retried.
+var ErrDeclined = errors.New("charge declined")
+
+type ChargeParams struct {
+	// PaymentMethod is a vault token, see payments.Vault.
+	PaymentMethod string
+	// Amount is in the currency's minor unit.
+	Amount   int64
+	Currency string
+	Metadata map[string]string
+	// IdempotencyKey makes retries of the same charge collect it only once.
+	IdempotencyKey string
+}
+
+type Charge struct {
+	ID       string
+	Amount   int64
+	Currency string
+}
+
+type Biller interface {
+	Charge(ctx context.Context, params ChargeParams) (Charge, error)
+}
@@ -0,0 +1,77 @@
+package billing
+
+import (
+	"backend/pkg/payments"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// FakeBiller is an in-memory biller for development and tests. Every charge
+// succeeds except for the payments.FakeCardDeclined and
+// payments.FakeCardRequires3DS tokens and the tokens passed to Decline.
+type FakeBiller struct {
+	mu       sync.Mutex
+	declined map[string]bool
+	charges  map[string]Charge
+}
+
+func NewFakeBiller() *FakeBiller {
+	return &FakeBiller{
+		declined: map[string]bool{
+			payments.FakeCardDeclined:    true,
+			payments.FakeCardRequires3DS: true,
+		},
+		charges: make(map[string]Charge),
+	}
+}
+
+// Decline makes charges of the payment method fail, or succeed again.
+func (b *FakeBiller) Decline(paymentMethod string, declined bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.declined[paymentMethod] = declined
+}
+
+// Charges returns the successful charges made so far.
+func (b *FakeBiller) Charges() []Charge {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	charges := make([]Charge, 0, len(b.charges))
+	for _, charge := range b.charges {
+		charges = append(charges, charge)
+	}
+
+	return charges
+}
+
+func (b *FakeBiller) Charge(_ context.Context, params ChargeParams) (Charge, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if charge, ok := b.charges[params.IdempotencyKey]; ok && params.IdempotencyKey != "" {
+		return charge, nil
+	}
+	if params.Amount <= 0 {
+		return Charge{}, fmt.Errorf("billing - fake - amount must be positive")
+	}
+	if b.declined[params.PaymentMethod] {
+		return Charge{}, fmt.Errorf("%w: Your card was declined.", ErrDeclined)
+	}
+
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	charge := Charge{ID: "ch_fake_" + hex.EncodeToString(buf), Amount: params.Amount, Currency: params.Currency}
+
+	key := params.IdempotencyKey
+	if key == "" {
+		key = charge.ID
+	}
+	b.charges[key] = charge
+
+	return charge, nil
+}
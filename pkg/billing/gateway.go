@@ -0,0 +1,53 @@
+package billing
+
+import (
+	"backend/pkg/payments"
+	"context"
+	"fmt"
+)
+
+// GatewayBiller charges through a payments.Gateway by creating and
+// confirming a payment intent with the saved payment method.
+type GatewayBiller struct {
+	gateway payments.Gateway
+}
+
+func NewGatewayBiller(gateway payments.Gateway) *GatewayBiller {
+	return &GatewayBiller{gateway}
+}
+
+func (b *GatewayBiller) Charge(ctx context.Context, params ChargeParams) (Charge, error) {
+	intent, err := b.gateway.CreateIntent(ctx, payments.CreateIntentParams{
+		Amount:         params.Amount,
+		Currency:       params.Currency,
+		Metadata:       params.Metadata,
+		IdempotencyKey: params.IdempotencyKey,
+	})
+	if err != nil {
+		return Charge{}, fmt.Errorf("billing - gateway.CreateIntent: %w", err)
+	}
+
+	// A retried idempotent request returns the intent of the first attempt,
+	// which may already be paid.
+	if intent.Status != payments.StatusSucceeded {
+		intent, err = b.gateway.ConfirmIntent(ctx, intent.ID, payments.ConfirmIntentParams{PaymentMethod: params.PaymentMethod})
+		if err != nil {
+			return Charge{}, fmt.Errorf("billing - gateway.ConfirmIntent: %w", err)
+		}
+	}
+
+	switch intent.Status {
+	case payments.StatusSucceeded:
+		return Charge{ID: intent.ID, Amount: intent.Amount, Currency: intent.Currency}, nil
+	case payments.StatusRequiresAction:
+		return Charge{}, fmt.Errorf("%w: the payment method requires authentication", ErrDeclined)
+	case payments.StatusRequiresPaymentMethod, payments.StatusCanceled:
+		reason := intent.LastError
+		if reason == "" {
+			reason = "the payment was not completed"
+		}
+		return Charge{}, fmt.Errorf("%w: %s", ErrDeclined, reason)
+	default:
+		return Charge{}, fmt.Errorf("billing - intent %s is %s", intent.ID, intent.Status)
+	}
+}
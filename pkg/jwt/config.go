@@ -5,8 +5,13 @@ import (
 	"time"
 )
 
+// Config holds the signing keys. New tokens are signed with the newest key of
+// the Keyring; SecretKey is only used when the keyring is empty, or to verify
+// HS256 tokens issued before the keyring was introduced until LegacyUntil.
 type Config struct {
 	SecretKey       string
+	Keyring         *Keyring
+	LegacyUntil     time.Time
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
 }
@@ -15,11 +20,15 @@ var defaultConfig *Config
 
 func MustInitializeConfig(
 	secretKey string,
+	keyring *Keyring,
+	legacyUntil time.Time,
 	accessTokenTTL,
 	refreshTokenTTL time.Duration,
 ) {
 	defaultConfig = &Config{
 		SecretKey:       secretKey,
+		Keyring:         keyring,
+		LegacyUntil:     legacyUntil,
 		AccessTokenTTL:  accessTokenTTL,
 		RefreshTokenTTL: refreshTokenTTL,
 	}
@@ -1,30 +1,48 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"slices"
 	"time"
 )
 
 var (
 	AccessToken  = "access_token"
 	RefreshToken = "refresh_token"
+
+	PurposeTwoFactorChallenge = "2fa_challenge"
 )
 
 type Claims struct {
-	UserID  int
-	IsAdmin bool
+	UserID      int
+	IsAdmin     bool
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	Purpose     string   `json:"purpose,omitempty"`
+	SessionID   string   `json:"sid,omitempty"`
+	TokenType   string   `json:"typ,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateTokenPair(userID int, isAdmin bool) (accessToken, refreshToken string, err error) {
-	accessToken, err = GenerateToken(userID, isAdmin, AccessToken)
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+func (c *Claims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
+func GenerateTokenPair(userID int, isAdmin bool, roles, permissions []string, sessionID string) (accessToken, refreshToken string, err error) {
+	accessToken, err = GenerateToken(userID, isAdmin, roles, permissions, sessionID, AccessToken)
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err = GenerateToken(userID, isAdmin, RefreshToken)
+	refreshToken, err = GenerateToken(userID, isAdmin, roles, permissions, sessionID, RefreshToken)
 	if err != nil {
 		return "", "", err
 	}
@@ -32,48 +50,100 @@ func GenerateTokenPair(userID int, isAdmin bool) (accessToken, refreshToken stri
 	return accessToken, refreshToken, nil
 }
 
-func GenerateToken(userID int, isAdmin bool, tokenType string) (string, error) {
+func GenerateToken(userID int, isAdmin bool, roles, permissions []string, sessionID string, tokenType string) (string, error) {
 	config, err := GetConfig()
 	if err != nil {
 		return "", fmt.Errorf("jwt - GenerateToken - GetConfig: %w", err)
 	}
 
-	var claims Claims
+	tokenID, err := generateTokenID()
+	if err != nil {
+		return "", fmt.Errorf("jwt - GenerateToken - generateTokenID: %w", err)
+	}
+
+	ttl := config.AccessTokenTTL
 	if tokenType == RefreshToken {
-		claims = Claims{
-			UserID:  userID,
-			IsAdmin: isAdmin,
-			RegisteredClaims: jwt.RegisteredClaims{
-				ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.AccessTokenTTL)),
-				IssuedAt:  jwt.NewNumericDate(time.Now()),
-			},
-		}
-	} else {
-		claims = Claims{
-			UserID:  userID,
-			IsAdmin: isAdmin,
-			RegisteredClaims: jwt.RegisteredClaims{
-				ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.RefreshTokenTTL)),
-				IssuedAt:  jwt.NewNumericDate(time.Now()),
-			},
-		}
+		ttl = config.RefreshTokenTTL
+	}
+
+	claims := Claims{
+		UserID:      userID,
+		IsAdmin:     isAdmin,
+		Roles:       roles,
+		Permissions: permissions,
+		SessionID:   sessionID,
+		TokenType:   tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return signClaims(config, claims)
+}
+
+// GenerateChallengeToken issues a short-lived token proving that the password
+// step of a sign-in succeeded. It is rejected by ParseToken, so it cannot be
+// used as an access or refresh token.
+func GenerateChallengeToken(userID int, ttl time.Duration) (string, error) {
+	config, err := GetConfig()
+	if err != nil {
+		return "", fmt.Errorf("jwt - GenerateChallengeToken - GetConfig: %w", err)
+	}
+
+	tokenID, err := generateTokenID()
+	if err != nil {
+		return "", fmt.Errorf("jwt - GenerateChallengeToken - generateTokenID: %w", err)
+	}
+
+	claims := Claims{
+		UserID:  userID,
+		Purpose: PurposeTwoFactorChallenge,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return signClaims(config, claims)
+}
+
+func ParseChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.SecretKey))
+	if claims.Purpose != PurposeTwoFactorChallenge {
+		return nil, ErrTokenInvalid
+	}
+
+	return claims, nil
 }
 
 func ParseToken(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != "" {
+		return nil, ErrTokenInvalid
+	}
+
+	return claims, nil
+}
+
+func parseClaims(tokenString string) (*Claims, error) {
 	config, err := GetConfig()
 	if err != nil {
-		return nil, fmt.Errorf("jwt - ParseToken - GetConfig: %w", err)
+		return nil, fmt.Errorf("jwt - parseClaims - GetConfig: %w", err)
 	}
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("jwt - ParseToken - token.Method %v", token.Header["alg"])
-		}
-		return []byte(config.SecretKey), nil
+		return verificationKey(config, token)
 	})
 
 	if err != nil {
@@ -96,3 +166,57 @@ func ParseToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+func signClaims(config *Config, claims Claims) (string, error) {
+	key := config.Keyring.SigningKey()
+	if key == nil {
+		if config.SecretKey == "" {
+			return "", errors.New("jwt - signClaims - no signing key configured")
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(config.SecretKey))
+	}
+
+	token := jwt.NewWithClaims(key.signingMethod(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// verificationKey picks the key a token was signed with. Tokens without a
+// "kid" header are signed with the secret. Once the keyring has a signing key
+// they predate it, and are only accepted until the LegacyUntil cutoff.
+func verificationKey(config *Config, token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		if _, isHMAC := token.Method.(*jwt.SigningMethodHMAC); !isHMAC || config.SecretKey == "" {
+			return nil, fmt.Errorf("jwt - verificationKey - token.Method %v", token.Header["alg"])
+		}
+		if config.Keyring.SigningKey() != nil && !time.Now().Before(config.LegacyUntil) {
+			return nil, errors.New("jwt - verificationKey - tokens without a kid are no longer accepted")
+		}
+		return []byte(config.SecretKey), nil
+	}
+
+	key := config.Keyring.Find(kid)
+	if key == nil {
+		return nil, fmt.Errorf("jwt - verificationKey - unknown kid %q", kid)
+	}
+
+	// The algorithm is pinned to the key, so a token cannot pick a weaker one.
+	if token.Method.Alg() != key.signingMethod().Alg() {
+		return nil, fmt.Errorf("jwt - verificationKey - token.Method %v", token.Header["alg"])
+	}
+
+	return key.PublicKey(), nil
+}
+
+// generateTokenID makes every issued token unique, so two refresh tokens
+// issued within the same second can still be told apart after rotation.
+func generateTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
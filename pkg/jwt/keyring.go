@@ -0,0 +1,235 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+const (
+	rsaKeyBits   = 2048
+	keyFileExt   = ".pem"
+	kidTimestamp = "20060102T150405Z"
+)
+
+// Key is an asymmetric signing key. Its ID is sent as the "kid" header so that
+// verifiers can pick the matching public key.
+type Key struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+}
+
+func (k *Key) PublicKey() crypto.PublicKey {
+	return k.PrivateKey.Public()
+}
+
+func (k *Key) signingMethod() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// Keyring holds every key that tokens may still be verified with. The newest
+// key signs new tokens; older keys stay until the tokens they signed expire.
+type Keyring struct {
+	keys []*Key
+}
+
+func NewKeyring(keys ...*Key) *Keyring {
+	sorted := append([]*Key(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	return &Keyring{keys: sorted}
+}
+
+// LoadKeyring reads every PEM encoded private key in dir. The key IDs are the
+// file names, which start with the creation time so that they sort by age.
+// A missing directory results in an empty keyring.
+func LoadKeyring(dir string) (*Keyring, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+keyFileExt))
+	if err != nil {
+		return nil, fmt.Errorf("jwt - LoadKeyring - filepath.Glob: %w", err)
+	}
+
+	keys := make([]*Key, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("jwt - LoadKeyring - os.ReadFile: %w", err)
+		}
+
+		key, err := parseKey(strings.TrimSuffix(filepath.Base(file), keyFileExt), data)
+		if err != nil {
+			return nil, fmt.Errorf("jwt - LoadKeyring - parseKey %s: %w", file, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return NewKeyring(keys...), nil
+}
+
+// SigningKey returns the newest key, or nil when the keyring is empty.
+func (k *Keyring) SigningKey() *Key {
+	if k == nil || len(k.keys) == 0 {
+		return nil
+	}
+	return k.keys[len(k.keys)-1]
+}
+
+func (k *Keyring) Find(id string) *Key {
+	if k == nil {
+		return nil
+	}
+
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key
+		}
+	}
+	return nil
+}
+
+func (k *Keyring) Keys() []*Key {
+	if k == nil {
+		return nil
+	}
+	return append([]*Key(nil), k.keys...)
+}
+
+// GenerateKey creates a new RS256 or EdDSA key.
+func GenerateKey(algorithm string) (*Key, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("jwt - GenerateKey - rand.Read: %w", err)
+	}
+	id := time.Now().UTC().Format(kidTimestamp) + "-" + hex.EncodeToString(suffix)
+
+	var privateKey crypto.Signer
+	var err error
+	switch algorithm {
+	case AlgorithmRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("jwt - GenerateKey - unsupported algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jwt - GenerateKey - %s: %w", algorithm, err)
+	}
+
+	return &Key{ID: id, Algorithm: algorithm, PrivateKey: privateKey}, nil
+}
+
+// WriteKey stores the private key in dir as <kid>.pem, readable by the owner only.
+func WriteKey(dir string, key *Key) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("jwt - WriteKey - x509.MarshalPKCS8PrivateKey: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("jwt - WriteKey - os.MkdirAll: %w", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, key.ID+keyFileExt), data, 0o600); err != nil {
+		return fmt.Errorf("jwt - WriteKey - os.WriteFile: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveKey deletes a retired key from dir.
+func RemoveKey(dir string, id string) error {
+	if err := os.Remove(filepath.Join(dir, id+keyFileExt)); err != nil {
+		return fmt.Errorf("jwt - RemoveKey - os.Remove: %w", err)
+	}
+
+	return nil
+}
+
+func parseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch privateKey := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Algorithm: AlgorithmRS256, PrivateKey: privateKey}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Algorithm: AlgorithmEdDSA, PrivateKey: privateKey}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+// JWK is the public part of a key as described in RFC 7517.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the keyring for other services to verify tokens with.
+func (k *Keyring) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range k.Keys() {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+
+		switch publicKey := key.PublicKey().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes every message as an .eml file into a directory instead of
+// delivering it, which is handy for local development.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("mailer - NewFileMailer - os.MkdirAll: %w", err)
+	}
+
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+func (m *FileMailer) Send(message Message) error {
+	filename := filepath.Join(m.dir, fmt.Sprintf("%d.eml", time.Now().UnixNano()))
+
+	if err := os.WriteFile(filename, buildMIME(m.from, message), 0o644); err != nil {
+		return fmt.Errorf("mailer - FileMailer.Send - os.WriteFile: %w", err)
+	}
+
+	return nil
+}
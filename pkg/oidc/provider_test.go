@@ -0,0 +1,253 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testIssuer is a minimal OpenID Connect provider. Codes are registered with
+// the PKCE challenge and nonce of the authorization request they answer.
+type testIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu         sync.Mutex
+	codes      map[string]testAuthorization
+	jwksServed int
+}
+
+type testAuthorization struct {
+	challenge string
+	nonce     string
+	subject   string
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+
+	issuer := &testIssuer{t: t, key: key, kid: "key-1", codes: make(map[string]testAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, discoveryDocument{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JWKSURI:               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		issuer.jwksServed++
+		issuer.mu.Unlock()
+
+		writeJSON(w, jsonWebKeySet{Keys: []jsonWebKey{{
+			KeyType: "RSA",
+			KeyID:   issuer.kid,
+			Use:     "sig",
+			N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", issuer.token)
+
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+// authorize plays the user signing in at the provider and returns the code
+// the provider redirects back with.
+func (i *testIssuer) authorize(authCodeURL string, subject string) (string, url.Values) {
+	i.t.Helper()
+
+	parsed, err := url.Parse(authCodeURL)
+	if err != nil {
+		i.t.Fatalf("url.Parse: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		i.t.Fatalf("code_challenge_method = %q, want S256", query.Get("code_challenge_method"))
+	}
+
+	code, err := GenerateRandom()
+	if err != nil {
+		i.t.Fatalf("GenerateRandom: %v", err)
+	}
+
+	i.mu.Lock()
+	i.codes[code] = testAuthorization{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), subject: subject}
+	i.mu.Unlock()
+
+	return code, query
+}
+
+func (i *testIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	i.mu.Lock()
+	authorization, ok := i.codes[r.PostForm.Get("code")]
+	delete(i.codes, r.PostForm.Get("code"))
+	i.mu.Unlock()
+
+	if !ok || CodeChallenge(r.PostForm.Get("code_verifier")) != authorization.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, map[string]string{"id_token": i.sign(i.kid, authorization.subject, authorization.nonce, r.PostForm.Get("client_id"))})
+}
+
+func (i *testIssuer) sign(kid, subject, nonce, audience string) string {
+	i.t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, IDTokenClaims{
+		Email:         subject + "@example.com",
+		EmailVerified: true,
+		Nonce:         nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.server.URL,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+		},
+	})
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		i.t.Fatalf("SignedString: %v", err)
+	}
+	return signed
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func newTestProvider(issuer *testIssuer) *Provider {
+	return NewProvider(Config{
+		Name:        "test",
+		IssuerURL:   issuer.server.URL,
+		ClientID:    "client-1",
+		RedirectURL: "https://app.example.com/callback",
+	}, issuer.server.Client())
+}
+
+func TestProviderSignIn(t *testing.T) {
+	issuer := newTestIssuer(t)
+	provider := newTestProvider(issuer)
+
+	authCodeURL, err := provider.AuthCodeURL("state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, query := issuer.authorize(authCodeURL, "user-1")
+	if query.Get("state") != "state-1" || query.Get("client_id") != "client-1" {
+		t.Fatalf("authorization request %v does not carry the state and client", query)
+	}
+
+	rawIDToken, err := provider.Exchange(code, "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	claims, err := provider.VerifyIDToken(rawIDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "user-1@example.com" || !claims.EmailVerified {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestProviderExchangeRejectsWrongCodeVerifier(t *testing.T) {
+	issuer := newTestIssuer(t)
+	provider := newTestProvider(issuer)
+
+	authCodeURL, err := provider.AuthCodeURL("state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, _ := issuer.authorize(authCodeURL, "user-1")
+
+	if _, err := provider.Exchange(code, "verifier-2"); err == nil {
+		t.Error("Exchange with another code verifier succeeded")
+	}
+}
+
+func TestProviderVerifyIDTokenRejectsWrongNonce(t *testing.T) {
+	issuer := newTestIssuer(t)
+	provider := newTestProvider(issuer)
+
+	authCodeURL, err := provider.AuthCodeURL("state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, _ := issuer.authorize(authCodeURL, "user-1")
+
+	rawIDToken, err := provider.Exchange(code, "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	if _, err := provider.VerifyIDToken(rawIDToken, "nonce-2"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("VerifyIDToken with another nonce = %v, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestProviderVerifyIDTokenRejectsOtherAudience(t *testing.T) {
+	issuer := newTestIssuer(t)
+	provider := newTestProvider(issuer)
+
+	rawIDToken := issuer.sign(issuer.kid, "user-1", "nonce-1", "client-2")
+	if _, err := provider.VerifyIDToken(rawIDToken, "nonce-1"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("VerifyIDToken for another client = %v, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestProviderRateLimitsKeyReloads(t *testing.T) {
+	issuer := newTestIssuer(t)
+	provider := newTestProvider(issuer)
+
+	if _, err := provider.VerifyIDToken(issuer.sign(issuer.kid, "user-1", "nonce-1", "client-1"), "nonce-1"); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+
+	for range 3 {
+		if _, err := provider.VerifyIDToken(issuer.sign("unknown", "user-1", "nonce-1", "client-1"), "nonce-1"); err == nil {
+			t.Fatal("VerifyIDToken with an unknown key succeeded")
+		}
+	}
+
+	issuer.mu.Lock()
+	served := issuer.jwksServed
+	issuer.mu.Unlock()
+	if served != 1 {
+		t.Errorf("key set fetched %d times, want 1", served)
+	}
+}
@@ -0,0 +1,226 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Payment method tokens understood by FakeGateway, named after the Stripe test tokens.
+const (
+	FakeCardSucceeds        = "pm_card_visa"
+	FakeCardRequires3DS     = "pm_card_threeDSecureRequired"
+	FakeCardDeclined        = "pm_card_chargeDeclined"
+	fakeWebhookSignatureKey = "Fake-Signature"
+)
+
+// FakeGateway is an in-process gateway for development and tests. Cards are
+// picked with the Fake* payment method tokens, 3-D Secure is completed with
+// Authenticate and webhook events are delivered to the OnEvent callback.
+type FakeGateway struct {
+	webhookSecret string
+	actionURL     string
+
+	mu      sync.Mutex
+	intents map[string]*Intent
+	onEvent func(Event)
+}
+
+// NewFakeGateway creates a fake gateway. actionURL is the page customers are
+// sent to for 3-D Secure; the intent ID is appended to it.
+func NewFakeGateway(webhookSecret string, actionURL string) *FakeGateway {
+	return &FakeGateway{
+		webhookSecret: webhookSecret,
+		actionURL:     actionURL,
+		intents:       make(map[string]*Intent),
+	}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+// OnEvent registers the receiver of webhook events. Events are delivered
+// asynchronously, like real webhooks.
+func (g *FakeGateway) OnEvent(handler func(Event)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.onEvent = handler
+}
+
+func (g *FakeGateway) CreateIntent(_ context.Context, params CreateIntentParams) (Intent, error) {
+	if params.Amount <= 0 {
+		return Intent{}, errors.New("payments - fake - amount must be positive")
+	}
+
+	id := "pi_fake_" + randomHex(12)
+	intent := &Intent{
+		ID:           id,
+		Amount:       params.Amount,
+		Currency:     params.Currency,
+		Status:       StatusRequiresPaymentMethod,
+		ClientSecret: id + "_secret_" + randomHex(12),
+		Metadata:     params.Metadata,
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.intents[id] = intent
+
+	return *intent, nil
+}
+
+func (g *FakeGateway) ConfirmIntent(_ context.Context, intentID string, params ConfirmIntentParams) (Intent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	intent, ok := g.intents[intentID]
+	if !ok {
+		return Intent{}, ErrIntentNotFound
+	}
+	if intent.Status != StatusRequiresPaymentMethod && intent.Status != StatusRequiresConfirmation {
+		return Intent{}, fmt.Errorf("payments - fake - intent cannot be confirmed in status %s", intent.Status)
+	}
+
+	intent.LastError = ""
+	intent.NextActionURL = ""
+	switch params.PaymentMethod {
+	case FakeCardSucceeds:
+		intent.Status = StatusSucceeded
+		g.emit(Event{Type: EventPaymentSucceeded, IntentID: intent.ID, Amount: intent.Amount})
+	case FakeCardRequires3DS:
+		intent.Status = StatusRequiresAction
+		intent.NextActionURL = g.actionURL + intent.ID
+	case FakeCardDeclined:
+		intent.Status = StatusRequiresPaymentMethod
+		intent.LastError = "Your card was declined."
+		g.emit(Event{Type: EventPaymentFailed, IntentID: intent.ID, Amount: intent.Amount})
+	default:
+		return Intent{}, fmt.Errorf("payments - fake - unknown payment method %q", params.PaymentMethod)
+	}
+
+	return *intent, nil
+}
+
+// Authenticate completes or fails the 3-D Secure step of an intent.
+func (g *FakeGateway) Authenticate(intentID string, approve bool) (Intent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	intent, ok := g.intents[intentID]
+	if !ok {
+		return Intent{}, ErrIntentNotFound
+	}
+	if intent.Status != StatusRequiresAction {
+		return Intent{}, fmt.Errorf("payments - fake - intent does not require action")
+	}
+
+	intent.NextActionURL = ""
+	if approve {
+		intent.Status = StatusSucceeded
+		g.emit(Event{Type: EventPaymentSucceeded, IntentID: intent.ID, Amount: intent.Amount})
+	} else {
+		intent.Status = StatusRequiresPaymentMethod
+		intent.LastError = "We are unable to authenticate your payment method."
+		g.emit(Event{Type: EventPaymentFailed, IntentID: intent.ID, Amount: intent.Amount})
+	}
+
+	return *intent, nil
+}
+
+// fakeCards are the cards behind the Fake* payment method tokens.
+var fakeCards = map[string]Card{
+	FakeCardSucceeds:    {Token: FakeCardSucceeds, Brand: "visa", Last4: "4242", ExpMonth: 12, ExpYear: 2034},
+	FakeCardRequires3DS: {Token: FakeCardRequires3DS, Brand: "visa", Last4: "3184", ExpMonth: 12, ExpYear: 2034},
+	FakeCardDeclined:    {Token: FakeCardDeclined, Brand: "visa", Last4: "0002", ExpMonth: 12, ExpYear: 2034},
+}
+
+func (g *FakeGateway) RetrieveCard(_ context.Context, token string) (Card, error) {
+	card, ok := fakeCards[token]
+	if !ok {
+		return Card{}, ErrCardNotFound
+	}
+
+	return card, nil
+}
+
+func (g *FakeGateway) DetachCard(_ context.Context, token string) error {
+	if _, ok := fakeCards[token]; !ok {
+		return ErrCardNotFound
+	}
+
+	return nil
+}
+
+func (g *FakeGateway) RetrieveIntent(_ context.Context, intentID string) (Intent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	intent, ok := g.intents[intentID]
+	if !ok {
+		return Intent{}, ErrIntentNotFound
+	}
+
+	return *intent, nil
+}
+
+func (g *FakeGateway) Refund(_ context.Context, intentID string) (Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	intent, ok := g.intents[intentID]
+	if !ok {
+		return Refund{}, ErrIntentNotFound
+	}
+	if intent.Status != StatusSucceeded {
+		return Refund{}, errors.New("payments - fake - only succeeded intents can be refunded")
+	}
+
+	g.emit(Event{Type: EventRefunded, IntentID: intent.ID, Amount: intent.Amount})
+
+	return Refund{ID: "re_fake_" + randomHex(12), IntentID: intent.ID, Amount: intent.Amount, Status: StatusSucceeded}, nil
+}
+
+// ParseWebhook accepts a JSON encoded Event signed with a hex HMAC-SHA256 of
+// the payload in the Fake-Signature header.
+func (g *FakeGateway) ParseWebhook(payload []byte, header http.Header) (Event, error) {
+	signature, err := hex.DecodeString(header.Get(fakeWebhookSignatureKey))
+	if err != nil || !hmac.Equal(signature, g.sign(payload)) {
+		return Event{}, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, fmt.Errorf("payments - fake - json.Unmarshal: %w", err)
+	}
+
+	return event, nil
+}
+
+func (g *FakeGateway) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(g.webhookSecret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// emit must be called with g.mu held.
+func (g *FakeGateway) emit(event Event) {
+	event.ID = "evt_fake_" + randomHex(12)
+	if g.onEvent != nil {
+		go g.onEvent(event)
+	}
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
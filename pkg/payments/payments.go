@@ -0,0 +1,86 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrIntentNotFound   = errors.New("payment intent not found")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Payment intent statuses, named after the Stripe lifecycle.
+const (
+	StatusRequiresPaymentMethod = "requires_payment_method"
+	StatusRequiresConfirmation  = "requires_confirmation"
+	StatusRequiresAction        = "requires_action"
+	StatusProcessing            = "processing"
+	StatusSucceeded             = "succeeded"
+	StatusCanceled              = "canceled"
+)
+
+// Webhook event types the application reacts to. Other events are passed
+// through with their original type and can be ignored.
+const (
+	EventPaymentSucceeded = "payment_intent.succeeded"
+	EventPaymentFailed    = "payment_intent.payment_failed"
+	EventRefunded         = "charge.refunded"
+)
+
+// Intent is a single attempt to collect an amount, in the currency's minor
+// unit, from the customer.
+type Intent struct {
+	ID            string
+	Amount        int64
+	Currency      string
+	Status        string
+	ClientSecret  string
+	NextActionURL string
+	LastError     string
+	Metadata      map[string]string
+}
+
+type CreateIntentParams struct {
+	Amount         int64
+	Currency       string
+	Metadata       map[string]string
+	IdempotencyKey string
+}
+
+// ConfirmIntentParams confirms an intent with a payment method token created
+// on the client, so card numbers never reach the server. ReturnURL is where
+// the customer lands after an authentication step such as 3-D Secure.
+type ConfirmIntentParams struct {
+	PaymentMethod string
+	ReturnURL     string
+}
+
+type Refund struct {
+	ID       string
+	IntentID string
+	Amount   int64
+	Status   string
+}
+
+// Event is a verified webhook notification. Events only tell which intent
+// changed; consumers should fetch the intent before acting on it.
+type Event struct {
+	ID       string
+	Type     string
+	IntentID string
+	Amount   int64
+}
+
+// Gateway collects payments. Every gateway is also the Vault for the cards
+// used with it.
+type Gateway interface {
+	Vault
+	Name() string
+	CreateIntent(ctx context.Context, params CreateIntentParams) (Intent, error)
+	ConfirmIntent(ctx context.Context, intentID string, params ConfirmIntentParams) (Intent, error)
+	RetrieveIntent(ctx context.Context, intentID string) (Intent, error)
+	Refund(ctx context.Context, intentID string) (Refund, error)
+	ParseWebhook(payload []byte, header http.Header) (Event, error)
+}
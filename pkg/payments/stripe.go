@@ -0,0 +1,338 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	stripeAPIURL             = "https://api.stripe.com"
+	stripeSignatureTolerance = 5 * time.Minute
+)
+
+// StripeGateway talks to the Stripe REST API, or any server implementing the
+// same payment intent, refund and webhook signature contract.
+type StripeGateway struct {
+	baseURL       string
+	secretKey     string
+	webhookSecret string
+	client        *http.Client
+}
+
+func NewStripeGateway(secretKey string, webhookSecret string, client *http.Client) *StripeGateway {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &StripeGateway{
+		baseURL:       stripeAPIURL,
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		client:        client,
+	}
+}
+
+// WithBaseURL points the gateway at a Stripe-compatible server, e.g. stripe-mock.
+func (g *StripeGateway) WithBaseURL(baseURL string) *StripeGateway {
+	g.baseURL = strings.TrimRight(baseURL, "/")
+	return g
+}
+
+func (g *StripeGateway) Name() string {
+	return "stripe"
+}
+
+type stripeIntent struct {
+	ID           string            `json:"id"`
+	Amount       int64             `json:"amount"`
+	Currency     string            `json:"currency"`
+	Status       string            `json:"status"`
+	ClientSecret string            `json:"client_secret"`
+	Metadata     map[string]string `json:"metadata"`
+	NextAction   *struct {
+		RedirectToURL *struct {
+			URL string `json:"url"`
+		} `json:"redirect_to_url"`
+	} `json:"next_action"`
+	LastPaymentError *struct {
+		Message string `json:"message"`
+	} `json:"last_payment_error"`
+}
+
+type stripeError struct {
+	Error struct {
+		Type          string        `json:"type"`
+		Code          string        `json:"code"`
+		Message       string        `json:"message"`
+		PaymentIntent *stripeIntent `json:"payment_intent"`
+	} `json:"error"`
+}
+
+func (g *StripeGateway) CreateIntent(ctx context.Context, params CreateIntentParams) (Intent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(params.Amount, 10))
+	form.Set("currency", strings.ToLower(params.Currency))
+	form.Set("payment_method_types[]", "card")
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	var intent stripeIntent
+	if err := g.do(ctx, http.MethodPost, "/v1/payment_intents", form, params.IdempotencyKey, &intent); err != nil {
+		return Intent{}, err
+	}
+
+	return intent.toIntent(), nil
+}
+
+// ConfirmIntent returns declined payments as an intent waiting for another
+// payment method rather than as an error.
+func (g *StripeGateway) ConfirmIntent(ctx context.Context, intentID string, params ConfirmIntentParams) (Intent, error) {
+	form := url.Values{}
+	form.Set("payment_method", params.PaymentMethod)
+	if params.ReturnURL != "" {
+		form.Set("return_url", params.ReturnURL)
+	}
+
+	var intent stripeIntent
+	err := g.do(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(intentID)+"/confirm", form, "", &intent)
+	if err != nil {
+		var declined *stripeDeclinedError
+		if errors.As(err, &declined) {
+			intent := declined.intent.toIntent()
+			if intent.LastError == "" {
+				intent.LastError = declined.message
+			}
+			return intent, nil
+		}
+		return Intent{}, err
+	}
+
+	return intent.toIntent(), nil
+}
+
+func (g *StripeGateway) RetrieveIntent(ctx context.Context, intentID string) (Intent, error) {
+	var intent stripeIntent
+	if err := g.do(ctx, http.MethodGet, "/v1/payment_intents/"+url.PathEscape(intentID), nil, "", &intent); err != nil {
+		return Intent{}, err
+	}
+
+	return intent.toIntent(), nil
+}
+
+func (g *StripeGateway) Refund(ctx context.Context, intentID string) (Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", intentID)
+
+	var refund struct {
+		ID            string `json:"id"`
+		Amount        int64  `json:"amount"`
+		Status        string `json:"status"`
+		PaymentIntent string `json:"payment_intent"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v1/refunds", form, "refund-"+intentID, &refund); err != nil {
+		return Refund{}, err
+	}
+
+	return Refund{ID: refund.ID, IntentID: refund.PaymentIntent, Amount: refund.Amount, Status: refund.Status}, nil
+}
+
+func (g *StripeGateway) RetrieveCard(ctx context.Context, token string) (Card, error) {
+	var method struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Card *struct {
+			Brand    string `json:"brand"`
+			Last4    string `json:"last4"`
+			ExpMonth int    `json:"exp_month"`
+			ExpYear  int    `json:"exp_year"`
+		} `json:"card"`
+	}
+	if err := g.do(ctx, http.MethodGet, "/v1/payment_methods/"+url.PathEscape(token), nil, "", &method); err != nil {
+		if errors.Is(err, ErrIntentNotFound) {
+			return Card{}, ErrCardNotFound
+		}
+		return Card{}, err
+	}
+	if method.Card == nil {
+		return Card{}, ErrCardNotFound
+	}
+
+	return Card{
+		Token:    method.ID,
+		Brand:    method.Card.Brand,
+		Last4:    method.Card.Last4,
+		ExpMonth: method.Card.ExpMonth,
+		ExpYear:  method.Card.ExpYear,
+	}, nil
+}
+
+func (g *StripeGateway) DetachCard(ctx context.Context, token string) error {
+	var method struct {
+		ID string `json:"id"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/v1/payment_methods/"+url.PathEscape(token)+"/detach", url.Values{}, "", &method); err != nil {
+		if errors.Is(err, ErrIntentNotFound) {
+			return ErrCardNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ParseWebhook verifies the Stripe-Signature header, an HMAC-SHA256 of
+// "<timestamp>.<payload>" keyed with the endpoint secret.
+func (g *StripeGateway) ParseWebhook(payload []byte, header http.Header) (Event, error) {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header.Get("Stripe-Signature"), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return Event{}, ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return Event{}, ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(g.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	valid := false
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return Event{}, ErrInvalidSignature
+	}
+
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object struct {
+				ID             string `json:"id"`
+				Object         string `json:"object"`
+				PaymentIntent  string `json:"payment_intent"`
+				Amount         int64  `json:"amount"`
+				AmountRefunded int64  `json:"amount_refunded"`
+			} `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, fmt.Errorf("payments - ParseWebhook - json.Unmarshal: %w", err)
+	}
+
+	object := event.Data.Object
+	parsed := Event{ID: event.ID, Type: event.Type, IntentID: object.ID, Amount: object.Amount}
+	if object.Object == "charge" {
+		parsed.IntentID = object.PaymentIntent
+		parsed.Amount = object.AmountRefunded
+	}
+
+	return parsed, nil
+}
+
+type stripeDeclinedError struct {
+	message string
+	intent  *stripeIntent
+}
+
+func (e *stripeDeclinedError) Error() string {
+	return e.message
+}
+
+func (g *StripeGateway) do(ctx context.Context, method string, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("payments - stripe - http.NewRequest: %w", err)
+	}
+	req.SetBasicAuth(g.secretKey, "")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("payments - stripe - client.Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("payments - stripe - io.ReadAll: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var stripeErr stripeError
+		if err := json.Unmarshal(raw, &stripeErr); err != nil || stripeErr.Error.Message == "" {
+			return fmt.Errorf("payments - stripe - %s %s: unexpected status %d", method, path, resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return ErrIntentNotFound
+		}
+		if stripeErr.Error.Type == "card_error" && stripeErr.Error.PaymentIntent != nil {
+			return &stripeDeclinedError{message: stripeErr.Error.Message, intent: stripeErr.Error.PaymentIntent}
+		}
+		return fmt.Errorf("payments - stripe - %s %s: %s", method, path, stripeErr.Error.Message)
+	}
+
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("payments - stripe - json.Unmarshal: %w", err)
+	}
+
+	return nil
+}
+
+func (i *stripeIntent) toIntent() Intent {
+	intent := Intent{
+		ID:           i.ID,
+		Amount:       i.Amount,
+		Currency:     i.Currency,
+		Status:       i.Status,
+		ClientSecret: i.ClientSecret,
+		Metadata:     i.Metadata,
+	}
+	if i.NextAction != nil && i.NextAction.RedirectToURL != nil {
+		intent.NextActionURL = i.NextAction.RedirectToURL.URL
+	}
+	if i.LastPaymentError != nil {
+		intent.LastError = i.LastPaymentError.Message
+	}
+
+	return intent
+}
@@ -0,0 +1,25 @@
+package payments
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrCardNotFound = errors.New("card not found")
+
+// Card describes a card kept by the gateway. The card number never leaves the
+// gateway; Token is what the application stores and charges.
+type Card struct {
+	Token    string
+	Brand    string
+	Last4    string
+	ExpMonth int
+	ExpYear  int
+}
+
+// Vault stores cards on behalf of the application. Tokens are created on the
+// client with the gateway's own SDK and only looked up or detached here.
+type Vault interface {
+	RetrieveCard(ctx context.Context, token string) (Card, error)
+	DetachCard(ctx context.Context, token string) error
+}
@@ -47,6 +47,11 @@ type Exercise struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Link        string `json:"link"`
+	Sets        int    `json:"sets"`
+	RepsMin     int    `json:"reps_min"`
+	RepsMax     int    `json:"reps_max"`
+	Tempo       string `json:"tempo"`
+	RestSeconds int    `json:"rest_seconds"`
 }
 
 func NewClient(apiKey string) *Client {
@@ -69,8 +74,12 @@ Rules to follow strictly:
    - **description**: A detailed plan overview including purpose, progression logic, rest days, and how often to train per week. Mention when to increase weights or take deloads.
    - **exercises**: A list of 4–5 exercises, each with:
      - name: string
-     - description: a step-by-step guide (including sets, reps, tempo if needed, rest time, key form tips)
+     - description: a step-by-step guide with the key form tips
      - link: a YouTube tutorial (must be relevant and accurate)
+     - sets: integer, number of working sets
+     - reps_min and reps_max: integers, the rep range (equal for a fixed number of reps)
+     - tempo: four phases like 3-1-2-0, or an empty string if not needed
+     - rest_seconds: integer, rest between sets
 
 3. The workout should be tailored precisely for:
    - Level: %s
@@ -88,8 +97,13 @@ Output example format (do not reuse the values directly, just structure similarl
   "exercises": [
     {
       "name": "Incline Barbell Press",
-      "description": "4 sets of 8–10 reps at moderate weight.\nRest 90 seconds.\nKeep elbows at ~45 degrees. Focus on slow controlled negatives (3s down).",
-      "link": "https://www.youtube.com/watch?v=SrqOu55lrYU"
+      "description": "Use a moderate weight.\nKeep elbows at ~45 degrees. Focus on slow controlled negatives (3s down).",
+      "link": "https://www.youtube.com/watch?v=SrqOu55lrYU",
+      "sets": 4,
+      "reps_min": 8,
+      "reps_max": 10,
+      "tempo": "3-0-1-0",
+      "rest_seconds": 90
     },
     ...
   ]
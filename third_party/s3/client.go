@@ -9,6 +9,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 type Client struct {
@@ -69,3 +70,54 @@ func (c *Client) UploadFile(file multipart.File, filename string, bucket string)
 
 	return url, nil
 }
+
+// DeleteObject removes the object with the given key. Deleting a missing
+// object is not an error.
+func (c *Client) DeleteObject(key string, bucket string) error {
+	_, err := c.client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 - DeleteObject - DeleteObject: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePrefix removes every object whose key starts with prefix.
+func (c *Client) DeletePrefix(prefix string, bucket string) error {
+	ctx := context.TODO()
+
+	paginator := s3.NewListObjectsV2Paginator(c.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("s3 - DeletePrefix - ListObjectsV2: %w", err)
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+
+		objects := make([]types.ObjectIdentifier, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			objects = append(objects, types.ObjectIdentifier{Key: object.Key})
+		}
+
+		output, err := c.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return fmt.Errorf("s3 - DeletePrefix - DeleteObjects: %w", err)
+		}
+		if len(output.Errors) > 0 {
+			return fmt.Errorf("s3 - DeletePrefix - DeleteObjects: %s: %s", aws.ToString(output.Errors[0].Key), aws.ToString(output.Errors[0].Message))
+		}
+	}
+
+	return nil
+}